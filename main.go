@@ -2,16 +2,30 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"html/template"
 	"io"
 	"net"
+	"net/http"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/navikt/knorten/pkg/github"
+	"github.com/navikt/knorten/pkg/health"
 	"github.com/navikt/knorten/pkg/maintenance"
+	"github.com/navikt/knorten/pkg/metrics"
+	"github.com/navikt/knorten/pkg/notifications"
+	"github.com/navikt/knorten/pkg/orphans"
+	"github.com/navikt/knorten/pkg/plan"
+	"github.com/navikt/knorten/pkg/retention"
 	"github.com/navikt/knorten/pkg/team"
+	"github.com/navikt/knorten/pkg/teamdeletion"
+	"github.com/navikt/knorten/pkg/teamrename"
+	"github.com/navikt/knorten/pkg/tracing"
 
 	"github.com/navikt/knorten/pkg/gcpapi"
 	"github.com/navikt/knorten/pkg/gcpapi/mock"
@@ -24,22 +38,31 @@ import (
 	"github.com/navikt/knorten/pkg/api/handlers"
 	"github.com/navikt/knorten/pkg/api/middlewares"
 	"github.com/navikt/knorten/pkg/api/service"
+	"github.com/navikt/knorten/pkg/azuregroups"
+	"github.com/navikt/knorten/pkg/cloudevents"
 	"github.com/navikt/knorten/pkg/config"
 	"github.com/navikt/knorten/pkg/database"
 	"github.com/navikt/knorten/pkg/events"
 	"github.com/navikt/knorten/pkg/helm"
+	"github.com/navikt/knorten/pkg/hibernation"
 	"github.com/navikt/knorten/pkg/imageupdater"
 	"github.com/sirupsen/logrus"
 )
 
 const (
 	imageUpdaterFrequency = 24 * time.Hour
+	notificationTimeout   = 10 * time.Second
 )
 
 var configFilePath = flag.String("config", "config.yaml", "path to config file")
 
 func main() {
 	ctx := context.Background()
+	// stopCtx is done when Knorten is asked to shut down. Event processing uses ctx,
+	// so that the events in flight can finish while draining.
+	stopCtx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
 	log := logrus.New()
 	log.SetFormatter(&logrus.JSONFormatter{})
 
@@ -60,6 +83,16 @@ func main() {
 		log.WithError(err).Fatal("validating config")
 	}
 
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing.OTLPEndpoint)
+	if err != nil {
+		log.WithError(err).Fatal("setting up tracing")
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			log.WithError(err).Error("flushing traces")
+		}
+	}()
+
 	maintenanceExclusionConfig, err := maintenance.LoadMaintenanceExclusionConfig(
 		cfg.MaintenanceExclusionConfig,
 	)
@@ -97,7 +130,7 @@ func main() {
 		if err != nil {
 			log.WithError(err).Fatal("creating imageupdater")
 		}
-		go imageUpdater.Run(imageUpdaterFrequency)
+		go imageUpdater.Run(stopCtx, imageUpdaterFrequency)
 	}
 
 	c, err := k8s.NewClient(cfg.Kubernetes.Context, k8s.DefaultSchemeAdder())
@@ -118,7 +151,12 @@ func main() {
 	fetcher := gcpapi.NewServiceAccountFetcher(cfg.GCP.Project, iamService)
 
 	if cfg.DryRun {
-		policyManager = mock.NewServiceAccountPolicyManager(&iam.Policy{}, nil)
+		dryRunPolicyManager := mock.NewServiceAccountPolicyManager(&iam.Policy{}, nil)
+		dryRunPolicyManager.SetPolicyFunc = func(ctx context.Context, resource string, policy *iam.Policy) (*iam.Policy, error) {
+			plan.Record(ctx, plan.KindGCP, "setIamPolicy", resource, policy)
+			return policy, nil
+		}
+		policyManager = dryRunPolicyManager
 		fetcher = mock.NewServiceAccountFetcher(&iam.ServiceAccount{}, nil)
 	}
 
@@ -164,8 +202,58 @@ func main() {
 		log.WithError(err).Fatal("creating helm client")
 	}
 
+	airflowStatusCache, err := team.NewAirflowStatusCache(ctx, c)
+	if err != nil {
+		log.WithError(err).Fatal("creating airflow status cache")
+	}
+
+	go func() {
+		if err := airflowStatusCache.Start(stopCtx); err != nil {
+			log.WithError(err).Error("running airflow status cache")
+		}
+	}()
+
 	k8sManager := k8s.NewManager(c)
-	teamAirflowClient := team.NewAirflowClient(k8sManager)
+	teamAirflowClient := team.NewAirflowClient(k8sManager, airflowStatusCache)
+
+	orphanSources := []orphans.Source{orphans.NewNamespaceSource(k8sManager)}
+	if !cfg.DryRun {
+		orphanSources = append(orphanSources,
+			orphans.NewServiceAccountSource(cfg.GCP.Project),
+			orphans.NewSecretSource(cfg.GCP.Project),
+			orphans.NewBucketSource(cfg.GCP.Project),
+		)
+	}
+	orphanScanner := orphans.NewScanner(dbClient, cfg.DryRun, log.WithField("subsystem", "orphans"), orphanSources...)
+
+	notificationSinks := map[database.NotificationSink]notifications.Sink{
+		database.NotificationSinkWebhook: notifications.NewWebhookSink(notifications.NewWebhookClient(notificationTimeout)),
+		database.NotificationSinkSlack:   notifications.NewSlackSink(notifications.NewWebhookClient(notificationTimeout)),
+	}
+	if cfg.Notifications.SMTP.Host != "" {
+		notificationSinks[database.NotificationSinkEmail] = notifications.NewEmailSink(
+			cfg.Notifications.SMTP.Host,
+			cfg.Notifications.SMTP.Port,
+			cfg.Notifications.SMTP.From,
+		)
+	}
+	notificationClient := notifications.NewClient(
+		dbClient,
+		notificationSinks,
+		cfg.Notifications.MaxAttempts,
+		log.WithField("subsystem", "notifications"),
+	)
+	go notificationClient.Run(stopCtx, time.Duration(cfg.Notifications.DeliveryIntervalSecs)*time.Second)
+
+	cloudEventsPublisher := cloudevents.NewPublisher(
+		dbClient,
+		&http.Client{Timeout: notificationTimeout},
+		cfg.CloudEvents.Source,
+		cfg.CloudEvents.Endpoints,
+		cfg.CloudEvents.MaxAttempts,
+		log.WithField("subsystem", "cloudevents"),
+	)
+	go cloudEventsPublisher.Run(stopCtx, time.Duration(cfg.CloudEvents.PublishIntervalSecs)*time.Second)
 
 	eventHandler, err := events.NewHandler(
 		ctx,
@@ -182,6 +270,8 @@ func main() {
 		cfg.Helm.AirflowChartVersion,
 		cfg.TopLevelDomain,
 		maintenanceExclusionConfig,
+		notificationClient,
+		cfg.Events,
 		cfg.DryRun,
 		log.WithField("subsystem", "events"),
 	)
@@ -189,9 +279,51 @@ func main() {
 		log.WithError(err).Fatal("starting event watcher")
 		return
 	}
+	metrics.Registry.MustRegister(events.NewQueueCollector(dbClient, log.WithField("subsystem", "metrics")))
 	eventHandler.Run(10 * time.Second)
 
+	if cfg.AirflowHibernation.IdlePolicyEnabled {
+		hibernationClient := hibernation.NewClient(
+			dbClient,
+			k8sManager,
+			time.Duration(cfg.AirflowHibernation.IdleAfterDays)*24*time.Hour,
+			log.WithField("subsystem", "hibernation"),
+		)
+		go hibernationClient.Run(stopCtx, time.Duration(cfg.AirflowHibernation.CheckIntervalMins)*time.Minute)
+	}
+
+	if cfg.AzureGroupSync.Enabled && !cfg.DryRun {
+		azureGroupsClient := azuregroups.NewClient(
+			dbClient,
+			azureClient.GraphClient(),
+			log.WithField("subsystem", "azuregroups"),
+		)
+		go azureGroupsClient.Run(stopCtx, time.Duration(cfg.AzureGroupSync.SyncIntervalMins)*time.Minute)
+	}
+
+	teamDeletionClient := teamdeletion.NewClient(dbClient, log.WithField("subsystem", "teamdeletion"))
+	go teamDeletionClient.Run(stopCtx, time.Duration(cfg.TeamDeletion.CheckIntervalMins)*time.Minute)
+
+	teamRenameClient := teamrename.NewClient(dbClient, k8sManager, log.WithField("subsystem", "teamrename"))
+	go teamRenameClient.Run(stopCtx, time.Duration(cfg.TeamRename.CheckIntervalMins)*time.Minute)
+
+	archive := retention.NewDirArchive(cfg.Retention.ArchiveDir)
+	if cfg.Retention.ArchiveBucket != "" {
+		archive = retention.NewBucketArchive(cfg.Retention.ArchiveBucket)
+	}
+	retentionClient := retention.NewClient(
+		dbClient,
+		archive,
+		cfg.Retention.CompletedEventDays,
+		cfg.Retention.FailedEventDays,
+		cfg.Retention.OutboxDays,
+		log.WithField("subsystem", "retention"),
+	)
+	go retentionClient.Run(stopCtx, time.Duration(cfg.Retention.CheckIntervalMins)*time.Minute)
+
 	router := gin.New()
+	router.ContextWithFallback = true
+	router.Use(middlewares.Tracing())
 
 	session, err := dbClient.NewSessionStore(cfg.SessionKey)
 	if err != nil {
@@ -228,7 +360,18 @@ func main() {
 	ghc := github.NewFromHTTPClient(cfg.Github.Organization, ghHttpClient)
 	ghf := github.NewFetcher(ghc, log.WithField("subsystem", "github"))
 
-	go ghf.StartRefreshLoop(ctx, time.Duration(cfg.Github.RefreshIntervalMins)*time.Minute)
+	go ghf.StartRefreshLoop(stopCtx, time.Duration(cfg.Github.RefreshIntervalMins)*time.Minute)
+
+	healthChecker := health.NewChecker(
+		time.Duration(cfg.Health.CheckTimeoutSecs)*time.Second,
+		log.WithField("subsystem", "health"),
+		health.Check{Name: "postgres", Fn: dbClient.Ping},
+		health.Check{Name: "kubernetes", Fn: c.Ping},
+		health.Check{Name: "helm", Fn: helmClient.CheckRepositories},
+		health.Check{Name: "github", Fn: func(context.Context) error {
+			return ghf.CheckFreshness(time.Duration(cfg.Health.GithubMaxAgeMins) * time.Minute)
+		}},
+	)
 
 	githubHandler := handlers.NewGithubHandler(
 		service.NewGithubService(ghf),
@@ -272,19 +415,76 @@ func main() {
 		cfg.GCP.Zone,
 		cfg.TopLevelDomain,
 		maintenanceExclusionConfig,
+		time.Duration(cfg.TeamDeletion.GracePeriodDays)*24*time.Hour,
+		time.Duration(cfg.TeamRename.RedirectDays)*24*time.Hour,
 		teamAirflowClient,
+		orphanScanner,
+		notificationClient,
 	)
 	if err != nil {
 		log.WithError(err).Fatal("creating api")
 		return
 	}
 
-	err = router.Run(net.JoinHostPort(cfg.Server.Hostname, cfg.Server.Port))
-	if err != nil {
-		log.WithError(err).Fatal("running api")
+	server := &http.Server{
+		Addr:    net.JoinHostPort(cfg.Server.Hostname, cfg.Server.Port),
+		Handler: router,
+	}
 
-		return
+	go func() {
+		err := server.ListenAndServe()
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.WithError(err).Fatal("running api")
+		}
+	}()
+
+	// Metrics and health checks are kept off the public router, on a port only reachable in the cluster
+	internalMux := http.NewServeMux()
+	internalMux.Handle("/metrics", metrics.Handler())
+	internalMux.HandleFunc("/healthz", healthChecker.Healthz)
+	internalMux.HandleFunc("/readyz", healthChecker.Readyz)
+	internalServer := &http.Server{
+		Addr:    net.JoinHostPort(cfg.Server.Hostname, cfg.Server.InternalPort),
+		Handler: internalMux,
 	}
+
+	go func() {
+		err := internalServer.ListenAndServe()
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.WithError(err).Fatal("running internal server")
+		}
+	}()
+
+	<-stopCtx.Done()
+	stop()
+	log.Info("shutting down, draining requests and events in flight")
+
+	drainCtx, cancel := context.WithTimeout(ctx, time.Duration(cfg.Shutdown.DrainTimeoutSecs)*time.Second)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		if err := server.Shutdown(drainCtx); err != nil {
+			log.WithError(err).Error("shutting down api")
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		if err := eventHandler.Shutdown(drainCtx); err != nil {
+			log.WithError(err).Error("draining events")
+		}
+	}()
+	wg.Wait()
+
+	// The health checks are answered while draining, so that the liveness probe doesn't restart Knorten
+	// before the events in flight have finished
+	if err := internalServer.Shutdown(context.Background()); err != nil {
+		log.WithError(err).Error("shutting down internal server")
+	}
+
+	log.Info("shut down")
 }
 
 // Need to move this
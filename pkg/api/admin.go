@@ -2,11 +2,14 @@ package api
 
 import (
 	"context"
+	"database/sql"
 	"encoding/gob"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/navikt/knorten/pkg/api/middlewares"
 
@@ -28,9 +31,13 @@ type diffValue struct {
 
 type teamInfo struct {
 	gensql.Team
-	Namespace string
-	Apps      []gensql.ChartType
-	Events    []gensql.Event
+	Namespace    string
+	Apps         []gensql.ChartType
+	Events       []gensql.Event
+	IsHibernated bool
+	DeleteAfter  *time.Time
+	// DeletionFailed is set when the team is past its grace period, and the deletion failed
+	DeletionFailed bool
 }
 
 const ActionTriggerResync = "action-trigger-resync"
@@ -76,12 +83,39 @@ func (c *client) setupAdminRoutes() {
 				return
 			}
 
-			teamApps[team.ID] = teamInfo{
-				Team:      team,
-				Namespace: k8s.TeamIDToNamespace(team.ID),
-				Apps:      apps,
-				Events:    events,
+			isHibernated, err := c.repo.AirflowIsHibernated(ctx, team.ID)
+			if err != nil {
+				c.log.WithError(err).Error("problem retrieving hibernation state for team")
+				ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err})
+				return
+			}
+
+			info := teamInfo{
+				Team:         team,
+				Namespace:    k8s.TeamIDToNamespace(team.ID),
+				Apps:         apps,
+				Events:       events,
+				IsHibernated: isHibernated,
+			}
+
+			deletion, err := c.repo.TeamDeletionGet(ctx, team.ID)
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				c.log.WithError(err).Error("problem retrieving pending deletion for team")
+				ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err})
+				return
+			}
+			if err == nil {
+				info.DeleteAfter = &deletion.DeleteAfter
+
+				info.DeletionFailed, err = c.repo.TeamDeletionFailed(ctx, team.ID)
+				if err != nil {
+					c.log.WithError(err).Error("problem retrieving failed deletion for team")
+					ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err})
+					return
+				}
 			}
+
+			teamApps[team.ID] = info
 		}
 
 		ctx.HTML(http.StatusOK, "admin/index", gin.H{
@@ -231,7 +265,19 @@ func (c *client) setupAdminRoutes() {
 			triggerResync = true
 		}
 
-		if err := c.updateGlobalValues(ctx, ctx.Request.PostForm, chartType, triggerResync); err != nil {
+		originals, err := c.repo.GlobalValuesGet(ctx, chartType)
+		if err != nil {
+			c.log.WithError(err)
+			session.AddFlash(err.Error())
+			err = session.Save()
+			if err != nil {
+				c.log.WithError(err).Error("problem saving session")
+			}
+			ctx.Redirect(http.StatusSeeOther, fmt.Sprintf("/admin/%v", chartType))
+			return
+		}
+
+		if err := c.updateGlobalValues(ctx, ctx.Request.PostForm, chartType, triggerResync, eventOptions(ctx)...); err != nil {
 			c.log.WithError(err)
 			session.AddFlash(err.Error())
 			err = session.Save()
@@ -257,6 +303,8 @@ func (c *client) setupAdminRoutes() {
 			return
 		}
 
+		before, after := auditGlobalValues(originals, ctx.Request.PostForm)
+		auditChange(ctx, before, after)
 		ctx.Redirect(http.StatusSeeOther, "/admin")
 	})
 
@@ -265,7 +313,7 @@ func (c *client) setupAdminRoutes() {
 		chartType := getChartType(ctx.Param("chart"))
 		team := ctx.PostForm("team")
 
-		if err := c.syncChart(ctx, team, chartType); err != nil {
+		if err := c.syncChart(ctx, team, chartType, eventOptions(ctx)...); err != nil {
 			c.log.WithError(err).Errorf("syncing %v", chartType)
 			session.AddFlash(err.Error())
 			err = session.Save()
@@ -281,7 +329,11 @@ func (c *client) setupAdminRoutes() {
 		session := sessions.Default(ctx)
 		chartType := getChartType(ctx.Param("chart"))
 
-		if err := c.syncChartForAllTeams(ctx, chartType); err != nil {
+		opts, err := eventOptionsWithRunAfter(ctx)
+		if err == nil {
+			err = c.syncChartForAllTeams(ctx, chartType, opts...)
+		}
+		if err != nil {
 			c.log.WithError(err).Errorf("resyncing all instances of %v", chartType)
 			session.AddFlash(err.Error())
 			err = session.Save()
@@ -296,7 +348,11 @@ func (c *client) setupAdminRoutes() {
 	c.router.POST("/admin/team/sync/all", func(ctx *gin.Context) {
 		session := sessions.Default(ctx)
 
-		if err := c.syncTeams(ctx); err != nil {
+		opts, err := eventOptionsWithRunAfter(ctx)
+		if err == nil {
+			err = c.syncTeams(ctx, opts...)
+		}
+		if err != nil {
 			c.log.WithError(err).Errorf("resyncing all teams")
 			session.AddFlash(err.Error())
 			err = session.Save()
@@ -322,8 +378,15 @@ func (c *client) setupAdminRoutes() {
 			}
 		}
 
-		if err := c.repo.RegisterDeleteTeamEvent(ctx, team.ID); err != nil {
-			c.log.WithError(err).Errorf("registering delete team event")
+		user, err := getUser(ctx)
+		if err != nil {
+			c.log.WithError(err).Errorf("deleting team")
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unable to identify logged in user when deleting team"})
+			return
+		}
+
+		if err := c.markTeamForDeletion(ctx, team.ID, user.Email, eventOptions(ctx)...); err != nil {
+			c.log.WithError(err).Errorf("marking team for deletion")
 			session.AddFlash(err.Error())
 			err = session.Save()
 			if err != nil {
@@ -405,35 +468,33 @@ func (c *client) setupAdminRoutes() {
 	})
 
 	c.router.POST("/admin/airflow/restart/:slug", func(ctx *gin.Context) {
-		teamSlug := ctx.Param("slug")
-		team, err := c.repo.TeamBySlugGet(ctx, teamSlug)
-		log := c.log.WithField("team", teamSlug)
-
-		if err != nil {
-			log.WithError(err).Error("problem fetching team")
-		}
-		namespace := k8s.TeamIDToNamespace(team.ID)
-
-		values := AirflowProperties{Namespace: namespace}
+		c.handleAirflowRestart(ctx, "/admin")
+	})
 
-		err = c.repo.RegisterDeleteSchedulerPodsEvent(ctx, team.ID, values)
-		if err != nil {
-			log.WithError(err).
-				Errorf("problem registering restart airflow scheduler event for team %s", team.ID)
-		}
+	c.router.POST("/admin/airflow/hibernate/:slug", func(ctx *gin.Context) {
+		c.handleAirflowHibernation(ctx, true, "/admin")
+	})
 
-		ctx.Redirect(http.StatusSeeOther, "/admin")
+	c.router.POST("/admin/airflow/wake/:slug", func(ctx *gin.Context) {
+		c.handleAirflowHibernation(ctx, false, "/admin")
 	})
 }
 
-func (c *client) syncTeams(ctx context.Context) error {
+func (c *client) syncTeams(ctx context.Context, opts ...database.EventOption) error {
 	teams, err := c.repo.TeamsGet(ctx)
 	if err != nil {
 		return err
 	}
 
+	opts = append(opts, database.WithEventPriority(database.EventPriorityLow))
+
 	for _, team := range teams {
-		err := c.repo.RegisterUpdateTeamEvent(ctx, team)
+		members, err := c.repo.TeamMembersGet(ctx, team.ID)
+		if err != nil {
+			return err
+		}
+
+		err = c.repo.RegisterUpdateTeamEvent(ctx, database.Team{Team: team, Members: members}, opts...)
 		if err != nil {
 			return err
 		}
@@ -442,14 +503,16 @@ func (c *client) syncTeams(ctx context.Context) error {
 	return nil
 }
 
-func (c *client) syncChartForAllTeams(ctx context.Context, chartType gensql.ChartType) error {
+func (c *client) syncChartForAllTeams(ctx context.Context, chartType gensql.ChartType, opts ...database.EventOption) error {
 	teams, err := c.repo.TeamsForChartGet(ctx, chartType)
 	if err != nil {
 		return err
 	}
 
+	opts = append(opts, database.WithEventPriority(database.EventPriorityLow))
+
 	for _, teamID := range teams {
-		err := c.syncChart(ctx, teamID, chartType)
+		err := c.syncChart(ctx, teamID, chartType, opts...)
 		if err != nil {
 			return err
 		}
@@ -458,13 +521,13 @@ func (c *client) syncChartForAllTeams(ctx context.Context, chartType gensql.Char
 	return nil
 }
 
-func (c *client) syncChart(ctx context.Context, teamID string, chartType gensql.ChartType) error {
+func (c *client) syncChart(ctx context.Context, teamID string, chartType gensql.ChartType, opts ...database.EventOption) error {
 	switch chartType {
 	case gensql.ChartTypeAirflow:
 		values := chart.AirflowConfigurableValues{
 			TeamID: teamID,
 		}
-		return c.repo.RegisterUpdateAirflowEvent(ctx, teamID, values)
+		return c.repo.RegisterUpdateAirflowEvent(ctx, teamID, values, opts...)
 	}
 
 	return nil
@@ -491,6 +554,7 @@ func (c *client) updateGlobalValues(
 	formValues url.Values,
 	chartType gensql.ChartType,
 	resync bool,
+	opts ...database.EventOption,
 ) error {
 	for key, values := range formValues {
 		if values[0] == "" {
@@ -512,7 +576,7 @@ func (c *client) updateGlobalValues(
 	}
 
 	if resync {
-		return c.syncChartForAllTeams(ctx, chartType)
+		return c.syncChartForAllTeams(ctx, chartType, opts...)
 	}
 
 	return nil
@@ -650,9 +714,15 @@ func (c *client) getEvent(ctx *gin.Context) (gin.H, error) {
 		return gin.H{}, err
 	}
 
+	planSteps, err := c.repo.EventPlanStepsGet(ctx, eventID)
+	if err != nil {
+		return gin.H{}, err
+	}
+
 	return gin.H{
 		"event": event,
 		"logs":  eventLogs,
+		"plan":  planSteps,
 	}, nil
 }
 
@@ -672,7 +742,21 @@ func (c *client) setEventStatus(ctx *gin.Context) error {
 		return fmt.Errorf("invalid status %v", ctx.PostForm("status"))
 	}
 
-	return c.repo.EventSetStatus(ctx, eventID, status)
+	event, err := c.repo.EventGet(ctx, eventID)
+	if err != nil {
+		return err
+	}
+
+	if err := c.repo.EventSetStatus(ctx, eventID, status); err != nil {
+		return err
+	}
+	c.eventStatusChanged(ctx, event, status, "")
+
+	if _, err := c.repo.TeamGet(ctx, event.Owner); err == nil {
+		auditTeam(ctx, event.Owner)
+	}
+	auditChange(ctx, event.Status, string(status))
+	return nil
 }
 
 func getTeamIDs(teams []gensql.Team) []string {
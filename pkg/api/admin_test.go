@@ -2,6 +2,7 @@ package api
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -14,6 +15,7 @@ import (
 	"github.com/navikt/knorten/pkg/database"
 	"github.com/navikt/knorten/pkg/database/gensql"
 	"github.com/navikt/knorten/pkg/k8s"
+	"github.com/navikt/knorten/pkg/team"
 )
 
 func TestAdminAPI(t *testing.T) {
@@ -249,7 +251,7 @@ func TestAdminAPI(t *testing.T) {
 	})
 
 	t.Run("restart airflow creates event", func(t *testing.T) {
-		oldEvents, err := repo.EventsGetType(ctx, database.EventTypeDeleteSchedulerPods)
+		oldEvents, err := repo.EventsGetType(ctx, database.EventTypeRestartAirflowComponent)
 		if err != nil {
 			t.Error(err)
 		}
@@ -264,7 +266,7 @@ func TestAdminAPI(t *testing.T) {
 			t.Errorf("Status code is %v, should be %v", resp.StatusCode, http.StatusOK)
 		}
 
-		events, err := repo.EventsGetType(ctx, database.EventTypeDeleteSchedulerPods)
+		events, err := repo.EventsGetType(ctx, database.EventTypeRestartAirflowComponent)
 		if err != nil {
 			t.Error(err)
 		}
@@ -282,6 +284,9 @@ func TestAdminAPI(t *testing.T) {
 		if airflowProperties[0].Namespace != k8s.TeamIDToNamespace(teams[1].ID) {
 			t.Errorf("AirflowProperties namespace was %s, should be %s", airflowProperties[0].Namespace, k8s.TeamIDToNamespace(teams[1].ID))
 		}
+		if airflowProperties[0].Component != string(team.AirflowComponentScheduler) {
+			t.Errorf("AirflowProperties component was %s, should be %s", airflowProperties[0].Component, team.AirflowComponentScheduler)
+		}
 	})
 
 	t.Run("update airflow global values and not trigger resync", func(t *testing.T) {
@@ -447,13 +452,18 @@ func TestAdminAPI(t *testing.T) {
 			t.Errorf("delete team: expected status code 200, got %v", resp.StatusCode)
 		}
 
-		events, err := repo.EventsGetType(ctx, database.EventTypeDeleteTeam)
+		if _, err := repo.TeamDeletionGet(ctx, team.ID); err != nil {
+			t.Errorf("delete team: expected team %v to be pending deletion: %v", team.ID, err)
+		}
+
+		resp, err = server.Client().PostForm(fmt.Sprintf("%v/admin/team/%v/restore", server.URL, team.Slug), nil)
 		if err != nil {
 			t.Error(err)
 		}
+		resp.Body.Close()
 
-		if !deleteEventCreatedForTeam(events, team.ID) {
-			t.Errorf("delete team: no event registered for team %v", team.ID)
+		if _, err := repo.TeamDeletionGet(ctx, team.ID); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("restore team: expected team %v to no longer be pending deletion, got %v", team.ID, err)
 		}
 	})
 
@@ -601,10 +611,10 @@ func prepareAdminTests(ctx context.Context) ([]gensql.Team, error) {
 	}
 
 	// events
-	if err := repo.RegisterCreateTeamEvent(ctx, teamA); err != nil {
+	if err := repo.RegisterCreateTeamEvent(ctx, database.NewTeam(teamA)); err != nil {
 		return nil, err
 	}
-	if err := repo.RegisterCreateTeamEvent(ctx, teamB); err != nil {
+	if err := repo.RegisterCreateTeamEvent(ctx, database.NewTeam(teamB)); err != nil {
 		return nil, err
 	}
 
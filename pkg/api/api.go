@@ -1,11 +1,16 @@
 package api
 
 import (
+	"time"
+
 	"github.com/gin-gonic/gin"
 	"github.com/navikt/knorten/pkg/api/auth"
 	"github.com/navikt/knorten/pkg/api/service"
+	"github.com/navikt/knorten/pkg/azuregroups"
 	"github.com/navikt/knorten/pkg/database"
 	"github.com/navikt/knorten/pkg/maintenance"
+	"github.com/navikt/knorten/pkg/notifications"
+	"github.com/navikt/knorten/pkg/orphans"
 	"github.com/sirupsen/logrus"
 )
 
@@ -19,7 +24,12 @@ type client struct {
 	gcpZone                    string
 	topLevelDomain             string
 	maintenanceExclusionConfig *maintenance.MaintenanceExclusion
+	teamDeletionGracePeriod    time.Duration
+	teamRenameRedirectPeriod   time.Duration
 	airflowService             service.AirflowService
+	azureGroups                *azuregroups.Client
+	orphanScanner              *orphans.Scanner
+	notifications              *notifications.Client
 }
 
 func New(
@@ -30,7 +40,11 @@ func New(
 	dryRun bool,
 	project, zone, topLevelDomain string,
 	maintenanceExclusionConfig *maintenance.MaintenanceExclusion,
+	teamDeletionGracePeriod time.Duration,
+	teamRenameRedirectPeriod time.Duration,
 	airflowService service.AirflowService,
+	orphanScanner *orphans.Scanner,
+	notificationClient *notifications.Client,
 ) error {
 	router.Use(gin.Recovery())
 	router.Use(func(ctx *gin.Context) {
@@ -48,12 +62,25 @@ func New(
 		gcpZone:                    zone,
 		topLevelDomain:             topLevelDomain,
 		maintenanceExclusionConfig: maintenanceExclusionConfig,
+		teamDeletionGracePeriod:    teamDeletionGracePeriod,
+		teamRenameRedirectPeriod:   teamRenameRedirectPeriod,
 		airflowService:             airflowService,
+		azureGroups:                azuregroups.NewClient(db, azureClient.GraphClient(), log.WithField("subsystem", "azuregroups")),
+		orphanScanner:              orphanScanner,
+		notifications:              notificationClient,
 	}
 
+	api.router.Use(api.auditMiddleware())
+	api.router.Use(api.idempotencyMiddleware())
+	api.router.Use(api.teamPendingDeletionMiddleware())
 	api.setupAuthenticatedRoutes()
 	api.router.Use(api.adminAuthMiddleware())
 	api.setupAdminRoutes()
+	api.setupInjectedContainerAdminRoutes()
+	api.setupTeamDeletionAdminRoutes()
+	api.setupAuditAdminRoutes()
+	api.setupNotificationAdminRoutes()
+	api.setupOrphanAdminRoutes()
 
 	return nil
 }
@@ -64,4 +91,12 @@ func (c *client) setupAuthenticatedRoutes() {
 	c.setupSecretRoutes()
 	c.setupChartRoutes()
 	c.setupMaintenanceExclusionRoutes()
+	c.setupInjectedContainerRoutes()
+	c.setupAzureGroupRoutes()
+	c.setupTeamDeletionRoutes()
+	c.setupTeamRenameRoutes()
+	c.setupAuditRoutes()
+	c.setupNotificationRoutes()
+	c.setupScheduledEventRoutes()
+	c.setupEventStreamRoutes()
 }
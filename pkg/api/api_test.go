@@ -17,6 +17,8 @@ import (
 	"github.com/navikt/knorten/pkg/config"
 	"github.com/navikt/knorten/pkg/k8s"
 	"github.com/navikt/knorten/pkg/maintenance"
+	"github.com/navikt/knorten/pkg/notifications"
+	"github.com/navikt/knorten/pkg/orphans"
 	"github.com/navikt/knorten/pkg/team"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
@@ -80,6 +82,8 @@ func TestMain(m *testing.M) {
 	}
 
 	router := gin.New()
+	router.ContextWithFallback = true
+	router.Use(middlewares.Tracing())
 
 	session, err := repo.NewSessionStore("knorten_session")
 	if err != nil {
@@ -145,7 +149,11 @@ func TestMain(m *testing.M) {
 		&maintenance.MaintenanceExclusion{
 			Periods: map[string][]*maintenance.MaintenanceExclusionPeriod{},
 		},
-		team.NewAirflowClient(manager),
+		7*24*time.Hour,
+		30*24*time.Hour,
+		team.NewAirflowClient(manager, c),
+		orphans.NewScanner(repo, true, logger, orphans.NewNamespaceSource(manager)),
+		notifications.NewClient(repo, nil, 1, logger),
 	)
 	if err != nil {
 		log.Fatalf("setting up api: %v", err)
@@ -0,0 +1,229 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"github.com/navikt/knorten/pkg/api/middlewares"
+	"github.com/navikt/knorten/pkg/database"
+	"github.com/navikt/knorten/pkg/database/gensql"
+)
+
+const (
+	auditTeamIDKey = "auditTeamID"
+	auditBeforeKey = "auditBefore"
+	auditAfterKey  = "auditAfter"
+
+	auditLogsLimit = 200
+)
+
+// auditMiddleware writes an audit log entry for every request which can change something. The team
+// is looked up before the handler runs, so renames and deletes are logged on the team they changed.
+func (c *client) auditMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if ctx.Request.Method == http.MethodGet || ctx.Request.Method == http.MethodHead || ctx.FullPath() == "" {
+			ctx.Next()
+			return
+		}
+
+		teamID := c.auditTeamID(ctx)
+
+		ctx.Next()
+
+		if id := ctx.GetString(auditTeamIDKey); id != "" {
+			teamID = id
+		}
+
+		entry := database.AuditEntry{
+			Action:     ctx.Request.Method + " " + ctx.FullPath(),
+			Target:     auditTarget(ctx.Params),
+			TeamID:     teamID,
+			Before:     ctx.GetString(auditBeforeKey),
+			After:      ctx.GetString(auditAfterKey),
+			Method:     ctx.Request.Method,
+			Path:       ctx.Request.URL.Path,
+			StatusCode: ctx.Writer.Status(),
+			ClientIP:   ctx.ClientIP(),
+			UserAgent:  ctx.Request.UserAgent(),
+		}
+
+		if user, err := getUser(ctx); err == nil {
+			entry.Actor = user.Email
+		}
+
+		// The request may already be cancelled, but the change it made should still be logged
+		if err := c.repo.AuditLogInsert(context.WithoutCancel(ctx), entry); err != nil {
+			c.log.WithError(err).Errorf("writing audit log entry for %v", entry.Action)
+		}
+	}
+}
+
+func (c *client) auditTeamID(ctx *gin.Context) string {
+	teamSlug := ctx.Param("slug")
+	if teamSlug == "" {
+		teamSlug = ctx.Param("team")
+	}
+
+	if teamSlug == "" {
+		return ""
+	}
+
+	team, err := c.repo.TeamBySlugGet(ctx, teamSlug)
+	if err != nil {
+		return ""
+	}
+
+	return team.ID
+}
+
+func auditTarget(params gin.Params) string {
+	target := []string{}
+	for _, param := range params {
+		target = append(target, param.Key+"="+param.Value)
+	}
+
+	return strings.Join(target, " ")
+}
+
+// auditTeam is for handlers where the team isn't part of the path
+func auditTeam(ctx *gin.Context, teamID string) {
+	ctx.Set(auditTeamIDKey, teamID)
+}
+
+// auditChange adds summaries of what the request changed to its audit log entry
+func auditChange(ctx *gin.Context, before, after any) {
+	ctx.Set(auditBeforeKey, auditSummary(before))
+	ctx.Set(auditAfterKey, auditSummary(after))
+}
+
+func auditSummary(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		summary, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprintf("%v", v)
+		}
+
+		return string(summary)
+	}
+}
+
+func auditMembers(members []database.TeamMember) map[string]gensql.TeamRole {
+	roles := map[string]gensql.TeamRole{}
+	for _, member := range members {
+		roles[member.Email] = member.Role
+	}
+
+	return roles
+}
+
+// auditGlobalValues summarizes the global values changed by the form, without the encrypted values
+func auditGlobalValues(originals []gensql.ChartGlobalValue, formValues url.Values) (map[string]string, map[string]string) {
+	before := map[string]string{}
+	after := map[string]string{}
+
+	for key, values := range formValues {
+		for _, original := range originals {
+			if original.Key != key {
+				continue
+			}
+
+			before[key] = original.Value
+			if original.Encrypted {
+				before[key] = "<kryptert>"
+			}
+		}
+
+		switch {
+		case values[0] == "":
+			after[key] = "<slettet>"
+		case len(values) == 2:
+			after[key] = "<kryptert>"
+		default:
+			after[key] = values[0]
+		}
+	}
+
+	return before, after
+}
+
+func (c *client) setupAuditRoutes() {
+	c.router.GET("/team/:slug/audit", func(ctx *gin.Context) {
+		teamSlug := ctx.Param("slug")
+
+		logs, err := c.teamAuditLogs(ctx, teamSlug)
+		if err != nil {
+			c.log.WithError(err).WithField("team", teamSlug).Error("problem getting audit logs")
+			session := sessions.Default(ctx)
+			session.AddFlash(err.Error())
+			err := session.Save()
+			if err != nil {
+				c.log.WithError(err).Error("problem saving session")
+			}
+			ctx.Redirect(http.StatusSeeOther, "/oversikt")
+			return
+		}
+
+		ctx.HTML(http.StatusOK, "team/audit", gin.H{
+			"logs":     logs,
+			"slug":     teamSlug,
+			"loggedIn": ctx.GetBool(middlewares.LoggedInKey),
+			"isAdmin":  ctx.GetBool(middlewares.AdminKey),
+		})
+	})
+}
+
+func (c *client) setupAuditAdminRoutes() {
+	c.router.GET("/admin/audit", func(ctx *gin.Context) {
+		actor := strings.TrimSpace(ctx.Query("actor"))
+		query := strings.TrimSpace(ctx.Query("q"))
+
+		limit := auditLogsLimit
+		if l, err := strconv.Atoi(ctx.Query("limit")); err == nil && l > 0 {
+			limit = l
+		}
+
+		session := sessions.Default(ctx)
+		flashes := session.Flashes()
+
+		logs, err := c.repo.AuditLogsSearch(ctx, actor, query, int32(limit))
+		if err != nil {
+			c.log.WithError(err).Error("problem searching audit logs")
+			flashes = append(flashes, err.Error())
+		}
+
+		err = session.Save()
+		if err != nil {
+			c.log.WithError(err).Error("problem saving session")
+		}
+
+		ctx.HTML(http.StatusOK, "admin/audit", gin.H{
+			"logs":     logs,
+			"actor":    actor,
+			"query":    query,
+			"errors":   flashes,
+			"loggedIn": ctx.GetBool(middlewares.LoggedInKey),
+			"isAdmin":  ctx.GetBool(middlewares.AdminKey),
+		})
+	})
+}
+
+func (c *client) teamAuditLogs(ctx context.Context, teamSlug string) ([]gensql.AuditLog, error) {
+	team, err := c.repo.TeamBySlugGet(ctx, teamSlug)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.repo.AuditLogsForTeamGet(ctx, team.ID, auditLogsLimit)
+}
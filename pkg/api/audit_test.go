@@ -0,0 +1,90 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/navikt/knorten/pkg/database/gensql"
+)
+
+func TestAuditAPI(t *testing.T) {
+	ctx := context.Background()
+
+	team := gensql.Team{
+		ID:    "audit-team-1234",
+		Slug:  "audit-team",
+		Users: []string{testUser.Email},
+	}
+	if err := repo.TeamCreate(ctx, &team); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		if err := repo.TeamDelete(ctx, team.ID); err != nil {
+			t.Errorf("cleaning up after audit tests: %v", err)
+		}
+	})
+
+	t.Run("changes are audited", func(t *testing.T) {
+		data := url.Values{"team": {team.Slug}, "users[]": {testUser.Email, "viewer@nav.no"}, "roles[]": {"owner", "viewer"}}
+		resp, err := server.Client().PostForm(fmt.Sprintf("%v/team/%v/edit", server.URL, team.Slug), data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		logs, err := repo.AuditLogsForTeamGet(ctx, team.ID, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(logs) != 1 {
+			t.Fatalf("expected one audit log entry for %v, got %v", team.Slug, logs)
+		}
+
+		entry := logs[0]
+		if entry.Actor != testUser.Email || entry.Action != "POST /team/:slug/edit" || entry.Target != "slug="+team.Slug {
+			t.Errorf("expected the edit by %v to be audited, got %+v", testUser.Email, entry)
+		}
+
+		if entry.After != `{"dummy@nav.no":"owner","viewer@nav.no":"viewer"}` {
+			t.Errorf("expected the new members in the after summary, got %v", entry.After)
+		}
+	})
+
+	t.Run("get team audit log", func(t *testing.T) {
+		resp, err := server.Client().Get(fmt.Sprintf("%v/team/%v/audit", server.URL, team.Slug))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("expected status code 200, got %v", resp.StatusCode)
+		}
+	})
+
+	t.Run("search audit log as admin", func(t *testing.T) {
+		resp, err := server.Client().Get(fmt.Sprintf("%v/admin/audit?actor=%v&q=%v", server.URL, testUser.Email, team.Slug))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("expected status code 200, got %v", resp.StatusCode)
+		}
+
+		logs, err := repo.AuditLogsSearch(ctx, testUser.Email, team.Slug, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(logs) == 0 {
+			t.Errorf("expected to find the changes to %v made by %v", team.Slug, testUser.Email)
+		}
+	})
+}
@@ -60,8 +60,9 @@ type TokenResponse struct {
 var ErrAzureTokenExpired = fmt.Errorf("token expired")
 
 const (
-	AzureUsersEndpoint  = "https://graph.microsoft.com/v1.0/users"
-	AzureGroupsEndpoint = "https://graph.microsoft.com/v1.0/groups"
+	AzureGraphEndpoint  = "https://graph.microsoft.com/v1.0"
+	AzureUsersEndpoint  = AzureGraphEndpoint + "/users"
+	AzureGroupsEndpoint = AzureGraphEndpoint + "/groups"
 )
 
 func NewAzureClient(dryRun bool, clientID, clientSecret, tenantID, redirectURL string, log *logrus.Entry) (*Azure, error) {
@@ -0,0 +1,107 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var ErrAzureGroupNotFound = fmt.Errorf("group not found")
+
+// GraphClient resolves Azure AD groups and their members through Microsoft Graph
+type GraphClient struct {
+	endpoint   string
+	token      func() (string, error)
+	httpClient *http.Client
+}
+
+// NewGraphClient creates a client for the Graph API at endpoint, e.g. AzureGraphEndpoint, where
+// every request is authorized with the bearer token returned by token
+func NewGraphClient(endpoint string, token func() (string, error)) *GraphClient {
+	return &GraphClient{
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		token:    token,
+		httpClient: &http.Client{
+			Timeout: time.Second * 10,
+		},
+	}
+}
+
+// GraphClient returns a client authorized as the Knorten application
+func (a *Azure) GraphClient() *GraphClient {
+	return NewGraphClient(AzureGraphEndpoint, a.getBearerTokenForApplication)
+}
+
+// Group returns ErrAzureGroupNotFound if there is no group with the id
+func (g *GraphClient) Group(ctx context.Context, groupID string) (*AzureGroupWithID, error) {
+	u := fmt.Sprintf("%v/groups/%v?$select=id,displayName,mail", g.endpoint, url.PathEscape(groupID))
+
+	var group AzureGroupWithID
+	if err := g.get(ctx, u, &group); err != nil {
+		return nil, err
+	}
+
+	return &group, nil
+}
+
+// GroupMembers lists the emails of every user in the group, including members of nested groups
+func (g *GraphClient) GroupMembers(ctx context.Context, groupID string) ([]string, error) {
+	type membersResponse struct {
+		Value []struct {
+			Email string `json:"userPrincipalName"`
+		} `json:"value"`
+		NextLink string `json:"@odata.nextLink"`
+	}
+
+	u := fmt.Sprintf("%v/groups/%v/transitiveMembers/microsoft.graph.user?$select=userPrincipalName&$top=999", g.endpoint, url.PathEscape(groupID))
+
+	emails := []string{}
+	for u != "" {
+		var members membersResponse
+		if err := g.get(ctx, u, &members); err != nil {
+			return nil, err
+		}
+
+		for _, member := range members.Value {
+			if member.Email != "" {
+				emails = append(emails, strings.ToLower(member.Email))
+			}
+		}
+
+		u = members.NextLink
+	}
+
+	return emails, nil
+}
+
+func (g *GraphClient) get(ctx context.Context, u string, into any) error {
+	token, err := g.token()
+	if err != nil {
+		return fmt.Errorf("getting graph token: %w", err)
+	}
+
+	r, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	r.Header.Add("Authorization", fmt.Sprintf("Bearer %v", token))
+
+	res, err := g.httpClient.Do(r)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	switch {
+	case res.StatusCode == http.StatusNotFound:
+		return ErrAzureGroupNotFound
+	case res.StatusCode != http.StatusOK:
+		return fmt.Errorf("graph returned %v for %v", res.Status, u)
+	}
+
+	return json.NewDecoder(res.Body).Decode(into)
+}
@@ -0,0 +1,64 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/navikt/knorten/pkg/api/auth"
+	"github.com/navikt/knorten/pkg/api/auth/graphtest"
+)
+
+func TestGraphClient(t *testing.T) {
+	ctx := context.Background()
+
+	server := graphtest.NewServer()
+	defer server.Close()
+
+	server.SetGroup("group-1", graphtest.Group{
+		DisplayName: "Team Data",
+		Mail:        "team-data@nav.no",
+		Members:     []string{"A@nav.no", "b@nav.no", "c@nav.no", "d@nav.no", "e@nav.no"},
+	})
+
+	client := auth.NewGraphClient(server.URL, server.TokenFunc)
+
+	t.Run("get group", func(t *testing.T) {
+		group, err := client.Group(ctx, "group-1")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expect := &auth.AzureGroupWithID{DisplayName: "Team Data", ID: "group-1", Mail: "team-data@nav.no"}
+		if diff := cmp.Diff(expect, group); diff != "" {
+			t.Errorf("Group(): mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("get members across pages", func(t *testing.T) {
+		members, err := client.GroupMembers(ctx, "group-1")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expect := []string{"a@nav.no", "b@nav.no", "c@nav.no", "d@nav.no", "e@nav.no"}
+		if diff := cmp.Diff(expect, members); diff != "" {
+			t.Errorf("GroupMembers(): mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("unknown group", func(t *testing.T) {
+		_, err := client.GroupMembers(ctx, "missing")
+		if !errors.Is(err, auth.ErrAzureGroupNotFound) {
+			t.Errorf("GroupMembers(): expected ErrAzureGroupNotFound, got %v", err)
+		}
+	})
+
+	t.Run("invalid token", func(t *testing.T) {
+		client := auth.NewGraphClient(server.URL, func() (string, error) { return "wrong", nil })
+		if _, err := client.Group(ctx, "group-1"); err == nil {
+			t.Errorf("Group(): expected error for invalid token")
+		}
+	})
+}
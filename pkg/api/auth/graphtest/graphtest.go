@@ -0,0 +1,134 @@
+// Package graphtest is a stand-in for the parts of Microsoft Graph used to resolve Azure AD groups
+package graphtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const Token = "graphtest-token"
+
+type Group struct {
+	DisplayName string
+	Mail        string
+	Members     []string
+}
+
+// Server answers group and transitive member lookups from the groups it has been given. Members
+// are paged, so clients have to follow the next links like they do against the real Graph API.
+type Server struct {
+	*httptest.Server
+
+	PageSize int
+
+	mu       sync.Mutex
+	groups   map[string]Group
+	requests int
+}
+
+func NewServer() *Server {
+	s := &Server{
+		PageSize: 2,
+		groups:   map[string]Group{},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+
+	return s
+}
+
+func (s *Server) SetGroup(id string, group Group) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.groups[id] = group
+}
+
+func (s *Server) DeleteGroup(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.groups, id)
+}
+
+// Requests returns the number of requests the server has answered
+func (s *Server) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.requests
+}
+
+// TokenFunc can be given to auth.NewGraphClient
+func (s *Server) TokenFunc() (string, error) {
+	return Token, nil
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests++
+
+	if r.Header.Get("Authorization") != "Bearer "+Token {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) < 2 || parts[0] != "groups" {
+		http.NotFound(w, r)
+		return
+	}
+
+	group, ok := s.groups[parts[1]]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch {
+	case len(parts) == 2:
+		writeJSON(w, map[string]string{
+			"id":          parts[1],
+			"displayName": group.DisplayName,
+			"mail":        group.Mail,
+		})
+	case len(parts) == 4 && parts[2] == "transitiveMembers" && parts[3] == "microsoft.graph.user":
+		s.writeMembers(w, r, group)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) writeMembers(w http.ResponseWriter, r *http.Request, group Group) {
+	skip, _ := strconv.Atoi(r.URL.Query().Get("$skiptoken"))
+	end := min(skip+s.PageSize, len(group.Members))
+
+	members := []map[string]string{}
+	for _, member := range group.Members[skip:end] {
+		members = append(members, map[string]string{"userPrincipalName": member})
+	}
+
+	response := map[string]any{"value": members}
+	if end < len(group.Members) {
+		next := *r.URL
+		query := next.Query()
+		query.Set("$skiptoken", strconv.Itoa(end))
+		next.RawQuery = query.Encode()
+		response["@odata.nextLink"] = fmt.Sprintf("%v%v", s.URL, next.RequestURI())
+	}
+
+	writeJSON(w, response)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/navikt/knorten/pkg/api/auth"
+	"github.com/navikt/knorten/pkg/database"
+	"github.com/navikt/knorten/pkg/database/gensql"
+)
+
+type azureGroupForm struct {
+	GroupID string `form:"group_id" binding:"required"`
+	Role    string `form:"role"`
+}
+
+func (c *client) setupAzureGroupRoutes() {
+	c.router.POST("/team/:slug/azure-groups", func(ctx *gin.Context) {
+		teamSlug := ctx.Param("slug")
+
+		err := c.linkAzureGroup(ctx, teamSlug)
+		if err != nil {
+			c.log.WithError(err).WithField("team", teamSlug).Info("link azure group")
+			session := sessions.Default(ctx)
+			session.AddFlash(err.Error())
+			err := session.Save()
+			if err != nil {
+				c.log.WithError(err).Error("problem saving session")
+			}
+		}
+
+		ctx.Redirect(http.StatusSeeOther, fmt.Sprintf("/team/%v/edit", teamSlug))
+	})
+
+	c.router.POST("/team/:slug/azure-groups/:group/delete", func(ctx *gin.Context) {
+		teamSlug := ctx.Param("slug")
+
+		err := c.unlinkAzureGroup(ctx, teamSlug, ctx.Param("group"))
+		if err != nil {
+			c.log.WithError(err).WithField("team", teamSlug).Info("unlink azure group")
+			session := sessions.Default(ctx)
+			session.AddFlash(err.Error())
+			err := session.Save()
+			if err != nil {
+				c.log.WithError(err).Error("problem saving session")
+			}
+		}
+
+		ctx.Redirect(http.StatusSeeOther, fmt.Sprintf("/team/%v/edit", teamSlug))
+	})
+}
+
+func (c *client) linkAzureGroup(ctx *gin.Context, teamSlug string) error {
+	var form azureGroupForm
+	if err := ctx.ShouldBindWith(&form, binding.Form); err != nil {
+		return fmt.Errorf("gruppe-ID er påkrevd")
+	}
+
+	role := gensql.TeamRoleMaintainer
+	if form.Role != "" {
+		role = gensql.TeamRole(form.Role)
+	}
+
+	if !slices.Contains(database.TeamRoles, role) {
+		return fmt.Errorf("ugyldig rolle %q", form.Role)
+	}
+
+	team, err := c.repo.TeamBySlugGet(ctx, teamSlug)
+	if err != nil {
+		return err
+	}
+
+	groupID := strings.TrimSpace(form.GroupID)
+	displayName := groupID
+	if c.dryRun {
+		c.log.Infof("NOOP: Would have looked up azure group %v", groupID)
+	} else {
+		group, err := c.azureClient.GraphClient().Group(ctx, groupID)
+		if err != nil {
+			if errors.Is(err, auth.ErrAzureGroupNotFound) {
+				return fmt.Errorf("fant ingen Azure AD-gruppe med ID %v", groupID)
+			}
+			return err
+		}
+
+		displayName = group.DisplayName
+	}
+
+	if err := c.repo.TeamAzureGroupSet(ctx, team.ID, groupID, displayName, role); err != nil {
+		return err
+	}
+
+	auditChange(ctx, nil, gin.H{"group_id": groupID, "display_name": displayName, "role": role})
+
+	return c.syncAzureGroups(ctx, team.ID)
+}
+
+func (c *client) unlinkAzureGroup(ctx *gin.Context, teamSlug, groupID string) error {
+	team, err := c.repo.TeamBySlugGet(ctx, teamSlug)
+	if err != nil {
+		return err
+	}
+
+	if err := c.repo.TeamAzureGroupDelete(ctx, team.ID, groupID); err != nil {
+		return err
+	}
+
+	auditChange(ctx, gin.H{"group_id": groupID}, nil)
+
+	return c.syncAzureGroups(ctx, team.ID)
+}
+
+// syncAzureGroups applies a change to the linked groups right away, instead of waiting for the
+// periodic sync
+func (c *client) syncAzureGroups(ctx *gin.Context, teamID string) error {
+	if c.dryRun {
+		c.log.Infof("NOOP: Would have synced azure groups for team %v", teamID)
+		return nil
+	}
+
+	return c.azureGroups.SyncTeam(ctx, teamID)
+}
@@ -1,12 +1,16 @@
 package api
 
 import (
+	"bufio"
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/navikt/knorten/pkg/api/middlewares"
 	"github.com/navikt/knorten/pkg/k8s"
@@ -17,10 +21,30 @@ import (
 	"github.com/go-playground/validator/v10"
 	"github.com/navikt/knorten/pkg/chart"
 	"github.com/navikt/knorten/pkg/database/gensql"
+	"github.com/navikt/knorten/pkg/team"
 )
 
 type AirflowProperties struct {
 	Namespace string
+	Component string
+}
+
+type airflowLogsForm struct {
+	Pod       string `form:"pod" binding:"required"`
+	Container string `form:"container"`
+	Lines     int64  `form:"lines"`
+	Follow    bool   `form:"follow"`
+}
+
+const (
+	defaultAirflowLogLines = 200
+	maxAirflowLogLines     = 2000
+	// Followed log streams are closed after this, so forgotten browser tabs don't keep them open
+	maxAirflowLogFollowDuration = 30 * time.Minute
+)
+
+type airflowRestartForm struct {
+	Component string `form:"component"`
 }
 
 type airflowForm struct {
@@ -210,6 +234,20 @@ func (c *client) setupChartRoutes() {
 			return
 		}
 
+		var injectedContainers []injectedContainerChoice
+		var scheduledEdit *scheduledEvent
+		if chartType == gensql.ChartTypeAirflow {
+			injectedContainers, err = c.injectedContainerChoices(ctx, teamID)
+			if err != nil {
+				log.WithError(err).Error("problem getting injected containers")
+			}
+
+			scheduledEdit, err = c.scheduledAirflowEdit(ctx, teamID)
+			if err != nil {
+				log.WithError(err).Error("problem getting scheduled edit")
+			}
+		}
+
 		flashes := session.Flashes()
 		err = session.Save()
 		if err != nil {
@@ -218,9 +256,11 @@ func (c *client) setupChartRoutes() {
 		}
 
 		ctx.HTML(http.StatusOK, fmt.Sprintf("charts/%v", chartType), gin.H{
-			"team":   teamSlug,
-			"values": form,
-			"errors": flashes,
+			"team":               teamSlug,
+			"values":             form,
+			"errors":             flashes,
+			"injectedContainers": injectedContainers,
+			"scheduledEdit":      scheduledEdit,
 			"upgradePausedStatuses": c.maintenanceExclusionConfig.ActiveExcludePeriodForTeams(
 				[]string{teamID},
 			),
@@ -285,28 +325,268 @@ func (c *client) setupChartRoutes() {
 		ctx.Redirect(http.StatusSeeOther, "/oversikt")
 	})
 
-	c.router.POST("/team/:slug/airflow/restart", func(ctx *gin.Context) {
+	c.router.GET("/team/:slug/airflow/status", func(ctx *gin.Context) {
 		teamSlug := ctx.Param("slug")
+		log := c.log.WithField("team", teamSlug)
+
 		team, err := c.repo.TeamBySlugGet(ctx, teamSlug)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				ctx.JSON(http.StatusNotFound, map[string]string{
+					"status":  strconv.Itoa(http.StatusNotFound),
+					"message": fmt.Sprintf("team %v does not exist", teamSlug),
+				})
+				return
+			}
+			log.WithError(err).Errorf("problem getting team %v", teamSlug)
+			ctx.Redirect(http.StatusSeeOther, "/oversikt")
+			return
+		}
+
+		session := sessions.Default(ctx)
+
+		status, err := c.airflowService.Status(ctx, k8s.TeamIDToNamespace(team.ID))
+		if err != nil {
+			log.WithError(err).Error("problem getting airflow status")
+			session.AddFlash(err.Error())
+			err := session.Save()
+			if err != nil {
+				log.WithError(err).Error("problem saving session")
+			}
+			ctx.Redirect(http.StatusSeeOther, "/oversikt")
+			return
+		}
+
+		isHibernated, err := c.repo.AirflowIsHibernated(ctx, team.ID)
+		if err != nil {
+			log.WithError(err).Error("problem getting airflow hibernation state")
+		}
+
+		// Viewers may see the status, but not read the logs of the pods
+		role, _ := ctx.Get(middlewares.TeamRoleKey)
+
+		flashes := session.Flashes()
+		err = session.Save()
+		if err != nil {
+			log.WithError(err).Error("problem saving session")
+			return
+		}
+
+		ctx.HTML(http.StatusOK, "charts/airflow/status", gin.H{
+			"slug":         team.Slug,
+			"status":       status,
+			"isHibernated": isHibernated,
+			"showLogs":     role != gensql.TeamRoleViewer,
+			"errors":       flashes,
+			"loggedIn":     ctx.GetBool(middlewares.LoggedInKey),
+			"isAdmin":      ctx.GetBool(middlewares.AdminKey),
+		})
+	})
+
+	c.router.GET("/team/:slug/airflow/logs", func(ctx *gin.Context) {
+		teamSlug := ctx.Param("slug")
 		log := c.log.WithField("team", teamSlug)
 
+		teamRow, err := c.repo.TeamBySlugGet(ctx, teamSlug)
 		if err != nil {
-			log.WithError(err).Error("problem fetching team")
+			if errors.Is(err, sql.ErrNoRows) {
+				ctx.JSON(http.StatusNotFound, map[string]string{
+					"status":  strconv.Itoa(http.StatusNotFound),
+					"message": fmt.Sprintf("team %v does not exist", teamSlug),
+				})
+				return
+			}
+			log.WithError(err).Errorf("problem getting team %v", teamSlug)
+			ctx.Redirect(http.StatusSeeOther, "/oversikt")
+			return
 		}
-		namespace := k8s.TeamIDToNamespace(team.ID)
 
-		values := AirflowProperties{Namespace: namespace}
+		status, err := c.airflowService.Status(ctx, k8s.TeamIDToNamespace(teamRow.ID))
+		if err != nil {
+			log.WithError(err).Error("problem getting airflow status")
+			session := sessions.Default(ctx)
+			session.AddFlash(err.Error())
+			err := session.Save()
+			if err != nil {
+				log.WithError(err).Error("problem saving session")
+			}
+			ctx.Redirect(http.StatusSeeOther, "/oversikt")
+			return
+		}
 
-		err = c.repo.RegisterDeleteSchedulerPodsEvent(ctx, team.ID, values)
+		ctx.HTML(http.StatusOK, "charts/airflow/logs", gin.H{
+			"slug":         teamRow.Slug,
+			"status":       status,
+			"pod":          ctx.Query("pod"),
+			"defaultLines": defaultAirflowLogLines,
+			"maxLines":     maxAirflowLogLines,
+			"loggedIn":     ctx.GetBool(middlewares.LoggedInKey),
+			"isAdmin":      ctx.GetBool(middlewares.AdminKey),
+		})
+	})
+
+	c.router.GET("/team/:slug/airflow/logs/stream", func(ctx *gin.Context) {
+		teamSlug := ctx.Param("slug")
+		log := c.log.WithField("team", teamSlug)
+
+		var form airflowLogsForm
+		err := ctx.ShouldBindQuery(&form)
 		if err != nil {
-			log.WithError(err).
-				Errorf("problem registering restart airflow scheduler event for team %s", team.ID)
+			ctx.String(http.StatusBadRequest, "ugyldig forespørsel: %v", err)
+			return
 		}
 
-		ctx.Redirect(http.StatusSeeOther, "/oversikt")
+		if form.Lines <= 0 {
+			form.Lines = defaultAirflowLogLines
+		}
+
+		if form.Lines > maxAirflowLogLines {
+			form.Lines = maxAirflowLogLines
+		}
+
+		teamRow, err := c.repo.TeamBySlugGet(ctx, teamSlug)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				ctx.String(http.StatusNotFound, "team %v finnes ikke", teamSlug)
+				return
+			}
+			log.WithError(err).Errorf("problem getting team %v", teamSlug)
+			ctx.String(http.StatusInternalServerError, "klarte ikke å hente team")
+			return
+		}
+
+		streamCtx := ctx.Request.Context()
+		if form.Follow {
+			var cancel context.CancelFunc
+			streamCtx, cancel = context.WithTimeout(streamCtx, maxAirflowLogFollowDuration)
+			defer cancel()
+		}
+
+		logs, err := c.airflowService.PodLogs(streamCtx, k8s.TeamIDToNamespace(teamRow.ID), form.Pod, team.PodLogOptions{
+			Container: form.Container,
+			TailLines: form.Lines,
+			Follow:    form.Follow,
+		})
+		if err != nil {
+			if errors.Is(err, team.ErrAirflowPodNotFound) || errors.Is(err, team.ErrAirflowContainerNotFound) {
+				ctx.String(http.StatusNotFound, err.Error())
+				return
+			}
+			log.WithError(err).Errorf("problem streaming logs for pod %v", form.Pod)
+			ctx.String(http.StatusInternalServerError, "klarte ikke å hente logger")
+			return
+		}
+		defer logs.Close()
+
+		ctx.Header("Content-Type", "text/plain; charset=utf-8")
+		ctx.Header("X-Content-Type-Options", "nosniff")
+		ctx.Header("Cache-Control", "no-cache")
+
+		scanner := bufio.NewScanner(logs)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		ctx.Stream(func(w io.Writer) bool {
+			if !scanner.Scan() {
+				return false
+			}
+
+			_, err := fmt.Fprintln(w, scanner.Text())
+			return err == nil
+		})
+	})
+
+	c.router.POST("/team/:slug/airflow/restart", func(ctx *gin.Context) {
+		c.handleAirflowRestart(ctx, "/oversikt")
+	})
+
+	c.router.POST("/team/:slug/airflow/hibernate", func(ctx *gin.Context) {
+		c.handleAirflowHibernation(ctx, true, "/oversikt")
+	})
+
+	c.router.POST("/team/:slug/airflow/wake", func(ctx *gin.Context) {
+		c.handleAirflowHibernation(ctx, false, "/oversikt")
 	})
 }
 
+// handleAirflowRestart registers a restart event for the Airflow component in the form,
+// defaulting to the scheduler, and redirects back to the given page.
+func (c *client) handleAirflowRestart(ctx *gin.Context, redirect string) {
+	teamSlug := ctx.Param("slug")
+	log := c.log.WithField("team", teamSlug)
+
+	err := c.registerAirflowRestartEvent(ctx, teamSlug)
+	if err != nil {
+		log.WithError(err).Error("problem registering restart airflow component event")
+		session := sessions.Default(ctx)
+		session.AddFlash(err.Error())
+		err := session.Save()
+		if err != nil {
+			log.WithError(err).Error("problem saving session")
+		}
+	}
+
+	ctx.Redirect(http.StatusSeeOther, redirect)
+}
+
+func (c *client) registerAirflowRestartEvent(ctx *gin.Context, teamSlug string) error {
+	var form airflowRestartForm
+	err := ctx.ShouldBindWith(&form, binding.Form)
+	if err != nil {
+		return err
+	}
+
+	component := team.AirflowComponent(form.Component)
+	if component == "" {
+		component = team.AirflowComponentScheduler
+	}
+
+	if !component.IsValid() {
+		return fmt.Errorf("ukjent Airflow-komponent: %v", form.Component)
+	}
+
+	t, err := c.repo.TeamBySlugGet(ctx, teamSlug)
+	if err != nil {
+		return err
+	}
+
+	return c.repo.RegisterRestartAirflowComponentEvent(ctx, t.ID, AirflowProperties{
+		Namespace: k8s.TeamIDToNamespace(t.ID),
+		Component: string(component),
+	}, eventOptions(ctx)...)
+}
+
+// handleAirflowHibernation registers a hibernate or wake event for the team in the slug
+// parameter, and redirects back to the given page.
+func (c *client) handleAirflowHibernation(ctx *gin.Context, hibernate bool, redirect string) {
+	teamSlug := ctx.Param("slug")
+	log := c.log.WithField("team", teamSlug)
+
+	err := c.registerAirflowHibernationEvent(ctx, teamSlug, hibernate)
+	if err != nil {
+		log.WithError(err).Errorf("problem registering airflow hibernation event (hibernate: %v)", hibernate)
+		session := sessions.Default(ctx)
+		session.AddFlash(err.Error())
+		err := session.Save()
+		if err != nil {
+			log.WithError(err).Error("problem saving session")
+		}
+	}
+
+	ctx.Redirect(http.StatusSeeOther, redirect)
+}
+
+func (c *client) registerAirflowHibernationEvent(ctx *gin.Context, teamSlug string, hibernate bool) error {
+	team, err := c.repo.TeamBySlugGet(ctx, teamSlug)
+	if err != nil {
+		return err
+	}
+
+	if hibernate {
+		return c.repo.RegisterHibernateAirflowEvent(ctx, team.ID, eventOptions(ctx)...)
+	}
+
+	return c.repo.RegisterWakeAirflowEvent(ctx, team.ID, eventOptions(ctx)...)
+}
+
 func (c *client) newChart(ctx *gin.Context, teamSlug string, chartType gensql.ChartType) error {
 	team, err := c.repo.TeamBySlugGet(ctx, teamSlug)
 	if err != nil {
@@ -343,7 +623,7 @@ func (c *client) newChart(ctx *gin.Context, teamSlug string, chartType gensql.Ch
 			AirflowTag:    airflowTag,
 		}
 
-		return c.repo.RegisterCreateAirflowEvent(ctx, team.ID, values)
+		return c.repo.RegisterCreateAirflowEvent(ctx, team.ID, values, eventOptions(ctx)...)
 	}
 
 	return fmt.Errorf("chart type %v is not supported", chartType)
@@ -442,7 +722,24 @@ func (c *client) editChart(ctx *gin.Context, teamSlug string, chartType gensql.C
 			AirflowTag:    airflowTag,
 		}
 
-		return c.repo.RegisterUpdateAirflowEvent(ctx, team.ID, values)
+		scheduledEdit, err := c.scheduledAirflowEdit(ctx, team.ID)
+		if err != nil {
+			return err
+		}
+
+		if scheduledEdit != nil {
+			return fmt.Errorf(
+				"en endring av Airflow er planlagt %v, flytt eller avbryt den før du gjør nye endringer",
+				scheduledEdit.RunAfter.Format("02.01.2006 15:04"),
+			)
+		}
+
+		opts, err := eventOptionsWithRunAfter(ctx)
+		if err != nil {
+			return err
+		}
+
+		return c.repo.RegisterUpdateAirflowEvent(ctx, team.ID, values, opts...)
 	}
 
 	return fmt.Errorf("chart type %v is not supported", chartType)
@@ -456,7 +753,7 @@ func (c *client) deleteChart(ctx *gin.Context, teamSlug, chartTypeString string)
 
 	switch getChartType(chartTypeString) {
 	case gensql.ChartTypeAirflow:
-		return c.repo.RegisterDeleteAirflowEvent(ctx, team.ID)
+		return c.repo.RegisterDeleteAirflowEvent(ctx, team.ID, eventOptions(ctx)...)
 	}
 
 	return fmt.Errorf("chart type %v is not supported", chartTypeString)
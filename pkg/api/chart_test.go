@@ -11,6 +11,7 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/navikt/knorten/pkg/chart"
@@ -210,6 +211,53 @@ func TestAirflowAPI(t *testing.T) {
 		}
 	})
 
+	t.Run("schedule edit airflow", func(t *testing.T) {
+		location, err := time.LoadLocation(runAfterLocation)
+		if err != nil {
+			t.Fatal(err)
+		}
+		runAfter := time.Now().Add(24 * time.Hour).In(location).Truncate(time.Minute)
+
+		data := url.Values{"dagrepo": {"navikt/newrepo"}, "dagrepobranch": {"main"}, "run_after": {runAfter.Format(runAfterLayout)}}
+		resp, err := server.Client().PostForm(fmt.Sprintf("%v/team/%v/airflow/edit", server.URL, team.Slug), data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		scheduled, err := repo.EventsScheduledForOwnerGet(ctx, team.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(scheduled) != 1 || scheduled[0].Type != string(database.EventTypeUpdateAirflow) || !scheduled[0].RunAfter.Time.Equal(runAfter) {
+			t.Errorf("schedule edit airflow: expected an update scheduled at %v, got %+v", runAfter, scheduled)
+		}
+	})
+
+	t.Run("edit airflow while an edit is scheduled", func(t *testing.T) {
+		before, err := repo.EventsByOwnerGet(ctx, team.ID, 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		data := url.Values{"dagrepo": {"navikt/otherrepo"}, "dagrepobranch": {"main"}}
+		resp, err := server.Client().PostForm(fmt.Sprintf("%v/team/%v/airflow/edit", server.URL, team.Slug), data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		after, err := repo.EventsByOwnerGet(ctx, team.ID, 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(after) != len(before) {
+			t.Errorf("edit airflow while an edit is scheduled: expected no new event, got %v events, had %v", len(after), len(before))
+		}
+	})
+
 	t.Run("delete airflow", func(t *testing.T) {
 		resp, err := server.Client().PostForm(fmt.Sprintf("%v/team/%v/airflow/delete", server.URL, team.Slug), nil)
 		if err != nil {
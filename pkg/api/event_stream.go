@@ -0,0 +1,204 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	eventStreamPollInterval = 2 * time.Second
+	// eventStreamLifetime ends each stream after a while, so that open streams don't hold up a
+	// graceful shutdown. The browser reconnects by itself, and continues from the id of the last
+	// message it got.
+	eventStreamLifetime = time.Minute
+)
+
+// eventStatusMessage is sent when an event is registered or changes status
+type eventStatusMessage struct {
+	ID     uuid.UUID `json:"id"`
+	Owner  string    `json:"owner"`
+	Type   string    `json:"type"`
+	Status string    `json:"status"`
+}
+
+// eventLogMessage is sent when a log is written for an event
+type eventLogMessage struct {
+	EventID   uuid.UUID `json:"eventID"`
+	LogType   string    `json:"logType"`
+	Message   string    `json:"message"`
+	CreatedAt string    `json:"createdAt"`
+}
+
+// eventStreamCursor is how far a stream has come. It's sent as the id of each message, and the
+// browser sends the id of the last message it got in the Last-Event-ID header when it reconnects.
+type eventStreamCursor struct {
+	logsSince   time.Time
+	eventsSince time.Time
+}
+
+func (c eventStreamCursor) String() string {
+	return c.logsSince.Format(time.RFC3339Nano) + "," + c.eventsSince.Format(time.RFC3339Nano)
+}
+
+func parseEventStreamCursor(id string) (eventStreamCursor, error) {
+	logsSince, eventsSince, ok := strings.Cut(id, ",")
+	if !ok {
+		return eventStreamCursor{}, fmt.Errorf("invalid event stream cursor %q", id)
+	}
+
+	var cursor eventStreamCursor
+	var err error
+	if cursor.logsSince, err = time.Parse(time.RFC3339Nano, logsSince); err != nil {
+		return eventStreamCursor{}, err
+	}
+	if cursor.eventsSince, err = time.Parse(time.RFC3339Nano, eventsSince); err != nil {
+		return eventStreamCursor{}, err
+	}
+
+	return cursor, nil
+}
+
+// setupEventStreamRoutes streams the progress of events to the browser as server-sent events. The
+// team stream is authorized by the team membership check, like the rest of the team routes, and the
+// user stream only includes the teams the user is a member of.
+func (c *client) setupEventStreamRoutes() {
+	c.router.GET("/team/:slug/events/stream", func(ctx *gin.Context) {
+		team, err := c.repo.TeamBySlugGet(ctx, ctx.Param("slug"))
+		if err != nil {
+			c.log.WithError(err).Errorf("problem getting team %v", ctx.Param("slug"))
+			ctx.Status(http.StatusNotFound)
+			return
+		}
+
+		c.streamEvents(ctx, []string{team.ID})
+	})
+
+	c.router.GET("/oversikt/events/stream", func(ctx *gin.Context) {
+		user, teams, err := c.getUserAndTeams(ctx)
+		if err != nil {
+			c.log.WithError(err).Error("problem getting teams for user")
+			ctx.Status(http.StatusInternalServerError)
+			return
+		}
+
+		c.streamEvents(ctx, append([]string{user.Email}, teams...))
+	})
+}
+
+func (c *client) streamEvents(ctx *gin.Context, owners []string) {
+	cursor, err := c.eventStreamStart(ctx)
+	if err != nil {
+		c.log.WithError(err).Error("problem starting event stream")
+		ctx.Status(http.StatusInternalServerError)
+		return
+	}
+
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.Status(http.StatusOK)
+	ctx.Writer.Flush()
+
+	lifetime := time.NewTimer(eventStreamLifetime)
+	defer lifetime.Stop()
+	ticker := time.NewTicker(eventStreamPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Request.Context().Done():
+			return
+		case <-lifetime.C:
+			return
+		case <-ticker.C:
+		}
+
+		cursor, err = c.sendEventProgress(ctx, owners, cursor)
+		if err != nil {
+			c.log.WithError(err).Error("problem streaming events")
+			return
+		}
+	}
+}
+
+// eventStreamStart continues from the cursor of a reconnecting browser, or starts at the current
+// time of the database, as the page already shows what has happened before
+func (c *client) eventStreamStart(ctx *gin.Context) (eventStreamCursor, error) {
+	if id := ctx.GetHeader("Last-Event-ID"); id != "" {
+		cursor, err := parseEventStreamCursor(id)
+		if err == nil {
+			return cursor, nil
+		}
+		c.log.WithError(err).Info("ignoring the id of the last event")
+	}
+
+	now, err := c.repo.EventsClockGet(ctx)
+	if err != nil {
+		return eventStreamCursor{}, err
+	}
+
+	return eventStreamCursor{logsSince: now, eventsSince: now}, nil
+}
+
+// sendEventProgress sends the status changes before the logs, so that the page knows about a new
+// event before its logs arrive
+func (c *client) sendEventProgress(ctx *gin.Context, owners []string, cursor eventStreamCursor) (eventStreamCursor, error) {
+	events, err := c.repo.EventsForOwnersUpdatedSinceGet(ctx, owners, cursor.eventsSince)
+	if err != nil {
+		return cursor, err
+	}
+
+	logs, err := c.repo.EventLogsForOwnersSinceGet(ctx, owners, cursor.logsSince)
+	if err != nil {
+		return cursor, err
+	}
+
+	w := ctx.Writer
+	for _, event := range events {
+		cursor.eventsSince = event.UpdatedAt
+		err := writeServerSentEvent(w, cursor, "status", eventStatusMessage{
+			ID:     event.ID,
+			Owner:  event.Owner,
+			Type:   event.Type,
+			Status: event.Status,
+		})
+		if err != nil {
+			return cursor, err
+		}
+	}
+
+	for _, log := range logs {
+		cursor.logsSince = log.CreatedAt
+		err := writeServerSentEvent(w, cursor, "log", eventLogMessage{
+			EventID:   log.EventID,
+			LogType:   string(log.LogType),
+			Message:   log.Message,
+			CreatedAt: log.CreatedAt.Format("02.01.2006 15:04:05"),
+		})
+		if err != nil {
+			return cursor, err
+		}
+	}
+
+	if len(events) > 0 || len(logs) > 0 {
+		w.Flush()
+	}
+
+	return cursor, nil
+}
+
+func writeServerSentEvent(w gin.ResponseWriter, cursor eventStreamCursor, name string, message any) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %v\nevent: %v\ndata: %s\n\n", cursor, name, data)
+	return err
+}
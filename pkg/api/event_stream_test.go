@@ -0,0 +1,104 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/navikt/knorten/pkg/database"
+	"github.com/navikt/knorten/pkg/database/gensql"
+)
+
+func TestEventStreamCursor(t *testing.T) {
+	cursor := eventStreamCursor{
+		logsSince:   time.Date(2024, 3, 1, 12, 0, 0, 123456000, time.UTC),
+		eventsSince: time.Date(2024, 3, 1, 12, 0, 1, 0, time.UTC),
+	}
+
+	parsed, err := parseEventStreamCursor(cursor.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !parsed.logsSince.Equal(cursor.logsSince) || !parsed.eventsSince.Equal(cursor.eventsSince) {
+		t.Errorf("expected %v, got %v", cursor, parsed)
+	}
+
+	if _, err := parseEventStreamCursor("not a cursor"); err == nil {
+		t.Error("expected an invalid cursor to be rejected")
+	}
+}
+
+func TestEventStreamAPI(t *testing.T) {
+	ctx := context.Background()
+
+	team := gensql.Team{
+		ID:    "stream-team-1234",
+		Slug:  "stream-team",
+		Users: []string{testUser.Email},
+	}
+	if err := repo.TeamCreate(ctx, &team); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		if err := repo.TeamDelete(ctx, team.ID); err != nil {
+			t.Errorf("cleaning up after event stream tests: %v", err)
+		}
+	})
+
+	if err := repo.RegisterUpdateAirflowEvent(ctx, team.ID, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	events, err := repo.EventsByOwnerGet(ctx, team.ID, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	event := events[0]
+
+	if err := repo.EventLogCreate(ctx, event.ID, "starter oppdatering", database.LogTypeInfo); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("stream status changes and logs from the cursor", func(t *testing.T) {
+		streamCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(streamCtx, http.MethodGet, fmt.Sprintf("%v/team/%v/events/stream", server.URL, team.Slug), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		since := event.CreatedAt.Add(-time.Second)
+		req.Header.Set("Last-Event-ID", eventStreamCursor{logsSince: since, eventsSince: since}.String())
+
+		resp, err := server.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		if resp.Header.Get("Content-Type") != "text/event-stream" {
+			t.Fatalf("expected an event stream, got %v", resp.Header.Get("Content-Type"))
+		}
+
+		var received []string
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() && len(received) < 2 {
+			if name, ok := strings.CutPrefix(scanner.Text(), "event: "); ok {
+				received = append(received, name)
+			}
+			if data, ok := strings.CutPrefix(scanner.Text(), "data: "); ok && !strings.Contains(data, event.ID.String()) {
+				t.Errorf("expected messages about %v, got %v", event.ID, data)
+			}
+		}
+
+		if strings.Join(received, ",") != "status,log" {
+			t.Errorf("expected the status before the log, got %v", received)
+		}
+	})
+}
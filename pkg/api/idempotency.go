@@ -0,0 +1,70 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/navikt/knorten/pkg/database"
+)
+
+const (
+	idempotencyKeyHeader = "Idempotency-Key"
+	// idempotencyKeyKey holds the idempotency key of the request
+	idempotencyKeyKey = "knorten/idempotency_key"
+)
+
+// idempotencyMiddleware makes the events registered by a request idempotent, so that a form which is
+// submitted twice, or a retried call, doesn't register the same event again. Clients can supply the key
+// in the Idempotency-Key header, otherwise it's derived from the user and the submitted form.
+func (c *client) idempotencyMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if ctx.Request.Method == http.MethodGet || ctx.Request.Method == http.MethodHead {
+			ctx.Next()
+			return
+		}
+
+		key := ctx.GetHeader(idempotencyKeyHeader)
+		if key == "" {
+			var err error
+			key, err = formIdempotencyKey(ctx)
+			if err != nil {
+				c.log.WithError(err).Info("deriving idempotency key from form")
+				ctx.Next()
+				return
+			}
+		}
+
+		ctx.Set(idempotencyKeyKey, key)
+		ctx.Next()
+	}
+}
+
+// eventOptions are the options for the events registered by the request, which makes them idempotent
+func eventOptions(ctx *gin.Context) []database.EventOption {
+	return []database.EventOption{database.WithEventIdempotencyKey(ctx.GetString(idempotencyKeyKey))}
+}
+
+// formIdempotencyKey hashes the user, the path and the submitted form. The form values are encoded
+// sorted by key, so the key doesn't depend on the order of the fields.
+func formIdempotencyKey(ctx *gin.Context) (string, error) {
+	err := ctx.Request.ParseMultipartForm(32 << 20)
+	if err != nil && !errors.Is(err, http.ErrNotMultipart) {
+		return "", err
+	}
+
+	var actor string
+	if user, err := getUser(ctx); err == nil {
+		actor = user.Email
+	}
+
+	hash := sha256.New()
+	for _, part := range []string{actor, ctx.Request.Method, ctx.Request.URL.Path, ctx.Request.PostForm.Encode()} {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,408 @@
+package api
+
+import (
+	"context"
+	"encoding/gob"
+	"fmt"
+	"net/http"
+	"regexp"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"github.com/navikt/knorten/pkg/api/middlewares"
+	"github.com/navikt/knorten/pkg/database/gensql"
+	"github.com/navikt/knorten/pkg/helm"
+	"sigs.k8s.io/yaml"
+)
+
+var injectedContainerNameRegex = regexp.MustCompile(`^[a-z][a-z0-9-]{0,62}$`)
+
+type injectedContainerForm struct {
+	Container     string `form:"container" binding:"required"`
+	Volumes       string `form:"volumes"`
+	Enabled       bool   `form:"enabled"`
+	OptOutAllowed bool   `form:"opt_out_allowed"`
+}
+
+// injectedContainerChange is passed through the session from the edit page to the confirm page
+type injectedContainerChange struct {
+	Name          string
+	Container     string
+	Volumes       string
+	Enabled       bool
+	OptOutAllowed bool
+}
+
+type injectedContainerChoice struct {
+	Name     string
+	Version  int32
+	OptedOut bool
+}
+
+func (c *client) setupInjectedContainerRoutes() {
+	c.router.POST("/team/:slug/airflow/injected-containers", func(ctx *gin.Context) {
+		teamSlug := ctx.Param("slug")
+		log := c.log.WithField("team", teamSlug)
+
+		err := c.setInjectedContainerOptOuts(ctx, teamSlug)
+		if err != nil {
+			log.WithError(err).Error("problem setting injected container opt-outs")
+			session := sessions.Default(ctx)
+			session.AddFlash(err.Error())
+			err := session.Save()
+			if err != nil {
+				log.WithError(err).Error("problem saving session")
+			}
+		}
+
+		ctx.Redirect(http.StatusSeeOther, fmt.Sprintf("/team/%v/airflow/edit", teamSlug))
+	})
+}
+
+func (c *client) setupInjectedContainerAdminRoutes() {
+	c.router.GET("/admin/injected-containers", func(ctx *gin.Context) {
+		session := sessions.Default(ctx)
+		flashes := session.Flashes()
+		err := session.Save()
+		if err != nil {
+			c.log.WithError(err).Error("problem saving session")
+			ctx.Redirect(http.StatusSeeOther, "/admin")
+			return
+		}
+
+		containers, err := c.repo.InjectedContainersGet(ctx, gensql.ChartTypeAirflow)
+		if err != nil {
+			c.log.WithError(err).Error("problem getting injected containers")
+			ctx.Redirect(http.StatusSeeOther, "/admin")
+			return
+		}
+
+		ctx.HTML(http.StatusOK, "admin/injected-containers", gin.H{
+			"containers": containers,
+			"errors":     flashes,
+			"loggedIn":   ctx.GetBool(middlewares.LoggedInKey),
+			"isAdmin":    ctx.GetBool(middlewares.AdminKey),
+		})
+	})
+
+	c.router.POST("/admin/injected-containers", func(ctx *gin.Context) {
+		name := ctx.PostForm("name")
+		if !injectedContainerNameRegex.MatchString(name) {
+			session := sessions.Default(ctx)
+			session.AddFlash(fmt.Sprintf("ugyldig navn %q, bruk små bokstaver, tall og bindestrek", name))
+			err := session.Save()
+			if err != nil {
+				c.log.WithError(err).Error("problem saving session")
+			}
+			ctx.Redirect(http.StatusSeeOther, "/admin/injected-containers")
+			return
+		}
+
+		ctx.Redirect(http.StatusSeeOther, fmt.Sprintf("/admin/injected-containers/%v", name))
+	})
+
+	c.router.GET("/admin/injected-containers/:name", func(ctx *gin.Context) {
+		name := ctx.Param("name")
+		session := sessions.Default(ctx)
+		flashes := session.Flashes()
+		err := session.Save()
+		if err != nil {
+			c.log.WithError(err).Error("problem saving session")
+			ctx.Redirect(http.StatusSeeOther, "/admin/injected-containers")
+			return
+		}
+
+		versions, err := c.repo.InjectedContainerVersionsGet(ctx, gensql.ChartTypeAirflow, name)
+		if err != nil {
+			c.log.WithError(err).Errorf("problem getting versions for injected container %v", name)
+			ctx.Redirect(http.StatusSeeOther, "/admin/injected-containers")
+			return
+		}
+
+		form := injectedContainerForm{Enabled: true}
+		if len(versions) > 0 {
+			form, err = injectedContainerFormFromStored(versions[0])
+			if err != nil {
+				c.log.WithError(err).Errorf("problem converting injected container %v", name)
+				ctx.Redirect(http.StatusSeeOther, "/admin/injected-containers")
+				return
+			}
+		}
+
+		ctx.HTML(http.StatusOK, "admin/injected-container", gin.H{
+			"name":     name,
+			"form":     form,
+			"versions": versions,
+			"errors":   flashes,
+			"loggedIn": ctx.GetBool(middlewares.LoggedInKey),
+			"isAdmin":  ctx.GetBool(middlewares.AdminKey),
+		})
+	})
+
+	c.router.POST("/admin/injected-containers/:name", func(ctx *gin.Context) {
+		name := ctx.Param("name")
+		session := sessions.Default(ctx)
+
+		change, err := parseInjectedContainerForm(ctx, name)
+		if err != nil {
+			session.AddFlash(err.Error())
+			err := session.Save()
+			if err != nil {
+				c.log.WithError(err).Error("problem saving session")
+			}
+			ctx.Redirect(http.StatusSeeOther, fmt.Sprintf("/admin/injected-containers/%v", name))
+			return
+		}
+
+		gob.Register(change)
+		session.AddFlash(change)
+		err = session.Save()
+		if err != nil {
+			c.log.WithError(err).Error("problem saving session")
+			ctx.Redirect(http.StatusSeeOther, fmt.Sprintf("/admin/injected-containers/%v", name))
+			return
+		}
+
+		ctx.Redirect(http.StatusSeeOther, fmt.Sprintf("/admin/injected-containers/%v/confirm", name))
+	})
+
+	c.router.GET("/admin/injected-containers/:name/confirm", func(ctx *gin.Context) {
+		name := ctx.Param("name")
+		session := sessions.Default(ctx)
+		flashes := session.Flashes()
+		err := session.Save()
+		if err != nil {
+			c.log.WithError(err).Error("problem saving session")
+			ctx.Redirect(http.StatusSeeOther, fmt.Sprintf("/admin/injected-containers/%v", name))
+			return
+		}
+
+		var change *injectedContainerChange
+		for _, flash := range flashes {
+			if pending, ok := flash.(injectedContainerChange); ok {
+				change = &pending
+			}
+		}
+
+		if change == nil {
+			ctx.Redirect(http.StatusSeeOther, fmt.Sprintf("/admin/injected-containers/%v", name))
+			return
+		}
+
+		current, next, err := c.injectedContainerPreviews(ctx, *change)
+		if err != nil {
+			c.log.WithError(err).Errorf("problem previewing injected container %v", name)
+			session.AddFlash(err.Error())
+			err := session.Save()
+			if err != nil {
+				c.log.WithError(err).Error("problem saving session")
+			}
+			ctx.Redirect(http.StatusSeeOther, fmt.Sprintf("/admin/injected-containers/%v", name))
+			return
+		}
+
+		ctx.HTML(http.StatusOK, "admin/injected-container-confirm", gin.H{
+			"change":          change,
+			"currentPreview":  current,
+			"proposedPreview": next,
+			"loggedIn":        ctx.GetBool(middlewares.LoggedInKey),
+			"isAdmin":         ctx.GetBool(middlewares.AdminKey),
+		})
+	})
+
+	c.router.POST("/admin/injected-containers/:name/confirm", func(ctx *gin.Context) {
+		name := ctx.Param("name")
+		session := sessions.Default(ctx)
+
+		err := c.insertInjectedContainer(ctx, name)
+		if err != nil {
+			c.log.WithError(err).Errorf("problem saving injected container %v", name)
+			session.AddFlash(err.Error())
+			err := session.Save()
+			if err != nil {
+				c.log.WithError(err).Error("problem saving session")
+			}
+			ctx.Redirect(http.StatusSeeOther, fmt.Sprintf("/admin/injected-containers/%v", name))
+			return
+		}
+
+		ctx.Redirect(http.StatusSeeOther, fmt.Sprintf("/admin/injected-containers/%v", name))
+	})
+}
+
+func parseInjectedContainerForm(ctx *gin.Context, name string) (injectedContainerChange, error) {
+	if !injectedContainerNameRegex.MatchString(name) {
+		return injectedContainerChange{}, fmt.Errorf("ugyldig navn %q", name)
+	}
+
+	var form injectedContainerForm
+	err := ctx.ShouldBind(&form)
+	if err != nil {
+		return injectedContainerChange{}, err
+	}
+
+	container, volumes, err := helm.ParseInjectedContainer(form.Container, form.Volumes)
+	if err != nil {
+		return injectedContainerChange{}, err
+	}
+
+	return injectedContainerChange{
+		Name:          name,
+		Container:     container,
+		Volumes:       volumes,
+		Enabled:       form.Enabled,
+		OptOutAllowed: form.OptOutAllowed,
+	}, nil
+}
+
+func (c *client) insertInjectedContainer(ctx *gin.Context, name string) error {
+	change, err := parseInjectedContainerForm(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	user, err := getUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = c.repo.InjectedContainerInsert(ctx, gensql.InjectedContainerInsertParams{
+		Name:          change.Name,
+		ChartType:     gensql.ChartTypeAirflow,
+		Container:     change.Container,
+		Volumes:       change.Volumes,
+		Enabled:       change.Enabled,
+		OptOutAllowed: change.OptOutAllowed,
+		CreatedBy:     user.Email,
+	})
+	if err != nil {
+		return err
+	}
+
+	if _, found := ctx.GetPostForm(ActionTriggerResync); found {
+		return c.syncChartForAllTeams(ctx, gensql.ChartTypeAirflow, eventOptions(ctx)...)
+	}
+
+	return nil
+}
+
+// injectedContainerPreviews renders the values the current version, and the proposed change,
+// adds to every Airflow instance which hasn't opted out.
+func (c *client) injectedContainerPreviews(ctx context.Context, change injectedContainerChange) (string, string, error) {
+	var current string
+
+	versions, err := c.repo.InjectedContainerVersionsGet(ctx, gensql.ChartTypeAirflow, change.Name)
+	if err != nil {
+		return "", "", err
+	}
+
+	if len(versions) > 0 {
+		current, err = helm.InjectedContainerPreview(versions[0])
+		if err != nil {
+			return "", "", err
+		}
+	}
+
+	next, err := helm.InjectedContainerPreview(gensql.InjectedContainer{
+		Name:      change.Name,
+		Container: change.Container,
+		Volumes:   change.Volumes,
+		Enabled:   change.Enabled,
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	return current, next, nil
+}
+
+func injectedContainerFormFromStored(container gensql.InjectedContainer) (injectedContainerForm, error) {
+	containerYAML, err := yaml.JSONToYAML([]byte(container.Container))
+	if err != nil {
+		return injectedContainerForm{}, err
+	}
+
+	volumesYAML, err := yaml.JSONToYAML([]byte(container.Volumes))
+	if err != nil {
+		return injectedContainerForm{}, err
+	}
+
+	return injectedContainerForm{
+		Container:     string(containerYAML),
+		Volumes:       string(volumesYAML),
+		Enabled:       container.Enabled,
+		OptOutAllowed: container.OptOutAllowed,
+	}, nil
+}
+
+// injectedContainerChoices lists the injected containers the team is allowed to opt out of
+func (c *client) injectedContainerChoices(ctx context.Context, teamID string) ([]injectedContainerChoice, error) {
+	containers, err := c.repo.InjectedContainersGet(ctx, gensql.ChartTypeAirflow)
+	if err != nil {
+		return nil, err
+	}
+
+	optOuts, err := c.repo.InjectedContainerOptOutsGet(ctx, gensql.ChartTypeAirflow, teamID)
+	if err != nil {
+		return nil, err
+	}
+
+	choices := []injectedContainerChoice{}
+	for _, container := range containers {
+		if !container.Enabled || !container.OptOutAllowed {
+			continue
+		}
+
+		choice := injectedContainerChoice{Name: container.Name, Version: container.Version}
+		for _, optOut := range optOuts {
+			if optOut == container.Name {
+				choice.OptedOut = true
+			}
+		}
+
+		choices = append(choices, choice)
+	}
+
+	return choices, nil
+}
+
+func (c *client) setInjectedContainerOptOuts(ctx *gin.Context, teamSlug string) error {
+	team, err := c.repo.TeamBySlugGet(ctx, teamSlug)
+	if err != nil {
+		return err
+	}
+
+	choices, err := c.injectedContainerChoices(ctx, team.ID)
+	if err != nil {
+		return err
+	}
+
+	enabled := ctx.PostFormArray("enabled")
+
+	changed := false
+	for _, choice := range choices {
+		optOut := true
+		for _, name := range enabled {
+			if name == choice.Name {
+				optOut = false
+			}
+		}
+
+		if optOut == choice.OptedOut {
+			continue
+		}
+
+		err := c.repo.InjectedContainerOptOutSet(ctx, gensql.ChartTypeAirflow, team.ID, choice.Name, optOut)
+		if err != nil {
+			return err
+		}
+
+		changed = true
+	}
+
+	if !changed {
+		return nil
+	}
+
+	return c.syncChart(ctx, team.ID, gensql.ChartTypeAirflow, eventOptions(ctx)...)
+}
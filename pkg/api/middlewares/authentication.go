@@ -5,7 +5,6 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
-	"slices"
 	"strings"
 	"time"
 
@@ -67,7 +66,8 @@ func Authenticate(log *logrus.Entry, repo *database.Repo, azureClient *auth.Azur
 		}
 
 		teamSlug := ctx.Param("slug")
-		if teamSlug != "" {
+		// Admin routes act on any team, and are guarded by the admin middleware instead
+		if teamSlug != "" && !strings.HasPrefix(ctx.FullPath(), "/admin/") {
 			team, err := repo.TeamBySlugGet(ctx, teamSlug)
 			if err != nil {
 				log.WithError(err).Errorf("problem checking for authorization %v", user.Email)
@@ -75,7 +75,15 @@ func Authenticate(log *logrus.Entry, repo *database.Repo, azureClient *auth.Azur
 				return
 			}
 
-			if !slices.Contains(team.Users, strings.ToLower(user.Email)) {
+			role, err := repo.TeamMemberRoleGet(ctx, team.ID, user.Email)
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				log.WithError(err).Errorf("problem checking for authorization %v", user.Email)
+				ctx.Redirect(http.StatusSeeOther, "/")
+				return
+			}
+
+			required := RequiredTeamRole(ctx.Request.Method, ctx.FullPath())
+			if errors.Is(err, sql.ErrNoRows) || !database.TeamRoleAllows(role, required) {
 				sess := sessions.Default(ctx)
 				sess.AddFlash(fmt.Sprintf("%v is not authorized", user.Email))
 				err = sess.Save()
@@ -84,9 +92,11 @@ func Authenticate(log *logrus.Entry, repo *database.Repo, azureClient *auth.Azur
 					ctx.Redirect(http.StatusSeeOther, "/")
 					return
 				}
-				ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": fmt.Sprintf("%v is not part of team %v", user.Email, teamSlug)})
+				ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": fmt.Sprintf("%v needs the %v role in team %v", user.Email, required, teamSlug)})
 				return
 			}
+
+			ctx.Set(TeamRoleKey, role)
 		}
 
 		ctx.Set("user", user)
@@ -0,0 +1,51 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/navikt/knorten/pkg/database/gensql"
+)
+
+// TeamRoleKey holds the role the logged in user has in the team of the current request
+const TeamRoleKey string = "knorten/team_role"
+
+// ownerRoutes are the team routes for managing membership, renaming and deleting the team, for
+// seeing who has changed it, and for choosing who gets notified about its events
+var ownerRoutes = map[string]bool{
+	http.MethodGet + " /team/:slug/edit":     true,
+	http.MethodPost + " /team/:slug/edit":    true,
+	http.MethodPost + " /team/:slug/delete":  true,
+	http.MethodPost + " /team/:slug/restore": true,
+	http.MethodPost + " /team/:slug/rename":  true,
+	http.MethodGet + " /team/:slug/audit":    true,
+
+	http.MethodGet + " /team/:slug/notifications":             true,
+	http.MethodPost + " /team/:slug/notifications":            true,
+	http.MethodPost + " /team/:slug/notifications/:id/delete": true,
+
+	http.MethodPost + " /team/:slug/azure-groups":               true,
+	http.MethodPost + " /team/:slug/azure-groups/:group/delete": true,
+}
+
+// viewerRoutes are the team routes showing the status of the team's services and its events. Other
+// routes, like the Airflow pod logs, need at least the maintainer role.
+var viewerRoutes = map[string]bool{
+	http.MethodGet + " /team/:slug/airflow/status": true,
+	http.MethodGet + " /team/:slug/events":         true,
+	http.MethodGet + " /team/:slug/events/stream":  true,
+}
+
+// RequiredTeamRole decides which role a team member needs for the route. Owners manage the team
+// itself, maintainers change the charts and read their logs, and viewers only see status and events.
+func RequiredTeamRole(method, fullPath string) gensql.TeamRole {
+	route := method + " " + fullPath
+	if ownerRoutes[route] {
+		return gensql.TeamRoleOwner
+	}
+
+	if viewerRoutes[route] {
+		return gensql.TeamRoleViewer
+	}
+
+	return gensql.TeamRoleMaintainer
+}
@@ -0,0 +1,41 @@
+package middlewares
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/navikt/knorten/pkg/database/gensql"
+)
+
+func TestRequiredTeamRole(t *testing.T) {
+	testCases := []struct {
+		method string
+		path   string
+		expect gensql.TeamRole
+	}{
+		{method: http.MethodGet, path: "/team/:slug/edit", expect: gensql.TeamRoleOwner},
+		{method: http.MethodPost, path: "/team/:slug/edit", expect: gensql.TeamRoleOwner},
+		{method: http.MethodPost, path: "/team/:slug/delete", expect: gensql.TeamRoleOwner},
+		{method: http.MethodPost, path: "/team/:slug/azure-groups", expect: gensql.TeamRoleOwner},
+		{method: http.MethodPost, path: "/team/:slug/restore", expect: gensql.TeamRoleOwner},
+		{method: http.MethodPost, path: "/team/:slug/rename", expect: gensql.TeamRoleOwner},
+		{method: http.MethodGet, path: "/team/:slug/audit", expect: gensql.TeamRoleOwner},
+		{method: http.MethodGet, path: "/team/:slug/notifications", expect: gensql.TeamRoleOwner},
+		{method: http.MethodPost, path: "/team/:slug/notifications/:id/delete", expect: gensql.TeamRoleOwner},
+		{method: http.MethodGet, path: "/team/:slug/:chart/edit", expect: gensql.TeamRoleMaintainer},
+		{method: http.MethodGet, path: "/team/:slug/:chart/new", expect: gensql.TeamRoleMaintainer},
+		{method: http.MethodPost, path: "/team/:slug/:chart/delete", expect: gensql.TeamRoleMaintainer},
+		{method: http.MethodPost, path: "/team/:slug/airflow/restart", expect: gensql.TeamRoleMaintainer},
+		{method: http.MethodGet, path: "/team/:slug/airflow/status", expect: gensql.TeamRoleViewer},
+		{method: http.MethodGet, path: "/team/:slug/airflow/logs", expect: gensql.TeamRoleMaintainer},
+		{method: http.MethodGet, path: "/team/:slug/airflow/logs/stream", expect: gensql.TeamRoleMaintainer},
+		{method: http.MethodGet, path: "/team/:slug/events", expect: gensql.TeamRoleViewer},
+		{method: http.MethodGet, path: "/team/:slug/events/stream", expect: gensql.TeamRoleViewer},
+	}
+
+	for _, tc := range testCases {
+		if got := RequiredTeamRole(tc.method, tc.path); got != tc.expect {
+			t.Errorf("RequiredTeamRole(%v, %v): expected %v, got %v", tc.method, tc.path, tc.expect, got)
+		}
+	}
+}
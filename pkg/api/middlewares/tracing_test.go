@@ -0,0 +1,59 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/navikt/knorten/pkg/tracing"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracing(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(tracing.NewProvider(sdktrace.WithSyncer(exporter)))
+	if _, err := tracing.Setup(context.Background(), ""); err != nil {
+		t.Fatal(err)
+	}
+
+	var handlerSpan trace.SpanContext
+	router := gin.New()
+	router.ContextWithFallback = true
+	router.Use(Tracing())
+	router.POST("/team/:slug/edit", func(ctx *gin.Context) {
+		handlerSpan = trace.SpanContextFromContext(ctx)
+		ctx.Status(http.StatusInternalServerError)
+	})
+
+	traceparent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	req := httptest.NewRequest(http.MethodPost, "/team/my-team/edit", nil)
+	req.Header.Set("traceparent", traceparent)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected one span, got %v", len(spans))
+	}
+
+	span := spans[0]
+	if span.Name != "POST /team/:slug/edit" || span.SpanKind != trace.SpanKindServer {
+		t.Errorf("unexpected span %v (%v)", span.Name, span.SpanKind)
+	}
+
+	if span.SpanContext.TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" || span.Parent.SpanID().String() != "00f067aa0ba902b7" {
+		t.Errorf("expected the span to continue the trace of the caller, got %v", span.SpanContext)
+	}
+
+	if handlerSpan.SpanID() != span.SpanContext.SpanID() {
+		t.Errorf("expected the gin context to carry the span, got %v", handlerSpan)
+	}
+
+	if span.Status.Code.String() != "Error" {
+		t.Errorf("expected a server error to mark the span as failed, got %v", span.Status)
+	}
+}
@@ -0,0 +1,348 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"slices"
+	"strings"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/navikt/knorten/pkg/api/middlewares"
+	"github.com/navikt/knorten/pkg/database"
+	"github.com/navikt/knorten/pkg/database/gensql"
+	"github.com/navikt/knorten/pkg/notifications"
+)
+
+const notificationDeliveriesLimit = 50
+
+type notificationSubscriptionForm struct {
+	Team       string   `form:"team"`
+	Sink       string   `form:"sink"`
+	Target     string   `form:"target"`
+	EventTypes []string `form:"event_types[]"`
+	Statuses   []string `form:"statuses[]"`
+}
+
+func (c *client) setupNotificationRoutes() {
+	c.router.GET("/team/:slug/notifications", func(ctx *gin.Context) {
+		teamSlug := ctx.Param("slug")
+		team, err := c.repo.TeamBySlugGet(ctx, teamSlug)
+		if err != nil {
+			c.log.WithError(err).Errorf("problem getting team %v", teamSlug)
+			ctx.Redirect(http.StatusSeeOther, "/oversikt")
+			return
+		}
+
+		session := sessions.Default(ctx)
+		flashes := session.Flashes()
+		err = session.Save()
+		if err != nil {
+			c.log.WithError(err).Error("problem saving session")
+			return
+		}
+
+		subscriptions, err := c.repo.NotificationSubscriptionsForTeamGet(ctx, team.ID)
+		if err != nil {
+			c.log.WithError(err).Errorf("problem getting notification subscriptions for team %v", teamSlug)
+			ctx.Redirect(http.StatusSeeOther, "/oversikt")
+			return
+		}
+
+		ctx.HTML(http.StatusOK, "team/notifications", gin.H{
+			"slug":          team.Slug,
+			"subscriptions": subscriptions,
+			"options":       notificationOptions(),
+			"errors":        flashes,
+			"loggedIn":      ctx.GetBool(middlewares.LoggedInKey),
+			"isAdmin":       ctx.GetBool(middlewares.AdminKey),
+		})
+	})
+
+	c.router.POST("/team/:slug/notifications", func(ctx *gin.Context) {
+		teamSlug := ctx.Param("slug")
+
+		err := c.subscribeTeamNotifications(ctx, teamSlug)
+		if err != nil {
+			c.log.WithError(err).WithField("team", teamSlug).Info("subscribe to notifications")
+			session := sessions.Default(ctx)
+			session.AddFlash(err.Error())
+			err := session.Save()
+			if err != nil {
+				c.log.WithError(err).Error("problem saving session")
+			}
+		}
+
+		ctx.Redirect(http.StatusSeeOther, fmt.Sprintf("/team/%v/notifications", teamSlug))
+	})
+
+	c.router.POST("/team/:slug/notifications/:id/delete", func(ctx *gin.Context) {
+		teamSlug := ctx.Param("slug")
+
+		err := c.unsubscribeTeamNotifications(ctx, teamSlug, ctx.Param("id"))
+		if err != nil {
+			c.log.WithError(err).WithField("team", teamSlug).Info("unsubscribe from notifications")
+			session := sessions.Default(ctx)
+			session.AddFlash(err.Error())
+			err := session.Save()
+			if err != nil {
+				c.log.WithError(err).Error("problem saving session")
+			}
+		}
+
+		ctx.Redirect(http.StatusSeeOther, fmt.Sprintf("/team/%v/notifications", teamSlug))
+	})
+}
+
+func (c *client) setupNotificationAdminRoutes() {
+	c.router.GET("/admin/notifications", func(ctx *gin.Context) {
+		session := sessions.Default(ctx)
+		flashes := session.Flashes()
+		err := session.Save()
+		if err != nil {
+			c.log.WithError(err).Error("problem saving session")
+			return
+		}
+
+		header, err := c.adminNotifications(ctx)
+		if err != nil {
+			c.log.WithError(err).Error("problem getting notifications")
+			ctx.Redirect(http.StatusSeeOther, "/admin")
+			return
+		}
+
+		header["errors"] = flashes
+		header["loggedIn"] = ctx.GetBool(middlewares.LoggedInKey)
+		header["isAdmin"] = ctx.GetBool(middlewares.AdminKey)
+
+		ctx.HTML(http.StatusOK, "admin/notifications", header)
+	})
+
+	c.router.POST("/admin/notifications", func(ctx *gin.Context) {
+		err := c.subscribeNotifications(ctx)
+		if err != nil {
+			c.log.WithError(err).Info("subscribe to notifications")
+			session := sessions.Default(ctx)
+			session.AddFlash(err.Error())
+			err := session.Save()
+			if err != nil {
+				c.log.WithError(err).Error("problem saving session")
+			}
+		}
+
+		ctx.Redirect(http.StatusSeeOther, "/admin/notifications")
+	})
+
+	c.router.POST("/admin/notifications/:id/delete", func(ctx *gin.Context) {
+		err := c.unsubscribeNotifications(ctx, ctx.Param("id"))
+		if err != nil {
+			c.log.WithError(err).Info("unsubscribe from notifications")
+			session := sessions.Default(ctx)
+			session.AddFlash(err.Error())
+			err := session.Save()
+			if err != nil {
+				c.log.WithError(err).Error("problem saving session")
+			}
+		}
+
+		ctx.Redirect(http.StatusSeeOther, "/admin/notifications")
+	})
+}
+
+func notificationOptions() gin.H {
+	return gin.H{
+		"sinks":      database.NotificationSinks,
+		"eventTypes": database.NotificationEventTypes,
+		"statuses":   database.NotificationStatuses,
+	}
+}
+
+func (c *client) adminNotifications(ctx context.Context) (gin.H, error) {
+	subscriptions, err := c.repo.NotificationSubscriptionsGet(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries, err := c.repo.NotificationDeliveriesGet(ctx, notificationDeliveriesLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	teams, err := c.repo.TeamsGet(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return gin.H{
+		"subscriptions": subscriptions,
+		"deliveries":    deliveries,
+		"teams":         teams,
+		"options":       notificationOptions(),
+	}, nil
+}
+
+func (c *client) subscribeTeamNotifications(ctx *gin.Context, teamSlug string) error {
+	team, err := c.repo.TeamBySlugGet(ctx, teamSlug)
+	if err != nil {
+		return err
+	}
+
+	subscription, err := notificationSubscriptionFromForm(ctx)
+	if err != nil {
+		return err
+	}
+
+	subscription.TeamID = team.ID
+	if err := c.repo.NotificationSubscriptionCreate(ctx, subscription); err != nil {
+		return err
+	}
+
+	auditChange(ctx, nil, subscription)
+	return nil
+}
+
+func (c *client) unsubscribeTeamNotifications(ctx *gin.Context, teamSlug, id string) error {
+	team, err := c.repo.TeamBySlugGet(ctx, teamSlug)
+	if err != nil {
+		return err
+	}
+
+	subscriptionID, err := uuid.Parse(id)
+	if err != nil {
+		return err
+	}
+
+	subscription, err := c.repo.NotificationSubscriptionGet(ctx, subscriptionID)
+	if err != nil {
+		return err
+	}
+
+	// Subscriptions for every team are managed by the admins
+	if subscription.TeamID.String != team.ID {
+		return fmt.Errorf("varselet tilhører ikke %v", teamSlug)
+	}
+
+	if err := c.repo.NotificationSubscriptionDelete(ctx, subscriptionID); err != nil {
+		return err
+	}
+
+	auditChange(ctx, subscription, nil)
+	return nil
+}
+
+// subscribeNotifications lets admins subscribe to the events of any team, or all of them
+func (c *client) subscribeNotifications(ctx *gin.Context) error {
+	subscription, err := notificationSubscriptionFromForm(ctx)
+	if err != nil {
+		return err
+	}
+
+	if subscription.TeamID != "" {
+		if _, err := c.repo.TeamGet(ctx, subscription.TeamID); err != nil {
+			return fmt.Errorf("fant ikke teamet %v: %w", subscription.TeamID, err)
+		}
+		auditTeam(ctx, subscription.TeamID)
+	}
+
+	if err := c.repo.NotificationSubscriptionCreate(ctx, subscription); err != nil {
+		return err
+	}
+
+	auditChange(ctx, nil, subscription)
+	return nil
+}
+
+func (c *client) unsubscribeNotifications(ctx *gin.Context, id string) error {
+	subscriptionID, err := uuid.Parse(id)
+	if err != nil {
+		return err
+	}
+
+	subscription, err := c.repo.NotificationSubscriptionGet(ctx, subscriptionID)
+	if err != nil {
+		return err
+	}
+
+	if err := c.repo.NotificationSubscriptionDelete(ctx, subscriptionID); err != nil {
+		return err
+	}
+
+	auditTeam(ctx, subscription.TeamID.String)
+	auditChange(ctx, subscription, nil)
+	return nil
+}
+
+func notificationSubscriptionFromForm(ctx *gin.Context) (database.NotificationSubscription, error) {
+	var form notificationSubscriptionForm
+	if err := ctx.ShouldBind(&form); err != nil {
+		return database.NotificationSubscription{}, err
+	}
+
+	sink := database.NotificationSink(form.Sink)
+	if !slices.Contains(database.NotificationSinks, sink) {
+		return database.NotificationSubscription{}, fmt.Errorf("ukjent varslingskanal %q", form.Sink)
+	}
+
+	target := strings.TrimSpace(form.Target)
+	if err := validateNotificationTarget(sink, target); err != nil {
+		return database.NotificationSubscription{}, err
+	}
+
+	eventTypes := []database.EventType{}
+	for _, eventType := range form.EventTypes {
+		if !slices.Contains(database.NotificationEventTypes, database.EventType(eventType)) {
+			return database.NotificationSubscription{}, fmt.Errorf("ukjent eventtype %q", eventType)
+		}
+		eventTypes = append(eventTypes, database.EventType(eventType))
+	}
+
+	if len(form.Statuses) == 0 {
+		return database.NotificationSubscription{}, fmt.Errorf("velg minst én status å bli varslet om")
+	}
+
+	statuses := []database.EventStatus{}
+	for _, status := range form.Statuses {
+		if !slices.Contains(database.NotificationStatuses, database.EventStatus(status)) {
+			return database.NotificationSubscription{}, fmt.Errorf("ukjent status %q", status)
+		}
+		statuses = append(statuses, database.EventStatus(status))
+	}
+
+	user, err := getUser(ctx)
+	if err != nil {
+		return database.NotificationSubscription{}, err
+	}
+
+	return database.NotificationSubscription{
+		TeamID:     form.Team,
+		Sink:       sink,
+		Target:     target,
+		EventTypes: eventTypes,
+		Statuses:   statuses,
+		CreatedBy:  user.Email,
+	}, nil
+}
+
+func validateNotificationTarget(sink database.NotificationSink, target string) error {
+	switch sink {
+	case database.NotificationSinkEmail:
+		if _, err := mail.ParseAddress(target); err != nil {
+			return fmt.Errorf("'%v' er ikke en gyldig e-postadresse", target)
+		}
+	default:
+		return notifications.ValidateWebhookTarget(target)
+	}
+
+	return nil
+}
+
+// eventStatusChanged notifies those subscribing to a status the event was given outside the event
+// handler. A failing notification doesn't undo the change.
+func (c *client) eventStatusChanged(ctx context.Context, event gensql.Event, status database.EventStatus, message string) {
+	if err := c.notifications.EventStatusChanged(ctx, event, status, message); err != nil {
+		c.log.WithError(err).WithField("eventID", event.ID).Errorf("queueing notifications for status %v", status)
+	}
+}
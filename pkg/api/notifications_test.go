@@ -0,0 +1,116 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/navikt/knorten/pkg/database/gensql"
+)
+
+func TestNotificationsAPI(t *testing.T) {
+	ctx := context.Background()
+
+	team := gensql.Team{
+		ID:    "notify-team-1234",
+		Slug:  "notify-team",
+		Users: []string{testUser.Email},
+	}
+	if err := repo.TeamCreate(ctx, &team); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		if err := repo.TeamDelete(ctx, team.ID); err != nil {
+			t.Errorf("cleaning up after notifications tests: %v", err)
+		}
+	})
+
+	t.Run("subscribe team to notifications", func(t *testing.T) {
+		data := url.Values{
+			"sink":          {"slack"},
+			"target":        {"https://hooks.slack.example/services/abc"},
+			"event_types[]": {"update:airflow"},
+			"statuses[]":    {"failed", "deadline_reached"},
+		}
+		resp, err := server.Client().PostForm(fmt.Sprintf("%v/team/%v/notifications", server.URL, team.Slug), data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		subscriptions, err := repo.NotificationSubscriptionsForTeamGet(ctx, team.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(subscriptions) != 1 {
+			t.Fatalf("expected one subscription for %v, got %v", team.Slug, subscriptions)
+		}
+
+		subscription := subscriptions[0]
+		if subscription.Sink != "slack" || subscription.CreatedBy != testUser.Email || len(subscription.Statuses) != 2 {
+			t.Errorf("unexpected subscription %+v", subscription)
+		}
+	})
+
+	t.Run("invalid targets are rejected", func(t *testing.T) {
+		data := url.Values{
+			"sink":       {"email"},
+			"target":     {"not an address"},
+			"statuses[]": {"failed"},
+		}
+		resp, err := server.Client().PostForm(fmt.Sprintf("%v/team/%v/notifications", server.URL, team.Slug), data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		subscriptions, err := repo.NotificationSubscriptionsForTeamGet(ctx, team.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(subscriptions) != 1 {
+			t.Errorf("expected the invalid subscription to be rejected, got %v", subscriptions)
+		}
+	})
+
+	t.Run("unsubscribe team from notifications", func(t *testing.T) {
+		subscriptions, err := repo.NotificationSubscriptionsForTeamGet(ctx, team.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, subscription := range subscriptions {
+			resp, err := server.Client().PostForm(fmt.Sprintf("%v/team/%v/notifications/%v/delete", server.URL, team.Slug, subscription.ID), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+		}
+
+		subscriptions, err = repo.NotificationSubscriptionsForTeamGet(ctx, team.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(subscriptions) != 0 {
+			t.Errorf("expected no subscriptions left, got %v", subscriptions)
+		}
+	})
+
+	t.Run("get admin notifications", func(t *testing.T) {
+		resp, err := server.Client().Get(fmt.Sprintf("%v/admin/notifications", server.URL))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("expected status code 200, got %v", resp.StatusCode)
+		}
+	})
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"github.com/navikt/knorten/pkg/api/middlewares"
+	"github.com/navikt/knorten/pkg/orphans"
+)
+
+func (c *client) setupOrphanAdminRoutes() {
+	c.router.GET("/admin/orphans", func(ctx *gin.Context) {
+		session := sessions.Default(ctx)
+		flashes := session.Flashes()
+		err := session.Save()
+		if err != nil {
+			c.log.WithError(err).Error("problem saving session")
+			return
+		}
+
+		candidates, err := c.orphanScanner.Scan(ctx)
+		if err != nil {
+			c.log.WithError(err).Error("problem scanning for orphaned resources")
+			flashes = append(flashes, err.Error())
+		}
+
+		ctx.HTML(http.StatusOK, "admin/orphans", gin.H{
+			"candidates": candidates,
+			"errors":     flashes,
+			"loggedIn":   ctx.GetBool(middlewares.LoggedInKey),
+			"isAdmin":    ctx.GetBool(middlewares.AdminKey),
+		})
+	})
+
+	c.router.POST("/admin/orphans/delete", func(ctx *gin.Context) {
+		err := c.deleteOrphan(ctx)
+		if err != nil {
+			c.log.WithError(err).Info("delete orphaned resource")
+			session := sessions.Default(ctx)
+			session.AddFlash(err.Error())
+			err := session.Save()
+			if err != nil {
+				c.log.WithError(err).Error("problem saving session")
+			}
+		}
+
+		ctx.Redirect(http.StatusSeeOther, "/admin/orphans")
+	})
+}
+
+// deleteOrphan deletes the resource, if the name has been typed in to confirm. The scanner checks
+// again that the resource hasn't been taken into use since the page was loaded.
+func (c *client) deleteOrphan(ctx *gin.Context) error {
+	candidate := orphans.Candidate{
+		Kind: orphans.Kind(ctx.PostForm("kind")),
+		Name: ctx.PostForm("name"),
+	}
+
+	if candidate.Name == "" || ctx.PostForm("confirm") != candidate.Name {
+		return fmt.Errorf("skriv inn navnet på ressursen for å bekrefte slettingen")
+	}
+
+	if err := c.orphanScanner.Delete(ctx, candidate.Kind, candidate.Name); err != nil {
+		if errors.Is(err, orphans.ErrNotOrphaned) {
+			return fmt.Errorf("%v %v tilhører et team, og blir ikke slettet", candidate.Kind, candidate.Name)
+		}
+		return err
+	}
+
+	auditChange(ctx, candidate, nil)
+	return nil
+}
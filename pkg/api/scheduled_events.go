@@ -0,0 +1,258 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/navikt/knorten/pkg/chart"
+	"github.com/navikt/knorten/pkg/database"
+	"github.com/navikt/knorten/pkg/database/gensql"
+)
+
+const (
+	// runAfterLayout is the format of datetime-local inputs
+	runAfterLayout   = "2006-01-02T15:04"
+	runAfterLocation = "Europe/Oslo"
+)
+
+type scheduledEvent struct {
+	ID       uuid.UUID
+	Type     string
+	RunAfter time.Time
+}
+
+// RunAfterInput is the time the event is scheduled at, as the value of a datetime-local input
+func (e scheduledEvent) RunAfterInput() string {
+	return e.RunAfter.Format(runAfterLayout)
+}
+
+func (c *client) setupScheduledEventRoutes() {
+	c.router.POST("/team/:slug/events/:id/reschedule", func(ctx *gin.Context) {
+		teamSlug := ctx.Param("slug")
+
+		err := c.rescheduleEvent(ctx, teamSlug, ctx.Param("id"), ctx.PostForm("run_after"))
+		if err != nil {
+			c.log.WithError(err).WithField("team", teamSlug).Info("rescheduling event")
+			session := sessions.Default(ctx)
+			session.AddFlash(err.Error())
+			err := session.Save()
+			if err != nil {
+				c.log.WithError(err).Error("problem saving session")
+			}
+		}
+
+		ctx.Redirect(http.StatusSeeOther, fmt.Sprintf("/team/%v/events", teamSlug))
+	})
+
+	c.router.POST("/team/:slug/events/:id/cancel", func(ctx *gin.Context) {
+		teamSlug := ctx.Param("slug")
+
+		err := c.cancelEvent(ctx, teamSlug, ctx.Param("id"))
+		if err != nil {
+			c.log.WithError(err).WithField("team", teamSlug).Info("cancelling event")
+			session := sessions.Default(ctx)
+			session.AddFlash(err.Error())
+			err := session.Save()
+			if err != nil {
+				c.log.WithError(err).Error("problem saving session")
+			}
+		}
+
+		ctx.Redirect(http.StatusSeeOther, fmt.Sprintf("/team/%v/events", teamSlug))
+	})
+}
+
+func (c *client) scheduledEvents(ctx context.Context, teamID string) ([]scheduledEvent, error) {
+	events, err := c.repo.EventsScheduledForOwnerGet(ctx, teamID)
+	if err != nil {
+		return nil, err
+	}
+
+	location, err := time.LoadLocation(runAfterLocation)
+	if err != nil {
+		return nil, err
+	}
+
+	scheduled := make([]scheduledEvent, len(events))
+	for i, event := range events {
+		scheduled[i] = scheduledEvent{
+			ID:       event.ID,
+			Type:     event.Type,
+			RunAfter: event.RunAfter.Time.In(location),
+		}
+	}
+
+	return scheduled, nil
+}
+
+func (c *client) rescheduleEvent(ctx *gin.Context, teamSlug, id, runAfterValue string) error {
+	event, err := c.teamEvent(ctx, teamSlug, id)
+	if err != nil {
+		return err
+	}
+
+	runAfter, err := parseRunAfter(runAfterValue)
+	if err != nil {
+		return err
+	}
+
+	user, err := getUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := c.repo.EventReschedule(ctx, event.ID, event.Owner, runAfter); err != nil {
+		if errors.Is(err, database.ErrEventNotScheduled) {
+			return fmt.Errorf("hendelsen har allerede startet, og kan ikke flyttes")
+		}
+		return err
+	}
+
+	message := fmt.Sprintf("Flyttet til %v av %v", runAfter.Format("02.01.2006 15:04"), user.Email)
+	if err := c.repo.EventLogCreate(ctx, event.ID, message, database.LogTypeInfo); err != nil {
+		c.log.WithError(err).WithField("eventID", event.ID).Error("logging that the event was rescheduled")
+	}
+
+	auditChange(ctx, scheduledEventFrom(event), scheduledEvent{ID: event.ID, Type: event.Type, RunAfter: runAfter})
+	return nil
+}
+
+func (c *client) cancelEvent(ctx *gin.Context, teamSlug, id string) error {
+	event, err := c.teamEvent(ctx, teamSlug, id)
+	if err != nil {
+		return err
+	}
+
+	user, err := getUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := c.repo.EventCancel(ctx, event.ID, event.Owner); err != nil {
+		if errors.Is(err, database.ErrEventNotScheduled) {
+			return fmt.Errorf("hendelsen har allerede startet, og kan ikke avbrytes")
+		}
+		return err
+	}
+
+	message := fmt.Sprintf("Avbrutt av %v", user.Email)
+	if err := c.repo.EventLogCreate(ctx, event.ID, message, database.LogTypeInfo); err != nil {
+		c.log.WithError(err).WithField("eventID", event.ID).Error("logging that the event was cancelled")
+	}
+	c.eventStatusChanged(ctx, event, database.EventStatusCancelled, message)
+
+	auditChange(ctx, scheduledEventFrom(event), nil)
+	return nil
+}
+
+// scheduledAirflowEdit is the scheduled Airflow update of the team which holds the values of an
+// edit, if there is one. It overwrites the changes made before it runs, so the team has to move or
+// cancel it first. Resyncs only hold the team ID, and read the values from the database when they run.
+func (c *client) scheduledAirflowEdit(ctx context.Context, teamID string) (*scheduledEvent, error) {
+	events, err := c.repo.EventsScheduledForOwnerGet(ctx, teamID)
+	if err != nil {
+		return nil, err
+	}
+
+	location, err := time.LoadLocation(runAfterLocation)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, event := range events {
+		if database.EventType(event.Type) != database.EventTypeUpdateAirflow {
+			continue
+		}
+
+		var values chart.AirflowConfigurableValues
+		if err := json.Unmarshal(event.Payload, &values); err != nil {
+			return nil, err
+		}
+
+		if values.DagRepo != "" {
+			return &scheduledEvent{
+				ID:       event.ID,
+				Type:     event.Type,
+				RunAfter: event.RunAfter.Time.In(location),
+			}, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// teamEvent gets the event, making sure that it belongs to the team
+func (c *client) teamEvent(ctx context.Context, teamSlug, id string) (gensql.Event, error) {
+	team, err := c.repo.TeamBySlugGet(ctx, teamSlug)
+	if err != nil {
+		return gensql.Event{}, err
+	}
+
+	eventID, err := uuid.Parse(id)
+	if err != nil {
+		return gensql.Event{}, err
+	}
+
+	event, err := c.repo.EventGet(ctx, eventID)
+	if err != nil {
+		return gensql.Event{}, err
+	}
+
+	if event.Owner != team.ID {
+		return gensql.Event{}, fmt.Errorf("hendelsen tilhører ikke %v", teamSlug)
+	}
+
+	return event, nil
+}
+
+func scheduledEventFrom(event gensql.Event) scheduledEvent {
+	return scheduledEvent{
+		ID:       event.ID,
+		Type:     event.Type,
+		RunAfter: event.RunAfter.Time,
+	}
+}
+
+// parseRunAfter parses the value of a datetime-local input, which is in Norwegian time
+func parseRunAfter(value string) (time.Time, error) {
+	location, err := time.LoadLocation(runAfterLocation)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	runAfter, err := time.ParseInLocation(runAfterLayout, value, location)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("'%v' er ikke et gyldig tidspunkt", value)
+	}
+
+	if !runAfter.After(time.Now()) {
+		return time.Time{}, fmt.Errorf("tidspunktet må være fram i tid")
+	}
+
+	return runAfter, nil
+}
+
+// eventOptionsWithRunAfter are the options for the events registered by the request, which
+// schedules them at the time in the run_after field of the form, if it is set
+func eventOptionsWithRunAfter(ctx *gin.Context) ([]database.EventOption, error) {
+	opts := eventOptions(ctx)
+
+	value := ctx.PostForm("run_after")
+	if value == "" {
+		return opts, nil
+	}
+
+	runAfter, err := parseRunAfter(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(opts, database.WithEventRunAfter(runAfter)), nil
+}
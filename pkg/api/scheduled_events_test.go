@@ -0,0 +1,142 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/navikt/knorten/pkg/database"
+	"github.com/navikt/knorten/pkg/database/gensql"
+)
+
+func TestScheduledEventsAPI(t *testing.T) {
+	ctx := context.Background()
+
+	team := gensql.Team{
+		ID:    "scheduled-team-1234",
+		Slug:  "scheduled-team",
+		Users: []string{testUser.Email},
+	}
+	if err := repo.TeamCreate(ctx, &team); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		if err := repo.TeamDelete(ctx, team.ID); err != nil {
+			t.Errorf("cleaning up after scheduled events tests: %v", err)
+		}
+	})
+
+	runAfter := time.Now().Add(24 * time.Hour).Truncate(time.Minute)
+	if err := repo.RegisterUpdateAirflowEvent(ctx, team.ID, nil, database.WithEventRunAfter(runAfter)); err != nil {
+		t.Fatal(err)
+	}
+
+	scheduled, err := repo.EventsScheduledForOwnerGet(ctx, team.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scheduled) != 1 {
+		t.Fatalf("expected one scheduled event for %v, got %v", team.Slug, scheduled)
+	}
+	event := scheduled[0]
+
+	t.Run("scheduled events are not dispatched", func(t *testing.T) {
+		dispatchable, err := repo.DispatchableEventsGet(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, e := range dispatchable {
+			if e.ID == event.ID {
+				t.Errorf("expected the event scheduled at %v not to be dispatchable", runAfter)
+			}
+		}
+	})
+
+	t.Run("reschedule event", func(t *testing.T) {
+		location, err := time.LoadLocation(runAfterLocation)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		newRunAfter := runAfter.Add(time.Hour).In(location)
+		data := url.Values{"run_after": {newRunAfter.Format(runAfterLayout)}}
+		resp, err := server.Client().PostForm(fmt.Sprintf("%v/team/%v/events/%v/reschedule", server.URL, team.Slug, event.ID), data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		rescheduled, err := repo.EventGet(ctx, event.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !rescheduled.RunAfter.Time.Equal(newRunAfter) {
+			t.Errorf("expected the event to be rescheduled to %v, got %v", newRunAfter, rescheduled.RunAfter.Time)
+		}
+	})
+
+	t.Run("cancel event", func(t *testing.T) {
+		err := repo.NotificationSubscriptionCreate(ctx, database.NotificationSubscription{
+			TeamID:    team.ID,
+			Sink:      database.NotificationSinkWebhook,
+			Target:    "https://example.com/hook",
+			Statuses:  []database.EventStatus{database.EventStatusCancelled},
+			CreatedBy: testUser.Email,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		resp, err := server.Client().PostForm(fmt.Sprintf("%v/team/%v/events/%v/cancel", server.URL, team.Slug, event.ID), url.Values{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		cancelled, err := repo.EventGet(ctx, event.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if cancelled.Status != string(database.EventStatusCancelled) {
+			t.Errorf("expected the event to be cancelled, got status %v", cancelled.Status)
+		}
+
+		deliveries, err := repo.NotificationDeliveriesGet(ctx, 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		notified := false
+		for _, delivery := range deliveries {
+			if delivery.TeamID.String == team.ID && strings.Contains(string(delivery.Payload), event.ID.String()) {
+				notified = true
+			}
+		}
+		if !notified {
+			t.Errorf("expected the subscribers to be notified that the event was cancelled")
+		}
+	})
+
+	t.Run("events which are not scheduled can't be cancelled", func(t *testing.T) {
+		if err := repo.RegisterDeleteAirflowEvent(ctx, team.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		events, err := repo.EventsByOwnerGet(ctx, team.ID, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = repo.EventCancel(ctx, events[0].ID, team.ID)
+		if err != database.ErrEventNotScheduled {
+			t.Errorf("expected %v, got %v", database.ErrEventNotScheduled, err)
+		}
+	})
+}
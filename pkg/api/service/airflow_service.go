@@ -2,8 +2,13 @@ package service
 
 import (
 	"context"
+	"io"
+
+	"github.com/navikt/knorten/pkg/team"
 )
 
 type AirflowService interface {
 	IsSchedulerDown(ctx context.Context, namespace string) (bool, error)
+	Status(ctx context.Context, namespace string) (*team.AirflowStatus, error)
+	PodLogs(ctx context.Context, namespace, podName string, opts team.PodLogOptions) (io.ReadCloser, error)
 }
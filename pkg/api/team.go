@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"net/mail"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -17,32 +18,81 @@ import (
 	"github.com/gin-gonic/gin/binding"
 	"github.com/go-playground/validator/v10"
 	"github.com/navikt/knorten/pkg/api/middlewares"
+	"github.com/navikt/knorten/pkg/database"
 	"github.com/navikt/knorten/pkg/database/gensql"
 )
 
 type teamForm struct {
 	Slug      string   `form:"team" binding:"required,validTeamName"`
 	Users     []string `form:"users[]" binding:"validEmail,userListNotEmpty"`
+	Roles     []string `form:"roles[]"`
 	APIAccess string   `form:"apiaccess"`
 }
 
-func formToTeam(ctx *gin.Context) (gensql.Team, error) {
+func formToTeam(ctx *gin.Context) (database.Team, error) {
 	var form teamForm
 	err := ctx.ShouldBindWith(&form, binding.Form)
 	if err != nil {
-		return gensql.Team{}, err
+		return database.Team{}, err
 	}
 
 	id, err := createTeamID(form.Slug)
 	if err != nil {
-		return gensql.Team{}, err
+		return database.Team{}, err
 	}
 
-	return gensql.Team{
-		ID:    id,
-		Slug:  form.Slug,
-		Users: form.Users,
-	}, nil
+	members, err := formToTeamMembers(form.Users, form.Roles)
+	if err != nil {
+		return database.Team{}, err
+	}
+
+	team := database.Team{
+		Team: gensql.Team{
+			ID:   id,
+			Slug: form.Slug,
+		},
+	}
+	team.SetMembers(members)
+
+	return team, nil
+}
+
+// formToTeamMembers pairs each user with the role on the same row in the form. Rows without a
+// role are owners, which is what every member was before roles were introduced.
+func formToTeamMembers(users, roles []string) ([]database.TeamMember, error) {
+	members := []database.TeamMember{}
+	seen := map[string]bool{}
+	hasOwner := false
+
+	for i, user := range users {
+		email := strings.ToLower(strings.TrimSpace(user))
+		if email == "" {
+			continue
+		}
+
+		if seen[email] {
+			return nil, fmt.Errorf("%v er lagt til flere ganger", email)
+		}
+		seen[email] = true
+
+		role := gensql.TeamRoleOwner
+		if i < len(roles) && roles[i] != "" {
+			role = gensql.TeamRole(roles[i])
+		}
+
+		if !slices.Contains(database.TeamRoles, role) {
+			return nil, fmt.Errorf("ugyldig rolle %q for %v", role, email)
+		}
+
+		hasOwner = hasOwner || role == gensql.TeamRoleOwner
+		members = append(members, database.TeamMember{Email: email, Role: role})
+	}
+
+	if !hasOwner {
+		return nil, fmt.Errorf("teamet må ha minst én eier")
+	}
+
+	return members, nil
 }
 
 func (c *client) setupTeamRoutes() {
@@ -141,11 +191,45 @@ func (c *client) setupTeamRoutes() {
 			return
 		}
 
+		members, err := c.repo.TeamMembersGet(ctx, team.ID)
+		if err != nil {
+			c.log.WithError(err).Errorf("problem getting members for team %v", teamSlug)
+			ctx.Redirect(http.StatusSeeOther, "/oversikt")
+			return
+		}
+
+		azureGroups, err := c.repo.TeamAzureGroupsGet(ctx, team.ID)
+		if err != nil {
+			c.log.WithError(err).Errorf("problem getting azure groups for team %v", teamSlug)
+			ctx.Redirect(http.StatusSeeOther, "/oversikt")
+			return
+		}
+
+		redirects, err := c.repo.TeamSlugRedirectsGet(ctx, team.ID)
+		if err != nil {
+			c.log.WithError(err).Errorf("problem getting slug redirects for team %v", teamSlug)
+			ctx.Redirect(http.StatusSeeOther, "/oversikt")
+			return
+		}
+
+		history, err := c.repo.TeamHistoryGet(ctx, team.ID)
+		if err != nil {
+			c.log.WithError(err).Errorf("problem getting history for team %v", teamSlug)
+			ctx.Redirect(http.StatusSeeOther, "/oversikt")
+			return
+		}
+
 		ctx.HTML(http.StatusOK, "team/edit", gin.H{
-			"team":     team,
-			"errors":   flashes,
-			"loggedIn": ctx.GetBool(middlewares.LoggedInKey),
-			"isAdmin":  ctx.GetBool(middlewares.AdminKey),
+			"team":              team,
+			"members":           database.ManualTeamMembers(members),
+			"azureGroups":       azureGroups,
+			"azureGroupMembers": database.AzureGroupTeamMembers(members),
+			"redirects":         redirects,
+			"redirectDays":      int(c.teamRenameRedirectPeriod.Hours() / 24),
+			"history":           history,
+			"errors":            flashes,
+			"loggedIn":          ctx.GetBool(middlewares.LoggedInKey),
+			"isAdmin":           ctx.GetBool(middlewares.AdminKey),
 		})
 	})
 
@@ -206,6 +290,13 @@ func (c *client) setupTeamRoutes() {
 			return
 		}
 
+		scheduled, err := c.scheduledEvents(ctx, team.ID)
+		if err != nil {
+			c.log.WithError(err).Errorf("problem getting scheduled events for team %v", teamSlug)
+			ctx.Redirect(http.StatusSeeOther, "/oversikt")
+			return
+		}
+
 		session := sessions.Default(ctx)
 		flashes := session.Flashes()
 		err = session.Save()
@@ -215,11 +306,13 @@ func (c *client) setupTeamRoutes() {
 		}
 
 		ctx.HTML(http.StatusOK, "team/events", gin.H{
-			"events":   events,
-			"slug":     team.Slug,
-			"errors":   flashes,
-			"loggedIn": ctx.GetBool(middlewares.LoggedInKey),
-			"isAdmin":  ctx.GetBool(middlewares.AdminKey),
+			"events":    events,
+			"scheduled": scheduled,
+			"slug":      team.Slug,
+			"teamID":    team.ID,
+			"errors":    flashes,
+			"loggedIn":  ctx.GetBool(middlewares.LoggedInKey),
+			"isAdmin":   ctx.GetBool(middlewares.AdminKey),
 		})
 	})
 }
@@ -300,21 +393,23 @@ func (c *client) newTeam(ctx *gin.Context) error {
 		return err
 	}
 
-	_, err = c.repo.TeamBySlugGet(ctx, team.Slug)
-	if err == nil {
-		return fmt.Errorf("team %v already exists", team.Slug)
-	}
-	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+	err = c.ensureTeamSlugAvailable(ctx, team.Slug, team.ID)
+	if err != nil {
 		return err
 	}
 
-	team.Users = removeEmptySliceElements(team.Users)
 	err = c.ensureUsersExists(team.Users)
 	if err != nil {
 		return err
 	}
 
-	return c.repo.RegisterCreateTeamEvent(ctx, team)
+	if err := c.repo.RegisterCreateTeamEvent(ctx, team, eventOptions(ctx)...); err != nil {
+		return err
+	}
+
+	auditTeam(ctx, team.ID)
+	auditChange(ctx, nil, gin.H{"slug": team.Slug, "members": auditMembers(team.Members)})
+	return nil
 }
 
 func (c *client) editTeam(ctx *gin.Context) error {
@@ -328,9 +423,20 @@ func (c *client) editTeam(ctx *gin.Context) error {
 		return err
 	}
 
+	// The form only has the members added by hand, members from Azure AD groups are kept as they are
+	existingMembers, err := c.repo.TeamMembersGet(ctx, existingTeam.ID)
+	if err != nil {
+		return err
+	}
+
 	team.ID = existingTeam.ID
-	team.Users = removeEmptySliceElements(team.Users)
-	return c.repo.RegisterUpdateTeamEvent(ctx, team)
+	team.SetMembers(database.WithAzureGroupMembers(team.Members, database.AzureGroupTeamMembers(existingMembers)))
+	if err := c.repo.RegisterUpdateTeamEvent(ctx, team, eventOptions(ctx)...); err != nil {
+		return err
+	}
+
+	auditChange(ctx, auditMembers(existingMembers), auditMembers(team.Members))
+	return nil
 }
 
 func (c *client) ensureUsersExists(users []string) error {
@@ -349,5 +455,10 @@ func (c *client) deleteTeam(ctx *gin.Context, teamSlug string) error {
 		return err
 	}
 
-	return c.repo.RegisterDeleteTeamEvent(ctx, team.ID)
+	user, err := getUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	return c.markTeamForDeletion(ctx, team.ID, user.Email, eventOptions(ctx)...)
 }
@@ -0,0 +1,199 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"github.com/navikt/knorten/pkg/database"
+	"github.com/navikt/knorten/pkg/database/gensql"
+)
+
+// teamRestorePaths can be used while a team is pending deletion, every other change is blocked
+var teamRestorePaths = []string{
+	"/team/:slug/restore",
+	"/admin/team/:team/restore",
+}
+
+func (c *client) setupTeamDeletionRoutes() {
+	c.router.POST("/team/:slug/restore", func(ctx *gin.Context) {
+		teamSlug := ctx.Param("slug")
+
+		err := c.restoreTeamBySlug(ctx, teamSlug, eventOptions(ctx)...)
+		if err != nil {
+			c.log.WithError(err).WithField("team", teamSlug).Info("restore team")
+			session := sessions.Default(ctx)
+			session.AddFlash(err.Error())
+			err := session.Save()
+			if err != nil {
+				c.log.WithError(err).Error("problem saving session")
+			}
+		}
+
+		ctx.Redirect(http.StatusSeeOther, "/oversikt")
+	})
+}
+
+func (c *client) setupTeamDeletionAdminRoutes() {
+	c.router.POST("/admin/team/:team/restore", func(ctx *gin.Context) {
+		teamSlug := ctx.Param("team")
+
+		err := c.restoreTeamBySlug(ctx, teamSlug, eventOptions(ctx)...)
+		if err != nil {
+			c.log.WithError(err).WithField("team", teamSlug).Info("restore team")
+			session := sessions.Default(ctx)
+			session.AddFlash(err.Error())
+			err := session.Save()
+			if err != nil {
+				c.log.WithError(err).Error("problem saving session")
+			}
+		}
+
+		ctx.Redirect(http.StatusSeeOther, "/admin")
+	})
+}
+
+// teamPendingDeletionMiddleware stops changes to teams which are pending deletion, so nothing is
+// started again after the workloads have been suspended
+func (c *client) teamPendingDeletionMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		teamSlug := ctx.Param("slug")
+		if teamSlug == "" {
+			teamSlug = ctx.Param("team")
+		}
+
+		if teamSlug == "" || ctx.Request.Method == http.MethodGet || slices.Contains(teamRestorePaths, ctx.FullPath()) {
+			ctx.Next()
+			return
+		}
+
+		pending, err := c.teamPendingDeletion(ctx, teamSlug)
+		if err == nil && !pending {
+			ctx.Next()
+			return
+		}
+
+		// We don't know if the team is pending deletion, so we rather stop the change
+		message := fmt.Sprintf("%v er markert for sletting, og må gjenopprettes før det kan endres", teamSlug)
+		if err != nil {
+			c.log.WithError(err).WithField("team", teamSlug).Error("problem checking if team is pending deletion")
+			message = fmt.Sprintf("kunne ikke sjekke om %v er markert for sletting, prøv igjen senere", teamSlug)
+		}
+
+		session := sessions.Default(ctx)
+		session.AddFlash(message)
+		err = session.Save()
+		if err != nil {
+			c.log.WithError(err).Error("problem saving session")
+		}
+
+		ctx.Redirect(http.StatusSeeOther, "/oversikt")
+		ctx.Abort()
+	}
+}
+
+func (c *client) teamPendingDeletion(ctx context.Context, teamSlug string) (bool, error) {
+	team, err := c.repo.TeamBySlugGet(ctx, teamSlug)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	_, err = c.repo.TeamDeletionGet(ctx, team.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+// markTeamForDeletion hibernates the team's Airflow, and leaves everything else in place until
+// the grace period is over and the team is purged
+func (c *client) markTeamForDeletion(ctx context.Context, teamID, requestedBy string, opts ...database.EventOption) error {
+	_, err := c.repo.TeamDeletionGet(ctx, teamID)
+	if err == nil {
+		return fmt.Errorf("teamet er allerede markert for sletting")
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	hibernate, err := c.airflowRunning(ctx, teamID)
+	if err != nil {
+		return err
+	}
+
+	deleteAfter := time.Now().Add(c.teamDeletionGracePeriod)
+	if err := c.repo.TeamDeletionInsert(ctx, teamID, requestedBy, deleteAfter, hibernate); err != nil {
+		return err
+	}
+
+	if hibernate {
+		return c.repo.RegisterHibernateAirflowEvent(ctx, teamID, opts...)
+	}
+
+	return nil
+}
+
+func (c *client) airflowRunning(ctx context.Context, teamID string) (bool, error) {
+	apps, err := c.repo.ChartsForTeamGet(ctx, teamID)
+	if err != nil {
+		return false, err
+	}
+
+	if !slices.Contains(apps, gensql.ChartTypeAirflow) {
+		return false, nil
+	}
+
+	hibernated, err := c.repo.AirflowIsHibernated(ctx, teamID)
+	if err != nil {
+		return false, err
+	}
+
+	return !hibernated, nil
+}
+
+func (c *client) restoreTeamBySlug(ctx context.Context, teamSlug string, opts ...database.EventOption) error {
+	team, err := c.repo.TeamBySlugGet(ctx, teamSlug)
+	if err != nil {
+		return err
+	}
+
+	return c.restoreTeam(ctx, team.ID, opts...)
+}
+
+// restoreTeam cancels a pending deletion, and wakes Airflow again if it was hibernated because of it
+func (c *client) restoreTeam(ctx context.Context, teamID string, opts ...database.EventOption) error {
+	deletion, err := c.repo.TeamDeletionGet(ctx, teamID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("teamet er ikke markert for sletting")
+		}
+		return err
+	}
+
+	if !time.Now().Before(deletion.DeleteAfter) {
+		return fmt.Errorf("fristen for å gjenopprette teamet gikk ut %v", deletion.DeleteAfter.Format(time.DateTime))
+	}
+
+	if err := c.repo.TeamDeletionDelete(ctx, teamID); err != nil {
+		return err
+	}
+
+	if deletion.HibernatedAirflow {
+		return c.repo.RegisterWakeAirflowEvent(ctx, teamID, opts...)
+	}
+
+	return nil
+}
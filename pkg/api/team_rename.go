@@ -0,0 +1,110 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/navikt/knorten/pkg/database"
+)
+
+type teamRenameForm struct {
+	Slug string `form:"slug" binding:"required,validTeamName"`
+}
+
+func (c *client) setupTeamRenameRoutes() {
+	c.router.POST("/team/:slug/rename", func(ctx *gin.Context) {
+		teamSlug := ctx.Param("slug")
+
+		newSlug, err := c.renameTeam(ctx, teamSlug)
+		if err != nil {
+			c.log.WithError(err).WithField("team", teamSlug).Info("rename team")
+			session := sessions.Default(ctx)
+			session.AddFlash(err.Error())
+			err := session.Save()
+			if err != nil {
+				c.log.WithError(err).Error("problem saving session")
+			}
+
+			ctx.Redirect(http.StatusSeeOther, fmt.Sprintf("/team/%v/edit", teamSlug))
+			return
+		}
+
+		ctx.Redirect(http.StatusSeeOther, fmt.Sprintf("/team/%v/edit", newSlug))
+	})
+}
+
+// renameTeam changes the slug right away, so the team is found under the new name, and leaves
+// moving the resources named after the slug to the rename:team event
+func (c *client) renameTeam(ctx *gin.Context, teamSlug string) (string, error) {
+	var form teamRenameForm
+	if err := ctx.ShouldBindWith(&form, binding.Form); err != nil {
+		return "", fmt.Errorf("teamnavn må være med små bokstaver og bindestrek")
+	}
+
+	team, err := c.repo.TeamBySlugGet(ctx, teamSlug)
+	if err != nil {
+		return "", err
+	}
+
+	if form.Slug == team.Slug {
+		return "", fmt.Errorf("teamet heter allerede %v", form.Slug)
+	}
+
+	if err := c.ensureTeamSlugAvailable(ctx, form.Slug, team.ID); err != nil {
+		return "", err
+	}
+
+	user, err := getUser(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	redirectUntil := time.Now().Add(c.teamRenameRedirectPeriod)
+	if err := c.repo.TeamRename(ctx, team.ID, team.Slug, form.Slug, user.Email, redirectUntil); err != nil {
+		return "", err
+	}
+
+	err = c.repo.RegisterRenameTeamEvent(ctx, team.ID, database.TeamSlugChange{
+		OldSlug: team.Slug,
+		NewSlug: form.Slug,
+	}, eventOptions(ctx)...)
+	if err != nil {
+		return "", err
+	}
+
+	auditChange(ctx, team.Slug, form.Slug)
+	return form.Slug, nil
+}
+
+// ensureTeamSlugAvailable checks that the slug isn't used by another team, either as its name or
+// as a redirect from before it was renamed. A team can always take back its own old slug.
+func (c *client) ensureTeamSlugAvailable(ctx context.Context, slug, teamID string) error {
+	_, err := c.repo.TeamBySlugGet(ctx, slug)
+	if err == nil {
+		return fmt.Errorf("team %v already exists", slug)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	redirect, err := c.repo.TeamSlugRedirectGet(ctx, slug)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+
+	if redirect.TeamID != teamID && time.Now().Before(redirect.Expires) {
+		return fmt.Errorf("%v er i bruk av et team som har byttet navn frem til %v", slug, redirect.Expires.Format(time.DateOnly))
+	}
+
+	return nil
+}
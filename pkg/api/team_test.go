@@ -2,12 +2,15 @@ package api
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/navikt/knorten/pkg/chart"
@@ -237,6 +240,10 @@ func TestTeamAPI(t *testing.T) {
 				Slug:  existingTeam,
 				Users: []string{testUser.Email},
 			},
+			"members": []database.TeamMember{
+				{Email: testUser.Email, Role: gensql.TeamRoleOwner},
+			},
+			"redirectDays": 30,
 		})
 		if err != nil {
 			t.Error(err)
@@ -253,8 +260,8 @@ func TestTeamAPI(t *testing.T) {
 	})
 
 	t.Run("edit team", func(t *testing.T) {
-		users := []string{"user@nav.no"}
-		data := url.Values{"team": {existingTeam}, "owner": {testUser.Email}, "users[]": users, "enableallowlist": {"on"}}
+		users := []string{"user@nav.no", "viewer@nav.no"}
+		data := url.Values{"team": {existingTeam}, "users[]": users, "roles[]": {"owner", "viewer"}, "enableallowlist": {"on"}}
 		resp, err := server.Client().PostForm(fmt.Sprintf("%v/team/%v/edit", server.URL, existingTeam), data)
 		if err != nil {
 			t.Error(err)
@@ -290,6 +297,39 @@ func TestTeamAPI(t *testing.T) {
 		if diff := cmp.Diff(eventPayload.Users, users); diff != "" {
 			t.Errorf("mismatch (-want +got):\n%s", diff)
 		}
+
+		expectedMembers := []database.TeamMember{
+			{Email: "user@nav.no", Role: gensql.TeamRoleOwner},
+			{Email: "viewer@nav.no", Role: gensql.TeamRoleViewer},
+		}
+		if diff := cmp.Diff(eventPayload.Members, expectedMembers); diff != "" {
+			t.Errorf("mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("edit team without owner", func(t *testing.T) {
+		data := url.Values{"team": {existingTeam}, "users[]": {"user@nav.no"}, "roles[]": {"viewer"}}
+		resp, err := server.Client().PostForm(fmt.Sprintf("%v/team/%v/edit", server.URL, existingTeam), data)
+		if err != nil {
+			t.Error(err)
+		}
+		resp.Body.Close()
+
+		events, err := repo.EventsGetType(ctx, database.EventTypeUpdateTeam)
+		if err != nil {
+			t.Error(err)
+		}
+
+		for _, event := range events {
+			var payload database.Team
+			if err := json.Unmarshal(event.Payload, &payload); err != nil {
+				t.Error(err)
+			}
+
+			if len(payload.Members) == 1 && payload.Members[0].Role == gensql.TeamRoleViewer {
+				t.Errorf("edit team: expected no event for a team without owners")
+			}
+		}
 	})
 
 	t.Run("delete team", func(t *testing.T) {
@@ -308,8 +348,140 @@ func TestTeamAPI(t *testing.T) {
 			t.Error(err)
 		}
 
-		if !deleteEventCreatedForTeam(events, existingTeamID) {
-			t.Errorf("delete team: no event registered for team %v", existingTeam)
+		if deleteEventCreatedForTeam(events, existingTeamID) {
+			t.Errorf("delete team: expected team %v to be kept until the grace period is over", existingTeam)
+		}
+
+		deletion, err := repo.TeamDeletionGet(ctx, existingTeamID)
+		if err != nil {
+			t.Fatalf("delete team: expected team %v to be pending deletion: %v", existingTeam, err)
+		}
+
+		if deletion.DeleteAfter.Before(time.Now().Add(6 * 24 * time.Hour)) {
+			t.Errorf("delete team: expected the grace period to be 7 days, team is deleted after %v", deletion.DeleteAfter)
+		}
+	})
+
+	t.Run("restore team", func(t *testing.T) {
+		resp, err := server.Client().PostForm(fmt.Sprintf("%v/team/%v/restore", server.URL, existingTeam), nil)
+		if err != nil {
+			t.Error(err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("restore team: expected status code 200, got %v", resp.StatusCode)
+		}
+
+		_, err = repo.TeamDeletionGet(ctx, existingTeamID)
+		if !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("restore team: expected team %v to no longer be pending deletion, got %v", existingTeam, err)
+		}
+	})
+
+	t.Run("rename team", func(t *testing.T) {
+		renamedTeam := "renamed-team"
+		data := url.Values{"slug": {renamedTeam}}
+		resp, err := server.Client().PostForm(fmt.Sprintf("%v/team/%v/rename", server.URL, existingTeam), data)
+		if err != nil {
+			t.Error(err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("rename team: expected status code 200, got %v", resp.StatusCode)
+		}
+
+		team, err := repo.TeamGet(ctx, existingTeamID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if team.Slug != renamedTeam {
+			t.Errorf("rename team: expected slug %v, got %v", renamedTeam, team.Slug)
+		}
+
+		events, err := repo.EventsGetType(ctx, database.EventTypeRenameTeam)
+		if err != nil {
+			t.Error(err)
+		}
+
+		var change database.TeamSlugChange
+		for _, event := range events {
+			if event.Owner == existingTeamID {
+				if err := json.Unmarshal(event.Payload, &change); err != nil {
+					t.Error(err)
+				}
+			}
+		}
+
+		if diff := cmp.Diff(database.TeamSlugChange{OldSlug: existingTeam, NewSlug: renamedTeam}, change); diff != "" {
+			t.Errorf("rename team: mismatch (-want +got):\n%s", diff)
+		}
+
+		redirect, err := repo.TeamSlugRedirectGet(ctx, existingTeam)
+		if err != nil {
+			t.Fatalf("rename team: expected %v to redirect to the renamed team: %v", existingTeam, err)
+		}
+
+		if redirect.TeamID != existingTeamID {
+			t.Errorf("rename team: expected redirect to team %v, got %v", existingTeamID, redirect.TeamID)
+		}
+
+		history, err := repo.TeamHistoryGet(ctx, existingTeamID)
+		if err != nil {
+			t.Error(err)
+		}
+
+		if len(history) != 1 || history[0].Action != database.TeamHistoryActionRename || history[0].Actor != testUser.Email {
+			t.Errorf("rename team: expected the rename by %v in the team history, got %v", testUser.Email, history)
+		}
+
+		// Renaming back frees the new slug from its redirect, and reserves the one we renamed to
+		data = url.Values{"slug": {existingTeam}}
+		resp, err = server.Client().PostForm(fmt.Sprintf("%v/team/%v/rename", server.URL, renamedTeam), data)
+		if err != nil {
+			t.Error(err)
+		}
+		resp.Body.Close()
+
+		team, err = repo.TeamGet(ctx, existingTeamID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if team.Slug != existingTeam {
+			t.Errorf("rename team back: expected slug %v, got %v", existingTeam, team.Slug)
+		}
+
+		_, err = repo.TeamSlugRedirectGet(ctx, existingTeam)
+		if !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("rename team back: expected redirect from %v to be removed, got %v", existingTeam, err)
+		}
+	})
+
+	t.Run("create team - slug reserved by renamed team", func(t *testing.T) {
+		data := url.Values{"team": {"renamed-team"}, "users[]": []string{testUser.Email}}
+		resp, err := server.Client().PostForm(fmt.Sprintf("%v/team/new", server.URL), data)
+		if err != nil {
+			t.Error(err)
+		}
+		resp.Body.Close()
+
+		events, err := repo.EventsGetType(ctx, database.EventTypeCreateTeam)
+		if err != nil {
+			t.Error(err)
+		}
+
+		for _, event := range events {
+			var team database.Team
+			if err := json.Unmarshal(event.Payload, &team); err != nil {
+				t.Error(err)
+			}
+
+			if team.Slug == "renamed-team" {
+				t.Errorf("create team: expected no team to be created with the slug reserved by %v", existingTeam)
+			}
 		}
 	})
 
@@ -376,12 +548,12 @@ func TestTeamAPI(t *testing.T) {
 	})
 }
 
-func getEventForTeam(events []gensql.Event, team string) (gensql.Team, error) {
+func getEventForTeam(events []gensql.Event, team string) (database.Team, error) {
 	for _, event := range events {
-		payload := gensql.Team{}
+		payload := database.Team{}
 		err := json.Unmarshal(event.Payload, &payload)
 		if err != nil {
-			return gensql.Team{}, err
+			return database.Team{}, err
 		}
 
 		if payload.Slug == team {
@@ -389,7 +561,7 @@ func getEventForTeam(events []gensql.Event, team string) (gensql.Team, error) {
 		}
 	}
 
-	return gensql.Team{}, nil
+	return database.Team{}, nil
 }
 
 func deleteEventCreatedForTeam(events []gensql.Event, team string) bool {
@@ -43,7 +43,7 @@ func (c *client) setupUserRoutes() {
 		}
 
 		for _, service := range services.Services {
-			if service.Airflow != nil {
+			if service.Airflow != nil && !service.Airflow.IsHibernated {
 				isDown, err := c.airflowService.IsSchedulerDown(
 					ctx,
 					k8s.TeamIDToNamespace(service.TeamID),
@@ -60,6 +60,7 @@ func (c *client) setupUserRoutes() {
 			"errors":     err,
 			"flashes":    flashes,
 			"user":       services,
+			"email":      user.Email,
 			"gcpProject": c.gcpProject,
 			"gcpZone":    c.gcpZone,
 			"upgradePausedStatuses": c.maintenanceExclusionConfig.ActiveExcludePeriodForTeams(
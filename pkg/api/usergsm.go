@@ -49,7 +49,7 @@ func (c *client) createSecret(ctx *gin.Context) error {
 		Name:  getNormalizedNameFromEmail(user.Email),
 	}
 
-	return c.repo.RegisterCreateUserGSMEvent(ctx, manager.Owner, manager)
+	return c.repo.RegisterCreateUserGSMEvent(ctx, manager.Owner, manager, eventOptions(ctx)...)
 }
 
 func (c *client) deleteSecret(ctx *gin.Context) error {
@@ -58,5 +58,5 @@ func (c *client) deleteSecret(ctx *gin.Context) error {
 		return err
 	}
 
-	return c.repo.RegisterDeleteUserGSMEvent(ctx, user.Email)
+	return c.repo.RegisterDeleteUserGSMEvent(ctx, user.Email, eventOptions(ctx)...)
 }
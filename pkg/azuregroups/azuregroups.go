@@ -0,0 +1,124 @@
+package azuregroups
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/navikt/knorten/pkg/database"
+	"github.com/navikt/knorten/pkg/database/gensql"
+	"github.com/navikt/knorten/pkg/leaderelection"
+	"github.com/sirupsen/logrus"
+)
+
+type GroupMemberLister interface {
+	GroupMembers(ctx context.Context, groupID string) ([]string, error)
+}
+
+// Client keeps the members of teams in sync with the Azure AD groups linked to them. Changes
+// are applied by registering update:team events, like when a team is edited by hand.
+type Client struct {
+	repo  *database.Repo
+	graph GroupMemberLister
+	log   *logrus.Entry
+}
+
+func NewClient(repo *database.Repo, graph GroupMemberLister, log *logrus.Entry) *Client {
+	return &Client{
+		repo:  repo,
+		graph: graph,
+		log:   log,
+	}
+}
+
+func (c *Client) Run(ctx context.Context, frequency time.Duration) {
+	leaderelection.RunPeriodically(ctx, frequency, c.log, c.run)
+}
+
+func (c *Client) run(ctx context.Context) {
+	teams, err := c.repo.TeamsWithAzureGroupsGet(ctx)
+	if err != nil {
+		c.log.WithError(err).Error("getting teams with azure groups")
+		return
+	}
+
+	for _, teamID := range teams {
+		if err := c.SyncTeam(ctx, teamID); err != nil {
+			c.log.WithError(err).WithField("team", teamID).Error("syncing azure groups")
+		}
+	}
+}
+
+// SyncTeam resolves the members of the groups linked to the team, and registers an update:team
+// event if the membership has changed. Members of a group that can't be resolved are kept until
+// the next sync, while members of groups which are no longer linked are removed.
+func (c *Client) SyncTeam(ctx context.Context, teamID string) error {
+	team, err := c.repo.TeamWithMembersGet(ctx, teamID)
+	if err != nil {
+		return fmt.Errorf("getting team: %w", err)
+	}
+
+	groups, err := c.repo.TeamAzureGroupsGet(ctx, teamID)
+	if err != nil {
+		return fmt.Errorf("getting azure groups: %w", err)
+	}
+
+	synced := []database.TeamMember{}
+	for _, group := range groups {
+		members, syncErr := c.groupMembers(ctx, group, team.Members)
+		if err := c.repo.TeamAzureGroupSyncedSet(ctx, teamID, group.GroupID, syncErr); err != nil {
+			return fmt.Errorf("recording sync of azure group %v: %w", group.GroupID, err)
+		}
+
+		synced = append(synced, members...)
+	}
+
+	members := database.WithAzureGroupMembers(database.ManualTeamMembers(team.Members), synced)
+	if database.SameTeamMembers(team.Members, members) {
+		return nil
+	}
+
+	// The members are only stored when the update has run, so until then they differ on every tick
+	pending, err := c.repo.EventUnprocessedExists(ctx, teamID, database.EventTypeUpdateTeam)
+	if err != nil {
+		return fmt.Errorf("checking for unprocessed team update: %w", err)
+	}
+
+	if pending {
+		return nil
+	}
+
+	c.log.WithField("team", teamID).Info("team membership changed in azure ad, registering team update")
+
+	team.SetMembers(members)
+	return c.repo.RegisterUpdateTeamEvent(ctx, team)
+}
+
+// groupMembers falls back to the members we already have from the group if Graph fails, so an
+// outage doesn't remove people from their teams. The error is returned so it can be recorded.
+func (c *Client) groupMembers(ctx context.Context, group gensql.TeamAzureGroup, current []database.TeamMember) ([]database.TeamMember, error) {
+	emails, err := c.graph.GroupMembers(ctx, group.GroupID)
+	if err != nil {
+		c.log.WithError(err).WithField("team", group.TeamID).Errorf("getting members of azure group %v", group.GroupID)
+
+		members := []database.TeamMember{}
+		for _, member := range current {
+			if member.AzureGroupID == group.GroupID {
+				members = append(members, member)
+			}
+		}
+
+		return members, err
+	}
+
+	members := []database.TeamMember{}
+	for _, email := range emails {
+		members = append(members, database.TeamMember{
+			Email:        email,
+			Role:         group.Role,
+			AzureGroupID: group.GroupID,
+		})
+	}
+
+	return members, nil
+}
@@ -0,0 +1,218 @@
+package azuregroups
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+	"path"
+	"runtime"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/navikt/knorten/local/dbsetup"
+	"github.com/navikt/knorten/pkg/api/auth"
+	"github.com/navikt/knorten/pkg/api/auth/graphtest"
+	"github.com/navikt/knorten/pkg/database"
+	"github.com/navikt/knorten/pkg/database/gensql"
+	"github.com/sirupsen/logrus"
+)
+
+var repo *database.Repo
+
+func init() {
+	_, filename, _, _ := runtime.Caller(0)
+	dir := path.Join(path.Dir(filename), "../..")
+	err := os.Chdir(dir)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func TestMain(m *testing.M) {
+	dbConn, err := dbsetup.SetupDBForTests()
+	if err != nil {
+		log.Fatal(err)
+	}
+	repo, err = database.New(dbConn, "", logrus.NewEntry(logrus.StandardLogger()))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	code := m.Run()
+	os.Exit(code)
+}
+
+func TestSyncTeam(t *testing.T) {
+	ctx := context.Background()
+
+	server := graphtest.NewServer()
+	defer server.Close()
+
+	server.SetGroup("group-data", graphtest.Group{
+		DisplayName: "Data",
+		Members:     []string{"owner@nav.no", "maintainer@nav.no", "viewer@nav.no"},
+	})
+
+	team := gensql.Team{
+		ID:    "azure-sync-1234",
+		Slug:  "azure-sync",
+		Users: []string{"owner@nav.no"},
+	}
+	if err := repo.TeamCreate(ctx, &team); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		if err := repo.TeamDelete(ctx, team.ID); err != nil {
+			t.Error(err)
+		}
+
+		if err := completeTeamUpdates(ctx, team.ID); err != nil {
+			t.Error(err)
+		}
+	})
+
+	if err := repo.TeamAzureGroupSet(ctx, team.ID, "group-data", "Data", gensql.TeamRoleViewer); err != nil {
+		t.Fatal(err)
+	}
+
+	client := NewClient(repo, auth.NewGraphClient(server.URL, server.TokenFunc), logrus.NewEntry(logrus.StandardLogger()))
+
+	t.Run("new group members are added", func(t *testing.T) {
+		// The second tick runs before the update has been processed, and must not register another
+		for tick := 0; tick < 2; tick++ {
+			if err := client.SyncTeam(ctx, team.ID); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		payload := lastTeamUpdate(t, ctx, team.ID)
+		expect := []database.TeamMember{
+			{Email: "owner@nav.no", Role: gensql.TeamRoleOwner},
+			{Email: "maintainer@nav.no", Role: gensql.TeamRoleViewer, AzureGroupID: "group-data"},
+			{Email: "viewer@nav.no", Role: gensql.TeamRoleViewer, AzureGroupID: "group-data"},
+		}
+		if diff := cmp.Diff(expect, payload.Members); diff != "" {
+			t.Errorf("SyncTeam(): members mismatch (-want +got):\n%s", diff)
+		}
+
+		if err := repo.TeamUpdate(ctx, &payload.Team, payload.Members...); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := completeTeamUpdates(ctx, team.ID); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("unchanged membership registers no event", func(t *testing.T) {
+		if err := client.SyncTeam(ctx, team.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		events, err := newTeamUpdates(ctx, team.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(events) != 0 {
+			t.Errorf("SyncTeam(): expected no events, got %v", len(events))
+		}
+	})
+
+	t.Run("graph failure keeps group members", func(t *testing.T) {
+		server.DeleteGroup("group-data")
+
+		if err := client.SyncTeam(ctx, team.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		events, err := newTeamUpdates(ctx, team.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(events) != 0 {
+			t.Errorf("SyncTeam(): expected no events, got %v", len(events))
+		}
+
+		groups, err := repo.TeamAzureGroupsGet(ctx, team.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(groups) != 1 || groups[0].SyncError == "" {
+			t.Errorf("SyncTeam(): expected the sync error to be recorded, got %+v", groups)
+		}
+	})
+
+	t.Run("unlinked group members are removed", func(t *testing.T) {
+		if err := repo.TeamAzureGroupDelete(ctx, team.ID, "group-data"); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := client.SyncTeam(ctx, team.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		payload := lastTeamUpdate(t, ctx, team.ID)
+		expect := []database.TeamMember{
+			{Email: "owner@nav.no", Role: gensql.TeamRoleOwner},
+		}
+		if diff := cmp.Diff(expect, payload.Members); diff != "" {
+			t.Errorf("SyncTeam(): members mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
+
+func lastTeamUpdate(t *testing.T, ctx context.Context, teamID string) database.Team {
+	t.Helper()
+
+	events, err := newTeamUpdates(ctx, teamID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != 1 {
+		t.Fatalf("expected one update team event, got %v", len(events))
+	}
+
+	var payload database.Team
+	if err := json.Unmarshal(events[0].Payload, &payload); err != nil {
+		t.Fatal(err)
+	}
+
+	return payload
+}
+
+func newTeamUpdates(ctx context.Context, teamID string) ([]gensql.Event, error) {
+	events, err := repo.EventsGetType(ctx, database.EventTypeUpdateTeam)
+	if err != nil {
+		return nil, err
+	}
+
+	var updates []gensql.Event
+	for _, event := range events {
+		if event.Owner == teamID && event.Status == string(database.EventStatusNew) {
+			updates = append(updates, event)
+		}
+	}
+
+	return updates, nil
+}
+
+func completeTeamUpdates(ctx context.Context, teamID string) error {
+	events, err := newTeamUpdates(ctx, teamID)
+	if err != nil {
+		return err
+	}
+
+	for _, event := range events {
+		if err := repo.EventSetStatus(ctx, event.ID, database.EventStatusCompleted); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -11,7 +11,9 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
+	cnpgv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
 	v1 "k8s.io/api/core/v1"
 
 	"github.com/navikt/knorten/pkg/gcpapi"
@@ -23,6 +25,7 @@ import (
 	"github.com/navikt/knorten/pkg/database/gensql"
 	"github.com/navikt/knorten/pkg/helm"
 	"github.com/navikt/knorten/pkg/k8s"
+	"github.com/navikt/knorten/pkg/plan"
 	"github.com/navikt/knorten/pkg/reflect"
 )
 
@@ -102,10 +105,15 @@ func (c Client) syncAirflow(ctx context.Context, configurableValues *AirflowConf
 	// Apply values to cluster
 	namespace := k8s.TeamIDToNamespace(team.ID)
 
-	if err := c.createHttpRoute(ctx, team.Slug+".airflow."+c.topLevelDomain, namespace, gensql.ChartTypeAirflow); err != nil {
+	hostname := c.airflowHostname(team.Slug)
+	if err := c.createHttpRoute(ctx, hostname, namespace, gensql.ChartTypeAirflow); err != nil {
 		return fmt.Errorf("creating http route: %w", err)
 	}
 
+	if err := c.createAirflowRedirectHttpRoutes(ctx, team.ID, namespace, hostname); err != nil {
+		return fmt.Errorf("creating redirect http routes: %w", err)
+	}
+
 	if err := c.createHealthCheckPolicy(ctx, namespace, gensql.ChartTypeAirflow); err != nil {
 		return fmt.Errorf("creating health check policy: %w", err)
 	}
@@ -144,6 +152,7 @@ func (c Client) deleteAirflow(ctx context.Context, teamID string) error {
 	}
 
 	if c.dryRun {
+		c.planDeleteAirflow(ctx, teamID)
 		return nil
 	}
 
@@ -161,6 +170,10 @@ func (c Client) deleteAirflow(ctx context.Context, teamID string) error {
 		return fmt.Errorf("deleting http route: %w", err)
 	}
 
+	if err := c.deleteAirflowRedirectHttpRoutes(ctx, teamID, namespace); err != nil {
+		return fmt.Errorf("deleting redirect http routes: %w", err)
+	}
+
 	if err := c.deleteHealthCheckPolicy(ctx, namespace, gensql.ChartTypeAirflow); err != nil {
 		return fmt.Errorf("deleting health check policy: %w", err)
 	}
@@ -180,6 +193,24 @@ func (c Client) deleteAirflow(ctx context.Context, teamID string) error {
 	return nil
 }
 
+// planDeleteAirflow records what deleteAirflow would have removed
+func (c Client) planDeleteAirflow(ctx context.Context, teamID string) {
+	namespace := k8s.TeamIDToNamespace(teamID)
+
+	for _, target := range []string{
+		fmt.Sprintf("Secret %v/%v", namespace, k8sAirflowFernetKeySecretName),
+		fmt.Sprintf("Secret %v/%v", namespace, k8sAirflowWebserverSecretName),
+		fmt.Sprintf("HTTPRoute %v/%v", namespace, k8sAirflowResourceName),
+		fmt.Sprintf("HealthCheckPolicy %v/%v", namespace, k8sAirflowResourceName),
+		fmt.Sprintf("ScheduledBackup %v/%v", namespace, teamID),
+		fmt.Sprintf("Cluster %v/%v", namespace, teamID),
+	} {
+		plan.Record(ctx, plan.KindKubernetes, "delete", target, nil)
+	}
+
+	plan.Record(ctx, plan.KindGCP, "removePolicyRole", teamID, map[string]string{"role": gcpapi.ServiceAccountTokenCreatorRole.String()})
+}
+
 // mergeAirflowValues merges the values from the database with the values from the request, generate the missing values and returns the final values.
 func (c Client) mergeAirflowValues(ctx context.Context, team gensql.TeamGetRow, configurableValues *AirflowConfigurableValues) (AirflowValues, error) {
 	if configurableValues.DagRepo == "" { // only required value
@@ -247,7 +278,16 @@ func (c Client) mergeAirflowValues(ctx context.Context, team gensql.TeamGetRow,
 		return AirflowValues{}, err
 	}
 
-	webserverEnv, err := c.createAirflowWebServerEnvs(team.Users, configurableValues.ApiAccess)
+	members, err := c.repo.TeamMembersGet(ctx, team.ID)
+	if err != nil {
+		return AirflowValues{}, err
+	}
+
+	teamWithMembers := database.Team{Team: gensql.Team{ID: team.ID, Users: team.Users}, Members: members}
+	webserverEnv, err := c.createAirflowWebServerEnvs(
+		teamWithMembers.EmailsWithRole(gensql.TeamRoleOwner, gensql.TeamRoleMaintainer, gensql.TeamRoleViewer),
+		configurableValues.ApiAccess,
+	)
 	if err != nil {
 		return AirflowValues{}, err
 	}
@@ -270,6 +310,9 @@ type airflowEnv struct {
 	Value string `json:"value"`
 }
 
+// createAirflowWebServerEnvs lets the users log in to Airflow through AIRFLOW_USERS. The
+// webserver config gives everyone in the list the same role, as Airflow has no viewer role
+// yet, so viewers are listed as well rather than losing access.
 func (Client) createAirflowWebServerEnvs(users []string, apiAccess bool) (string, error) {
 	envs := []airflowEnv{
 		{
@@ -349,24 +392,25 @@ func teamIDToDb(name string) string {
 }
 
 func (c Client) createAirflowDatabase(ctx context.Context, team *gensql.TeamGetRow) error {
-	if c.dryRun {
-		return nil
-	}
-
 	teamID := team.ID
-	dbInstance := getAirflowDatabaseName(teamID)
 	namespace := k8s.TeamIDToNamespace(teamID)
 
-	cluster := cnpg.NewCluster(
-		teamIDToDb(teamID),
-		namespace,
-		dbInstance,
-		teamID,
-		cnpg.WithAppLabel("airflow-postgres"),
-		cnpg.WithMonitoring(true),
-	)
+	hibernated, err := c.repo.AirflowIsHibernated(ctx, teamID)
+	if err != nil {
+		return err
+	}
+
+	cluster := newAirflowPostgresCluster(teamID, hibernated)
 
-	err := c.manager.ApplyPostgresCluster(ctx, cluster)
+	if c.dryRun {
+		// The database secret is made by the operator, so the rest can't be run as a dry run
+		plan.Record(ctx, plan.KindKubernetes, "apply", fmt.Sprintf("Cluster %v/%v", namespace, cluster.Name), cluster)
+		plan.Record(ctx, plan.KindKubernetes, "apply", fmt.Sprintf("ScheduledBackup %v/%v", namespace, teamID), cnpg.NewScheduledBackup(teamID, namespace, cluster.Name))
+		plan.Record(ctx, plan.KindKubernetes, "apply", fmt.Sprintf("Secret %v/airflow-db", namespace), nil)
+		return nil
+	}
+
+	err = c.manager.ApplyPostgresCluster(ctx, cluster)
 	if err != nil {
 		return err
 	}
@@ -407,12 +451,57 @@ func (c Client) createAirflowDatabase(ctx context.Context, team *gensql.TeamGetR
 	return nil
 }
 
-func (c Client) createLogBucketForAirflow(ctx context.Context, teamID string) error {
+func newAirflowPostgresCluster(teamID string, hibernated bool) *cnpgv1.Cluster {
+	return cnpg.NewCluster(
+		teamIDToDb(teamID),
+		k8s.TeamIDToNamespace(teamID),
+		getAirflowDatabaseName(teamID),
+		teamID,
+		cnpg.WithAppLabel("airflow-postgres"),
+		cnpg.WithMonitoring(true),
+		cnpg.WithHibernation(hibernated),
+	)
+}
+
+// setAirflowHibernation stores the hibernation state for the team, and pauses or resumes
+// the Airflow database accordingly. The Airflow components themselves are scaled by the
+// helm rollout, see helm.AirflowEnricher.
+func (c Client) setAirflowHibernation(ctx context.Context, teamID string, hibernate bool) error {
+	if err := c.repo.TeamValueInsert(ctx, gensql.ChartTypeAirflow, database.TeamValueKeyHibernated, strconv.FormatBool(hibernate), teamID); err != nil {
+		return fmt.Errorf("inserting %v team value to database: %w", database.TeamValueKeyHibernated, err)
+	}
+
+	if !hibernate {
+		// Give the team a fresh start, so the idle policy doesn't put it straight back to sleep
+		if err := c.repo.TeamValueInsert(ctx, gensql.ChartTypeAirflow, database.TeamValueKeyLastActivity, time.Now().Format(time.RFC3339), teamID); err != nil {
+			return fmt.Errorf("inserting %v team value to database: %w", database.TeamValueKeyLastActivity, err)
+		}
+	}
+
 	if c.dryRun {
+		cluster := newAirflowPostgresCluster(teamID, hibernate)
+		plan.Record(ctx, plan.KindKubernetes, "apply", fmt.Sprintf("Cluster %v/%v", cluster.Namespace, cluster.Name), cluster)
 		return nil
 	}
 
+	if err := c.manager.ApplyPostgresCluster(ctx, newAirflowPostgresCluster(teamID, hibernate)); err != nil {
+		return fmt.Errorf("applying postgres cluster: %w", err)
+	}
+
+	return nil
+}
+
+func (c Client) createLogBucketForAirflow(ctx context.Context, teamID string) error {
 	bucketName := createBucketName(teamID)
+	if c.dryRun {
+		plan.Record(ctx, plan.KindGCP, "createBucket", bucketName, map[string]string{"team": teamID, "created-by": "knorten"})
+		plan.Record(ctx, plan.KindGCP, "setIamPolicy", bucketName, map[string]any{
+			"role":    "roles/storage.objectAdmin",
+			"members": []string{fmt.Sprintf("serviceAccount:%v@%v.iam.gserviceaccount.com", teamID, c.gcpProject)},
+		})
+		return nil
+	}
+
 	if err := createBucket(ctx, teamID, bucketName, c.gcpProject, c.gcpRegion); err != nil {
 		return err
 	}
@@ -475,7 +564,12 @@ func (c Client) getOrGeneratePassword(ctx context.Context, teamID, key string, g
 	return "", fmt.Errorf("a %v exisits for %v, but it's empty or doesn't belong to Airflow", key, teamID)
 }
 
-func (c Client) registerAirflowHelmEvent(ctx context.Context, teamID string, eventType database.EventType) error {
+func (c Client) registerAirflowHelmEvent(
+	ctx context.Context,
+	teamID string,
+	eventType database.EventType,
+	opts ...database.EventOption,
+) error {
 	helmEventData := helm.EventData{
 		TeamID:       teamID,
 		Namespace:    k8s.TeamIDToNamespace(teamID),
@@ -486,7 +580,7 @@ func (c Client) registerAirflowHelmEvent(ctx context.Context, teamID string, eve
 		ChartVersion: c.chartVersionAirflow,
 	}
 
-	if err := c.registerHelmEvent(ctx, eventType, teamID, helmEventData); err != nil {
+	if err := c.registerHelmEvent(ctx, eventType, teamID, helmEventData, opts...); err != nil {
 		return err
 	}
 
@@ -47,13 +47,13 @@ func NewClient(
 	}, nil
 }
 
-func (c Client) SyncAirflow(ctx context.Context, values *AirflowConfigurableValues) error {
+func (c Client) SyncAirflow(ctx context.Context, values *AirflowConfigurableValues, opts ...database.EventOption) error {
 	err := c.syncAirflow(ctx, values)
 	if err != nil {
 		return fmt.Errorf("syncing airflow: %w", err)
 	}
 
-	err = c.registerAirflowHelmEvent(ctx, values.TeamID, database.EventTypeHelmRolloutAirflow)
+	err = c.registerAirflowHelmEvent(ctx, values.TeamID, database.EventTypeHelmRolloutAirflow, opts...)
 	if err != nil {
 		return fmt.Errorf("registering airflow helm event: %w", err)
 	}
@@ -61,13 +61,41 @@ func (c Client) SyncAirflow(ctx context.Context, values *AirflowConfigurableValu
 	return nil
 }
 
-func (c Client) DeleteAirflow(ctx context.Context, teamID string) error {
+func (c Client) DeleteAirflow(ctx context.Context, teamID string, opts ...database.EventOption) error {
 	err := c.deleteAirflow(ctx, teamID)
 	if err != nil {
 		return fmt.Errorf("deleting airflow: %w", err)
 	}
 
-	err = c.registerAirflowHelmEvent(ctx, teamID, database.EventTypeHelmUninstallAirflow)
+	err = c.registerAirflowHelmEvent(ctx, teamID, database.EventTypeHelmUninstallAirflow, opts...)
+	if err != nil {
+		return fmt.Errorf("registering airflow helm event: %w", err)
+	}
+
+	return nil
+}
+
+func (c Client) HibernateAirflow(ctx context.Context, teamID string, opts ...database.EventOption) error {
+	err := c.setAirflowHibernation(ctx, teamID, true)
+	if err != nil {
+		return fmt.Errorf("hibernating airflow: %w", err)
+	}
+
+	err = c.registerAirflowHelmEvent(ctx, teamID, database.EventTypeHelmRolloutAirflow, opts...)
+	if err != nil {
+		return fmt.Errorf("registering airflow helm event: %w", err)
+	}
+
+	return nil
+}
+
+func (c Client) WakeAirflow(ctx context.Context, teamID string, opts ...database.EventOption) error {
+	err := c.setAirflowHibernation(ctx, teamID, false)
+	if err != nil {
+		return fmt.Errorf("waking airflow: %w", err)
+	}
+
+	err = c.registerAirflowHelmEvent(ctx, teamID, database.EventTypeHelmRolloutAirflow, opts...)
 	if err != nil {
 		return fmt.Errorf("registering airflow helm event: %w", err)
 	}
@@ -80,14 +108,15 @@ func (c Client) registerHelmEvent(
 	eventType database.EventType,
 	teamID string,
 	helmEventData helm.EventData,
+	opts ...database.EventOption,
 ) error {
 	switch eventType {
 	case database.EventTypeHelmRolloutAirflow:
-		if err := c.repo.RegisterHelmRolloutAirflowEvent(ctx, teamID, helmEventData); err != nil {
+		if err := c.repo.RegisterHelmRolloutAirflowEvent(ctx, teamID, helmEventData, opts...); err != nil {
 			return err
 		}
 	case database.EventTypeHelmUninstallAirflow:
-		if err := c.repo.RegisterHelmUninstallAirflowEvent(ctx, teamID, helmEventData); err != nil {
+		if err := c.repo.RegisterHelmUninstallAirflowEvent(ctx, teamID, helmEventData, opts...); err != nil {
 			return err
 		}
 	default:
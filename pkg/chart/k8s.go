@@ -47,6 +47,44 @@ func (c Client) createHttpRoute(
 	return c.manager.ApplyHTTPRoute(ctx, route)
 }
 
+// createAirflowRedirectHttpRoutes keeps the hostnames of a renamed team working, by redirecting
+// the old slugs to the current hostname until the redirects expire
+func (c Client) createAirflowRedirectHttpRoutes(ctx context.Context, teamID, namespace, hostname string) error {
+	redirects, err := c.repo.TeamSlugRedirectsGet(ctx, teamID)
+	if err != nil {
+		return err
+	}
+
+	for _, redirect := range redirects {
+		from := c.airflowHostname(redirect.Slug)
+		route := networking.NewAirflowRedirectHTTPRoute(namespace, redirect.Slug, from, hostname)
+		if err := c.manager.ApplyHTTPRoute(ctx, route); err != nil {
+			return fmt.Errorf("applying redirect from %v: %w", from, err)
+		}
+	}
+
+	return nil
+}
+
+func (c Client) deleteAirflowRedirectHttpRoutes(ctx context.Context, teamID, namespace string) error {
+	redirects, err := c.repo.TeamSlugRedirectsGet(ctx, teamID)
+	if err != nil {
+		return err
+	}
+
+	for _, redirect := range redirects {
+		if err := c.manager.DeleteHTTPRoute(ctx, networking.AirflowRedirectHTTPRouteName(redirect.Slug), namespace); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c Client) airflowHostname(teamSlug string) string {
+	return teamSlug + ".airflow." + c.topLevelDomain
+}
+
 func (c Client) deleteHttpRoute(
 	ctx context.Context,
 	namespace string,
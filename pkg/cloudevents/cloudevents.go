@@ -0,0 +1,165 @@
+// Package cloudevents publishes the messages in the outbox as CloudEvents to the configured endpoints
+package cloudevents
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/navikt/knorten/pkg/database"
+	"github.com/navikt/knorten/pkg/database/gensql"
+	"github.com/navikt/knorten/pkg/leaderelection"
+	"github.com/navikt/knorten/pkg/notifications"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	specVersion = "1.0"
+	contentType = "application/cloudevents+json"
+	batchSize   = 100
+)
+
+// CloudEvent is the structured mode JSON format of CloudEvents 1.0
+type CloudEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            time.Time       `json:"time"`
+	DataContentType string          `json:"datacontenttype"`
+	Data            json.RawMessage `json:"data"`
+}
+
+// Publisher sends every message in the outbox to all the endpoints. A message is retried until
+// every endpoint has accepted it, or maxAttempts is reached. Endpoints which have accepted it are
+// not sent it again, but consumers should still use the id to ignore duplicates.
+type Publisher struct {
+	repo        *database.Repo
+	client      *http.Client
+	source      string
+	endpoints   []string
+	maxAttempts int
+	log         *logrus.Entry
+	now         func() time.Time
+}
+
+func NewPublisher(repo *database.Repo, client *http.Client, source string, endpoints []string, maxAttempts int, log *logrus.Entry) *Publisher {
+	return &Publisher{
+		repo:        repo,
+		client:      client,
+		source:      source,
+		endpoints:   endpoints,
+		maxAttempts: maxAttempts,
+		log:         log,
+		now:         time.Now,
+	}
+}
+
+func (p *Publisher) Run(ctx context.Context, frequency time.Duration) {
+	leaderelection.RunPeriodically(ctx, frequency, p.log, p.run)
+}
+
+func (p *Publisher) run(ctx context.Context) {
+	if err := p.PublishDue(ctx); err != nil {
+		p.log.WithError(err).Error("publishing cloudevents")
+	}
+}
+
+// PublishDue sends the messages in the outbox which are due, either for the first time or as a retry
+func (p *Publisher) PublishDue(ctx context.Context) error {
+	messages, err := p.repo.CloudEventsDueGet(ctx, batchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, message := range messages {
+		if err := p.publish(ctx, message); err != nil {
+			p.log.WithError(err).Errorf("recording delivery of cloudevent %v", message.ID)
+		}
+	}
+
+	return nil
+}
+
+func (p *Publisher) publish(ctx context.Context, message gensql.CloudeventsOutbox) error {
+	event := p.CloudEvent(message)
+	delivered := message.DeliveredEndpoints
+
+	var errs []error
+	for _, endpoint := range p.endpoints {
+		if slices.Contains(delivered, endpoint) {
+			continue
+		}
+
+		if err := p.send(ctx, endpoint, event); err != nil {
+			errs = append(errs, fmt.Errorf("%v: %w", endpoint, err))
+			continue
+		}
+
+		delivered = append(delivered, endpoint)
+	}
+
+	if len(errs) == 0 {
+		return p.repo.CloudEventDeliveredSet(ctx, message.ID, delivered)
+	}
+
+	attempts := int(message.Attempts) + 1
+	giveUp := attempts >= p.maxAttempts
+	sendErr := errors.Join(errs...)
+
+	if giveUp {
+		p.log.WithError(sendErr).Errorf("giving up publishing cloudevent %v after %v attempts", message.ID, attempts)
+	} else {
+		p.log.WithError(sendErr).Warnf("publishing cloudevent %v failed, retrying", message.ID)
+	}
+
+	return p.repo.CloudEventDeliveryFailedSet(ctx, message.ID, delivered, sendErr, p.now().Add(notifications.RetryDelay(attempts)), giveUp)
+}
+
+// CloudEvent wraps a message from the outbox
+func (p *Publisher) CloudEvent(message gensql.CloudeventsOutbox) CloudEvent {
+	return CloudEvent{
+		SpecVersion:     specVersion,
+		ID:              message.ID.String(),
+		Source:          p.source,
+		Type:            message.Type,
+		Subject:         message.Subject,
+		Time:            message.Time,
+		DataContentType: "application/json",
+		Data:            message.Data,
+	}
+}
+
+func (p *Publisher) send(ctx context.Context, endpoint string, event CloudEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("responded with %v: %v", resp.Status, strings.TrimSpace(string(message)))
+	}
+
+	return nil
+}
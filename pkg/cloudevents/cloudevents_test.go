@@ -0,0 +1,115 @@
+package cloudevents
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+	"path"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/navikt/knorten/local/dbsetup"
+	"github.com/navikt/knorten/pkg/database"
+	"github.com/navikt/knorten/pkg/notifications/notificationstest"
+	"github.com/sirupsen/logrus"
+)
+
+var repo *database.Repo
+
+func init() {
+	_, filename, _, _ := runtime.Caller(0)
+	dir := path.Join(path.Dir(filename), "../..")
+	err := os.Chdir(dir)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func TestMain(m *testing.M) {
+	dbConn, err := dbsetup.SetupDBForTests()
+	if err != nil {
+		log.Fatal(err)
+	}
+	repo, err = database.New(dbConn, "", logrus.NewEntry(logrus.StandardLogger()))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	code := m.Run()
+	os.Exit(code)
+}
+
+func TestPublishDue(t *testing.T) {
+	ctx := context.Background()
+
+	healthy := notificationstest.NewServer()
+	defer healthy.Close()
+	flaky := notificationstest.NewServer()
+	defer flaky.Close()
+
+	publisher := NewPublisher(repo, healthy.Client(), "/knorten/test", []string{healthy.URL, flaky.URL}, 3, logrus.NewEntry(logrus.StandardLogger()))
+	// Retries are scheduled in the past, so they are due right away
+	publisher.now = func() time.Time { return time.Now().Add(-2 * time.Hour) }
+
+	teamID := "cloudevents-1234"
+	if err := repo.RegisterDeleteTeamEvent(ctx, teamID); err != nil {
+		t.Fatal(err)
+	}
+
+	events, err := repo.EventsByOwnerGet(ctx, teamID, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := repo.EventSetStatus(ctx, events[0].ID, database.EventStatusProcessing); err != nil {
+		t.Fatal(err)
+	}
+
+	flaky.FailNext(1)
+	if err := publisher.PublishDue(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(healthy.Requests()) != 2 || len(flaky.Requests()) != 0 {
+		t.Fatalf("PublishDue(): expected both status changes to reach only the healthy endpoint, got %v and %v", len(healthy.Requests()), len(flaky.Requests()))
+	}
+
+	flaky.FailNext(0)
+	if err := publisher.PublishDue(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(healthy.Requests()) != 2 || len(flaky.Requests()) != 2 {
+		t.Fatalf("PublishDue(): expected the retry to only go to the flaky endpoint, got %v and %v", len(healthy.Requests()), len(flaky.Requests()))
+	}
+
+	request := healthy.Requests()[1]
+	if request.ContentType != contentType {
+		t.Errorf("PublishDue(): expected content type %v, got %v", contentType, request.ContentType)
+	}
+
+	var event struct {
+		CloudEvent
+		Data struct {
+			EventID        string `json:"eventId"`
+			EventType      string `json:"eventType"`
+			Team           string `json:"team"`
+			Status         string `json:"status"`
+			PreviousStatus string `json:"previousStatus"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(request.Body, &event); err != nil {
+		t.Fatal(err)
+	}
+
+	if event.SpecVersion != "1.0" || event.Type != database.CloudEventTypeEventStatus || event.Source != "/knorten/test" || event.Subject != teamID {
+		t.Errorf("PublishDue(): unexpected cloudevent %+v", event.CloudEvent)
+	}
+
+	if event.Data.EventID != events[0].ID.String() || event.Data.EventType != string(database.EventTypeDeleteTeam) ||
+		event.Data.Team != teamID || event.Data.Status != string(database.EventStatusProcessing) || event.Data.PreviousStatus != string(database.EventStatusNew) {
+		t.Errorf("PublishDue(): unexpected data %+v", event.Data)
+	}
+}
@@ -42,6 +42,17 @@ type Config struct {
 	DryRun                     bool                       `yaml:"dry_run"`
 	Debug                      bool                       `yaml:"debug"`
 	MaintenanceExclusionConfig MaintenanceExclusionConfig `yaml:"maintenance_exclusion"`
+	AirflowHibernation         AirflowHibernation         `yaml:"airflow_hibernation"`
+	AzureGroupSync             AzureGroupSync             `yaml:"azure_group_sync"`
+	TeamDeletion               TeamDeletion               `yaml:"team_deletion"`
+	TeamRename                 TeamRename                 `yaml:"team_rename"`
+	Notifications              Notifications              `yaml:"notifications"`
+	CloudEvents                CloudEvents                `yaml:"cloudevents"`
+	Tracing                    Tracing                    `yaml:"tracing"`
+	Health                     Health                     `yaml:"health"`
+	Shutdown                   Shutdown                   `yaml:"shutdown"`
+	Events                     Events                     `yaml:"events"`
+	Retention                  Retention                  `yaml:"retention"`
 }
 
 func (c Config) Validate() error {
@@ -58,6 +69,17 @@ func (c Config) Validate() error {
 		validation.Field(&c.LoginPage, validation.Required),
 		validation.Field(&c.AdminGroupID, validation.Required, is.UUID),
 		validation.Field(&c.SessionKey, validation.Required),
+		validation.Field(&c.AirflowHibernation),
+		validation.Field(&c.AzureGroupSync),
+		validation.Field(&c.TeamDeletion),
+		validation.Field(&c.TeamRename),
+		validation.Field(&c.Notifications),
+		validation.Field(&c.CloudEvents),
+		validation.Field(&c.Tracing),
+		validation.Field(&c.Health),
+		validation.Field(&c.Shutdown),
+		validation.Field(&c.Events),
+		validation.Field(&c.Retention),
 	)
 }
 
@@ -109,15 +131,19 @@ func (p Postgres) ConnectionString() string {
 	)
 }
 
+// Server is where Knorten listens. Metrics and health checks are served on the internal port,
+// which isn't exposed through the service, so that they can't be reached from outside the cluster.
 type Server struct {
-	Hostname string `yaml:"hostname"`
-	Port     string `yaml:"port"`
+	Hostname     string `yaml:"hostname"`
+	Port         string `yaml:"port"`
+	InternalPort string `yaml:"internal_port"`
 }
 
 func (s Server) Validate() error {
 	return validation.ValidateStruct(&s,
 		validation.Field(&s.Hostname, validation.Required, is.Host),
 		validation.Field(&s.Port, validation.Required, is.Port),
+		validation.Field(&s.InternalPort, validation.Required, is.Port, validation.NotIn(s.Port)),
 	)
 }
 
@@ -234,6 +260,171 @@ type MaintenanceExclusionConfig struct {
 	FilePath string `yaml:"file_path"`
 }
 
+type AirflowHibernation struct {
+	IdlePolicyEnabled bool `yaml:"idle_policy_enabled"`
+	IdleAfterDays     int  `yaml:"idle_after_days"`
+	CheckIntervalMins int  `yaml:"check_interval_mins"`
+}
+
+func (a AirflowHibernation) Validate() error {
+	return validation.ValidateStruct(&a,
+		validation.Field(&a.IdleAfterDays, validation.When(a.IdlePolicyEnabled, validation.Required, validation.Min(1))),
+		validation.Field(&a.CheckIntervalMins, validation.When(a.IdlePolicyEnabled, validation.Required, validation.Min(1))),
+	)
+}
+
+type AzureGroupSync struct {
+	Enabled          bool `yaml:"enabled"`
+	SyncIntervalMins int  `yaml:"sync_interval_mins"`
+}
+
+func (a AzureGroupSync) Validate() error {
+	return validation.ValidateStruct(&a,
+		validation.Field(&a.SyncIntervalMins, validation.When(a.Enabled, validation.Required, validation.Min(1))),
+	)
+}
+
+type TeamDeletion struct {
+	GracePeriodDays   int `yaml:"grace_period_days"`
+	CheckIntervalMins int `yaml:"check_interval_mins"`
+}
+
+func (t TeamDeletion) Validate() error {
+	return validation.ValidateStruct(&t,
+		validation.Field(&t.GracePeriodDays, validation.Required, validation.Min(1)),
+		validation.Field(&t.CheckIntervalMins, validation.Required, validation.Min(1)),
+	)
+}
+
+type TeamRename struct {
+	RedirectDays      int `yaml:"redirect_days"`
+	CheckIntervalMins int `yaml:"check_interval_mins"`
+}
+
+func (t TeamRename) Validate() error {
+	return validation.ValidateStruct(&t,
+		validation.Field(&t.RedirectDays, validation.Required, validation.Min(1)),
+		validation.Field(&t.CheckIntervalMins, validation.Required, validation.Min(1)),
+	)
+}
+
+type Notifications struct {
+	DeliveryIntervalSecs int  `yaml:"delivery_interval_secs"`
+	MaxAttempts          int  `yaml:"max_attempts"`
+	SMTP                 SMTP `yaml:"smtp"`
+}
+
+func (n Notifications) Validate() error {
+	return validation.ValidateStruct(&n,
+		validation.Field(&n.DeliveryIntervalSecs, validation.Required, validation.Min(1)),
+		validation.Field(&n.MaxAttempts, validation.Required, validation.Min(1)),
+		validation.Field(&n.SMTP),
+	)
+}
+
+// SMTP is the relay used for notifications by email, which are turned off when no host is set
+type SMTP struct {
+	Host string `yaml:"host"`
+	Port string `yaml:"port"`
+	From string `yaml:"from"`
+}
+
+func (s SMTP) Validate() error {
+	return validation.ValidateStruct(&s,
+		validation.Field(&s.Port, validation.When(s.Host != "", validation.Required)),
+		validation.Field(&s.From, validation.When(s.Host != "", validation.Required, is.EmailFormat)),
+	)
+}
+
+// CloudEvents are published to every endpoint, no endpoints means they are only kept in the outbox
+type CloudEvents struct {
+	Source              string   `yaml:"source"`
+	Endpoints           []string `yaml:"endpoints"`
+	PublishIntervalSecs int      `yaml:"publish_interval_secs"`
+	MaxAttempts         int      `yaml:"max_attempts"`
+}
+
+func (c CloudEvents) Validate() error {
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.Source, validation.Required),
+		validation.Field(&c.Endpoints, validation.Each(validation.Required, is.URL)),
+		validation.Field(&c.PublishIntervalSecs, validation.Required, validation.Min(1)),
+		validation.Field(&c.MaxAttempts, validation.Required, validation.Min(1)),
+	)
+}
+
+// Tracing exports spans over OTLP/HTTP, they are only propagated when no endpoint is set
+type Tracing struct {
+	OTLPEndpoint string `yaml:"otlp_endpoint"`
+}
+
+func (t Tracing) Validate() error {
+	return validation.ValidateStruct(&t,
+		validation.Field(&t.OTLPEndpoint, is.URL),
+	)
+}
+
+// Health configures the dependency checks behind /readyz
+type Health struct {
+	CheckTimeoutSecs int `yaml:"check_timeout_secs"`
+	GithubMaxAgeMins int `yaml:"github_max_age_mins"`
+}
+
+func (h Health) Validate() error {
+	return validation.ValidateStruct(&h,
+		validation.Field(&h.CheckTimeoutSecs, validation.Required, validation.Min(1)),
+		validation.Field(&h.GithubMaxAgeMins, validation.Required, validation.Min(1)),
+	)
+}
+
+// Shutdown is how long requests and events in flight get to finish after a SIGTERM
+type Shutdown struct {
+	DrainTimeoutSecs int `yaml:"drain_timeout_secs"`
+}
+
+func (s Shutdown) Validate() error {
+	return validation.ValidateStruct(&s,
+		validation.Field(&s.DrainTimeoutSecs, validation.Required, validation.Min(1)),
+	)
+}
+
+// Events limits how many events of each class are processed at the same time. The class
+// of an event is the part of its type after the colon, e.g. helm for rolloutAirflow:helm,
+// and classes without a limit of their own use the default.
+type Events struct {
+	DefaultConcurrency int            `yaml:"default_concurrency"`
+	Concurrency        map[string]int `yaml:"concurrency"`
+}
+
+func (e Events) Validate() error {
+	return validation.ValidateStruct(&e,
+		validation.Field(&e.DefaultConcurrency, validation.Required, validation.Min(1)),
+		validation.Field(&e.Concurrency, validation.Each(validation.Required, validation.Min(1))),
+	)
+}
+
+// Retention is how long completed and failed events are kept before they are archived and pruned.
+// The archive is written to the bucket, or to the directory when no bucket is set. Delivered and
+// given up messages in the CloudEvents outbox and the notification deliveries are kept for OutboxDays.
+type Retention struct {
+	CompletedEventDays int    `yaml:"completed_event_days"`
+	FailedEventDays    int    `yaml:"failed_event_days"`
+	OutboxDays         int    `yaml:"outbox_days"`
+	CheckIntervalMins  int    `yaml:"check_interval_mins"`
+	ArchiveBucket      string `yaml:"archive_bucket"`
+	ArchiveDir         string `yaml:"archive_dir"`
+}
+
+func (r Retention) Validate() error {
+	return validation.ValidateStruct(&r,
+		validation.Field(&r.CompletedEventDays, validation.Required, validation.Min(1)),
+		validation.Field(&r.FailedEventDays, validation.Required, validation.Min(r.CompletedEventDays)),
+		validation.Field(&r.OutboxDays, validation.Required, validation.Min(1)),
+		validation.Field(&r.CheckIntervalMins, validation.Required, validation.Min(1)),
+		validation.Field(&r.ArchiveDir, validation.When(r.ArchiveBucket == "", validation.Required)),
+	)
+}
+
 type FileParts struct {
 	FileName string
 	Path     string
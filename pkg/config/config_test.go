@@ -61,8 +61,9 @@ func newFakeConfig() config.Config {
 			AirflowChartVersion: "1.10.0",
 		},
 		Server: config.Server{
-			Hostname: "localhost",
-			Port:     "8080",
+			Hostname:     "localhost",
+			Port:         "8080",
+			InternalPort: "8090",
 		},
 		Postgres: config.Postgres{
 			Host:         "localhost",
@@ -82,6 +83,57 @@ func newFakeConfig() config.Config {
 		Kubernetes: config.Kubernetes{
 			Context: "minikube",
 		},
+		AirflowHibernation: config.AirflowHibernation{
+			IdlePolicyEnabled: true,
+			IdleAfterDays:     14,
+			CheckIntervalMins: 30,
+		},
+		AzureGroupSync: config.AzureGroupSync{
+			Enabled:          true,
+			SyncIntervalMins: 60,
+		},
+		TeamDeletion: config.TeamDeletion{
+			GracePeriodDays:   7,
+			CheckIntervalMins: 60,
+		},
+		TeamRename: config.TeamRename{
+			RedirectDays:      30,
+			CheckIntervalMins: 60,
+		},
+		Notifications: config.Notifications{
+			DeliveryIntervalSecs: 30,
+			MaxAttempts:          5,
+		},
+		CloudEvents: config.CloudEvents{
+			Source:              "/knorten/local",
+			Endpoints:           []string{},
+			PublishIntervalSecs: 10,
+			MaxAttempts:         10,
+		},
+		Tracing: config.Tracing{
+			OTLPEndpoint: "",
+		},
+		Health: config.Health{
+			CheckTimeoutSecs: 3,
+			GithubMaxAgeMins: 180,
+		},
+		Shutdown: config.Shutdown{
+			DrainTimeoutSecs: 90,
+		},
+		Events: config.Events{
+			DefaultConcurrency: 5,
+			Concurrency: map[string]int{
+				"helm":    2,
+				"airflow": 3,
+			},
+		},
+		Retention: config.Retention{
+			CompletedEventDays: 30,
+			FailedEventDays:    90,
+			OutboxDays:         14,
+			CheckIntervalMins:  60,
+			ArchiveDir:         "/tmp/knorten/archive",
+		},
 		DBEncKey:       "jegersekstentegn",
 		AdminGroupID:   "f2816319-7db0-4061-8d0c-5ddbe232d60c",
 		SessionKey:     "test-session",
@@ -0,0 +1,59 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/navikt/knorten/pkg/database/gensql"
+)
+
+// AuditEntry is a change made by a user, together with the request it was made through. Before
+// and After are short summaries of the state which was changed, when the handler provides them.
+type AuditEntry struct {
+	Actor      string
+	Action     string
+	Target     string
+	TeamID     string
+	Before     string
+	After      string
+	Method     string
+	Path       string
+	StatusCode int
+	ClientIP   string
+	UserAgent  string
+}
+
+// AuditLogInsert appends the entry to the audit log. The table rejects updates and deletes, so
+// entries can't be changed once written.
+func (r *Repo) AuditLogInsert(ctx context.Context, entry AuditEntry) error {
+	return r.querier.AuditLogInsert(ctx, gensql.AuditLogInsertParams{
+		Actor:      entry.Actor,
+		Action:     entry.Action,
+		Target:     entry.Target,
+		TeamID:     sql.NullString{String: entry.TeamID, Valid: entry.TeamID != ""},
+		Before:     entry.Before,
+		After:      entry.After,
+		Method:     entry.Method,
+		Path:       entry.Path,
+		StatusCode: int32(entry.StatusCode),
+		ClientIp:   entry.ClientIP,
+		UserAgent:  entry.UserAgent,
+	})
+}
+
+// AuditLogsSearch returns the latest entries, optionally only those made by actor and those where
+// the action, target or summaries contain query
+func (r *Repo) AuditLogsSearch(ctx context.Context, actor, query string, limit int32) ([]gensql.AuditLog, error) {
+	return r.querier.AuditLogsSearch(ctx, gensql.AuditLogsSearchParams{
+		Actor: actor,
+		Query: query,
+		Lim:   limit,
+	})
+}
+
+func (r *Repo) AuditLogsForTeamGet(ctx context.Context, teamID string, limit int32) ([]gensql.AuditLog, error) {
+	return r.querier.AuditLogsForTeamGet(ctx, gensql.AuditLogsForTeamGetParams{
+		TeamID: sql.NullString{String: teamID, Valid: true},
+		Lim:    limit,
+	})
+}
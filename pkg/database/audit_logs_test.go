@@ -0,0 +1,69 @@
+package database
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRepo_AuditLogs(t *testing.T) {
+	ctx := context.Background()
+
+	entries := []AuditEntry{
+		{
+			Actor:      "audit-owner@nav.no",
+			Action:     "POST /team/:slug/edit",
+			Target:     "slug=audit-team",
+			TeamID:     "audit-team-1234",
+			Before:     `["audit-owner@nav.no"]`,
+			After:      `["audit-owner@nav.no","audit-viewer@nav.no"]`,
+			Method:     "POST",
+			Path:       "/team/audit-team/edit",
+			StatusCode: 303,
+		},
+		{
+			Actor:      "audit-admin@nav.no",
+			Action:     "POST /admin/event/:id",
+			Target:     "id=1234",
+			Method:     "POST",
+			Path:       "/admin/event/1234",
+			StatusCode: 303,
+		},
+	}
+
+	for _, entry := range entries {
+		if err := repo.AuditLogInsert(ctx, entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	logs, err := repo.AuditLogsForTeamGet(ctx, "audit-team-1234", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(logs) != 1 || logs[0].Actor != "audit-owner@nav.no" {
+		t.Errorf("AuditLogsForTeamGet(): expected the change to the team, got %v", logs)
+	}
+
+	logs, err = repo.AuditLogsSearch(ctx, "", "audit-viewer", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(logs) != 1 || logs[0].TeamID.String != "audit-team-1234" {
+		t.Errorf("AuditLogsSearch(): expected to find the entry by its after summary, got %v", logs)
+	}
+
+	logs, err = repo.AuditLogsSearch(ctx, "audit-admin@nav.no", "", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(logs) != 1 || logs[0].Action != "POST /admin/event/:id" {
+		t.Errorf("AuditLogsSearch(): expected the entry by audit-admin@nav.no, got %v", logs)
+	}
+
+	if _, err := repo.db.ExecContext(ctx, "DELETE FROM audit_logs"); err == nil {
+		t.Errorf("expected deleting from audit_logs to fail")
+	}
+}
@@ -0,0 +1,44 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/navikt/knorten/pkg/database/gensql"
+)
+
+// The outbox is filled by database triggers on events and audit_logs, see 043_cloudevents_outbox.sql
+const (
+	CloudEventTypeEventStatus = "no.nav.knorten.event.status"
+	CloudEventTypeAudit       = "no.nav.knorten.audit"
+)
+
+func (r *Repo) CloudEventsDueGet(ctx context.Context, limit int32) ([]gensql.CloudeventsOutbox, error) {
+	return r.querier.CloudEventsDueGet(ctx, limit)
+}
+
+func (r *Repo) CloudEventDeliveredSet(ctx context.Context, id uuid.UUID, deliveredEndpoints []string) error {
+	return r.querier.CloudEventDeliveredSet(ctx, gensql.CloudEventDeliveredSetParams{
+		ID:                 id,
+		DeliveredEndpoints: deliveredEndpoints,
+	})
+}
+
+// CloudEventDeliveryFailedSet records the endpoints which have received the message so far. It is
+// sent to the rest at nextAttempt, unless giveUp is set.
+func (r *Repo) CloudEventDeliveryFailedSet(ctx context.Context, id uuid.UUID, deliveredEndpoints []string, deliveryErr error, nextAttempt time.Time, giveUp bool) error {
+	return r.querier.CloudEventDeliveryFailedSet(ctx, gensql.CloudEventDeliveryFailedSetParams{
+		ID:                 id,
+		DeliveredEndpoints: deliveredEndpoints,
+		LastError:          deliveryErr.Error(),
+		NextAttempt:        nextAttempt,
+		Failed:             giveUp,
+	})
+}
+
+// CloudEventsExpiredDelete deletes the messages which were delivered or given up on more than days
+// ago, and returns how many were deleted
+func (r *Repo) CloudEventsExpiredDelete(ctx context.Context, days int32) (int64, error) {
+	return r.querier.CloudEventsExpiredDelete(ctx, days)
+}
@@ -0,0 +1,59 @@
+package database
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestRepo_CloudEventsExpiredDelete(t *testing.T) {
+	ctx := context.Background()
+
+	messages := []struct {
+		subject   string
+		delivered bool
+		failed    bool
+		ageDays   int
+	}{
+		{subject: "retention-delivered-expired", delivered: true, ageDays: 20},
+		{subject: "retention-failed-expired", failed: true, ageDays: 20},
+		{subject: "retention-delivered-kept", delivered: true, ageDays: 5},
+		{subject: "retention-due-kept", ageDays: 20},
+	}
+	for _, message := range messages {
+		_, err := repo.db.Exec(`INSERT INTO cloudevents_outbox ("type","subject","data","time","delivered","failed")
+VALUES ($1,$2,'{}',NOW() - make_interval(days => $3),CASE WHEN $4 THEN NOW() END,$5);`,
+			CloudEventTypeAudit, message.subject, message.ageDays, message.delivered, message.failed)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Cleanup(func() {
+		if _, err := repo.db.Exec("DELETE FROM cloudevents_outbox WHERE subject LIKE 'retention-%'"); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	if _, err := repo.CloudEventsExpiredDelete(ctx, 14); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := repo.db.Query("SELECT subject FROM cloudevents_outbox WHERE subject LIKE 'retention-%' ORDER BY subject")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	var kept []string
+	for rows.Next() {
+		var subject string
+		if err := rows.Scan(&subject); err != nil {
+			t.Fatal(err)
+		}
+		kept = append(kept, subject)
+	}
+
+	if !slices.Equal(kept, []string{"retention-delivered-kept", "retention-due-kept"}) {
+		t.Errorf("CloudEventsExpiredDelete(): expected the messages which are due or recent to be kept, got %v", kept)
+	}
+}
@@ -0,0 +1,35 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/navikt/knorten/pkg/database/gensql"
+	"github.com/navikt/knorten/pkg/plan"
+)
+
+func (r *Repo) EventPlanStepCreate(ctx context.Context, eventID uuid.UUID, step plan.Step) error {
+	details, err := json.Marshal(step.Details)
+	if err != nil {
+		return fmt.Errorf("marshalling details of plan step: %w", err)
+	}
+
+	return r.querier.EventPlanStepCreate(ctx, gensql.EventPlanStepCreateParams{
+		EventID: eventID,
+		Kind:    step.Kind,
+		Action:  step.Action,
+		Target:  step.Target,
+		Details: details,
+	})
+}
+
+// EventPlanStepsGet returns what the event would have changed, in the order it was planned
+func (r *Repo) EventPlanStepsGet(ctx context.Context, eventID uuid.UUID) ([]gensql.EventPlanStep, error) {
+	return r.querier.EventPlanStepsGet(ctx, eventID)
+}
+
+func (r *Repo) EventPlanStepsDelete(ctx context.Context, eventID uuid.UUID) error {
+	return r.querier.EventPlanStepsDelete(ctx, eventID)
+}
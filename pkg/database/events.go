@@ -1,14 +1,18 @@
 package database
 
 import (
+	"cmp"
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/navikt/knorten/pkg/database/gensql"
+	"github.com/navikt/knorten/pkg/tracing"
 )
 
 type EventType string
@@ -17,6 +21,7 @@ const (
 	EventTypeCreateTeam           EventType = "create:team"
 	EventTypeUpdateTeam           EventType = "update:team"
 	EventTypeDeleteTeam           EventType = "delete:team"
+	EventTypeRenameTeam           EventType = "rename:team"
 	EventTypeCreateAirflow        EventType = "create:airflow"
 	EventTypeUpdateAirflow        EventType = "update:airflow"
 	EventTypeDeleteAirflow        EventType = "delete:airflow"
@@ -25,9 +30,29 @@ const (
 	EventTypeHelmRolloutAirflow   EventType = "rolloutAirflow:helm"
 	EventTypeHelmRollbackAirflow  EventType = "rollbackAirflow:helm"
 	EventTypeHelmUninstallAirflow EventType = "uninstallAirflow:helm"
-	EventTypeDeleteSchedulerPods  EventType = "restart:airflowscheduler"
+	EventTypeHibernateAirflow     EventType = "hibernate:airflow"
+	EventTypeWakeAirflow          EventType = "wake:airflow"
+
+	EventTypeRestartAirflowComponent EventType = "restart:airflowcomponent"
+	// Deprecated: replaced by EventTypeRestartAirflowComponent, only kept so that
+	// events registered before the change are still processed.
+	EventTypeDeleteSchedulerPods EventType = "restart:airflowscheduler"
 )
 
+// eventSchemaVersions are the current versions of the event payloads, for the event types whose
+// payload has changed since the first version. Bump the version when the payload of an event type
+// changes, and add an upcaster from the previous version to the payload schema in the events package.
+var eventSchemaVersions = map[EventType]int32{}
+
+// EventSchemaVersion is the version of the payload schema new events of the type are registered with
+func EventSchemaVersion(eventType EventType) int32 {
+	if version, ok := eventSchemaVersions[eventType]; ok {
+		return version
+	}
+
+	return 1
+}
+
 type EventStatus string
 
 const (
@@ -38,8 +63,71 @@ const (
 	EventStatusFailed          EventStatus = "failed"
 	EventStatusManualFailed    EventStatus = "manual_failed"
 	EventStatusDeadlineReached EventStatus = "deadline_reached"
+	EventStatusCancelled       EventStatus = "cancelled"
+)
+
+// ErrEventNotScheduled is returned when a scheduled event has already started, or was never scheduled
+var ErrEventNotScheduled = errors.New("event is not scheduled")
+
+// EventPriority decides the order in which events are dispatched, the highest first
+type EventPriority int32
+
+const (
+	// EventPriorityLow is for bulk operations, like resyncing every team, which
+	// shouldn't hold up the actions of users
+	EventPriorityLow    EventPriority = -10
+	EventPriorityNormal EventPriority = 0
 )
 
+// EventOption changes how an event is registered
+type EventOption func(params *gensql.EventCreateParams)
+
+// WithEventPriority sets the priority of the event
+func WithEventPriority(priority EventPriority) EventOption {
+	return func(params *gensql.EventCreateParams) {
+		params.Priority = int32(priority)
+	}
+}
+
+// WithEventRunAfter schedules the event, so that it isn't dispatched before runAfter
+func WithEventRunAfter(runAfter time.Time) EventOption {
+	return func(params *gensql.EventCreateParams) {
+		if runAfter.IsZero() {
+			return
+		}
+
+		params.RunAfter = sql.NullTime{Time: runAfter, Valid: true}
+	}
+}
+
+// eventIdempotencyWindow is how long a registration with the same idempotency key returns the
+// event it registered
+const eventIdempotencyWindow = 10 * time.Minute
+
+// WithEventIdempotencyKey makes the registration idempotent. When the latest event of the same type for
+// the same owner was registered with the key within the window, and hasn't been processed yet, it's
+// returned instead of creating a new one. Only the latest is considered, so that going back to earlier
+// values, e.g. saving A, B and then A again, still registers an event.
+func WithEventIdempotencyKey(key string) EventOption {
+	return func(params *gensql.EventCreateParams) {
+		if key == "" {
+			return
+		}
+
+		params.IdempotencyKey = sql.NullString{String: key, Valid: true}
+	}
+}
+
+// isEventUnprocessed is true for events which are waiting to be processed, or are being processed
+func isEventUnprocessed(event gensql.Event) bool {
+	switch EventStatus(event.Status) {
+	case EventStatusNew, EventStatusPending, EventStatusProcessing:
+		return true
+	}
+
+	return false
+}
+
 type LogType string
 
 const (
@@ -59,88 +147,175 @@ func (r *Repo) registerEvent(
 	owner string,
 	deadline time.Duration,
 	data any,
-) error {
+	opts ...EventOption,
+) (gensql.Event, error) {
 	jsonPayload, err := json.Marshal(data)
 	if err != nil {
-		return err
+		return gensql.Event{}, err
 	}
 
 	params := gensql.EventCreateParams{
-		Owner:    owner,
-		Type:     string(eventType),
-		Payload:  jsonPayload,
-		Deadline: deadline.String(),
+		Owner:         owner,
+		Type:          string(eventType),
+		Payload:       jsonPayload,
+		Deadline:      deadline.String(),
+		TraceContext:  tracing.Inject(ctx),
+		Priority:      int32(EventPriorityNormal),
+		SchemaVersion: EventSchemaVersion(eventType),
+	}
+	for _, opt := range opts {
+		opt(&params)
 	}
 
-	if err = r.querier.EventCreate(ctx, params); err != nil {
-		return err
+	if !params.IdempotencyKey.Valid {
+		return r.querier.EventCreate(ctx, params)
 	}
 
-	return nil
+	return r.registerIdempotentEvent(ctx, params)
 }
 
-func (r *Repo) RegisterCreateTeamEvent(ctx context.Context, team gensql.Team) error {
-	return r.registerEvent(ctx, EventTypeCreateTeam, team.ID, 5*time.Minute, team)
+// registerIdempotentEvent returns the latest event of the type for the owner if it was registered with
+// the same idempotency key within the window and hasn't been processed yet, or creates it. Once the event
+// has completed, failed or been cancelled, submitting again is a new attempt. The lock serializes registrations of the type
+// for the owner, so that concurrent duplicates don't both see that there is no event yet.
+func (r *Repo) registerIdempotentEvent(ctx context.Context, params gensql.EventCreateParams) (gensql.Event, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return gensql.Event{}, err
+	}
+
+	querier := r.querier.WithTx(tx)
+	rollback := func(err error) (gensql.Event, error) {
+		if err := tx.Rollback(); err != nil {
+			r.log.WithError(err).Error("rolling back idempotent event create transaction")
+		}
+		return gensql.Event{}, err
+	}
+
+	if err := querier.EventIdempotencyLock(ctx, params.Owner+"/"+params.Type); err != nil {
+		return rollback(err)
+	}
+
+	latest, err := querier.EventLatestRegisteredGet(ctx, gensql.EventLatestRegisteredGetParams{
+		Owner:         params.Owner,
+		Type:          params.Type,
+		WindowSeconds: int32(eventIdempotencyWindow.Seconds()),
+	})
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return rollback(err)
+	}
+	if err == nil && latest.IdempotencyKey == params.IdempotencyKey && isEventUnprocessed(latest) {
+		r.log.Infof("%v event for %v already registered as %v with the same idempotency key", params.Type, params.Owner, latest.ID)
+		return latest, tx.Commit()
+	}
+
+	event, err := querier.EventCreate(ctx, params)
+	if err != nil {
+		return rollback(err)
+	}
+
+	return event, tx.Commit()
+}
+
+func (r *Repo) RegisterCreateTeamEvent(ctx context.Context, team Team, opts ...EventOption) error {
+	_, err := r.registerEvent(ctx, EventTypeCreateTeam, team.ID, 5*time.Minute, team, opts...)
+	return err
+}
+
+func (r *Repo) RegisterUpdateTeamEvent(ctx context.Context, team Team, opts ...EventOption) error {
+	_, err := r.registerEvent(ctx, EventTypeUpdateTeam, team.ID, 5*time.Minute, team, opts...)
+	return err
 }
 
-func (r *Repo) RegisterUpdateTeamEvent(ctx context.Context, team gensql.Team) error {
-	return r.registerEvent(ctx, EventTypeUpdateTeam, team.ID, 5*time.Minute, team)
+// TeamSlugChange is the payload of rename:team events
+type TeamSlugChange struct {
+	OldSlug string
+	NewSlug string
 }
 
-func (r *Repo) RegisterDeleteTeamEvent(ctx context.Context, teamID string) error {
-	return r.registerEvent(ctx, EventTypeDeleteTeam, teamID, 5*time.Minute, nil)
+func (r *Repo) RegisterRenameTeamEvent(ctx context.Context, teamID string, change TeamSlugChange, opts ...EventOption) error {
+	_, err := r.registerEvent(ctx, EventTypeRenameTeam, teamID, 5*time.Minute, change, opts...)
+	return err
 }
 
-func (r *Repo) RegisterCreateAirflowEvent(ctx context.Context, teamID string, values any) error {
-	return r.registerEvent(ctx, EventTypeCreateAirflow, teamID, 30*time.Minute, values)
+func (r *Repo) RegisterDeleteTeamEvent(ctx context.Context, teamID string, opts ...EventOption) error {
+	_, err := r.registerEvent(ctx, EventTypeDeleteTeam, teamID, 5*time.Minute, nil, opts...)
+	return err
 }
 
-func (r *Repo) RegisterUpdateAirflowEvent(ctx context.Context, teamID string, values any) error {
-	return r.registerEvent(ctx, EventTypeUpdateAirflow, teamID, 15*time.Minute, values)
+func (r *Repo) RegisterCreateAirflowEvent(ctx context.Context, teamID string, values any, opts ...EventOption) error {
+	_, err := r.registerEvent(ctx, EventTypeCreateAirflow, teamID, 30*time.Minute, values, opts...)
+	return err
 }
 
-func (r *Repo) RegisterDeleteAirflowEvent(ctx context.Context, teamID string) error {
-	return r.registerEvent(ctx, EventTypeDeleteAirflow, teamID, 5*time.Minute, nil)
+func (r *Repo) RegisterUpdateAirflowEvent(ctx context.Context, teamID string, values any, opts ...EventOption) error {
+	_, err := r.registerEvent(ctx, EventTypeUpdateAirflow, teamID, 15*time.Minute, values, opts...)
+	return err
 }
 
-func (r *Repo) RegisterCreateUserGSMEvent(ctx context.Context, owner string, values any) error {
-	return r.registerEvent(ctx, EventTypeCreateUserGSM, owner, 5*time.Minute, values)
+func (r *Repo) RegisterDeleteAirflowEvent(ctx context.Context, teamID string, opts ...EventOption) error {
+	_, err := r.registerEvent(ctx, EventTypeDeleteAirflow, teamID, 5*time.Minute, nil, opts...)
+	return err
 }
 
-func (r *Repo) RegisterDeleteUserGSMEvent(ctx context.Context, owner string) error {
-	return r.registerEvent(ctx, EventTypeDeleteUserGSM, owner, 5*time.Minute, nil)
+func (r *Repo) RegisterHibernateAirflowEvent(ctx context.Context, teamID string, opts ...EventOption) error {
+	_, err := r.registerEvent(ctx, EventTypeHibernateAirflow, teamID, 10*time.Minute, nil, opts...)
+	return err
+}
+
+func (r *Repo) RegisterWakeAirflowEvent(ctx context.Context, teamID string, opts ...EventOption) error {
+	_, err := r.registerEvent(ctx, EventTypeWakeAirflow, teamID, 10*time.Minute, nil, opts...)
+	return err
+}
+
+func (r *Repo) RegisterCreateUserGSMEvent(ctx context.Context, owner string, values any, opts ...EventOption) error {
+	_, err := r.registerEvent(ctx, EventTypeCreateUserGSM, owner, 5*time.Minute, values, opts...)
+	return err
+}
+
+func (r *Repo) RegisterDeleteUserGSMEvent(ctx context.Context, owner string, opts ...EventOption) error {
+	_, err := r.registerEvent(ctx, EventTypeDeleteUserGSM, owner, 5*time.Minute, nil, opts...)
+	return err
 }
 
 func (r *Repo) RegisterHelmRolloutAirflowEvent(
 	ctx context.Context,
 	teamID string,
 	values any,
+	opts ...EventOption,
 ) error {
-	return r.registerEvent(ctx, EventTypeHelmRolloutAirflow, teamID, 5*time.Minute, values)
+	_, err := r.registerEvent(ctx, EventTypeHelmRolloutAirflow, teamID, 5*time.Minute, values, opts...)
+	return err
 }
 
 func (r *Repo) RegisterHelmRollbackAirflowEvent(
 	ctx context.Context,
 	teamID string,
 	values any,
+	opts ...EventOption,
 ) error {
-	return r.registerEvent(ctx, EventTypeHelmRollbackAirflow, teamID, 5*time.Minute, values)
+	_, err := r.registerEvent(ctx, EventTypeHelmRollbackAirflow, teamID, 5*time.Minute, values, opts...)
+	return err
 }
 
 func (r *Repo) RegisterHelmUninstallAirflowEvent(
 	ctx context.Context,
 	teamID string,
 	values any,
+	opts ...EventOption,
 ) error {
-	return r.registerEvent(ctx, EventTypeHelmUninstallAirflow, teamID, 10*time.Minute, values)
+	_, err := r.registerEvent(ctx, EventTypeHelmUninstallAirflow, teamID, 10*time.Minute, values, opts...)
+	return err
 }
 
-func (r *Repo) RegisterDeleteSchedulerPodsEvent(
+func (r *Repo) RegisterRestartAirflowComponentEvent(
 	ctx context.Context,
 	teamID string,
 	values any,
+	opts ...EventOption,
 ) error {
-	return r.registerEvent(ctx, EventTypeDeleteSchedulerPods, teamID, 5*time.Minute, values)
+	_, err := r.registerEvent(ctx, EventTypeRestartAirflowComponent, teamID, 5*time.Minute, values, opts...)
+	return err
 }
 
 func (r *Repo) EventSetStatus(ctx context.Context, id uuid.UUID, status EventStatus) error {
@@ -154,6 +329,11 @@ func (r *Repo) EventIncrementRetryCount(ctx context.Context, id uuid.UUID) error
 	return r.querier.EventIncrementRetryCount(ctx, id)
 }
 
+// EventCountsGet counts the events by status and type
+func (r *Repo) EventCountsGet(ctx context.Context) ([]gensql.EventCountsGetRow, error) {
+	return r.querier.EventCountsGet(ctx)
+}
+
 func (r *Repo) EventsReset(ctx context.Context) error {
 	return r.querier.EventsReset(ctx)
 }
@@ -169,6 +349,8 @@ func (r *Repo) DispatchableEventsGet(ctx context.Context) ([]gensql.Event, error
 		return nil, err
 	}
 
+	// The oldest event of each owner and class goes first, whatever its priority, so that the events
+	// of a team run in the order they were registered. Priority only decides between owners.
 	var dispatchableEvents []gensql.Event
 	for _, upcomingEvent := range upcomingEvents {
 		if isEventDispatchable(processingEvents, dispatchableEvents, upcomingEvent) {
@@ -176,6 +358,10 @@ func (r *Repo) DispatchableEventsGet(ctx context.Context) ([]gensql.Event, error
 		}
 	}
 
+	slices.SortStableFunc(dispatchableEvents, func(a, b gensql.Event) int {
+		return cmp.Compare(b.Priority, a.Priority)
+	})
+
 	return dispatchableEvents, nil
 }
 
@@ -205,6 +391,46 @@ func containsEvent(events []gensql.Event, new gensql.Event) bool {
 	return false
 }
 
+// EventsScheduledForOwnerGet returns the events which are waiting for their time to run
+func (r *Repo) EventsScheduledForOwnerGet(ctx context.Context, owner string) ([]gensql.Event, error) {
+	return r.querier.EventsScheduledForOwnerGet(ctx, owner)
+}
+
+// EventReschedule moves a scheduled event which hasn't started yet
+func (r *Repo) EventReschedule(ctx context.Context, id uuid.UUID, owner string, runAfter time.Time) error {
+	rows, err := r.querier.EventReschedule(ctx, gensql.EventRescheduleParams{
+		RunAfter: sql.NullTime{Time: runAfter, Valid: true},
+		ID:       id,
+		Owner:    owner,
+	})
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrEventNotScheduled
+	}
+
+	return nil
+}
+
+// EventCancel cancels a scheduled event which hasn't started yet
+func (r *Repo) EventCancel(ctx context.Context, id uuid.UUID, owner string) error {
+	rows, err := r.querier.EventCancel(ctx, gensql.EventCancelParams{
+		ID:    id,
+		Owner: owner,
+	})
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrEventNotScheduled
+	}
+
+	return nil
+}
+
 func (r *Repo) EventsGetType(ctx context.Context, eventType EventType) ([]gensql.Event, error) {
 	return r.querier.EventsGetType(ctx, string(eventType))
 }
@@ -222,6 +448,15 @@ func (r *Repo) EventLogCreate(
 	})
 }
 
+// EventUnprocessedExists is true when the owner has an event of the type which is waiting to be
+// processed, or is being processed
+func (r *Repo) EventUnprocessedExists(ctx context.Context, owner string, eventType EventType) (bool, error) {
+	return r.querier.EventUnprocessedExists(ctx, gensql.EventUnprocessedExistsParams{
+		Owner: owner,
+		Type:  string(eventType),
+	})
+}
+
 func (r *Repo) EventGet(ctx context.Context, id uuid.UUID) (gensql.Event, error) {
 	return r.querier.EventGet(ctx, id)
 }
@@ -250,19 +485,81 @@ func (r *Repo) EventLogsForOwnerGet(
 		Owner: owner,
 		Lim:   sql.NullInt32{Int32: limit, Valid: limit > 0},
 	})
+	if err != nil {
+		return nil, err
+	}
 
-	eventsWithLogs := make([]EventWithLogs, len(events))
+	return r.withEventLogs(ctx, events)
+}
+
+// withEventLogs fetches the logs of all the events in one query
+func (r *Repo) withEventLogs(ctx context.Context, events []gensql.Event) ([]EventWithLogs, error) {
+	ids := make([]uuid.UUID, len(events))
 	for i, event := range events {
-		eventslogs, err := r.querier.EventLogsForEventGet(ctx, event.ID)
-		if err != nil {
-			return nil, err
-		}
+		ids[i] = event.ID
+	}
+
+	logs, err := r.querier.EventLogsForEventsGet(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	logsByEvent := map[uuid.UUID][]gensql.EventLog{}
+	for _, log := range logs {
+		logsByEvent[log.EventID] = append(logsByEvent[log.EventID], log)
+	}
 
+	eventsWithLogs := make([]EventWithLogs, len(events))
+	for i, event := range events {
 		eventsWithLogs[i] = EventWithLogs{
 			Event: event,
-			Logs:  eventslogs,
+			Logs:  logsByEvent[event.ID],
 		}
 	}
 
-	return eventsWithLogs, err
+	return eventsWithLogs, nil
+}
+
+// EventsClockGet returns the time of the database, in the same time zone as the timestamps of the
+// events and event logs, so that it can be compared with them
+func (r *Repo) EventsClockGet(ctx context.Context) (time.Time, error) {
+	return r.querier.EventsClockGet(ctx)
+}
+
+// EventsForOwnersUpdatedSinceGet returns the events of the owners which have been registered or
+// changed status after since, the oldest change first
+func (r *Repo) EventsForOwnersUpdatedSinceGet(ctx context.Context, owners []string, since time.Time) ([]gensql.Event, error) {
+	return r.querier.EventsForOwnersUpdatedSinceGet(ctx, gensql.EventsForOwnersUpdatedSinceGetParams{
+		Owners: owners,
+		Since:  since,
+	})
+}
+
+// EventLogsForOwnersSinceGet returns the logs of the events of the owners which were written after
+// since, the oldest first
+func (r *Repo) EventLogsForOwnersSinceGet(ctx context.Context, owners []string, since time.Time) ([]gensql.EventLog, error) {
+	return r.querier.EventLogsForOwnersSinceGet(ctx, gensql.EventLogsForOwnersSinceGetParams{
+		Owners: owners,
+		Since:  since,
+	})
+}
+
+// EventsExpiredGet returns the events which have been completed or cancelled for longer than
+// completedDays, and the events which have failed for longer than failedDays, the oldest first
+func (r *Repo) EventsExpiredGet(ctx context.Context, completedDays, failedDays, limit int32) ([]EventWithLogs, error) {
+	events, err := r.querier.EventsExpiredGet(ctx, gensql.EventsExpiredGetParams{
+		CompletedDays: completedDays,
+		FailedDays:    failedDays,
+		Lim:           limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.withEventLogs(ctx, events)
+}
+
+// EventsDelete deletes the events, along with their logs and plans
+func (r *Repo) EventsDelete(ctx context.Context, ids []uuid.UUID) error {
+	return r.querier.EventsDelete(ctx, ids)
 }
@@ -3,8 +3,11 @@ package database
 import (
 	"context"
 	"testing"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/navikt/knorten/pkg/database/gensql"
+	"github.com/navikt/knorten/pkg/plan"
 )
 
 func TestRepo_DispatchableEventsGet(t *testing.T) {
@@ -104,6 +107,76 @@ func TestRepo_DispatchableEventsGet(t *testing.T) {
 	}
 }
 
+func TestRepo_DispatchableEventsGetPriority(t *testing.T) {
+	ctx := context.Background()
+
+	if err := cleanupEvents(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := cleanupEvents(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	for _, teamID := range []string{"team-a-1234", "team-b-1234"} {
+		if err := repo.RegisterUpdateAirflowEvent(ctx, teamID, nil, WithEventPriority(EventPriorityLow)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := repo.RegisterCreateTeamEvent(ctx, Team{Team: gensql.Team{ID: "team-c-1234"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	events, err := repo.DispatchableEventsGet(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != 3 {
+		t.Fatalf("dispatchable events: expected 3 events, got %v", len(events))
+	}
+
+	if events[0].Type != string(EventTypeCreateTeam) {
+		t.Errorf("dispatchable events: expected %v to be dispatched first, got %v", EventTypeCreateTeam, events[0].Type)
+	}
+
+	if events[1].Priority != int32(EventPriorityLow) {
+		t.Errorf("dispatchable events: expected priority %v, got %v", EventPriorityLow, events[1].Priority)
+	}
+}
+
+func TestRepo_DispatchableEventsGetPriorityKeepsOrderForOwner(t *testing.T) {
+	ctx := context.Background()
+
+	if err := cleanupEvents(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := cleanupEvents(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	if err := repo.RegisterUpdateAirflowEvent(ctx, "team-a-1234", nil, WithEventPriority(EventPriorityLow)); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.RegisterDeleteAirflowEvent(ctx, "team-a-1234"); err != nil {
+		t.Fatal(err)
+	}
+
+	events, err := repo.DispatchableEventsGet(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != 1 || events[0].Type != string(EventTypeUpdateAirflow) {
+		t.Errorf("dispatchable events: expected the older low priority %v to go before %v of the same team, got %+v",
+			EventTypeUpdateAirflow, EventTypeDeleteAirflow, events)
+	}
+}
+
 func prepareEventsTest(events []gensql.Event) error {
 	for _, event := range events {
 		_, err := repo.db.Exec("INSERT INTO events (owner,type,payload,deadline,status) VALUES ($1,$2,$3,$4,$5);",
@@ -124,3 +197,172 @@ func cleanupEvents() error {
 
 	return nil
 }
+
+func TestRepo_EventPlanSteps(t *testing.T) {
+	ctx := context.Background()
+
+	if err := cleanupEvents(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := cleanupEvents(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	if err := repo.RegisterDeleteAirflowEvent(ctx, "team-plan-1234"); err != nil {
+		t.Fatal(err)
+	}
+
+	events, err := repo.EventsByOwnerGet(ctx, "team-plan-1234", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	eventID := events[0].ID
+
+	steps := []plan.Step{
+		{Kind: plan.KindKubernetes, Action: "delete", Target: "Secret team-plan/airflow-db"},
+		{Kind: plan.KindHelm, Action: "uninstall", Target: "team-plan/airflow", Details: map[string]string{"chart": "airflow"}},
+	}
+	for _, step := range steps {
+		if err := repo.EventPlanStepCreate(ctx, eventID, step); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	planned, err := repo.EventPlanStepsGet(ctx, eventID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(planned) != 2 || planned[0].Action != "delete" || string(planned[1].Details) != `{"chart":"airflow"}` {
+		t.Errorf("EventPlanStepsGet(): expected the steps in the order they were planned, got %+v", planned)
+	}
+
+	if err := repo.EventPlanStepsDelete(ctx, eventID); err != nil {
+		t.Fatal(err)
+	}
+
+	planned, err = repo.EventPlanStepsGet(ctx, eventID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(planned) != 0 {
+		t.Errorf("EventPlanStepsDelete(): expected no steps, got %+v", planned)
+	}
+}
+
+func TestRepo_RegisterEventIdempotencyKey(t *testing.T) {
+	ctx := context.Background()
+
+	if err := cleanupEvents(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := cleanupEvents(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	owner := "team-idempotent-1234"
+	register := func(key string, values map[string]string) gensql.Event {
+		t.Helper()
+
+		event, err := repo.registerEvent(ctx, EventTypeUpdateAirflow, owner, time.Minute, values, WithEventIdempotencyKey(key))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return event
+	}
+
+	first := register("a", map[string]string{"image": "a"})
+	if duplicate := register("a", map[string]string{"image": "a"}); duplicate.ID != first.ID {
+		t.Errorf("registerEvent(): expected the duplicate to return %v, got %v", first.ID, duplicate.ID)
+	}
+
+	second := register("b", map[string]string{"image": "b"})
+	if second.ID == first.ID {
+		t.Error("registerEvent(): expected a new event for another key")
+	}
+
+	if third := register("a", map[string]string{"image": "a"}); third.ID == first.ID || third.ID == second.ID {
+		t.Error("registerEvent(): expected a new event when the key isn't the one of the latest event")
+	}
+
+	if _, err := repo.registerEvent(ctx, EventTypeUpdateAirflow, owner, time.Minute, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	events, err := repo.EventsByOwnerGet(ctx, owner, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 4 {
+		t.Errorf("expected 4 events, got %v", len(events))
+	}
+
+	t.Run("resubmitting after the event failed registers a new event", func(t *testing.T) {
+		failed := register("c", map[string]string{"image": "c"})
+		if err := repo.EventSetStatus(ctx, failed.ID, EventStatusFailed); err != nil {
+			t.Fatal(err)
+		}
+
+		if retry := register("c", map[string]string{"image": "c"}); retry.ID == failed.ID {
+			t.Errorf("registerEvent(): expected a new event after %v failed", failed.ID)
+		}
+	})
+}
+
+func TestRepo_EventsExpired(t *testing.T) {
+	ctx := context.Background()
+
+	if err := cleanupEvents(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := cleanupEvents(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	events := []struct {
+		owner   string
+		status  EventStatus
+		ageDays int
+	}{
+		{owner: "completed-expired", status: EventStatusCompleted, ageDays: 40},
+		{owner: "completed-kept", status: EventStatusCompleted, ageDays: 10},
+		{owner: "failed-expired", status: EventStatusFailed, ageDays: 100},
+		{owner: "failed-kept", status: EventStatusFailed, ageDays: 40},
+		{owner: "pending-kept", status: EventStatusPending, ageDays: 100},
+	}
+	for _, event := range events {
+		_, err := repo.db.Exec("INSERT INTO events (owner,type,payload,deadline,status,updated_at) VALUES ($1,$2,$3,$4,$5,NOW() - make_interval(days => $6));",
+			event.owner, EventTypeUpdateAirflow, "{}", "5m", event.status, event.ageDays)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expired, err := repo.EventsExpiredGet(ctx, 30, 90, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(expired) != 2 || expired[0].Owner != "failed-expired" || expired[1].Owner != "completed-expired" {
+		t.Fatalf("EventsExpiredGet(): expected the expired events, the oldest first, got %+v", expired)
+	}
+
+	if err := repo.EventsDelete(ctx, []uuid.UUID{expired[0].ID, expired[1].ID}); err != nil {
+		t.Fatal(err)
+	}
+
+	expired, err = repo.EventsExpiredGet(ctx, 30, 90, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(expired) != 0 {
+		t.Errorf("EventsDelete(): expected no expired events left, got %+v", expired)
+	}
+}
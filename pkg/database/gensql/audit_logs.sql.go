@@ -0,0 +1,152 @@
+// Code generated by sqlc. DO NOT EDIT.
+// source: audit_logs.sql
+
+package gensql
+
+import (
+	"context"
+	"database/sql"
+)
+
+const auditLogInsert = `-- name: AuditLogInsert :exec
+INSERT INTO audit_logs ("actor", "action", "target", "team_id", "before", "after", "method", "path", "status_code",
+                        "client_ip", "user_agent")
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+`
+
+type AuditLogInsertParams struct {
+	Actor      string
+	Action     string
+	Target     string
+	TeamID     sql.NullString
+	Before     string
+	After      string
+	Method     string
+	Path       string
+	StatusCode int32
+	ClientIp   string
+	UserAgent  string
+}
+
+func (q *Queries) AuditLogInsert(ctx context.Context, arg AuditLogInsertParams) error {
+	_, err := q.db.ExecContext(ctx, auditLogInsert,
+		arg.Actor,
+		arg.Action,
+		arg.Target,
+		arg.TeamID,
+		arg.Before,
+		arg.After,
+		arg.Method,
+		arg.Path,
+		arg.StatusCode,
+		arg.ClientIp,
+		arg.UserAgent,
+	)
+	return err
+}
+
+const auditLogsForTeamGet = `-- name: AuditLogsForTeamGet :many
+SELECT id, actor, action, target, team_id, before, after, method, path, status_code, client_ip, user_agent, created
+FROM audit_logs
+WHERE team_id = $1
+ORDER BY created DESC, id DESC
+LIMIT $2
+`
+
+type AuditLogsForTeamGetParams struct {
+	TeamID sql.NullString
+	Lim    int32
+}
+
+func (q *Queries) AuditLogsForTeamGet(ctx context.Context, arg AuditLogsForTeamGetParams) ([]AuditLog, error) {
+	rows, err := q.db.QueryContext(ctx, auditLogsForTeamGet, arg.TeamID, arg.Lim)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AuditLog{}
+	for rows.Next() {
+		var i AuditLog
+		if err := rows.Scan(
+			&i.ID,
+			&i.Actor,
+			&i.Action,
+			&i.Target,
+			&i.TeamID,
+			&i.Before,
+			&i.After,
+			&i.Method,
+			&i.Path,
+			&i.StatusCode,
+			&i.ClientIp,
+			&i.UserAgent,
+			&i.Created,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const auditLogsSearch = `-- name: AuditLogsSearch :many
+SELECT id, actor, action, target, team_id, before, after, method, path, status_code, client_ip, user_agent, created
+FROM audit_logs
+WHERE ($1::TEXT = '' OR actor = $1)
+  AND ($2::TEXT = ''
+    OR action ILIKE '%' || $2 || '%'
+    OR target ILIKE '%' || $2 || '%'
+    OR before ILIKE '%' || $2 || '%'
+    OR after ILIKE '%' || $2 || '%')
+ORDER BY created DESC, id DESC
+LIMIT $3
+`
+
+type AuditLogsSearchParams struct {
+	Actor string
+	Query string
+	Lim   int32
+}
+
+func (q *Queries) AuditLogsSearch(ctx context.Context, arg AuditLogsSearchParams) ([]AuditLog, error) {
+	rows, err := q.db.QueryContext(ctx, auditLogsSearch, arg.Actor, arg.Query, arg.Lim)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AuditLog{}
+	for rows.Next() {
+		var i AuditLog
+		if err := rows.Scan(
+			&i.ID,
+			&i.Actor,
+			&i.Action,
+			&i.Target,
+			&i.TeamID,
+			&i.Before,
+			&i.After,
+			&i.Method,
+			&i.Path,
+			&i.StatusCode,
+			&i.ClientIp,
+			&i.UserAgent,
+			&i.Created,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,119 @@
+// Code generated by sqlc. DO NOT EDIT.
+// source: cloudevents.sql
+
+package gensql
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const cloudEventDeliveredSet = `-- name: CloudEventDeliveredSet :exec
+UPDATE cloudevents_outbox
+SET delivered           = NOW(),
+    delivered_endpoints = $1,
+    attempts            = attempts + 1
+WHERE id = $2
+`
+
+type CloudEventDeliveredSetParams struct {
+	DeliveredEndpoints []string
+	ID                 uuid.UUID
+}
+
+func (q *Queries) CloudEventDeliveredSet(ctx context.Context, arg CloudEventDeliveredSetParams) error {
+	_, err := q.db.ExecContext(ctx, cloudEventDeliveredSet, pq.Array(arg.DeliveredEndpoints), arg.ID)
+	return err
+}
+
+const cloudEventDeliveryFailedSet = `-- name: CloudEventDeliveryFailedSet :exec
+UPDATE cloudevents_outbox
+SET delivered_endpoints = $1,
+    attempts            = attempts + 1,
+    last_error          = $2,
+    next_attempt        = $3,
+    failed              = $4
+WHERE id = $5
+`
+
+type CloudEventDeliveryFailedSetParams struct {
+	DeliveredEndpoints []string
+	LastError          string
+	NextAttempt        time.Time
+	Failed             bool
+	ID                 uuid.UUID
+}
+
+func (q *Queries) CloudEventDeliveryFailedSet(ctx context.Context, arg CloudEventDeliveryFailedSetParams) error {
+	_, err := q.db.ExecContext(ctx, cloudEventDeliveryFailedSet,
+		pq.Array(arg.DeliveredEndpoints),
+		arg.LastError,
+		arg.NextAttempt,
+		arg.Failed,
+		arg.ID,
+	)
+	return err
+}
+
+const cloudEventsDueGet = `-- name: CloudEventsDueGet :many
+SELECT id, type, subject, data, time, delivered_endpoints, attempts, next_attempt, last_error, delivered, failed
+FROM cloudevents_outbox
+WHERE delivered IS NULL
+  AND NOT failed
+  AND next_attempt <= NOW()
+ORDER BY "time"
+LIMIT $1
+`
+
+func (q *Queries) CloudEventsDueGet(ctx context.Context, lim int32) ([]CloudeventsOutbox, error) {
+	rows, err := q.db.QueryContext(ctx, cloudEventsDueGet, lim)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []CloudeventsOutbox{}
+	for rows.Next() {
+		var i CloudeventsOutbox
+		if err := rows.Scan(
+			&i.ID,
+			&i.Type,
+			&i.Subject,
+			&i.Data,
+			&i.Time,
+			pq.Array(&i.DeliveredEndpoints),
+			&i.Attempts,
+			&i.NextAttempt,
+			&i.LastError,
+			&i.Delivered,
+			&i.Failed,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const cloudEventsExpiredDelete = `-- name: CloudEventsExpiredDelete :execrows
+DELETE
+FROM cloudevents_outbox
+WHERE (delivered IS NOT NULL OR failed)
+  AND "time" < NOW() - make_interval(days => $1::INT)
+`
+
+func (q *Queries) CloudEventsExpiredDelete(ctx context.Context, days int32) (int64, error) {
+	result, err := q.db.ExecContext(ctx, cloudEventsExpiredDelete, days)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,84 @@
+// Code generated by sqlc. DO NOT EDIT.
+// source: event_plan_steps.sql
+
+package gensql
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/google/uuid"
+)
+
+const eventPlanStepCreate = `-- name: EventPlanStepCreate :exec
+INSERT INTO event_plan_steps ("event_id", "kind", "action", "target", "details")
+VALUES ($1, $2, $3, $4, $5)
+`
+
+type EventPlanStepCreateParams struct {
+	EventID uuid.UUID
+	Kind    string
+	Action  string
+	Target  string
+	Details json.RawMessage
+}
+
+func (q *Queries) EventPlanStepCreate(ctx context.Context, arg EventPlanStepCreateParams) error {
+	_, err := q.db.ExecContext(ctx, eventPlanStepCreate,
+		arg.EventID,
+		arg.Kind,
+		arg.Action,
+		arg.Target,
+		arg.Details,
+	)
+	return err
+}
+
+const eventPlanStepsDelete = `-- name: EventPlanStepsDelete :exec
+DELETE
+FROM event_plan_steps
+WHERE event_id = $1
+`
+
+func (q *Queries) EventPlanStepsDelete(ctx context.Context, eventID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, eventPlanStepsDelete, eventID)
+	return err
+}
+
+const eventPlanStepsGet = `-- name: EventPlanStepsGet :many
+SELECT id, event_id, kind, action, target, details, created_at
+FROM event_plan_steps
+WHERE event_id = $1
+ORDER BY id
+`
+
+func (q *Queries) EventPlanStepsGet(ctx context.Context, eventID uuid.UUID) ([]EventPlanStep, error) {
+	rows, err := q.db.QueryContext(ctx, eventPlanStepsGet, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []EventPlanStep{}
+	for rows.Next() {
+		var i EventPlanStep
+		if err := rows.Scan(
+			&i.ID,
+			&i.EventID,
+			&i.Kind,
+			&i.Action,
+			&i.Target,
+			&i.Details,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -7,38 +7,130 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
-const eventCreate = `-- name: EventCreate :exec
-INSERT INTO Events (owner, type, payload, status, deadline)
+const eventCancel = `-- name: EventCancel :execrows
+UPDATE events
+SET status = 'cancelled'
+WHERE id = $1
+  AND owner = $2
+  AND status = 'new'
+  AND run_after > NOW()
+`
+
+type EventCancelParams struct {
+	ID    uuid.UUID
+	Owner string
+}
+
+func (q *Queries) EventCancel(ctx context.Context, arg EventCancelParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, eventCancel, arg.ID, arg.Owner)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const eventCountsGet = `-- name: EventCountsGet :many
+SELECT status, type, COUNT(*)::INT AS count
+FROM events
+GROUP BY status, type
+`
+
+type EventCountsGetRow struct {
+	Status string
+	Type   string
+	Count  int32
+}
+
+func (q *Queries) EventCountsGet(ctx context.Context) ([]EventCountsGetRow, error) {
+	rows, err := q.db.QueryContext(ctx, eventCountsGet)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []EventCountsGetRow{}
+	for rows.Next() {
+		var i EventCountsGetRow
+		if err := rows.Scan(&i.Status, &i.Type, &i.Count); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const eventCreate = `-- name: EventCreate :one
+INSERT INTO Events (owner, type, payload, status, deadline, trace_context, priority, run_after, schema_version, idempotency_key)
 VALUES ($1,
         $2,
         $3,
         'new',
-        $4)
+        $4,
+        $5,
+        $6,
+        $7,
+        $8,
+        $9)
+RETURNING id, type, payload, status, deadline, created_at, updated_at, owner, retry_count, trace_context, priority, run_after, schema_version, idempotency_key
 `
 
 type EventCreateParams struct {
-	Owner    string
-	Type     string
-	Payload  json.RawMessage
-	Deadline string
+	Owner          string
+	Type           string
+	Payload        json.RawMessage
+	Deadline       string
+	TraceContext   json.RawMessage
+	Priority       int32
+	RunAfter       sql.NullTime
+	SchemaVersion  int32
+	IdempotencyKey sql.NullString
 }
 
-func (q *Queries) EventCreate(ctx context.Context, arg EventCreateParams) error {
-	_, err := q.db.ExecContext(ctx, eventCreate,
+func (q *Queries) EventCreate(ctx context.Context, arg EventCreateParams) (Event, error) {
+	row := q.db.QueryRowContext(ctx, eventCreate,
 		arg.Owner,
 		arg.Type,
 		arg.Payload,
 		arg.Deadline,
+		arg.TraceContext,
+		arg.Priority,
+		arg.RunAfter,
+		arg.SchemaVersion,
+		arg.IdempotencyKey,
 	)
-	return err
+	var i Event
+	err := row.Scan(
+		&i.ID,
+		&i.Type,
+		&i.Payload,
+		&i.Status,
+		&i.Deadline,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Owner,
+		&i.RetryCount,
+		&i.TraceContext,
+		&i.Priority,
+		&i.RunAfter,
+		&i.SchemaVersion,
+		&i.IdempotencyKey,
+	)
+	return i, err
 }
 
 const eventGet = `-- name: EventGet :one
-SELECT id, type, payload, status, deadline, created_at, updated_at, owner, retry_count
+SELECT id, type, payload, status, deadline, created_at, updated_at, owner, retry_count, trace_context, priority, run_after, schema_version, idempotency_key
 FROM Events
 WHERE id = $1
 `
@@ -56,10 +148,24 @@ func (q *Queries) EventGet(ctx context.Context, id uuid.UUID) (Event, error) {
 		&i.UpdatedAt,
 		&i.Owner,
 		&i.RetryCount,
+		&i.TraceContext,
+		&i.Priority,
+		&i.RunAfter,
+		&i.SchemaVersion,
+		&i.IdempotencyKey,
 	)
 	return i, err
 }
 
+const eventIdempotencyLock = `-- name: EventIdempotencyLock :exec
+SELECT pg_advisory_xact_lock(hashtext($1::TEXT))
+`
+
+func (q *Queries) EventIdempotencyLock(ctx context.Context, lockKey string) error {
+	_, err := q.db.ExecContext(ctx, eventIdempotencyLock, lockKey)
+	return err
+}
+
 const eventIncrementRetryCount = `-- name: EventIncrementRetryCount :exec
 UPDATE events
 SET retry_count = retry_count + 1
@@ -71,6 +177,44 @@ func (q *Queries) EventIncrementRetryCount(ctx context.Context, id uuid.UUID) er
 	return err
 }
 
+const eventLatestRegisteredGet = `-- name: EventLatestRegisteredGet :one
+SELECT id, type, payload, status, deadline, created_at, updated_at, owner, retry_count, trace_context, priority, run_after, schema_version, idempotency_key
+FROM Events
+WHERE owner = $1
+  AND type = $2
+  AND created_at > NOW() - make_interval(secs => $3::INT)
+ORDER BY created_at DESC
+LIMIT 1
+`
+
+type EventLatestRegisteredGetParams struct {
+	Owner         string
+	Type          string
+	WindowSeconds int32
+}
+
+func (q *Queries) EventLatestRegisteredGet(ctx context.Context, arg EventLatestRegisteredGetParams) (Event, error) {
+	row := q.db.QueryRowContext(ctx, eventLatestRegisteredGet, arg.Owner, arg.Type, arg.WindowSeconds)
+	var i Event
+	err := row.Scan(
+		&i.ID,
+		&i.Type,
+		&i.Payload,
+		&i.Status,
+		&i.Deadline,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Owner,
+		&i.RetryCount,
+		&i.TraceContext,
+		&i.Priority,
+		&i.RunAfter,
+		&i.SchemaVersion,
+		&i.IdempotencyKey,
+	)
+	return i, err
+}
+
 const eventLogCreate = `-- name: EventLogCreate :exec
 INSERT INTO Event_Logs (event_id, log_type, message)
 VALUES ($1, $2, $3)
@@ -123,6 +267,108 @@ func (q *Queries) EventLogsForEventGet(ctx context.Context, id uuid.UUID) ([]Eve
 	return items, nil
 }
 
+const eventLogsForEventsGet = `-- name: EventLogsForEventsGet :many
+SELECT id, event_id, log_type, message, created_at
+FROM event_logs
+WHERE event_id = ANY ($1::UUID[])
+ORDER BY created_at DESC
+`
+
+func (q *Queries) EventLogsForEventsGet(ctx context.Context, ids []uuid.UUID) ([]EventLog, error) {
+	rows, err := q.db.QueryContext(ctx, eventLogsForEventsGet, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []EventLog{}
+	for rows.Next() {
+		var i EventLog
+		if err := rows.Scan(
+			&i.ID,
+			&i.EventID,
+			&i.LogType,
+			&i.Message,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const eventLogsForOwnersSinceGet = `-- name: EventLogsForOwnersSinceGet :many
+SELECT el.id, el.event_id, el.log_type, el.message, el.created_at
+FROM event_logs el
+         JOIN events e ON e.id = el.event_id
+WHERE e.owner = ANY ($1::TEXT[])
+  AND el.created_at > $2
+ORDER BY el.created_at
+`
+
+type EventLogsForOwnersSinceGetParams struct {
+	Owners []string
+	Since  time.Time
+}
+
+func (q *Queries) EventLogsForOwnersSinceGet(ctx context.Context, arg EventLogsForOwnersSinceGetParams) ([]EventLog, error) {
+	rows, err := q.db.QueryContext(ctx, eventLogsForOwnersSinceGet, pq.Array(arg.Owners), arg.Since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []EventLog{}
+	for rows.Next() {
+		var i EventLog
+		if err := rows.Scan(
+			&i.ID,
+			&i.EventID,
+			&i.LogType,
+			&i.Message,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const eventReschedule = `-- name: EventReschedule :execrows
+UPDATE events
+SET run_after = $1
+WHERE id = $2
+  AND owner = $3
+  AND status = 'new'
+  AND run_after > NOW()
+`
+
+type EventRescheduleParams struct {
+	RunAfter sql.NullTime
+	ID       uuid.UUID
+	Owner    string
+}
+
+func (q *Queries) EventReschedule(ctx context.Context, arg EventRescheduleParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, eventReschedule, arg.RunAfter, arg.ID, arg.Owner)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const eventSetStatus = `-- name: EventSetStatus :exec
 UPDATE Events
 SET status = $1
@@ -139,8 +385,28 @@ func (q *Queries) EventSetStatus(ctx context.Context, arg EventSetStatusParams)
 	return err
 }
 
+const eventUnprocessedExists = `-- name: EventUnprocessedExists :one
+SELECT EXISTS (SELECT 1
+               FROM Events
+               WHERE owner = $1
+                 AND type = $2
+                 AND status IN ('new', 'pending', 'processing'))
+`
+
+type EventUnprocessedExistsParams struct {
+	Owner string
+	Type  string
+}
+
+func (q *Queries) EventUnprocessedExists(ctx context.Context, arg EventUnprocessedExistsParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, eventUnprocessedExists, arg.Owner, arg.Type)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
 const eventsByOwnerGet = `-- name: EventsByOwnerGet :many
-SELECT id, type, payload, status, deadline, created_at, updated_at, owner, retry_count
+SELECT id, type, payload, status, deadline, created_at, updated_at, owner, retry_count, trace_context, priority, run_after, schema_version, idempotency_key
 FROM Events
 WHERE owner = $1
 ORDER BY updated_at DESC
@@ -171,6 +437,137 @@ func (q *Queries) EventsByOwnerGet(ctx context.Context, arg EventsByOwnerGetPara
 			&i.UpdatedAt,
 			&i.Owner,
 			&i.RetryCount,
+			&i.TraceContext,
+			&i.Priority,
+			&i.RunAfter,
+			&i.SchemaVersion,
+			&i.IdempotencyKey,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const eventsClockGet = `-- name: EventsClockGet :one
+SELECT LOCALTIMESTAMP::TIMESTAMP AS now
+`
+
+func (q *Queries) EventsClockGet(ctx context.Context) (time.Time, error) {
+	row := q.db.QueryRowContext(ctx, eventsClockGet)
+	var now time.Time
+	err := row.Scan(&now)
+	return now, err
+}
+
+const eventsDelete = `-- name: EventsDelete :exec
+DELETE
+FROM events
+WHERE id = ANY ($1::UUID[])
+`
+
+func (q *Queries) EventsDelete(ctx context.Context, ids []uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, eventsDelete, pq.Array(ids))
+	return err
+}
+
+const eventsExpiredGet = `-- name: EventsExpiredGet :many
+SELECT id, type, payload, status, deadline, created_at, updated_at, owner, retry_count, trace_context, priority, run_after, schema_version, idempotency_key
+FROM events
+WHERE (status IN ('completed', 'cancelled') AND updated_at < NOW() - make_interval(days => $1::INT))
+   OR (status IN ('failed', 'manual_failed') AND updated_at < NOW() - make_interval(days => $2::INT))
+ORDER BY updated_at
+LIMIT $3
+`
+
+type EventsExpiredGetParams struct {
+	CompletedDays int32
+	FailedDays    int32
+	Lim           int32
+}
+
+func (q *Queries) EventsExpiredGet(ctx context.Context, arg EventsExpiredGetParams) ([]Event, error) {
+	rows, err := q.db.QueryContext(ctx, eventsExpiredGet, arg.CompletedDays, arg.FailedDays, arg.Lim)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Event{}
+	for rows.Next() {
+		var i Event
+		if err := rows.Scan(
+			&i.ID,
+			&i.Type,
+			&i.Payload,
+			&i.Status,
+			&i.Deadline,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Owner,
+			&i.RetryCount,
+			&i.TraceContext,
+			&i.Priority,
+			&i.RunAfter,
+			&i.SchemaVersion,
+			&i.IdempotencyKey,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const eventsForOwnersUpdatedSinceGet = `-- name: EventsForOwnersUpdatedSinceGet :many
+SELECT id, type, payload, status, deadline, created_at, updated_at, owner, retry_count, trace_context, priority, run_after, schema_version, idempotency_key
+FROM events
+WHERE owner = ANY ($1::TEXT[])
+  AND updated_at > $2
+ORDER BY updated_at
+`
+
+type EventsForOwnersUpdatedSinceGetParams struct {
+	Owners []string
+	Since  time.Time
+}
+
+func (q *Queries) EventsForOwnersUpdatedSinceGet(ctx context.Context, arg EventsForOwnersUpdatedSinceGetParams) ([]Event, error) {
+	rows, err := q.db.QueryContext(ctx, eventsForOwnersUpdatedSinceGet, pq.Array(arg.Owners), arg.Since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Event{}
+	for rows.Next() {
+		var i Event
+		if err := rows.Scan(
+			&i.ID,
+			&i.Type,
+			&i.Payload,
+			&i.Status,
+			&i.Deadline,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Owner,
+			&i.RetryCount,
+			&i.TraceContext,
+			&i.Priority,
+			&i.RunAfter,
+			&i.SchemaVersion,
+			&i.IdempotencyKey,
 		); err != nil {
 			return nil, err
 		}
@@ -186,7 +583,7 @@ func (q *Queries) EventsByOwnerGet(ctx context.Context, arg EventsByOwnerGetPara
 }
 
 const eventsGetType = `-- name: EventsGetType :many
-SELECT id, type, payload, status, deadline, created_at, updated_at, owner, retry_count
+SELECT id, type, payload, status, deadline, created_at, updated_at, owner, retry_count, trace_context, priority, run_after, schema_version, idempotency_key
 FROM Events
 WHERE type = $1
 `
@@ -210,6 +607,11 @@ func (q *Queries) EventsGetType(ctx context.Context, eventType string) ([]Event,
 			&i.UpdatedAt,
 			&i.Owner,
 			&i.RetryCount,
+			&i.TraceContext,
+			&i.Priority,
+			&i.RunAfter,
+			&i.SchemaVersion,
+			&i.IdempotencyKey,
 		); err != nil {
 			return nil, err
 		}
@@ -225,7 +627,7 @@ func (q *Queries) EventsGetType(ctx context.Context, eventType string) ([]Event,
 }
 
 const eventsProcessingGet = `-- name: EventsProcessingGet :many
-SELECT id, type, payload, status, deadline, created_at, updated_at, owner, retry_count
+SELECT id, type, payload, status, deadline, created_at, updated_at, owner, retry_count, trace_context, priority, run_after, schema_version, idempotency_key
 FROM events
 WHERE status = 'processing'
 ORDER BY created_at DESC
@@ -250,6 +652,11 @@ func (q *Queries) EventsProcessingGet(ctx context.Context) ([]Event, error) {
 			&i.UpdatedAt,
 			&i.Owner,
 			&i.RetryCount,
+			&i.TraceContext,
+			&i.Priority,
+			&i.RunAfter,
+			&i.SchemaVersion,
+			&i.IdempotencyKey,
 		); err != nil {
 			return nil, err
 		}
@@ -275,12 +682,60 @@ func (q *Queries) EventsReset(ctx context.Context) error {
 	return err
 }
 
+const eventsScheduledForOwnerGet = `-- name: EventsScheduledForOwnerGet :many
+SELECT id, type, payload, status, deadline, created_at, updated_at, owner, retry_count, trace_context, priority, run_after, schema_version, idempotency_key
+FROM events
+WHERE owner = $1
+  AND status = 'new'
+  AND run_after > NOW()
+ORDER BY run_after ASC
+`
+
+func (q *Queries) EventsScheduledForOwnerGet(ctx context.Context, owner string) ([]Event, error) {
+	rows, err := q.db.QueryContext(ctx, eventsScheduledForOwnerGet, owner)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Event{}
+	for rows.Next() {
+		var i Event
+		if err := rows.Scan(
+			&i.ID,
+			&i.Type,
+			&i.Payload,
+			&i.Status,
+			&i.Deadline,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Owner,
+			&i.RetryCount,
+			&i.TraceContext,
+			&i.Priority,
+			&i.RunAfter,
+			&i.SchemaVersion,
+			&i.IdempotencyKey,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const eventsUpcomingGet = `-- name: EventsUpcomingGet :many
-SELECT id, type, payload, status, deadline, created_at, updated_at, owner, retry_count
+SELECT id, type, payload, status, deadline, created_at, updated_at, owner, retry_count, trace_context, priority, run_after, schema_version, idempotency_key
 FROM Events
-WHERE status = 'new'
-   OR status = 'pending'
-   OR status = 'deadline_reached'
+WHERE (status = 'new'
+    OR status = 'pending'
+    OR status = 'deadline_reached')
+  AND (run_after IS NULL OR run_after <= NOW())
 ORDER BY created_at ASC
 `
 
@@ -303,6 +758,11 @@ func (q *Queries) EventsUpcomingGet(ctx context.Context) ([]Event, error) {
 			&i.UpdatedAt,
 			&i.Owner,
 			&i.RetryCount,
+			&i.TraceContext,
+			&i.Priority,
+			&i.RunAfter,
+			&i.SchemaVersion,
+			&i.IdempotencyKey,
 		); err != nil {
 			return nil, err
 		}
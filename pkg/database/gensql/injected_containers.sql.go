@@ -0,0 +1,244 @@
+// Code generated by sqlc. DO NOT EDIT.
+// source: injected_containers.sql
+
+package gensql
+
+import (
+	"context"
+)
+
+const injectedContainerGet = `-- name: InjectedContainerGet :one
+SELECT id, created, name, version, chart_type, container, volumes, enabled, opt_out_allowed, created_by
+FROM injected_containers
+WHERE chart_type = $1
+  AND "name" = $2
+ORDER BY "version" DESC
+LIMIT 1
+`
+
+type InjectedContainerGetParams struct {
+	ChartType ChartType
+	Name      string
+}
+
+func (q *Queries) InjectedContainerGet(ctx context.Context, arg InjectedContainerGetParams) (InjectedContainer, error) {
+	row := q.db.QueryRowContext(ctx, injectedContainerGet, arg.ChartType, arg.Name)
+	var i InjectedContainer
+	err := row.Scan(
+		&i.ID,
+		&i.Created,
+		&i.Name,
+		&i.Version,
+		&i.ChartType,
+		&i.Container,
+		&i.Volumes,
+		&i.Enabled,
+		&i.OptOutAllowed,
+		&i.CreatedBy,
+	)
+	return i, err
+}
+
+const injectedContainerInsert = `-- name: InjectedContainerInsert :exec
+INSERT INTO injected_containers ("name",
+                                 "version",
+                                 "chart_type",
+                                 "container",
+                                 "volumes",
+                                 "enabled",
+                                 "opt_out_allowed",
+                                 "created_by")
+VALUES ($1,
+        (SELECT COALESCE(MAX("version"), 0) + 1
+         FROM injected_containers
+         WHERE "name" = $1
+           AND chart_type = $2),
+        $2,
+        $3,
+        $4,
+        $5,
+        $6,
+        $7)
+`
+
+type InjectedContainerInsertParams struct {
+	Name          string
+	ChartType     ChartType
+	Container     string
+	Volumes       string
+	Enabled       bool
+	OptOutAllowed bool
+	CreatedBy     string
+}
+
+func (q *Queries) InjectedContainerInsert(ctx context.Context, arg InjectedContainerInsertParams) error {
+	_, err := q.db.ExecContext(ctx, injectedContainerInsert,
+		arg.Name,
+		arg.ChartType,
+		arg.Container,
+		arg.Volumes,
+		arg.Enabled,
+		arg.OptOutAllowed,
+		arg.CreatedBy,
+	)
+	return err
+}
+
+const injectedContainerOptOutDelete = `-- name: InjectedContainerOptOutDelete :exec
+DELETE
+FROM injected_container_opt_outs
+WHERE team_id = $1
+  AND chart_type = $2
+  AND "name" = $3
+`
+
+type InjectedContainerOptOutDeleteParams struct {
+	TeamID    string
+	ChartType ChartType
+	Name      string
+}
+
+func (q *Queries) InjectedContainerOptOutDelete(ctx context.Context, arg InjectedContainerOptOutDeleteParams) error {
+	_, err := q.db.ExecContext(ctx, injectedContainerOptOutDelete, arg.TeamID, arg.ChartType, arg.Name)
+	return err
+}
+
+const injectedContainerOptOutInsert = `-- name: InjectedContainerOptOutInsert :exec
+INSERT INTO injected_container_opt_outs ("team_id", "chart_type", "name")
+VALUES ($1, $2, $3)
+ON CONFLICT DO NOTHING
+`
+
+type InjectedContainerOptOutInsertParams struct {
+	TeamID    string
+	ChartType ChartType
+	Name      string
+}
+
+func (q *Queries) InjectedContainerOptOutInsert(ctx context.Context, arg InjectedContainerOptOutInsertParams) error {
+	_, err := q.db.ExecContext(ctx, injectedContainerOptOutInsert, arg.TeamID, arg.ChartType, arg.Name)
+	return err
+}
+
+const injectedContainerOptOutsGet = `-- name: InjectedContainerOptOutsGet :many
+SELECT "name"
+FROM injected_container_opt_outs
+WHERE team_id = $1
+  AND chart_type = $2
+`
+
+type InjectedContainerOptOutsGetParams struct {
+	TeamID    string
+	ChartType ChartType
+}
+
+func (q *Queries) InjectedContainerOptOutsGet(ctx context.Context, arg InjectedContainerOptOutsGetParams) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, injectedContainerOptOutsGet, arg.TeamID, arg.ChartType)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		items = append(items, name)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const injectedContainerVersionsGet = `-- name: InjectedContainerVersionsGet :many
+SELECT id, created, name, version, chart_type, container, volumes, enabled, opt_out_allowed, created_by
+FROM injected_containers
+WHERE chart_type = $1
+  AND "name" = $2
+ORDER BY "version" DESC
+`
+
+type InjectedContainerVersionsGetParams struct {
+	ChartType ChartType
+	Name      string
+}
+
+func (q *Queries) InjectedContainerVersionsGet(ctx context.Context, arg InjectedContainerVersionsGetParams) ([]InjectedContainer, error) {
+	rows, err := q.db.QueryContext(ctx, injectedContainerVersionsGet, arg.ChartType, arg.Name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []InjectedContainer{}
+	for rows.Next() {
+		var i InjectedContainer
+		if err := rows.Scan(
+			&i.ID,
+			&i.Created,
+			&i.Name,
+			&i.Version,
+			&i.ChartType,
+			&i.Container,
+			&i.Volumes,
+			&i.Enabled,
+			&i.OptOutAllowed,
+			&i.CreatedBy,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const injectedContainersGet = `-- name: InjectedContainersGet :many
+SELECT DISTINCT ON ("name") id, created, name, version, chart_type, container, volumes, enabled, opt_out_allowed, created_by
+FROM injected_containers
+WHERE chart_type = $1
+ORDER BY "name", "version" DESC
+`
+
+func (q *Queries) InjectedContainersGet(ctx context.Context, chartType ChartType) ([]InjectedContainer, error) {
+	rows, err := q.db.QueryContext(ctx, injectedContainersGet, chartType)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []InjectedContainer{}
+	for rows.Next() {
+		var i InjectedContainer
+		if err := rows.Scan(
+			&i.ID,
+			&i.Created,
+			&i.Name,
+			&i.Version,
+			&i.ChartType,
+			&i.Container,
+			&i.Volumes,
+			&i.Enabled,
+			&i.OptOutAllowed,
+			&i.CreatedBy,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -53,6 +53,65 @@ func (ns NullChartType) Value() (driver.Value, error) {
 	return string(ns.ChartType), nil
 }
 
+type TeamRole string
+
+const (
+	TeamRoleOwner      TeamRole = "owner"
+	TeamRoleMaintainer TeamRole = "maintainer"
+	TeamRoleViewer     TeamRole = "viewer"
+)
+
+func (e *TeamRole) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = TeamRole(s)
+	case string:
+		*e = TeamRole(s)
+	default:
+		return fmt.Errorf("unsupported scan type for TeamRole: %T", src)
+	}
+	return nil
+}
+
+type NullTeamRole struct {
+	TeamRole TeamRole
+	Valid    bool // Valid is true if TeamRole is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullTeamRole) Scan(value interface{}) error {
+	if value == nil {
+		ns.TeamRole, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.TeamRole.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullTeamRole) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.TeamRole), nil
+}
+
+type AuditLog struct {
+	ID         int64
+	Actor      string
+	Action     string
+	Target     string
+	TeamID     sql.NullString
+	Before     string
+	After      string
+	Method     string
+	Path       string
+	StatusCode int32
+	ClientIp   string
+	UserAgent  string
+	Created    time.Time
+}
+
 type ChartGlobalValue struct {
 	ID        uuid.UUID
 	Created   sql.NullTime
@@ -71,16 +130,35 @@ type ChartTeamValue struct {
 	TeamID    string
 }
 
+type CloudeventsOutbox struct {
+	ID                 uuid.UUID
+	Type               string
+	Subject            string
+	Data               json.RawMessage
+	Time               time.Time
+	DeliveredEndpoints []string
+	Attempts           int32
+	NextAttempt        time.Time
+	LastError          string
+	Delivered          sql.NullTime
+	Failed             bool
+}
+
 type Event struct {
-	ID         uuid.UUID
-	Type       string
-	Payload    json.RawMessage
-	Status     string
-	Deadline   string
-	CreatedAt  time.Time
-	UpdatedAt  time.Time
-	Owner      string
-	RetryCount int32
+	ID             uuid.UUID
+	Type           string
+	Payload        json.RawMessage
+	Status         string
+	Deadline       string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	Owner          string
+	RetryCount     int32
+	TraceContext   json.RawMessage
+	Priority       int32
+	RunAfter       sql.NullTime
+	SchemaVersion  int32
+	IdempotencyKey sql.NullString
 }
 
 type EventLog struct {
@@ -91,6 +169,59 @@ type EventLog struct {
 	CreatedAt time.Time
 }
 
+type EventPlanStep struct {
+	ID        int64
+	EventID   uuid.UUID
+	Kind      string
+	Action    string
+	Target    string
+	Details   json.RawMessage
+	CreatedAt time.Time
+}
+
+type InjectedContainer struct {
+	ID            uuid.UUID
+	Created       sql.NullTime
+	Name          string
+	Version       int32
+	ChartType     ChartType
+	Container     string
+	Volumes       string
+	Enabled       bool
+	OptOutAllowed bool
+	CreatedBy     string
+}
+
+type InjectedContainerOptOut struct {
+	Created   sql.NullTime
+	TeamID    string
+	ChartType ChartType
+	Name      string
+}
+
+type NotificationDelivery struct {
+	ID             uuid.UUID
+	SubscriptionID uuid.UUID
+	Payload        json.RawMessage
+	Attempts       int32
+	NextAttempt    time.Time
+	LastError      string
+	Delivered      sql.NullTime
+	Failed         bool
+	Created        time.Time
+}
+
+type NotificationSubscription struct {
+	ID         uuid.UUID
+	TeamID     sql.NullString
+	Sink       string
+	Target     string
+	EventTypes []string
+	Statuses   []string
+	CreatedBy  string
+	Created    time.Time
+}
+
 type Session struct {
 	Token       string
 	AccessToken string
@@ -108,6 +239,57 @@ type Team struct {
 	Created sql.NullTime
 }
 
+type TeamAzureGroup struct {
+	TeamID      string
+	GroupID     string
+	DisplayName string
+	Role        TeamRole
+	LastSynced  sql.NullTime
+	SyncError   string
+	Created     sql.NullTime
+}
+
+type TeamDeletion struct {
+	TeamID            string
+	DeleteAfter       time.Time
+	RequestedBy       string
+	HibernatedAirflow bool
+	Created           time.Time
+}
+
+type TeamHistory struct {
+	ID       int32
+	TeamID   string
+	Action   string
+	OldValue string
+	NewValue string
+	Actor    string
+	Created  time.Time
+}
+
+type TeamMember struct {
+	TeamID       string
+	Email        string
+	Role         TeamRole
+	Created      sql.NullTime
+	AzureGroupID sql.NullString
+}
+
+type TeamProvisioningStep struct {
+	TeamID      string
+	Step        string
+	Resource    string
+	Completed   time.Time
+	Compensated sql.NullTime
+}
+
+type TeamSlugRedirect struct {
+	Slug    string
+	TeamID  string
+	Expires time.Time
+	Created time.Time
+}
+
 type UserGoogleSecretManager struct {
 	Owner string
 	Name  string
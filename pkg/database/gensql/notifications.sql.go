@@ -0,0 +1,383 @@
+// Code generated by sqlc. DO NOT EDIT.
+// source: notifications.sql
+
+package gensql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const notificationDeliveredSet = `-- name: NotificationDeliveredSet :exec
+UPDATE notification_deliveries
+SET delivered = NOW(),
+    attempts  = attempts + 1
+WHERE id = $1
+`
+
+func (q *Queries) NotificationDeliveredSet(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, notificationDeliveredSet, id)
+	return err
+}
+
+const notificationDeliveriesDueGet = `-- name: NotificationDeliveriesDueGet :many
+SELECT d.id, d.subscription_id, d.payload, d.attempts, d.next_attempt, d.last_error, d.delivered, d.failed, d.created, s.sink, s.target
+FROM notification_deliveries d
+         JOIN notification_subscriptions s ON s.id = d.subscription_id
+WHERE d.delivered IS NULL
+  AND NOT d.failed
+  AND d.next_attempt <= NOW()
+ORDER BY d.next_attempt
+LIMIT $1
+`
+
+type NotificationDeliveriesDueGetRow struct {
+	ID             uuid.UUID
+	SubscriptionID uuid.UUID
+	Payload        json.RawMessage
+	Attempts       int32
+	NextAttempt    time.Time
+	LastError      string
+	Delivered      sql.NullTime
+	Failed         bool
+	Created        time.Time
+	Sink           string
+	Target         string
+}
+
+func (q *Queries) NotificationDeliveriesDueGet(ctx context.Context, lim int32) ([]NotificationDeliveriesDueGetRow, error) {
+	rows, err := q.db.QueryContext(ctx, notificationDeliveriesDueGet, lim)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []NotificationDeliveriesDueGetRow{}
+	for rows.Next() {
+		var i NotificationDeliveriesDueGetRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.SubscriptionID,
+			&i.Payload,
+			&i.Attempts,
+			&i.NextAttempt,
+			&i.LastError,
+			&i.Delivered,
+			&i.Failed,
+			&i.Created,
+			&i.Sink,
+			&i.Target,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const notificationDeliveriesExpiredDelete = `-- name: NotificationDeliveriesExpiredDelete :execrows
+DELETE
+FROM notification_deliveries
+WHERE (delivered IS NOT NULL OR failed)
+  AND created < NOW() - make_interval(days => $1::INT)
+`
+
+func (q *Queries) NotificationDeliveriesExpiredDelete(ctx context.Context, days int32) (int64, error) {
+	result, err := q.db.ExecContext(ctx, notificationDeliveriesExpiredDelete, days)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const notificationDeliveriesGet = `-- name: NotificationDeliveriesGet :many
+SELECT d.id, d.subscription_id, d.payload, d.attempts, d.next_attempt, d.last_error, d.delivered, d.failed, d.created, s.sink, s.target, s.team_id
+FROM notification_deliveries d
+         JOIN notification_subscriptions s ON s.id = d.subscription_id
+ORDER BY d.created DESC
+LIMIT $1
+`
+
+type NotificationDeliveriesGetRow struct {
+	ID             uuid.UUID
+	SubscriptionID uuid.UUID
+	Payload        json.RawMessage
+	Attempts       int32
+	NextAttempt    time.Time
+	LastError      string
+	Delivered      sql.NullTime
+	Failed         bool
+	Created        time.Time
+	Sink           string
+	Target         string
+	TeamID         sql.NullString
+}
+
+func (q *Queries) NotificationDeliveriesGet(ctx context.Context, lim int32) ([]NotificationDeliveriesGetRow, error) {
+	rows, err := q.db.QueryContext(ctx, notificationDeliveriesGet, lim)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []NotificationDeliveriesGetRow{}
+	for rows.Next() {
+		var i NotificationDeliveriesGetRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.SubscriptionID,
+			&i.Payload,
+			&i.Attempts,
+			&i.NextAttempt,
+			&i.LastError,
+			&i.Delivered,
+			&i.Failed,
+			&i.Created,
+			&i.Sink,
+			&i.Target,
+			&i.TeamID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const notificationDeliveryCreate = `-- name: NotificationDeliveryCreate :exec
+INSERT INTO notification_deliveries ("subscription_id", "payload")
+VALUES ($1, $2)
+`
+
+type NotificationDeliveryCreateParams struct {
+	SubscriptionID uuid.UUID
+	Payload        json.RawMessage
+}
+
+func (q *Queries) NotificationDeliveryCreate(ctx context.Context, arg NotificationDeliveryCreateParams) error {
+	_, err := q.db.ExecContext(ctx, notificationDeliveryCreate, arg.SubscriptionID, arg.Payload)
+	return err
+}
+
+const notificationDeliveryFailedSet = `-- name: NotificationDeliveryFailedSet :exec
+UPDATE notification_deliveries
+SET attempts     = attempts + 1,
+    last_error   = $1,
+    next_attempt = $2,
+    failed       = $3
+WHERE id = $4
+`
+
+type NotificationDeliveryFailedSetParams struct {
+	LastError   string
+	NextAttempt time.Time
+	Failed      bool
+	ID          uuid.UUID
+}
+
+func (q *Queries) NotificationDeliveryFailedSet(ctx context.Context, arg NotificationDeliveryFailedSetParams) error {
+	_, err := q.db.ExecContext(ctx, notificationDeliveryFailedSet,
+		arg.LastError,
+		arg.NextAttempt,
+		arg.Failed,
+		arg.ID,
+	)
+	return err
+}
+
+const notificationSubscriptionCreate = `-- name: NotificationSubscriptionCreate :exec
+INSERT INTO notification_subscriptions ("team_id", "sink", "target", "event_types", "statuses", "created_by")
+VALUES ($1, $2, $3, $4, $5, $6)
+`
+
+type NotificationSubscriptionCreateParams struct {
+	TeamID     sql.NullString
+	Sink       string
+	Target     string
+	EventTypes []string
+	Statuses   []string
+	CreatedBy  string
+}
+
+func (q *Queries) NotificationSubscriptionCreate(ctx context.Context, arg NotificationSubscriptionCreateParams) error {
+	_, err := q.db.ExecContext(ctx, notificationSubscriptionCreate,
+		arg.TeamID,
+		arg.Sink,
+		arg.Target,
+		pq.Array(arg.EventTypes),
+		pq.Array(arg.Statuses),
+		arg.CreatedBy,
+	)
+	return err
+}
+
+const notificationSubscriptionDelete = `-- name: NotificationSubscriptionDelete :exec
+DELETE
+FROM notification_subscriptions
+WHERE id = $1
+`
+
+func (q *Queries) NotificationSubscriptionDelete(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, notificationSubscriptionDelete, id)
+	return err
+}
+
+const notificationSubscriptionGet = `-- name: NotificationSubscriptionGet :one
+SELECT id, team_id, sink, target, event_types, statuses, created_by, created
+FROM notification_subscriptions
+WHERE id = $1
+`
+
+func (q *Queries) NotificationSubscriptionGet(ctx context.Context, id uuid.UUID) (NotificationSubscription, error) {
+	row := q.db.QueryRowContext(ctx, notificationSubscriptionGet, id)
+	var i NotificationSubscription
+	err := row.Scan(
+		&i.ID,
+		&i.TeamID,
+		&i.Sink,
+		&i.Target,
+		pq.Array(&i.EventTypes),
+		pq.Array(&i.Statuses),
+		&i.CreatedBy,
+		&i.Created,
+	)
+	return i, err
+}
+
+const notificationSubscriptionsForTeamGet = `-- name: NotificationSubscriptionsForTeamGet :many
+SELECT id, team_id, sink, target, event_types, statuses, created_by, created
+FROM notification_subscriptions
+WHERE team_id = $1
+ORDER BY created
+`
+
+func (q *Queries) NotificationSubscriptionsForTeamGet(ctx context.Context, teamID sql.NullString) ([]NotificationSubscription, error) {
+	rows, err := q.db.QueryContext(ctx, notificationSubscriptionsForTeamGet, teamID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []NotificationSubscription{}
+	for rows.Next() {
+		var i NotificationSubscription
+		if err := rows.Scan(
+			&i.ID,
+			&i.TeamID,
+			&i.Sink,
+			&i.Target,
+			pq.Array(&i.EventTypes),
+			pq.Array(&i.Statuses),
+			&i.CreatedBy,
+			&i.Created,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const notificationSubscriptionsGet = `-- name: NotificationSubscriptionsGet :many
+SELECT id, team_id, sink, target, event_types, statuses, created_by, created
+FROM notification_subscriptions
+ORDER BY team_id NULLS FIRST, created
+`
+
+func (q *Queries) NotificationSubscriptionsGet(ctx context.Context) ([]NotificationSubscription, error) {
+	rows, err := q.db.QueryContext(ctx, notificationSubscriptionsGet)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []NotificationSubscription{}
+	for rows.Next() {
+		var i NotificationSubscription
+		if err := rows.Scan(
+			&i.ID,
+			&i.TeamID,
+			&i.Sink,
+			&i.Target,
+			pq.Array(&i.EventTypes),
+			pq.Array(&i.Statuses),
+			&i.CreatedBy,
+			&i.Created,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const notificationSubscriptionsMatchingGet = `-- name: NotificationSubscriptionsMatchingGet :many
+SELECT id, team_id, sink, target, event_types, statuses, created_by, created
+FROM notification_subscriptions
+WHERE (team_id = $1 OR team_id IS NULL)
+  AND (cardinality(event_types) = 0 OR $2::TEXT = ANY (event_types))
+  AND $3::TEXT = ANY (statuses)
+`
+
+type NotificationSubscriptionsMatchingGetParams struct {
+	TeamID    sql.NullString
+	EventType string
+	Status    string
+}
+
+func (q *Queries) NotificationSubscriptionsMatchingGet(ctx context.Context, arg NotificationSubscriptionsMatchingGetParams) ([]NotificationSubscription, error) {
+	rows, err := q.db.QueryContext(ctx, notificationSubscriptionsMatchingGet, arg.TeamID, arg.EventType, arg.Status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []NotificationSubscription{}
+	for rows.Next() {
+		var i NotificationSubscription
+		if err := rows.Scan(
+			&i.ID,
+			&i.TeamID,
+			&i.Sink,
+			&i.Target,
+			pq.Array(&i.EventTypes),
+			pq.Array(&i.Statuses),
+			&i.CreatedBy,
+			&i.Created,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
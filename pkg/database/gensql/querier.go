@@ -4,35 +4,107 @@ package gensql
 
 import (
 	"context"
+	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 type Querier interface {
+	AuditLogInsert(ctx context.Context, arg AuditLogInsertParams) error
+	AuditLogsForTeamGet(ctx context.Context, arg AuditLogsForTeamGetParams) ([]AuditLog, error)
+	AuditLogsSearch(ctx context.Context, arg AuditLogsSearchParams) ([]AuditLog, error)
 	ChartDelete(ctx context.Context, arg ChartDeleteParams) error
 	ChartsForTeamGet(ctx context.Context, teamID string) ([]ChartType, error)
-	EventCreate(ctx context.Context, arg EventCreateParams) error
+	CloudEventDeliveredSet(ctx context.Context, arg CloudEventDeliveredSetParams) error
+	CloudEventDeliveryFailedSet(ctx context.Context, arg CloudEventDeliveryFailedSetParams) error
+	CloudEventsDueGet(ctx context.Context, lim int32) ([]CloudeventsOutbox, error)
+	CloudEventsExpiredDelete(ctx context.Context, days int32) (int64, error)
+	EventCancel(ctx context.Context, arg EventCancelParams) (int64, error)
+	EventCountsGet(ctx context.Context) ([]EventCountsGetRow, error)
+	EventCreate(ctx context.Context, arg EventCreateParams) (Event, error)
 	EventGet(ctx context.Context, id uuid.UUID) (Event, error)
+	EventIdempotencyLock(ctx context.Context, lockKey string) error
 	EventIncrementRetryCount(ctx context.Context, id uuid.UUID) error
+	EventLatestRegisteredGet(ctx context.Context, arg EventLatestRegisteredGetParams) (Event, error)
 	EventLogCreate(ctx context.Context, arg EventLogCreateParams) error
 	EventLogsForEventGet(ctx context.Context, id uuid.UUID) ([]EventLog, error)
+	EventLogsForEventsGet(ctx context.Context, ids []uuid.UUID) ([]EventLog, error)
+	EventLogsForOwnersSinceGet(ctx context.Context, arg EventLogsForOwnersSinceGetParams) ([]EventLog, error)
+	EventPlanStepCreate(ctx context.Context, arg EventPlanStepCreateParams) error
+	EventPlanStepsDelete(ctx context.Context, eventID uuid.UUID) error
+	EventPlanStepsGet(ctx context.Context, eventID uuid.UUID) ([]EventPlanStep, error)
+	EventReschedule(ctx context.Context, arg EventRescheduleParams) (int64, error)
 	EventSetStatus(ctx context.Context, arg EventSetStatusParams) error
+	EventUnprocessedExists(ctx context.Context, arg EventUnprocessedExistsParams) (bool, error)
 	EventsByOwnerGet(ctx context.Context, arg EventsByOwnerGetParams) ([]Event, error)
+	EventsClockGet(ctx context.Context) (time.Time, error)
+	EventsDelete(ctx context.Context, ids []uuid.UUID) error
+	EventsExpiredGet(ctx context.Context, arg EventsExpiredGetParams) ([]Event, error)
+	EventsForOwnersUpdatedSinceGet(ctx context.Context, arg EventsForOwnersUpdatedSinceGetParams) ([]Event, error)
 	EventsGetType(ctx context.Context, eventType string) ([]Event, error)
 	EventsProcessingGet(ctx context.Context) ([]Event, error)
 	EventsReset(ctx context.Context) error
+	EventsScheduledForOwnerGet(ctx context.Context, owner string) ([]Event, error)
 	EventsUpcomingGet(ctx context.Context) ([]Event, error)
 	GlobalValueDelete(ctx context.Context, arg GlobalValueDeleteParams) error
 	GlobalValueGet(ctx context.Context, arg GlobalValueGetParams) (ChartGlobalValue, error)
 	GlobalValueInsert(ctx context.Context, arg GlobalValueInsertParams) error
 	GlobalValuesGet(ctx context.Context, chartType ChartType) ([]ChartGlobalValue, error)
+	InjectedContainerGet(ctx context.Context, arg InjectedContainerGetParams) (InjectedContainer, error)
+	InjectedContainerInsert(ctx context.Context, arg InjectedContainerInsertParams) error
+	InjectedContainerOptOutDelete(ctx context.Context, arg InjectedContainerOptOutDeleteParams) error
+	InjectedContainerOptOutInsert(ctx context.Context, arg InjectedContainerOptOutInsertParams) error
+	InjectedContainerOptOutsGet(ctx context.Context, arg InjectedContainerOptOutsGetParams) ([]string, error)
+	InjectedContainerVersionsGet(ctx context.Context, arg InjectedContainerVersionsGetParams) ([]InjectedContainer, error)
+	InjectedContainersGet(ctx context.Context, chartType ChartType) ([]InjectedContainer, error)
+	NotificationDeliveredSet(ctx context.Context, id uuid.UUID) error
+	NotificationDeliveriesDueGet(ctx context.Context, lim int32) ([]NotificationDeliveriesDueGetRow, error)
+	NotificationDeliveriesExpiredDelete(ctx context.Context, days int32) (int64, error)
+	NotificationDeliveriesGet(ctx context.Context, lim int32) ([]NotificationDeliveriesGetRow, error)
+	NotificationDeliveryCreate(ctx context.Context, arg NotificationDeliveryCreateParams) error
+	NotificationDeliveryFailedSet(ctx context.Context, arg NotificationDeliveryFailedSetParams) error
+	NotificationSubscriptionCreate(ctx context.Context, arg NotificationSubscriptionCreateParams) error
+	NotificationSubscriptionDelete(ctx context.Context, id uuid.UUID) error
+	NotificationSubscriptionGet(ctx context.Context, id uuid.UUID) (NotificationSubscription, error)
+	NotificationSubscriptionsForTeamGet(ctx context.Context, teamID sql.NullString) ([]NotificationSubscription, error)
+	NotificationSubscriptionsGet(ctx context.Context) ([]NotificationSubscription, error)
+	NotificationSubscriptionsMatchingGet(ctx context.Context, arg NotificationSubscriptionsMatchingGetParams) ([]NotificationSubscription, error)
 	SessionCreate(ctx context.Context, arg SessionCreateParams) error
 	SessionDelete(ctx context.Context, token string) error
 	SessionGet(ctx context.Context, token string) (Session, error)
+	SessionsExpiredDelete(ctx context.Context) (int64, error)
+	TeamAzureGroupDelete(ctx context.Context, arg TeamAzureGroupDeleteParams) error
+	TeamAzureGroupSyncedSet(ctx context.Context, arg TeamAzureGroupSyncedSetParams) error
+	TeamAzureGroupUpsert(ctx context.Context, arg TeamAzureGroupUpsertParams) error
+	TeamAzureGroupsGet(ctx context.Context, teamID string) ([]TeamAzureGroup, error)
 	TeamBySlugGet(ctx context.Context, slug string) (TeamBySlugGetRow, error)
 	TeamCreate(ctx context.Context, arg TeamCreateParams) error
 	TeamDelete(ctx context.Context, id string) error
+	TeamDeletionDelete(ctx context.Context, teamID string) error
+	TeamDeletionFailed(ctx context.Context, teamID string) (bool, error)
+	TeamDeletionGet(ctx context.Context, teamID string) (TeamDeletion, error)
+	TeamDeletionInsert(ctx context.Context, arg TeamDeletionInsertParams) error
+	TeamDeletionsDueGet(ctx context.Context) ([]string, error)
+	TeamDeletionsGet(ctx context.Context) ([]TeamDeletionsGetRow, error)
 	TeamGet(ctx context.Context, id string) (TeamGetRow, error)
+	TeamHistoryGet(ctx context.Context, teamID string) ([]TeamHistory, error)
+	TeamHistoryInsert(ctx context.Context, arg TeamHistoryInsertParams) error
+	TeamIDsInUseGet(ctx context.Context) ([]string, error)
+	TeamMemberInsert(ctx context.Context, arg TeamMemberInsertParams) error
+	TeamMemberRoleGet(ctx context.Context, arg TeamMemberRoleGetParams) (TeamRole, error)
+	TeamMembersDelete(ctx context.Context, teamID string) error
+	TeamMembersGet(ctx context.Context, teamID string) ([]TeamMembersGetRow, error)
+	TeamProvisioningStepCompensatedSet(ctx context.Context, arg TeamProvisioningStepCompensatedSetParams) error
+	TeamProvisioningStepComplete(ctx context.Context, arg TeamProvisioningStepCompleteParams) error
+	TeamProvisioningStepsDelete(ctx context.Context, teamID string) error
+	TeamProvisioningStepsGet(ctx context.Context, teamID string) ([]TeamProvisioningStep, error)
+	TeamSlugRedirectDelete(ctx context.Context, slug string) error
+	TeamSlugRedirectGet(ctx context.Context, slug string) (TeamSlugRedirect, error)
+	TeamSlugRedirectUpsert(ctx context.Context, arg TeamSlugRedirectUpsertParams) error
+	TeamSlugRedirectsExpiredGet(ctx context.Context) ([]TeamSlugRedirect, error)
+	TeamSlugRedirectsGet(ctx context.Context, teamID string) ([]TeamSlugRedirect, error)
+	TeamSlugUpdate(ctx context.Context, arg TeamSlugUpdateParams) error
 	TeamUpdate(ctx context.Context, arg TeamUpdateParams) error
 	TeamValueDelete(ctx context.Context, arg TeamValueDeleteParams) error
 	TeamValueGet(ctx context.Context, arg TeamValueGetParams) (ChartTeamValue, error)
@@ -41,6 +113,7 @@ type Querier interface {
 	TeamsForChartGet(ctx context.Context, chartType ChartType) ([]string, error)
 	TeamsForUserGet(ctx context.Context, email string) ([]TeamsForUserGetRow, error)
 	TeamsGet(ctx context.Context) ([]Team, error)
+	TeamsWithAzureGroupsGet(ctx context.Context) ([]string, error)
 	UserGoogleSecretManagerCreate(ctx context.Context, arg UserGoogleSecretManagerCreateParams) error
 	UserGoogleSecretManagerDelete(ctx context.Context, owner string) error
 	UserGoogleSecretManagerGet(ctx context.Context, owner string) (UserGoogleSecretManager, error)
@@ -79,3 +79,17 @@ func (q *Queries) SessionGet(ctx context.Context, token string) (Session, error)
 	)
 	return i, err
 }
+
+const sessionsExpiredDelete = `-- name: SessionsExpiredDelete :execrows
+DELETE
+FROM "sessions"
+WHERE expires < NOW()
+`
+
+func (q *Queries) SessionsExpiredDelete(ctx context.Context) (int64, error) {
+	result, err := q.db.ExecContext(ctx, sessionsExpiredDelete)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,136 @@
+// Code generated by sqlc. DO NOT EDIT.
+// source: team_azure_groups.sql
+
+package gensql
+
+import (
+	"context"
+)
+
+const teamAzureGroupDelete = `-- name: TeamAzureGroupDelete :exec
+DELETE
+FROM team_azure_groups
+WHERE team_id = $1
+  AND group_id = $2
+`
+
+type TeamAzureGroupDeleteParams struct {
+	TeamID  string
+	GroupID string
+}
+
+func (q *Queries) TeamAzureGroupDelete(ctx context.Context, arg TeamAzureGroupDeleteParams) error {
+	_, err := q.db.ExecContext(ctx, teamAzureGroupDelete, arg.TeamID, arg.GroupID)
+	return err
+}
+
+const teamAzureGroupSyncedSet = `-- name: TeamAzureGroupSyncedSet :exec
+UPDATE team_azure_groups
+SET last_synced = NOW(),
+    sync_error  = $1
+WHERE team_id = $2
+  AND group_id = $3
+`
+
+type TeamAzureGroupSyncedSetParams struct {
+	SyncError string
+	TeamID    string
+	GroupID   string
+}
+
+func (q *Queries) TeamAzureGroupSyncedSet(ctx context.Context, arg TeamAzureGroupSyncedSetParams) error {
+	_, err := q.db.ExecContext(ctx, teamAzureGroupSyncedSet, arg.SyncError, arg.TeamID, arg.GroupID)
+	return err
+}
+
+const teamAzureGroupUpsert = `-- name: TeamAzureGroupUpsert :exec
+INSERT INTO team_azure_groups ("team_id", "group_id", "display_name", "role")
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (team_id, group_id) DO UPDATE
+    SET display_name = EXCLUDED.display_name,
+        role         = EXCLUDED.role
+`
+
+type TeamAzureGroupUpsertParams struct {
+	TeamID      string
+	GroupID     string
+	DisplayName string
+	Role        TeamRole
+}
+
+func (q *Queries) TeamAzureGroupUpsert(ctx context.Context, arg TeamAzureGroupUpsertParams) error {
+	_, err := q.db.ExecContext(ctx, teamAzureGroupUpsert,
+		arg.TeamID,
+		arg.GroupID,
+		arg.DisplayName,
+		arg.Role,
+	)
+	return err
+}
+
+const teamAzureGroupsGet = `-- name: TeamAzureGroupsGet :many
+SELECT team_id, group_id, display_name, role, last_synced, sync_error, created
+FROM team_azure_groups
+WHERE team_id = $1
+ORDER BY display_name
+`
+
+func (q *Queries) TeamAzureGroupsGet(ctx context.Context, teamID string) ([]TeamAzureGroup, error) {
+	rows, err := q.db.QueryContext(ctx, teamAzureGroupsGet, teamID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []TeamAzureGroup{}
+	for rows.Next() {
+		var i TeamAzureGroup
+		if err := rows.Scan(
+			&i.TeamID,
+			&i.GroupID,
+			&i.DisplayName,
+			&i.Role,
+			&i.LastSynced,
+			&i.SyncError,
+			&i.Created,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const teamsWithAzureGroupsGet = `-- name: TeamsWithAzureGroupsGet :many
+SELECT DISTINCT team_id
+FROM team_azure_groups
+ORDER BY team_id
+`
+
+func (q *Queries) TeamsWithAzureGroupsGet(ctx context.Context) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, teamsWithAzureGroupsGet)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var team_id string
+		if err := rows.Scan(&team_id); err != nil {
+			return nil, err
+		}
+		items = append(items, team_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
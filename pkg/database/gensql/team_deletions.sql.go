@@ -0,0 +1,159 @@
+// Code generated by sqlc. DO NOT EDIT.
+// source: team_deletions.sql
+
+package gensql
+
+import (
+	"context"
+	"time"
+)
+
+const teamDeletionDelete = `-- name: TeamDeletionDelete :exec
+DELETE
+FROM team_deletions
+WHERE team_id = $1
+`
+
+func (q *Queries) TeamDeletionDelete(ctx context.Context, teamID string) error {
+	_, err := q.db.ExecContext(ctx, teamDeletionDelete, teamID)
+	return err
+}
+
+const teamDeletionFailed = `-- name: TeamDeletionFailed :one
+SELECT EXISTS (SELECT 1
+               FROM team_deletions d
+                        JOIN events e ON e.owner = d.team_id
+               WHERE d.team_id = $1
+                 AND e.type = 'delete:team'
+                 AND e.status IN ('failed', 'manual_failed')
+                 AND e.created_at >= d.created)
+`
+
+func (q *Queries) TeamDeletionFailed(ctx context.Context, teamID string) (bool, error) {
+	row := q.db.QueryRowContext(ctx, teamDeletionFailed, teamID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const teamDeletionGet = `-- name: TeamDeletionGet :one
+SELECT team_id, delete_after, requested_by, hibernated_airflow, created
+FROM team_deletions
+WHERE team_id = $1
+`
+
+func (q *Queries) TeamDeletionGet(ctx context.Context, teamID string) (TeamDeletion, error) {
+	row := q.db.QueryRowContext(ctx, teamDeletionGet, teamID)
+	var i TeamDeletion
+	err := row.Scan(
+		&i.TeamID,
+		&i.DeleteAfter,
+		&i.RequestedBy,
+		&i.HibernatedAirflow,
+		&i.Created,
+	)
+	return i, err
+}
+
+const teamDeletionInsert = `-- name: TeamDeletionInsert :exec
+INSERT INTO team_deletions ("team_id", "delete_after", "requested_by", "hibernated_airflow")
+VALUES ($1, $2, $3, $4)
+`
+
+type TeamDeletionInsertParams struct {
+	TeamID            string
+	DeleteAfter       time.Time
+	RequestedBy       string
+	HibernatedAirflow bool
+}
+
+func (q *Queries) TeamDeletionInsert(ctx context.Context, arg TeamDeletionInsertParams) error {
+	_, err := q.db.ExecContext(ctx, teamDeletionInsert,
+		arg.TeamID,
+		arg.DeleteAfter,
+		arg.RequestedBy,
+		arg.HibernatedAirflow,
+	)
+	return err
+}
+
+const teamDeletionsDueGet = `-- name: TeamDeletionsDueGet :many
+SELECT d.team_id
+FROM team_deletions d
+WHERE d.delete_after <= NOW()
+  AND NOT EXISTS (SELECT 1
+                  FROM events e
+                  WHERE e.owner = d.team_id
+                    AND e.type = 'delete:team'
+                    AND (e.status IN ('new', 'processing', 'pending', 'deadline_reached')
+                      OR (e.status IN ('failed', 'manual_failed') AND e.created_at >= d.created)))
+`
+
+func (q *Queries) TeamDeletionsDueGet(ctx context.Context) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, teamDeletionsDueGet)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var team_id string
+		if err := rows.Scan(&team_id); err != nil {
+			return nil, err
+		}
+		items = append(items, team_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const teamDeletionsGet = `-- name: TeamDeletionsGet :many
+SELECT d.team_id, d.delete_after, d.requested_by, d.hibernated_airflow, d.created, t.slug
+FROM team_deletions d
+         JOIN teams t ON t.id = d.team_id
+ORDER BY d.delete_after
+`
+
+type TeamDeletionsGetRow struct {
+	TeamID            string
+	DeleteAfter       time.Time
+	RequestedBy       string
+	HibernatedAirflow bool
+	Created           time.Time
+	Slug              string
+}
+
+func (q *Queries) TeamDeletionsGet(ctx context.Context) ([]TeamDeletionsGetRow, error) {
+	rows, err := q.db.QueryContext(ctx, teamDeletionsGet)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []TeamDeletionsGetRow{}
+	for rows.Next() {
+		var i TeamDeletionsGetRow
+		if err := rows.Scan(
+			&i.TeamID,
+			&i.DeleteAfter,
+			&i.RequestedBy,
+			&i.HibernatedAirflow,
+			&i.Created,
+			&i.Slug,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
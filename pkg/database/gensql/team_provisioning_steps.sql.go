@@ -0,0 +1,92 @@
+// Code generated by sqlc. DO NOT EDIT.
+// source: team_provisioning_steps.sql
+
+package gensql
+
+import (
+	"context"
+)
+
+const teamProvisioningStepCompensatedSet = `-- name: TeamProvisioningStepCompensatedSet :exec
+UPDATE team_provisioning_steps
+SET compensated = NOW()
+WHERE team_id = $1
+  AND step = $2
+`
+
+type TeamProvisioningStepCompensatedSetParams struct {
+	TeamID string
+	Step   string
+}
+
+func (q *Queries) TeamProvisioningStepCompensatedSet(ctx context.Context, arg TeamProvisioningStepCompensatedSetParams) error {
+	_, err := q.db.ExecContext(ctx, teamProvisioningStepCompensatedSet, arg.TeamID, arg.Step)
+	return err
+}
+
+const teamProvisioningStepComplete = `-- name: TeamProvisioningStepComplete :exec
+INSERT INTO team_provisioning_steps ("team_id", "step", "resource")
+VALUES ($1, $2, $3)
+ON CONFLICT (team_id, step) DO UPDATE
+    SET resource    = EXCLUDED.resource,
+        completed   = NOW(),
+        compensated = NULL
+`
+
+type TeamProvisioningStepCompleteParams struct {
+	TeamID   string
+	Step     string
+	Resource string
+}
+
+func (q *Queries) TeamProvisioningStepComplete(ctx context.Context, arg TeamProvisioningStepCompleteParams) error {
+	_, err := q.db.ExecContext(ctx, teamProvisioningStepComplete, arg.TeamID, arg.Step, arg.Resource)
+	return err
+}
+
+const teamProvisioningStepsDelete = `-- name: TeamProvisioningStepsDelete :exec
+DELETE
+FROM team_provisioning_steps
+WHERE team_id = $1
+`
+
+func (q *Queries) TeamProvisioningStepsDelete(ctx context.Context, teamID string) error {
+	_, err := q.db.ExecContext(ctx, teamProvisioningStepsDelete, teamID)
+	return err
+}
+
+const teamProvisioningStepsGet = `-- name: TeamProvisioningStepsGet :many
+SELECT team_id, step, resource, completed, compensated
+FROM team_provisioning_steps
+WHERE team_id = $1
+ORDER BY completed
+`
+
+func (q *Queries) TeamProvisioningStepsGet(ctx context.Context, teamID string) ([]TeamProvisioningStep, error) {
+	rows, err := q.db.QueryContext(ctx, teamProvisioningStepsGet, teamID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []TeamProvisioningStep{}
+	for rows.Next() {
+		var i TeamProvisioningStep
+		if err := rows.Scan(
+			&i.TeamID,
+			&i.Step,
+			&i.Resource,
+			&i.Completed,
+			&i.Compensated,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
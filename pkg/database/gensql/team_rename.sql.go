@@ -0,0 +1,189 @@
+// Code generated by sqlc. DO NOT EDIT.
+// source: team_rename.sql
+
+package gensql
+
+import (
+	"context"
+	"time"
+)
+
+const teamHistoryGet = `-- name: TeamHistoryGet :many
+SELECT id, team_id, action, old_value, new_value, actor, created
+FROM team_history
+WHERE team_id = $1
+ORDER BY created DESC
+`
+
+func (q *Queries) TeamHistoryGet(ctx context.Context, teamID string) ([]TeamHistory, error) {
+	rows, err := q.db.QueryContext(ctx, teamHistoryGet, teamID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []TeamHistory{}
+	for rows.Next() {
+		var i TeamHistory
+		if err := rows.Scan(
+			&i.ID,
+			&i.TeamID,
+			&i.Action,
+			&i.OldValue,
+			&i.NewValue,
+			&i.Actor,
+			&i.Created,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const teamHistoryInsert = `-- name: TeamHistoryInsert :exec
+INSERT INTO team_history ("team_id", "action", "old_value", "new_value", "actor")
+VALUES ($1, $2, $3, $4, $5)
+`
+
+type TeamHistoryInsertParams struct {
+	TeamID   string
+	Action   string
+	OldValue string
+	NewValue string
+	Actor    string
+}
+
+func (q *Queries) TeamHistoryInsert(ctx context.Context, arg TeamHistoryInsertParams) error {
+	_, err := q.db.ExecContext(ctx, teamHistoryInsert,
+		arg.TeamID,
+		arg.Action,
+		arg.OldValue,
+		arg.NewValue,
+		arg.Actor,
+	)
+	return err
+}
+
+const teamSlugRedirectDelete = `-- name: TeamSlugRedirectDelete :exec
+DELETE
+FROM team_slug_redirects
+WHERE slug = $1
+`
+
+func (q *Queries) TeamSlugRedirectDelete(ctx context.Context, slug string) error {
+	_, err := q.db.ExecContext(ctx, teamSlugRedirectDelete, slug)
+	return err
+}
+
+const teamSlugRedirectGet = `-- name: TeamSlugRedirectGet :one
+SELECT slug, team_id, expires, created
+FROM team_slug_redirects
+WHERE slug = $1
+`
+
+func (q *Queries) TeamSlugRedirectGet(ctx context.Context, slug string) (TeamSlugRedirect, error) {
+	row := q.db.QueryRowContext(ctx, teamSlugRedirectGet, slug)
+	var i TeamSlugRedirect
+	err := row.Scan(
+		&i.Slug,
+		&i.TeamID,
+		&i.Expires,
+		&i.Created,
+	)
+	return i, err
+}
+
+const teamSlugRedirectUpsert = `-- name: TeamSlugRedirectUpsert :exec
+INSERT INTO team_slug_redirects ("slug", "team_id", "expires")
+VALUES ($1, $2, $3)
+ON CONFLICT (slug) DO UPDATE
+    SET expires = EXCLUDED.expires
+`
+
+type TeamSlugRedirectUpsertParams struct {
+	Slug    string
+	TeamID  string
+	Expires time.Time
+}
+
+func (q *Queries) TeamSlugRedirectUpsert(ctx context.Context, arg TeamSlugRedirectUpsertParams) error {
+	_, err := q.db.ExecContext(ctx, teamSlugRedirectUpsert, arg.Slug, arg.TeamID, arg.Expires)
+	return err
+}
+
+const teamSlugRedirectsExpiredGet = `-- name: TeamSlugRedirectsExpiredGet :many
+SELECT slug, team_id, expires, created
+FROM team_slug_redirects
+WHERE expires <= NOW()
+ORDER BY slug
+`
+
+func (q *Queries) TeamSlugRedirectsExpiredGet(ctx context.Context) ([]TeamSlugRedirect, error) {
+	rows, err := q.db.QueryContext(ctx, teamSlugRedirectsExpiredGet)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []TeamSlugRedirect{}
+	for rows.Next() {
+		var i TeamSlugRedirect
+		if err := rows.Scan(
+			&i.Slug,
+			&i.TeamID,
+			&i.Expires,
+			&i.Created,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const teamSlugRedirectsGet = `-- name: TeamSlugRedirectsGet :many
+SELECT slug, team_id, expires, created
+FROM team_slug_redirects
+WHERE team_id = $1
+  AND expires > NOW()
+ORDER BY slug
+`
+
+func (q *Queries) TeamSlugRedirectsGet(ctx context.Context, teamID string) ([]TeamSlugRedirect, error) {
+	rows, err := q.db.QueryContext(ctx, teamSlugRedirectsGet, teamID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []TeamSlugRedirect{}
+	for rows.Next() {
+		var i TeamSlugRedirect
+		if err := rows.Scan(
+			&i.Slug,
+			&i.TeamID,
+			&i.Expires,
+			&i.Created,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -5,6 +5,7 @@ package gensql
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/lib/pq"
 )
@@ -74,6 +75,145 @@ func (q *Queries) TeamGet(ctx context.Context, id string) (TeamGetRow, error) {
 	return i, err
 }
 
+const teamIDsInUseGet = `-- name: TeamIDsInUseGet :many
+SELECT id
+FROM teams
+UNION
+SELECT team_id
+FROM team_provisioning_steps
+UNION
+SELECT owner
+FROM events
+WHERE status IN ('new', 'processing', 'pending', 'deadline_reached')
+`
+
+func (q *Queries) TeamIDsInUseGet(ctx context.Context) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, teamIDsInUseGet)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const teamMemberInsert = `-- name: TeamMemberInsert :exec
+INSERT INTO team_members ("team_id", "email", "role", "azure_group_id")
+VALUES ($1, $2, $3, $4)
+`
+
+type TeamMemberInsertParams struct {
+	TeamID       string
+	Email        string
+	Role         TeamRole
+	AzureGroupID sql.NullString
+}
+
+func (q *Queries) TeamMemberInsert(ctx context.Context, arg TeamMemberInsertParams) error {
+	_, err := q.db.ExecContext(ctx, teamMemberInsert,
+		arg.TeamID,
+		arg.Email,
+		arg.Role,
+		arg.AzureGroupID,
+	)
+	return err
+}
+
+const teamMemberRoleGet = `-- name: TeamMemberRoleGet :one
+SELECT role
+FROM team_members
+WHERE team_id = $1
+  AND email = LOWER($2::TEXT)
+`
+
+type TeamMemberRoleGetParams struct {
+	TeamID string
+	Email  string
+}
+
+func (q *Queries) TeamMemberRoleGet(ctx context.Context, arg TeamMemberRoleGetParams) (TeamRole, error) {
+	row := q.db.QueryRowContext(ctx, teamMemberRoleGet, arg.TeamID, arg.Email)
+	var role TeamRole
+	err := row.Scan(&role)
+	return role, err
+}
+
+const teamMembersDelete = `-- name: TeamMembersDelete :exec
+DELETE
+FROM team_members
+WHERE team_id = $1
+`
+
+func (q *Queries) TeamMembersDelete(ctx context.Context, teamID string) error {
+	_, err := q.db.ExecContext(ctx, teamMembersDelete, teamID)
+	return err
+}
+
+const teamMembersGet = `-- name: TeamMembersGet :many
+SELECT email, role, azure_group_id
+FROM team_members
+WHERE team_id = $1
+ORDER BY role, email
+`
+
+type TeamMembersGetRow struct {
+	Email        string
+	Role         TeamRole
+	AzureGroupID sql.NullString
+}
+
+func (q *Queries) TeamMembersGet(ctx context.Context, teamID string) ([]TeamMembersGetRow, error) {
+	rows, err := q.db.QueryContext(ctx, teamMembersGet, teamID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []TeamMembersGetRow{}
+	for rows.Next() {
+		var i TeamMembersGetRow
+		if err := rows.Scan(&i.Email, &i.Role, &i.AzureGroupID); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const teamSlugUpdate = `-- name: TeamSlugUpdate :exec
+UPDATE teams
+SET slug = $1
+WHERE id = $2
+`
+
+type TeamSlugUpdateParams struct {
+	Slug string
+	ID   string
+}
+
+func (q *Queries) TeamSlugUpdate(ctx context.Context, arg TeamSlugUpdateParams) error {
+	_, err := q.db.ExecContext(ctx, teamSlugUpdate, arg.Slug, arg.ID)
+	return err
+}
+
 const teamUpdate = `-- name: TeamUpdate :exec
 UPDATE teams
 SET users = $1
@@ -91,14 +231,16 @@ func (q *Queries) TeamUpdate(ctx context.Context, arg TeamUpdateParams) error {
 }
 
 const teamsForUserGet = `-- name: TeamsForUserGet :many
-SELECT id, slug
-FROM teams
-WHERE $1::TEXT = ANY ("users")
+SELECT t.id, t.slug, m.role
+FROM teams t
+         JOIN team_members m ON m.team_id = t.id
+WHERE m.email = LOWER($1::TEXT)
 `
 
 type TeamsForUserGetRow struct {
 	ID   string
 	Slug string
+	Role TeamRole
 }
 
 func (q *Queries) TeamsForUserGet(ctx context.Context, email string) ([]TeamsForUserGetRow, error) {
@@ -110,7 +252,7 @@ func (q *Queries) TeamsForUserGet(ctx context.Context, email string) ([]TeamsFor
 	items := []TeamsForUserGetRow{}
 	for rows.Next() {
 		var i TeamsForUserGetRow
-		if err := rows.Scan(&i.ID, &i.Slug); err != nil {
+		if err := rows.Scan(&i.ID, &i.Slug, &i.Role); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
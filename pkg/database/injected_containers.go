@@ -0,0 +1,56 @@
+package database
+
+import (
+	"context"
+
+	"github.com/navikt/knorten/pkg/database/gensql"
+)
+
+func (r *Repo) InjectedContainerInsert(ctx context.Context, params gensql.InjectedContainerInsertParams) error {
+	return r.querier.InjectedContainerInsert(ctx, params)
+}
+
+// InjectedContainersGet returns the newest version of every injected container for the chart type
+func (r *Repo) InjectedContainersGet(ctx context.Context, chartType gensql.ChartType) ([]gensql.InjectedContainer, error) {
+	return r.querier.InjectedContainersGet(ctx, chartType)
+}
+
+func (r *Repo) InjectedContainerGet(ctx context.Context, chartType gensql.ChartType, name string) (gensql.InjectedContainer, error) {
+	return r.querier.InjectedContainerGet(ctx, gensql.InjectedContainerGetParams{
+		ChartType: chartType,
+		Name:      name,
+	})
+}
+
+func (r *Repo) InjectedContainerVersionsGet(ctx context.Context, chartType gensql.ChartType, name string) ([]gensql.InjectedContainer, error) {
+	return r.querier.InjectedContainerVersionsGet(ctx, gensql.InjectedContainerVersionsGetParams{
+		ChartType: chartType,
+		Name:      name,
+	})
+}
+
+func (r *Repo) InjectedContainerOptOutsGet(ctx context.Context, chartType gensql.ChartType, teamID string) ([]string, error) {
+	return r.querier.InjectedContainerOptOutsGet(ctx, gensql.InjectedContainerOptOutsGetParams{
+		TeamID:    teamID,
+		ChartType: chartType,
+	})
+}
+
+// InjectedContainerOptOutSet opts the team in or out of an injected container. Whether the team
+// is allowed to opt out is checked when the values are enriched, so a policy change takes effect
+// on the next rollout.
+func (r *Repo) InjectedContainerOptOutSet(ctx context.Context, chartType gensql.ChartType, teamID, name string, optOut bool) error {
+	if optOut {
+		return r.querier.InjectedContainerOptOutInsert(ctx, gensql.InjectedContainerOptOutInsertParams{
+			TeamID:    teamID,
+			ChartType: chartType,
+			Name:      name,
+		})
+	}
+
+	return r.querier.InjectedContainerOptOutDelete(ctx, gensql.InjectedContainerOptOutDeleteParams{
+		TeamID:    teamID,
+		ChartType: chartType,
+		Name:      name,
+	})
+}
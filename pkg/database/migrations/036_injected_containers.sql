@@ -0,0 +1,50 @@
+-- +goose Up
+CREATE TABLE injected_containers
+(
+    "id"              uuid        DEFAULT uuid_generate_v4(),
+    "created"         TIMESTAMPTZ DEFAULT NOW(),
+    "name"            TEXT       NOT NULL,
+    "version"         INT        NOT NULL,
+    "chart_type"      CHART_TYPE NOT NULL,
+    "container"       TEXT       NOT NULL,
+    "volumes"         TEXT       NOT NULL DEFAULT '[]',
+    "enabled"         BOOLEAN    NOT NULL DEFAULT true,
+    "opt_out_allowed" BOOLEAN    NOT NULL DEFAULT false,
+    "created_by"      TEXT       NOT NULL DEFAULT '',
+    PRIMARY KEY (id),
+    UNIQUE ("chart_type", "name", "version")
+);
+
+CREATE TABLE injected_container_opt_outs
+(
+    "created"    TIMESTAMPTZ DEFAULT NOW(),
+    "team_id"    TEXT       NOT NULL,
+    "chart_type" CHART_TYPE NOT NULL,
+    "name"       TEXT       NOT NULL,
+    PRIMARY KEY (team_id, chart_type, name),
+    CONSTRAINT fk_injected_container_opt_outs_team
+        FOREIGN KEY (team_id)
+            REFERENCES teams (id) ON DELETE CASCADE
+);
+
+INSERT INTO injected_containers ("name", "version", "chart_type", "container", "created_by")
+VALUES ('knaudit', 1, 'airflow',
+        jsonb_set('{"name":"knaudit","image":"","env":[{"name":"POD_NAME","valueFrom":{"fieldRef":{"fieldPath":"metadata.name"}}},{"name":"NAMESPACE","valueFrom":{"fieldRef":{"fieldPath":"metadata.namespace"}}},{"name":"KNAUDIT_PROXY_URL","value":"http://knaudit-proxy.knada-system.svc.cluster.local"},{"name":"CA_CERT_PATH","value":"/etc/pki/tls/certs/ca-bundle.crt"},{"name":"GIT_REPO_PATH","value":"/dags"},{"name":"AIRFLOW_DAG_ID","valueFrom":{"fieldRef":{"fieldPath":"metadata.annotations[''dag_id'']"}}},{"name":"AIRFLOW_RUN_ID","valueFrom":{"fieldRef":{"fieldPath":"metadata.annotations[''run_id'']"}}},{"name":"AIRFLOW_TASK_ID","valueFrom":{"fieldRef":{"fieldPath":"metadata.annotations[''task_id'']"}}},{"name":"AIRFLOW_DB_URL","valueFrom":{"secretKeyRef":{"name":"airflow-db","key":"connection"}}}],"resources":{"requests":{"cpu":"200m","memory":"128Mi"}},"volumeMounts":[{"mountPath":"/dags","name":"dags"},{"mountPath":"/etc/pki/tls/certs/ca-bundle.crt","name":"ca-bundle-pem","readOnly":true,"subPath":"ca-bundle.pem"}],"securityContext":{"allowPrivilegeEscalation":false,"runAsGroup":0,"runAsUser":50000}}'::jsonb, '{image}', to_jsonb(COALESCE(
+            (SELECT DISTINCT ON ("key") "value" FROM chart_global_values
+             WHERE "key" = 'knauditImage,omit' AND chart_type = 'airflow'
+             ORDER BY "key", "created" DESC),
+            'europe-north1-docker.pkg.dev/knada-gcp/knada-north/knaudit:2024-03-15-ef2f8de'
+        )))::text,
+        'migration');
+
+DELETE FROM chart_global_values WHERE "key" = 'knauditImage,omit';
+
+-- +goose Down
+INSERT INTO chart_global_values ("key", "value", "chart_type")
+SELECT DISTINCT ON ("name") 'knauditImage,omit', "container"::jsonb ->> 'image', 'airflow'
+FROM injected_containers
+WHERE "name" = 'knaudit' AND chart_type = 'airflow'
+ORDER BY "name", "version" DESC;
+
+DROP TABLE injected_container_opt_outs;
+DROP TABLE injected_containers;
@@ -0,0 +1,24 @@
+-- +goose Up
+CREATE TYPE TEAM_ROLE AS ENUM ('owner', 'maintainer', 'viewer');
+
+CREATE TABLE team_members
+(
+    "team_id" TEXT      NOT NULL,
+    "email"   TEXT      NOT NULL,
+    "role"    TEAM_ROLE NOT NULL,
+    "created" TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (team_id, email),
+    CONSTRAINT fk_team_members_team
+        FOREIGN KEY (team_id)
+            REFERENCES teams (id) ON DELETE CASCADE
+);
+
+-- Everyone could do everything before roles were introduced, so existing members become owners
+INSERT INTO team_members ("team_id", "email", "role")
+SELECT id, LOWER(UNNEST(users)), 'owner'
+FROM teams
+ON CONFLICT DO NOTHING;
+
+-- +goose Down
+DROP TABLE team_members;
+DROP TYPE TEAM_ROLE;
@@ -0,0 +1,26 @@
+-- +goose Up
+CREATE TABLE team_azure_groups
+(
+    "team_id"      TEXT      NOT NULL,
+    "group_id"     TEXT      NOT NULL,
+    "display_name" TEXT      NOT NULL,
+    "role"         TEAM_ROLE NOT NULL DEFAULT 'maintainer',
+    "last_synced"  TIMESTAMPTZ,
+    "sync_error"   TEXT      NOT NULL DEFAULT '',
+    "created"      TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (team_id, group_id),
+    CONSTRAINT fk_team_azure_groups_team
+        FOREIGN KEY (team_id)
+            REFERENCES teams (id) ON DELETE CASCADE
+);
+
+-- Members added through an Azure AD group are replaced on every sync, while members without a
+-- group are maintained by hand
+ALTER TABLE team_members
+    ADD COLUMN "azure_group_id" TEXT;
+
+-- +goose Down
+ALTER TABLE team_members
+    DROP COLUMN "azure_group_id";
+
+DROP TABLE team_azure_groups;
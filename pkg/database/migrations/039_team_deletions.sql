@@ -0,0 +1,15 @@
+-- +goose Up
+CREATE TABLE team_deletions
+(
+    "team_id"            TEXT        NOT NULL PRIMARY KEY,
+    "delete_after"       TIMESTAMPTZ NOT NULL,
+    "requested_by"       TEXT        NOT NULL,
+    "hibernated_airflow" BOOLEAN     NOT NULL DEFAULT FALSE,
+    "created"            TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_team_deletions_team
+        FOREIGN KEY (team_id)
+            REFERENCES teams (id) ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE team_deletions;
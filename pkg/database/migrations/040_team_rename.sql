@@ -0,0 +1,31 @@
+-- +goose Up
+CREATE TABLE team_history
+(
+    "id"        SERIAL PRIMARY KEY,
+    "team_id"   TEXT        NOT NULL,
+    "action"    TEXT        NOT NULL,
+    "old_value" TEXT        NOT NULL DEFAULT '',
+    "new_value" TEXT        NOT NULL DEFAULT '',
+    "actor"     TEXT        NOT NULL,
+    "created"   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_team_history_team
+        FOREIGN KEY (team_id)
+            REFERENCES teams (id) ON DELETE CASCADE
+);
+
+-- The old hostname of a renamed team keeps redirecting to the new one until the redirect expires,
+-- and the old slug can't be taken by another team in the meantime
+CREATE TABLE team_slug_redirects
+(
+    "slug"    TEXT        NOT NULL PRIMARY KEY,
+    "team_id" TEXT        NOT NULL,
+    "expires" TIMESTAMPTZ NOT NULL,
+    "created" TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_team_slug_redirects_team
+        FOREIGN KEY (team_id)
+            REFERENCES teams (id) ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE team_slug_redirects;
+DROP TABLE team_history;
@@ -0,0 +1,39 @@
+-- +goose Up
+CREATE TABLE audit_logs
+(
+    "id"          BIGSERIAL PRIMARY KEY,
+    "actor"       TEXT        NOT NULL,
+    "action"      TEXT        NOT NULL,
+    "target"      TEXT        NOT NULL DEFAULT '',
+    "team_id"     TEXT,
+    "before"      TEXT        NOT NULL DEFAULT '',
+    "after"       TEXT        NOT NULL DEFAULT '',
+    "method"      TEXT        NOT NULL,
+    "path"        TEXT        NOT NULL,
+    "status_code" INT         NOT NULL,
+    "client_ip"   TEXT        NOT NULL DEFAULT '',
+    "user_agent"  TEXT        NOT NULL DEFAULT '',
+    "created"     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX audit_logs_team_id_idx ON audit_logs (team_id, created DESC);
+CREATE INDEX audit_logs_created_idx ON audit_logs (created DESC);
+
+-- +goose StatementBegin
+CREATE FUNCTION audit_logs_append_only() RETURNS TRIGGER AS
+$$
+BEGIN
+    RAISE EXCEPTION 'audit_logs is append-only';
+END
+$$ LANGUAGE 'plpgsql';
+-- +goose StatementEnd
+
+CREATE TRIGGER audit_logs_append_only
+    BEFORE UPDATE OR DELETE OR TRUNCATE
+    ON audit_logs
+    FOR EACH STATEMENT
+EXECUTE FUNCTION audit_logs_append_only();
+
+-- +goose Down
+DROP TABLE audit_logs;
+DROP FUNCTION audit_logs_append_only;
@@ -0,0 +1,38 @@
+-- +goose Up
+CREATE TABLE notification_subscriptions
+(
+    "id"          UUID        NOT NULL DEFAULT uuid_generate_v4() PRIMARY KEY,
+    "team_id"     TEXT,
+    "sink"        TEXT        NOT NULL,
+    "target"      TEXT        NOT NULL,
+    "event_types" TEXT[]      NOT NULL DEFAULT '{}',
+    "statuses"    TEXT[]      NOT NULL,
+    "created_by"  TEXT        NOT NULL,
+    "created"     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_notification_subscriptions_team
+        FOREIGN KEY (team_id)
+            REFERENCES teams (id) ON DELETE CASCADE
+);
+
+CREATE TABLE notification_deliveries
+(
+    "id"              UUID        NOT NULL DEFAULT uuid_generate_v4() PRIMARY KEY,
+    "subscription_id" UUID        NOT NULL,
+    "payload"         JSONB       NOT NULL,
+    "attempts"        INT         NOT NULL DEFAULT 0,
+    "next_attempt"    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    "last_error"      TEXT        NOT NULL DEFAULT '',
+    "delivered"       TIMESTAMPTZ,
+    "failed"          BOOLEAN     NOT NULL DEFAULT FALSE,
+    "created"         TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_notification_deliveries_subscription
+        FOREIGN KEY (subscription_id)
+            REFERENCES notification_subscriptions (id) ON DELETE CASCADE
+);
+
+CREATE INDEX notification_deliveries_due_idx ON notification_deliveries (next_attempt)
+    WHERE delivered IS NULL AND NOT failed;
+
+-- +goose Down
+DROP TABLE notification_deliveries;
+DROP TABLE notification_subscriptions;
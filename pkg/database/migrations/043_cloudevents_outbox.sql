@@ -0,0 +1,90 @@
+-- +goose Up
+CREATE TABLE cloudevents_outbox
+(
+    "id"                  UUID        NOT NULL DEFAULT uuid_generate_v4() PRIMARY KEY,
+    "type"                TEXT        NOT NULL,
+    "subject"             TEXT        NOT NULL,
+    "data"                JSONB       NOT NULL,
+    "time"                TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    "delivered_endpoints" TEXT[]      NOT NULL DEFAULT '{}',
+    "attempts"            INT         NOT NULL DEFAULT 0,
+    "next_attempt"        TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    "last_error"          TEXT        NOT NULL DEFAULT '',
+    "delivered"           TIMESTAMPTZ,
+    "failed"              BOOLEAN     NOT NULL DEFAULT FALSE
+);
+
+CREATE INDEX cloudevents_outbox_due_idx ON cloudevents_outbox (next_attempt)
+    WHERE delivered IS NULL AND NOT failed;
+
+-- The outbox is written by triggers, so that a status change or an audit log entry is never
+-- committed without the message announcing it
+
+-- +goose StatementBegin
+CREATE FUNCTION cloudevents_outbox_event_status() RETURNS TRIGGER AS
+$$
+BEGIN
+    IF TG_OP = 'UPDATE' AND OLD.status = NEW.status THEN
+        RETURN NEW;
+    END IF;
+
+    INSERT INTO cloudevents_outbox ("type", "subject", "data")
+    VALUES ('no.nav.knorten.event.status',
+            NEW.owner,
+            jsonb_build_object(
+                    'eventId', NEW.id,
+                    'eventType', NEW.type,
+                    'team', NEW.owner,
+                    'status', NEW.status,
+                    'previousStatus', CASE WHEN TG_OP = 'UPDATE' THEN OLD.status END,
+                    'createdAt', NEW.created_at,
+                    'updatedAt', NEW.updated_at,
+                    'deadline', NEW.deadline
+            ));
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER events_cloudevents_outbox
+    AFTER INSERT OR UPDATE OF status
+    ON events
+    FOR EACH ROW
+EXECUTE PROCEDURE cloudevents_outbox_event_status();
+
+-- +goose StatementBegin
+CREATE FUNCTION cloudevents_outbox_audit_log() RETURNS TRIGGER AS
+$$
+BEGIN
+    INSERT INTO cloudevents_outbox ("type", "subject", "data", "time")
+    VALUES ('no.nav.knorten.audit',
+            COALESCE(NEW.team_id, ''),
+            jsonb_build_object(
+                    'auditId', NEW.id,
+                    'actor', NEW.actor,
+                    'action', NEW.action,
+                    'target', NEW.target,
+                    'team', NEW.team_id,
+                    'statusCode', NEW.status_code,
+                    'createdAt', NEW.created
+            ),
+            NEW.created);
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER audit_logs_cloudevents_outbox
+    AFTER INSERT
+    ON audit_logs
+    FOR EACH ROW
+EXECUTE PROCEDURE cloudevents_outbox_audit_log();
+
+-- +goose Down
+DROP TRIGGER audit_logs_cloudevents_outbox ON audit_logs;
+DROP FUNCTION cloudevents_outbox_audit_log();
+DROP TRIGGER events_cloudevents_outbox ON events;
+DROP FUNCTION cloudevents_outbox_event_status();
+DROP TABLE cloudevents_outbox;
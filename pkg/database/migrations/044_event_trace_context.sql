@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE events ADD COLUMN trace_context JSONB NOT NULL DEFAULT '{}';
+
+-- +goose Down
+ALTER TABLE events DROP COLUMN trace_context;
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE events ADD COLUMN run_after TIMESTAMPTZ;
+
+-- +goose Down
+ALTER TABLE events DROP COLUMN run_after;
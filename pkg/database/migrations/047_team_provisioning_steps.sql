@@ -0,0 +1,16 @@
+-- +goose Up
+-- The steps of creating a team which have completed, so that a retry can skip them, and a
+-- failed creation can remove exactly what was created. The team itself is saved last, so
+-- there is no foreign key to teams.
+CREATE TABLE team_provisioning_steps
+(
+    "team_id"     TEXT        NOT NULL,
+    "step"        TEXT        NOT NULL,
+    "resource"    TEXT        NOT NULL,
+    "completed"   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    "compensated" TIMESTAMPTZ,
+    PRIMARY KEY (team_id, step)
+);
+
+-- +goose Down
+DROP TABLE team_provisioning_steps;
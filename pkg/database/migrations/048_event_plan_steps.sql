@@ -0,0 +1,17 @@
+-- +goose Up
+-- What an event would have changed when knorten runs in dry run, in the order it was planned
+CREATE TABLE event_plan_steps
+(
+    "id"         BIGSERIAL PRIMARY KEY,
+    "event_id"   UUID        NOT NULL REFERENCES events (id) ON DELETE CASCADE,
+    "kind"       TEXT        NOT NULL,
+    "action"     TEXT        NOT NULL,
+    "target"     TEXT        NOT NULL,
+    "details"    JSONB       NOT NULL,
+    "created_at" TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX event_plan_steps_event_id_idx ON event_plan_steps (event_id);
+
+-- +goose Down
+DROP TABLE event_plan_steps;
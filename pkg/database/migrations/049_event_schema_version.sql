@@ -0,0 +1,7 @@
+-- +goose Up
+-- The version of the payload schema of the event type, the events registered before it was
+-- added have the first version
+ALTER TABLE events ADD COLUMN schema_version INT NOT NULL DEFAULT 1;
+
+-- +goose Down
+ALTER TABLE events DROP COLUMN schema_version;
@@ -0,0 +1,10 @@
+-- +goose Up
+-- The key a registration was made with, so that repeating the latest registration within a short
+-- window returns the event instead of creating a new one
+ALTER TABLE events ADD COLUMN idempotency_key TEXT;
+
+CREATE INDEX events_owner_type_created_at_idx ON events (owner, type, created_at);
+
+-- +goose Down
+DROP INDEX events_owner_type_created_at_idx;
+ALTER TABLE events DROP COLUMN idempotency_key;
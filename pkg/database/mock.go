@@ -5,6 +5,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/navikt/knorten/pkg/database/gensql"
+	"github.com/navikt/knorten/pkg/plan"
 )
 
 type RepoMock struct{}
@@ -33,6 +34,14 @@ func (r *RepoMock) EventLogCreate(ctx context.Context, id uuid.UUID, message str
 	return nil
 }
 
+func (r *RepoMock) EventPlanStepCreate(ctx context.Context, id uuid.UUID, step plan.Step) error {
+	return nil
+}
+
+func (r *RepoMock) EventPlanStepsDelete(ctx context.Context, id uuid.UUID) error {
+	return nil
+}
+
 func (r *RepoMock) RegisterHelmInstallOrUpgradeEvent(ctx context.Context, teamID string, helmEvent any) error {
 	return nil
 }
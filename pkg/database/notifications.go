@@ -0,0 +1,163 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/navikt/knorten/pkg/database/gensql"
+)
+
+type NotificationSink string
+
+const (
+	NotificationSinkWebhook NotificationSink = "webhook"
+	NotificationSinkSlack   NotificationSink = "slack"
+	NotificationSinkEmail   NotificationSink = "email"
+)
+
+var NotificationSinks = []NotificationSink{
+	NotificationSinkWebhook,
+	NotificationSinkSlack,
+	NotificationSinkEmail,
+}
+
+// NotificationEventTypes are the event types which can be subscribed to
+var NotificationEventTypes = []EventType{
+	EventTypeCreateTeam,
+	EventTypeUpdateTeam,
+	EventTypeRenameTeam,
+	EventTypeDeleteTeam,
+	EventTypeCreateAirflow,
+	EventTypeUpdateAirflow,
+	EventTypeDeleteAirflow,
+	EventTypeHelmRolloutAirflow,
+	EventTypeHelmRollbackAirflow,
+	EventTypeHelmUninstallAirflow,
+	EventTypeHibernateAirflow,
+	EventTypeWakeAirflow,
+	EventTypeRestartAirflowComponent,
+}
+
+// NotificationStatuses are the event statuses which can be subscribed to
+var NotificationStatuses = []EventStatus{
+	EventStatusCompleted,
+	EventStatusPending,
+	EventStatusFailed,
+	EventStatusDeadlineReached,
+	EventStatusCancelled,
+}
+
+// NotificationSubscription is a subscription to status changes of events. Without a team it is
+// for the events of every team, and without event types it is for every type of event.
+type NotificationSubscription struct {
+	TeamID     string
+	Sink       NotificationSink
+	Target     string
+	EventTypes []EventType
+	Statuses   []EventStatus
+	CreatedBy  string
+}
+
+func (r *Repo) NotificationSubscriptionCreate(ctx context.Context, subscription NotificationSubscription) error {
+	eventTypes := []string{}
+	for _, eventType := range subscription.EventTypes {
+		eventTypes = append(eventTypes, string(eventType))
+	}
+
+	statuses := []string{}
+	for _, status := range subscription.Statuses {
+		statuses = append(statuses, string(status))
+	}
+
+	return r.querier.NotificationSubscriptionCreate(ctx, gensql.NotificationSubscriptionCreateParams{
+		TeamID:     sql.NullString{String: subscription.TeamID, Valid: subscription.TeamID != ""},
+		Sink:       string(subscription.Sink),
+		Target:     subscription.Target,
+		EventTypes: eventTypes,
+		Statuses:   statuses,
+		CreatedBy:  subscription.CreatedBy,
+	})
+}
+
+func (r *Repo) NotificationSubscriptionDelete(ctx context.Context, id uuid.UUID) error {
+	return r.querier.NotificationSubscriptionDelete(ctx, id)
+}
+
+func (r *Repo) NotificationSubscriptionGet(ctx context.Context, id uuid.UUID) (gensql.NotificationSubscription, error) {
+	return r.querier.NotificationSubscriptionGet(ctx, id)
+}
+
+func (r *Repo) NotificationSubscriptionsGet(ctx context.Context) ([]gensql.NotificationSubscription, error) {
+	return r.querier.NotificationSubscriptionsGet(ctx)
+}
+
+func (r *Repo) NotificationSubscriptionsForTeamGet(ctx context.Context, teamID string) ([]gensql.NotificationSubscription, error) {
+	return r.querier.NotificationSubscriptionsForTeamGet(ctx, sql.NullString{String: teamID, Valid: true})
+}
+
+// NotificationDeliveriesCreate queues the payload for every subscription matching the status
+// change. The owner of the event is used as the team, so events owned by users only match
+// subscriptions for every team.
+func (r *Repo) NotificationDeliveriesCreate(ctx context.Context, owner string, eventType EventType, status EventStatus, payload any) (int, error) {
+	subscriptions, err := r.querier.NotificationSubscriptionsMatchingGet(ctx, gensql.NotificationSubscriptionsMatchingGetParams{
+		TeamID:    sql.NullString{String: owner, Valid: true},
+		EventType: string(eventType),
+		Status:    string(status),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if len(subscriptions) == 0 {
+		return 0, nil
+	}
+
+	jsonPayload, err := json.Marshal(payload)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, subscription := range subscriptions {
+		err := r.querier.NotificationDeliveryCreate(ctx, gensql.NotificationDeliveryCreateParams{
+			SubscriptionID: subscription.ID,
+			Payload:        jsonPayload,
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return len(subscriptions), nil
+}
+
+// NotificationDeliveriesExpiredDelete deletes the deliveries which were delivered or given up on more
+// than days ago, and returns how many were deleted
+func (r *Repo) NotificationDeliveriesExpiredDelete(ctx context.Context, days int32) (int64, error) {
+	return r.querier.NotificationDeliveriesExpiredDelete(ctx, days)
+}
+
+func (r *Repo) NotificationDeliveriesDueGet(ctx context.Context, limit int32) ([]gensql.NotificationDeliveriesDueGetRow, error) {
+	return r.querier.NotificationDeliveriesDueGet(ctx, limit)
+}
+
+func (r *Repo) NotificationDeliveredSet(ctx context.Context, id uuid.UUID) error {
+	return r.querier.NotificationDeliveredSet(ctx, id)
+}
+
+// NotificationDeliveryFailedSet records a failed attempt. The delivery is tried again at
+// nextAttempt, unless giveUp is set.
+func (r *Repo) NotificationDeliveryFailedSet(ctx context.Context, id uuid.UUID, deliveryErr error, nextAttempt time.Time, giveUp bool) error {
+	return r.querier.NotificationDeliveryFailedSet(ctx, gensql.NotificationDeliveryFailedSetParams{
+		ID:          id,
+		LastError:   deliveryErr.Error(),
+		NextAttempt: nextAttempt,
+		Failed:      giveUp,
+	})
+}
+
+func (r *Repo) NotificationDeliveriesGet(ctx context.Context, limit int32) ([]gensql.NotificationDeliveriesGetRow, error) {
+	return r.querier.NotificationDeliveriesGet(ctx, limit)
+}
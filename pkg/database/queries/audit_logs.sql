@@ -0,0 +1,23 @@
+-- name: AuditLogInsert :exec
+INSERT INTO audit_logs ("actor", "action", "target", "team_id", "before", "after", "method", "path", "status_code",
+                        "client_ip", "user_agent")
+VALUES (@actor, @action, @target, @team_id, @before, @after, @method, @path, @status_code, @client_ip, @user_agent);
+
+-- name: AuditLogsSearch :many
+SELECT *
+FROM audit_logs
+WHERE (@actor::TEXT = '' OR actor = @actor)
+  AND (@query::TEXT = ''
+    OR action ILIKE '%' || @query || '%'
+    OR target ILIKE '%' || @query || '%'
+    OR before ILIKE '%' || @query || '%'
+    OR after ILIKE '%' || @query || '%')
+ORDER BY created DESC, id DESC
+LIMIT @lim;
+
+-- name: AuditLogsForTeamGet :many
+SELECT *
+FROM audit_logs
+WHERE team_id = @team_id
+ORDER BY created DESC, id DESC
+LIMIT @lim;
@@ -0,0 +1,30 @@
+-- name: CloudEventsDueGet :many
+SELECT *
+FROM cloudevents_outbox
+WHERE delivered IS NULL
+  AND NOT failed
+  AND next_attempt <= NOW()
+ORDER BY "time"
+LIMIT @lim;
+
+-- name: CloudEventDeliveredSet :exec
+UPDATE cloudevents_outbox
+SET delivered           = NOW(),
+    delivered_endpoints = @delivered_endpoints,
+    attempts            = attempts + 1
+WHERE id = @id;
+
+-- name: CloudEventDeliveryFailedSet :exec
+UPDATE cloudevents_outbox
+SET delivered_endpoints = @delivered_endpoints,
+    attempts            = attempts + 1,
+    last_error          = @last_error,
+    next_attempt        = @next_attempt,
+    failed              = @failed
+WHERE id = @id;
+
+-- name: CloudEventsExpiredDelete :execrows
+DELETE
+FROM cloudevents_outbox
+WHERE (delivered IS NOT NULL OR failed)
+  AND "time" < NOW() - make_interval(days => @days::INT);
@@ -0,0 +1,14 @@
+-- name: EventPlanStepCreate :exec
+INSERT INTO event_plan_steps ("event_id", "kind", "action", "target", "details")
+VALUES (@event_id, @kind, @action, @target, @details);
+
+-- name: EventPlanStepsGet :many
+SELECT *
+FROM event_plan_steps
+WHERE event_id = @event_id
+ORDER BY id;
+
+-- name: EventPlanStepsDelete :exec
+DELETE
+FROM event_plan_steps
+WHERE event_id = @event_id;
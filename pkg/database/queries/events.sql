@@ -1,10 +1,35 @@
--- name: EventCreate :exec
-INSERT INTO Events (owner, type, payload, status, deadline)
+-- name: EventCreate :one
+INSERT INTO Events (owner, type, payload, status, deadline, trace_context, priority, run_after, schema_version, idempotency_key)
 VALUES (@owner,
         @type,
         @payload,
         'new',
-        @deadline);
+        @deadline,
+        @trace_context,
+        @priority,
+        sqlc.narg('run_after'),
+        @schema_version,
+        @idempotency_key)
+RETURNING *;
+
+-- name: EventIdempotencyLock :exec
+SELECT pg_advisory_xact_lock(hashtext(@lock_key::TEXT));
+
+-- name: EventLatestRegisteredGet :one
+SELECT *
+FROM Events
+WHERE owner = @owner
+  AND type = @type
+  AND created_at > NOW() - make_interval(secs => @window_seconds::INT)
+ORDER BY created_at DESC
+LIMIT 1;
+
+-- name: EventUnprocessedExists :one
+SELECT EXISTS (SELECT 1
+               FROM Events
+               WHERE owner = @owner
+                 AND type = @type
+                 AND status IN ('new', 'pending', 'processing'));
 
 -- name: EventGet :one
 SELECT *
@@ -32,11 +57,36 @@ WHERE status = 'processing';
 -- name: EventsUpcomingGet :many
 SELECT *
 FROM Events
-WHERE status = 'new'
-   OR status = 'pending'
-   OR status = 'deadline_reached'
+WHERE (status = 'new'
+    OR status = 'pending'
+    OR status = 'deadline_reached')
+  AND (run_after IS NULL OR run_after <= NOW())
 ORDER BY created_at ASC;
 
+-- name: EventsScheduledForOwnerGet :many
+SELECT *
+FROM events
+WHERE owner = @owner
+  AND status = 'new'
+  AND run_after > NOW()
+ORDER BY run_after ASC;
+
+-- name: EventReschedule :execrows
+UPDATE events
+SET run_after = @run_after
+WHERE id = @id
+  AND owner = @owner
+  AND status = 'new'
+  AND run_after > NOW();
+
+-- name: EventCancel :execrows
+UPDATE events
+SET status = 'cancelled'
+WHERE id = @id
+  AND owner = @owner
+  AND status = 'new'
+  AND run_after > NOW();
+
 -- name: EventsGetType :many
 SELECT *
 FROM Events
@@ -61,3 +111,45 @@ SELECT *
 FROM event_logs
 WHERE event_id = @id
 ORDER BY created_at DESC;
+
+-- name: EventLogsForEventsGet :many
+SELECT *
+FROM event_logs
+WHERE event_id = ANY (@ids::UUID[])
+ORDER BY created_at DESC;
+
+-- name: EventLogsForOwnersSinceGet :many
+SELECT el.*
+FROM event_logs el
+         JOIN events e ON e.id = el.event_id
+WHERE e.owner = ANY (@owners::TEXT[])
+  AND el.created_at > @since
+ORDER BY el.created_at;
+
+-- name: EventsForOwnersUpdatedSinceGet :many
+SELECT *
+FROM events
+WHERE owner = ANY (@owners::TEXT[])
+  AND updated_at > @since
+ORDER BY updated_at;
+
+-- name: EventsClockGet :one
+SELECT LOCALTIMESTAMP::TIMESTAMP AS now;
+
+-- name: EventsExpiredGet :many
+SELECT *
+FROM events
+WHERE (status IN ('completed', 'cancelled') AND updated_at < NOW() - make_interval(days => @completed_days::INT))
+   OR (status IN ('failed', 'manual_failed') AND updated_at < NOW() - make_interval(days => @failed_days::INT))
+ORDER BY updated_at
+LIMIT @lim;
+
+-- name: EventsDelete :exec
+DELETE
+FROM events
+WHERE id = ANY (@ids::UUID[]);
+
+-- name: EventCountsGet :many
+SELECT status, type, COUNT(*)::INT AS count
+FROM events
+GROUP BY status, type;
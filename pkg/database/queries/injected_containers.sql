@@ -0,0 +1,59 @@
+-- name: InjectedContainerInsert :exec
+INSERT INTO injected_containers ("name",
+                                 "version",
+                                 "chart_type",
+                                 "container",
+                                 "volumes",
+                                 "enabled",
+                                 "opt_out_allowed",
+                                 "created_by")
+VALUES (@name,
+        (SELECT COALESCE(MAX("version"), 0) + 1
+         FROM injected_containers
+         WHERE "name" = @name
+           AND chart_type = @chart_type),
+        @chart_type,
+        @container,
+        @volumes,
+        @enabled,
+        @opt_out_allowed,
+        @created_by);
+
+-- name: InjectedContainersGet :many
+SELECT DISTINCT ON ("name") *
+FROM injected_containers
+WHERE chart_type = @chart_type
+ORDER BY "name", "version" DESC;
+
+-- name: InjectedContainerGet :one
+SELECT *
+FROM injected_containers
+WHERE chart_type = @chart_type
+  AND "name" = @name
+ORDER BY "version" DESC
+LIMIT 1;
+
+-- name: InjectedContainerVersionsGet :many
+SELECT *
+FROM injected_containers
+WHERE chart_type = @chart_type
+  AND "name" = @name
+ORDER BY "version" DESC;
+
+-- name: InjectedContainerOptOutsGet :many
+SELECT "name"
+FROM injected_container_opt_outs
+WHERE team_id = @team_id
+  AND chart_type = @chart_type;
+
+-- name: InjectedContainerOptOutInsert :exec
+INSERT INTO injected_container_opt_outs ("team_id", "chart_type", "name")
+VALUES (@team_id, @chart_type, @name)
+ON CONFLICT DO NOTHING;
+
+-- name: InjectedContainerOptOutDelete :exec
+DELETE
+FROM injected_container_opt_outs
+WHERE team_id = @team_id
+  AND chart_type = @chart_type
+  AND "name" = @name;
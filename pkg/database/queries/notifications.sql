@@ -0,0 +1,72 @@
+-- name: NotificationSubscriptionCreate :exec
+INSERT INTO notification_subscriptions ("team_id", "sink", "target", "event_types", "statuses", "created_by")
+VALUES (@team_id, @sink, @target, @event_types, @statuses, @created_by);
+
+-- name: NotificationSubscriptionDelete :exec
+DELETE
+FROM notification_subscriptions
+WHERE id = @id;
+
+-- name: NotificationSubscriptionGet :one
+SELECT *
+FROM notification_subscriptions
+WHERE id = @id;
+
+-- name: NotificationSubscriptionsGet :many
+SELECT *
+FROM notification_subscriptions
+ORDER BY team_id NULLS FIRST, created;
+
+-- name: NotificationSubscriptionsForTeamGet :many
+SELECT *
+FROM notification_subscriptions
+WHERE team_id = @team_id
+ORDER BY created;
+
+-- name: NotificationSubscriptionsMatchingGet :many
+SELECT *
+FROM notification_subscriptions
+WHERE (team_id = @team_id OR team_id IS NULL)
+  AND (cardinality(event_types) = 0 OR @event_type::TEXT = ANY (event_types))
+  AND @status::TEXT = ANY (statuses);
+
+-- name: NotificationDeliveryCreate :exec
+INSERT INTO notification_deliveries ("subscription_id", "payload")
+VALUES (@subscription_id, @payload);
+
+-- name: NotificationDeliveriesDueGet :many
+SELECT d.*, s.sink, s.target
+FROM notification_deliveries d
+         JOIN notification_subscriptions s ON s.id = d.subscription_id
+WHERE d.delivered IS NULL
+  AND NOT d.failed
+  AND d.next_attempt <= NOW()
+ORDER BY d.next_attempt
+LIMIT @lim;
+
+-- name: NotificationDeliveredSet :exec
+UPDATE notification_deliveries
+SET delivered = NOW(),
+    attempts  = attempts + 1
+WHERE id = @id;
+
+-- name: NotificationDeliveryFailedSet :exec
+UPDATE notification_deliveries
+SET attempts     = attempts + 1,
+    last_error   = @last_error,
+    next_attempt = @next_attempt,
+    failed       = @failed
+WHERE id = @id;
+
+-- name: NotificationDeliveriesGet :many
+SELECT d.*, s.sink, s.target, s.team_id
+FROM notification_deliveries d
+         JOIN notification_subscriptions s ON s.id = d.subscription_id
+ORDER BY d.created DESC
+LIMIT @lim;
+
+-- name: NotificationDeliveriesExpiredDelete :execrows
+DELETE
+FROM notification_deliveries
+WHERE (delivered IS NOT NULL OR failed)
+  AND created < NOW() - make_interval(days => @days::INT);
@@ -25,3 +25,8 @@ AND expires > now();
 UPDATE "sessions"
 SET "expires" = NOW()
 WHERE token = @token;
+
+-- name: SessionsExpiredDelete :execrows
+DELETE
+FROM "sessions"
+WHERE expires < NOW();
@@ -0,0 +1,30 @@
+-- name: TeamAzureGroupsGet :many
+SELECT *
+FROM team_azure_groups
+WHERE team_id = @team_id
+ORDER BY display_name;
+
+-- name: TeamAzureGroupUpsert :exec
+INSERT INTO team_azure_groups ("team_id", "group_id", "display_name", "role")
+VALUES (@team_id, @group_id, @display_name, @role)
+ON CONFLICT (team_id, group_id) DO UPDATE
+    SET display_name = EXCLUDED.display_name,
+        role         = EXCLUDED.role;
+
+-- name: TeamAzureGroupDelete :exec
+DELETE
+FROM team_azure_groups
+WHERE team_id = @team_id
+  AND group_id = @group_id;
+
+-- name: TeamAzureGroupSyncedSet :exec
+UPDATE team_azure_groups
+SET last_synced = NOW(),
+    sync_error  = @sync_error
+WHERE team_id = @team_id
+  AND group_id = @group_id;
+
+-- name: TeamsWithAzureGroupsGet :many
+SELECT DISTINCT team_id
+FROM team_azure_groups
+ORDER BY team_id;
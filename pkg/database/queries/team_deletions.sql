@@ -0,0 +1,39 @@
+-- name: TeamDeletionInsert :exec
+INSERT INTO team_deletions ("team_id", "delete_after", "requested_by", "hibernated_airflow")
+VALUES (@team_id, @delete_after, @requested_by, @hibernated_airflow);
+
+-- name: TeamDeletionGet :one
+SELECT *
+FROM team_deletions
+WHERE team_id = @team_id;
+
+-- name: TeamDeletionDelete :exec
+DELETE
+FROM team_deletions
+WHERE team_id = @team_id;
+
+-- name: TeamDeletionsGet :many
+SELECT d.*, t.slug
+FROM team_deletions d
+         JOIN teams t ON t.id = d.team_id
+ORDER BY d.delete_after;
+
+-- name: TeamDeletionsDueGet :many
+SELECT d.team_id
+FROM team_deletions d
+WHERE d.delete_after <= NOW()
+  AND NOT EXISTS (SELECT 1
+                  FROM events e
+                  WHERE e.owner = d.team_id
+                    AND e.type = 'delete:team'
+                    AND (e.status IN ('new', 'processing', 'pending', 'deadline_reached')
+                      OR (e.status IN ('failed', 'manual_failed') AND e.created_at >= d.created)));
+
+-- name: TeamDeletionFailed :one
+SELECT EXISTS (SELECT 1
+               FROM team_deletions d
+                        JOIN events e ON e.owner = d.team_id
+               WHERE d.team_id = @team_id
+                 AND e.type = 'delete:team'
+                 AND e.status IN ('failed', 'manual_failed')
+                 AND e.created_at >= d.created);
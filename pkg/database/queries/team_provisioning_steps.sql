@@ -0,0 +1,24 @@
+-- name: TeamProvisioningStepsGet :many
+SELECT *
+FROM team_provisioning_steps
+WHERE team_id = @team_id
+ORDER BY completed;
+
+-- name: TeamProvisioningStepComplete :exec
+INSERT INTO team_provisioning_steps ("team_id", "step", "resource")
+VALUES (@team_id, @step, @resource)
+ON CONFLICT (team_id, step) DO UPDATE
+    SET resource    = EXCLUDED.resource,
+        completed   = NOW(),
+        compensated = NULL;
+
+-- name: TeamProvisioningStepCompensatedSet :exec
+UPDATE team_provisioning_steps
+SET compensated = NOW()
+WHERE team_id = @team_id
+  AND step = @step;
+
+-- name: TeamProvisioningStepsDelete :exec
+DELETE
+FROM team_provisioning_steps
+WHERE team_id = @team_id;
@@ -0,0 +1,38 @@
+-- name: TeamHistoryInsert :exec
+INSERT INTO team_history ("team_id", "action", "old_value", "new_value", "actor")
+VALUES (@team_id, @action, @old_value, @new_value, @actor);
+
+-- name: TeamHistoryGet :many
+SELECT *
+FROM team_history
+WHERE team_id = @team_id
+ORDER BY created DESC;
+
+-- name: TeamSlugRedirectUpsert :exec
+INSERT INTO team_slug_redirects ("slug", "team_id", "expires")
+VALUES (@slug, @team_id, @expires)
+ON CONFLICT (slug) DO UPDATE
+    SET expires = EXCLUDED.expires;
+
+-- name: TeamSlugRedirectGet :one
+SELECT *
+FROM team_slug_redirects
+WHERE slug = @slug;
+
+-- name: TeamSlugRedirectDelete :exec
+DELETE
+FROM team_slug_redirects
+WHERE slug = @slug;
+
+-- name: TeamSlugRedirectsGet :many
+SELECT *
+FROM team_slug_redirects
+WHERE team_id = @team_id
+  AND expires > NOW()
+ORDER BY slug;
+
+-- name: TeamSlugRedirectsExpiredGet :many
+SELECT *
+FROM team_slug_redirects
+WHERE expires <= NOW()
+ORDER BY slug;
@@ -8,9 +8,10 @@ SET users = @users
 WHERE id = @id;
 
 -- name: TeamsForUserGet :many
-SELECT id, slug
-FROM teams
-WHERE @email::TEXT = ANY ("users");
+SELECT t.id, t.slug, m.role
+FROM teams t
+         JOIN team_members m ON m.team_id = t.id
+WHERE m.email = LOWER(@email::TEXT);
 
 -- name: TeamGet :one
 SELECT id, users, slug
@@ -31,3 +32,40 @@ WHERE id = @id;
 select *
 from teams
 ORDER BY slug;
+
+-- name: TeamMembersGet :many
+SELECT email, role, azure_group_id
+FROM team_members
+WHERE team_id = @team_id
+ORDER BY role, email;
+
+-- name: TeamMemberRoleGet :one
+SELECT role
+FROM team_members
+WHERE team_id = @team_id
+  AND email = LOWER(@email::TEXT);
+
+-- name: TeamMemberInsert :exec
+INSERT INTO team_members ("team_id", "email", "role", "azure_group_id")
+VALUES (@team_id, @email, @role, @azure_group_id);
+
+-- name: TeamMembersDelete :exec
+DELETE
+FROM team_members
+WHERE team_id = @team_id;
+
+-- name: TeamSlugUpdate :exec
+UPDATE teams
+SET slug = @slug
+WHERE id = @id;
+
+-- name: TeamIDsInUseGet :many
+SELECT id
+FROM teams
+UNION
+SELECT team_id
+FROM team_provisioning_steps
+UNION
+SELECT owner
+FROM events
+WHERE status IN ('new', 'processing', 'pending', 'deadline_reached');
@@ -14,6 +14,7 @@ import (
 	_ "github.com/lib/pq"
 	"github.com/navikt/knorten/pkg/database/crypto"
 	"github.com/navikt/knorten/pkg/database/gensql"
+	"github.com/navikt/knorten/pkg/plan"
 	"github.com/pressly/goose/v3"
 	"github.com/sirupsen/logrus"
 
@@ -30,6 +31,8 @@ type Repository interface {
 	DispatchableEventsGet(context.Context) ([]gensql.Event, error)
 	EventsReset(context.Context) error
 	EventLogCreate(context.Context, uuid.UUID, string, LogType) error
+	EventPlanStepCreate(context.Context, uuid.UUID, plan.Step) error
+	EventPlanStepsDelete(context.Context, uuid.UUID) error
 }
 
 type Repo struct {
@@ -56,7 +59,7 @@ func New(dbConnDSN, cryptoKey string, log *logrus.Entry) (*Repo, error) {
 	}
 
 	return &Repo{
-		querier:     gensql.New(db),
+		querier:     newTracedQueries(db),
 		db:          db,
 		cryptClient: crypto.New(cryptoKey),
 		log:         log,
@@ -98,6 +101,11 @@ func (r *Repo) NewSessionStore(key string) (gin.HandlerFunc, error) {
 	return sessions.Sessions("session", store), nil
 }
 
+// Ping verifies that the database is reachable
+func (r *Repo) Ping(ctx context.Context) error {
+	return r.db.PingContext(ctx)
+}
+
 func (r *Repo) DecryptValue(encValue string) (string, error) {
 	return r.cryptClient.DecryptValue(encValue)
 }
@@ -5,25 +5,37 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/navikt/knorten/pkg/database/gensql"
 	"github.com/navikt/knorten/pkg/k8s"
 	"golang.org/x/exp/slices"
 )
 
+const (
+	// TeamValueKeyHibernated is "true" when a team's Airflow is scaled down and its database hibernated.
+	TeamValueKeyHibernated = "hibernated,omit"
+	// TeamValueKeyLastActivity is the last time (RFC 3339) a team's Airflow ran a DAG, or was woken.
+	TeamValueKeyLastActivity = "lastActivity,omit"
+)
+
 type AppService struct {
 	App             string
 	Ingress         string
 	Slug            string
 	Namespace       string
 	IsSchedulerDown bool
+	IsHibernated    bool
+	Role            gensql.TeamRole
 }
 
 type TeamServices struct {
-	TeamID  string
-	Slug    string
-	Airflow *AppService
-	Events  []EventWithLogs
+	TeamID      string
+	Slug        string
+	Role        gensql.TeamRole
+	Airflow     *AppService
+	Events      []EventWithLogs
+	DeleteAfter *time.Time
 }
 
 type UserServices struct {
@@ -99,13 +111,28 @@ func (r *Repo) ServicesForUser(
 		teamServices := TeamServices{
 			TeamID: team.ID,
 			Slug:   team.Slug,
+			Role:   team.Role,
 			Events: events,
 		}
 
+		deletion, err := r.querier.TeamDeletionGet(ctx, team.ID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return UserServices{}, err
+		}
+		if err == nil {
+			teamServices.DeleteAfter = &deletion.DeleteAfter
+		}
+
 		for _, app := range apps {
 			switch app {
 			case gensql.ChartTypeAirflow:
 				teamServices.Airflow = createAppService(team, app, topLevelDomain)
+				teamServices.Airflow.Role = team.Role
+
+				teamServices.Airflow.IsHibernated, err = r.AirflowIsHibernated(ctx, team.ID)
+				if err != nil {
+					return UserServices{}, err
+				}
 			}
 		}
 
@@ -135,6 +162,22 @@ func (r *Repo) ServicesForUser(
 	return userServices, nil
 }
 
+func (r *Repo) AirflowIsHibernated(ctx context.Context, teamID string) (bool, error) {
+	value, err := r.querier.TeamValueGet(ctx, gensql.TeamValueGetParams{
+		Key:    TeamValueKeyHibernated,
+		TeamID: teamID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return value.Value == "true", nil
+}
+
 func (r *Repo) TeamValueInsert(
 	ctx context.Context,
 	chartType gensql.ChartType,
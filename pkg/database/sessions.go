@@ -49,3 +49,9 @@ func (r *Repo) SessionDelete(ctx context.Context, token string) error {
 
 	return nil
 }
+
+// SessionsExpiredDelete deletes the sessions which have expired, or been logged out of, and returns
+// how many were deleted
+func (r *Repo) SessionsExpiredDelete(ctx context.Context) (int64, error) {
+	return r.querier.SessionsExpiredDelete(ctx)
+}
@@ -0,0 +1,122 @@
+package database
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/navikt/knorten/pkg/database/gensql"
+)
+
+func (r *Repo) TeamAzureGroupsGet(ctx context.Context, teamID string) ([]gensql.TeamAzureGroup, error) {
+	return r.querier.TeamAzureGroupsGet(ctx, teamID)
+}
+
+// TeamAzureGroupSet links the Azure AD group to the team, or updates the role given to the
+// members of an already linked group
+func (r *Repo) TeamAzureGroupSet(ctx context.Context, teamID, groupID, displayName string, role gensql.TeamRole) error {
+	return r.querier.TeamAzureGroupUpsert(ctx, gensql.TeamAzureGroupUpsertParams{
+		TeamID:      teamID,
+		GroupID:     groupID,
+		DisplayName: displayName,
+		Role:        role,
+	})
+}
+
+func (r *Repo) TeamAzureGroupDelete(ctx context.Context, teamID, groupID string) error {
+	return r.querier.TeamAzureGroupDelete(ctx, gensql.TeamAzureGroupDeleteParams{
+		TeamID:  teamID,
+		GroupID: groupID,
+	})
+}
+
+// TeamAzureGroupSyncedSet records when the group was last synced, and why it failed if it did
+func (r *Repo) TeamAzureGroupSyncedSet(ctx context.Context, teamID, groupID string, syncErr error) error {
+	errMsg := ""
+	if syncErr != nil {
+		errMsg = syncErr.Error()
+	}
+
+	return r.querier.TeamAzureGroupSyncedSet(ctx, gensql.TeamAzureGroupSyncedSetParams{
+		TeamID:    teamID,
+		GroupID:   groupID,
+		SyncError: errMsg,
+	})
+}
+
+func (r *Repo) TeamsWithAzureGroupsGet(ctx context.Context) ([]string, error) {
+	return r.querier.TeamsWithAzureGroupsGet(ctx)
+}
+
+// ManualTeamMembers returns the members which were not added through an Azure AD group
+func ManualTeamMembers(members []TeamMember) []TeamMember {
+	manual := []TeamMember{}
+	for _, member := range members {
+		if member.AzureGroupID == "" {
+			manual = append(manual, member)
+		}
+	}
+
+	return manual
+}
+
+// AzureGroupTeamMembers returns the members which were added through an Azure AD group
+func AzureGroupTeamMembers(members []TeamMember) []TeamMember {
+	synced := []TeamMember{}
+	for _, member := range members {
+		if member.AzureGroupID != "" {
+			synced = append(synced, member)
+		}
+	}
+
+	return synced
+}
+
+// WithAzureGroupMembers adds the members synced from Azure AD groups to the members maintained by
+// hand. Members added by hand keep their role, and a user in several groups gets the most
+// privileged role of those groups.
+func WithAzureGroupMembers(manual, synced []TeamMember) []TeamMember {
+	members := slices.Clone(manual)
+	index := map[string]int{}
+	for i, member := range members {
+		index[member.Email] = i
+	}
+
+	for _, member := range synced {
+		member.Email = strings.ToLower(member.Email)
+
+		i, ok := index[member.Email]
+		if !ok {
+			index[member.Email] = len(members)
+			members = append(members, member)
+			continue
+		}
+
+		existing := members[i]
+		if existing.AzureGroupID != "" && !TeamRoleAllows(existing.Role, member.Role) {
+			members[i] = member
+		}
+	}
+
+	return members
+}
+
+// SameTeamMembers checks if the members and their roles are equal, regardless of order
+func SameTeamMembers(a, b []TeamMember) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	byEmail := map[string]TeamMember{}
+	for _, member := range a {
+		byEmail[member.Email] = member
+	}
+
+	for _, member := range b {
+		if existing, ok := byEmail[member.Email]; !ok || existing != member {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,41 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/navikt/knorten/pkg/database/gensql"
+)
+
+func TestWithAzureGroupMembers(t *testing.T) {
+	manual := []TeamMember{
+		{Email: "owner@nav.no", Role: gensql.TeamRoleOwner},
+		{Email: "viewer@nav.no", Role: gensql.TeamRoleViewer},
+	}
+
+	synced := []TeamMember{
+		{Email: "Viewer@nav.no", Role: gensql.TeamRoleMaintainer, AzureGroupID: "group-a"},
+		{Email: "both@nav.no", Role: gensql.TeamRoleViewer, AzureGroupID: "group-a"},
+		{Email: "both@nav.no", Role: gensql.TeamRoleMaintainer, AzureGroupID: "group-b"},
+		{Email: "both@nav.no", Role: gensql.TeamRoleViewer, AzureGroupID: "group-c"},
+	}
+
+	expect := []TeamMember{
+		{Email: "owner@nav.no", Role: gensql.TeamRoleOwner},
+		{Email: "viewer@nav.no", Role: gensql.TeamRoleViewer},
+		{Email: "both@nav.no", Role: gensql.TeamRoleMaintainer, AzureGroupID: "group-b"},
+	}
+
+	got := WithAzureGroupMembers(manual, synced)
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Errorf("WithAzureGroupMembers(): mismatch (-want +got):\n%s", diff)
+	}
+
+	if !SameTeamMembers(expect, []TeamMember{expect[2], expect[0], expect[1]}) {
+		t.Errorf("SameTeamMembers(): expected members in another order to be the same")
+	}
+
+	if diff := cmp.Diff(manual, ManualTeamMembers(got)); diff != "" {
+		t.Errorf("ManualTeamMembers(): mismatch (-want +got):\n%s", diff)
+	}
+}
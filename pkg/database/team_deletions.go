@@ -0,0 +1,44 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/navikt/knorten/pkg/database/gensql"
+)
+
+// TeamDeletionInsert marks the team as pending deletion. The team is purged after deleteAfter,
+// unless the deletion is removed before then.
+func (r *Repo) TeamDeletionInsert(ctx context.Context, teamID, requestedBy string, deleteAfter time.Time, hibernatedAirflow bool) error {
+	return r.querier.TeamDeletionInsert(ctx, gensql.TeamDeletionInsertParams{
+		TeamID:            teamID,
+		DeleteAfter:       deleteAfter,
+		RequestedBy:       requestedBy,
+		HibernatedAirflow: hibernatedAirflow,
+	})
+}
+
+// TeamDeletionGet returns sql.ErrNoRows if the team isn't pending deletion
+func (r *Repo) TeamDeletionGet(ctx context.Context, teamID string) (gensql.TeamDeletion, error) {
+	return r.querier.TeamDeletionGet(ctx, teamID)
+}
+
+func (r *Repo) TeamDeletionDelete(ctx context.Context, teamID string) error {
+	return r.querier.TeamDeletionDelete(ctx, teamID)
+}
+
+func (r *Repo) TeamDeletionsGet(ctx context.Context) ([]gensql.TeamDeletionsGetRow, error) {
+	return r.querier.TeamDeletionsGet(ctx)
+}
+
+// TeamDeletionsDueGet lists the teams where the grace period is over, and the deletion hasn't
+// been registered yet. Teams where the deletion failed are left for an admin to look at, instead
+// of being retried on every run.
+func (r *Repo) TeamDeletionsDueGet(ctx context.Context) ([]string, error) {
+	return r.querier.TeamDeletionsDueGet(ctx)
+}
+
+// TeamDeletionFailed is true when the team was marked for deletion, and the deletion failed
+func (r *Repo) TeamDeletionFailed(ctx context.Context, teamID string) (bool, error) {
+	return r.querier.TeamDeletionFailed(ctx, teamID)
+}
@@ -0,0 +1,86 @@
+package database
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/navikt/knorten/pkg/database/gensql"
+)
+
+func TestRepo_TeamDeletionsDueGet(t *testing.T) {
+	ctx := context.Background()
+
+	due := gensql.Team{ID: "deletion-due-1234", Slug: "deletion-due", Users: []string{"user@nav.no"}}
+	later := gensql.Team{ID: "deletion-later-1234", Slug: "deletion-later", Users: []string{"user@nav.no"}}
+
+	for _, team := range []gensql.Team{due, later} {
+		if err := repo.TeamCreate(ctx, &team); err != nil {
+			t.Fatal(err)
+		}
+
+		t.Cleanup(func() {
+			if err := repo.TeamDelete(ctx, team.ID); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+
+	if err := repo.TeamDeletionInsert(ctx, due.ID, "user@nav.no", time.Now().Add(-time.Minute), false); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := repo.TeamDeletionInsert(ctx, later.ID, "user@nav.no", time.Now().Add(time.Hour), false); err != nil {
+		t.Fatal(err)
+	}
+
+	teams, err := repo.TeamDeletionsDueGet(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Contains(teams, due.ID) || slices.Contains(teams, later.ID) {
+		t.Errorf("TeamDeletionsDueGet(): expected only %v to be due, got %v", due.ID, teams)
+	}
+
+	if err := repo.RegisterDeleteTeamEvent(ctx, due.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	teams, err = repo.TeamDeletionsDueGet(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if slices.Contains(teams, due.ID) {
+		t.Errorf("TeamDeletionsDueGet(): expected %v to be left out once the delete event is registered", due.ID)
+	}
+
+	events, err := repo.EventsByOwnerGet(ctx, due.ID, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := repo.EventSetStatus(ctx, events[0].ID, EventStatusFailed); err != nil {
+		t.Fatal(err)
+	}
+
+	teams, err = repo.TeamDeletionsDueGet(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if slices.Contains(teams, due.ID) {
+		t.Errorf("TeamDeletionsDueGet(): expected %v to be left out after the deletion failed", due.ID)
+	}
+
+	failed, err := repo.TeamDeletionFailed(ctx, due.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !failed {
+		t.Errorf("TeamDeletionFailed(): expected the deletion of %v to have failed", due.ID)
+	}
+}
@@ -0,0 +1,34 @@
+package database
+
+import (
+	"context"
+
+	"github.com/navikt/knorten/pkg/database/gensql"
+)
+
+// TeamProvisioningStepsGet returns the steps of creating the team which have completed, in the
+// order they completed
+func (r *Repo) TeamProvisioningStepsGet(ctx context.Context, teamID string) ([]gensql.TeamProvisioningStep, error) {
+	return r.querier.TeamProvisioningStepsGet(ctx, teamID)
+}
+
+// TeamProvisioningStepComplete records that the step created the resource
+func (r *Repo) TeamProvisioningStepComplete(ctx context.Context, teamID, step, resource string) error {
+	return r.querier.TeamProvisioningStepComplete(ctx, gensql.TeamProvisioningStepCompleteParams{
+		TeamID:   teamID,
+		Step:     step,
+		Resource: resource,
+	})
+}
+
+// TeamProvisioningStepCompensatedSet records that what the step created has been removed
+func (r *Repo) TeamProvisioningStepCompensatedSet(ctx context.Context, teamID, step string) error {
+	return r.querier.TeamProvisioningStepCompensatedSet(ctx, gensql.TeamProvisioningStepCompensatedSetParams{
+		TeamID: teamID,
+		Step:   step,
+	})
+}
+
+func (r *Repo) TeamProvisioningStepsDelete(ctx context.Context, teamID string) error {
+	return r.querier.TeamProvisioningStepsDelete(ctx, teamID)
+}
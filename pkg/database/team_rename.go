@@ -0,0 +1,91 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/navikt/knorten/pkg/database/gensql"
+)
+
+// TeamHistoryActionRename is the team history action recorded when the slug of a team changes
+const TeamHistoryActionRename = "rename"
+
+// TeamRename changes the slug of the team and records it in the team history. The old slug
+// redirects to the new one until redirectUntil, and a redirect from the new slug is removed in
+// case the team is renamed back.
+func (r *Repo) TeamRename(ctx context.Context, teamID, oldSlug, newSlug, actor string, redirectUntil time.Time) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	querier := r.querier.WithTx(tx)
+	err = querier.TeamSlugUpdate(ctx, gensql.TeamSlugUpdateParams{
+		ID:   teamID,
+		Slug: newSlug,
+	})
+	if err != nil {
+		if err := tx.Rollback(); err != nil {
+			r.log.WithError(err).Error("rolling back team rename transaction")
+		}
+		return err
+	}
+
+	err = querier.TeamHistoryInsert(ctx, gensql.TeamHistoryInsertParams{
+		TeamID:   teamID,
+		Action:   TeamHistoryActionRename,
+		OldValue: oldSlug,
+		NewValue: newSlug,
+		Actor:    actor,
+	})
+	if err != nil {
+		if err := tx.Rollback(); err != nil {
+			r.log.WithError(err).Error("rolling back team rename transaction - history insert")
+		}
+		return err
+	}
+
+	err = querier.TeamSlugRedirectDelete(ctx, newSlug)
+	if err != nil {
+		if err := tx.Rollback(); err != nil {
+			r.log.WithError(err).Error("rolling back team rename transaction - redirect delete")
+		}
+		return err
+	}
+
+	err = querier.TeamSlugRedirectUpsert(ctx, gensql.TeamSlugRedirectUpsertParams{
+		Slug:    oldSlug,
+		TeamID:  teamID,
+		Expires: redirectUntil,
+	})
+	if err != nil {
+		if err := tx.Rollback(); err != nil {
+			r.log.WithError(err).Error("rolling back team rename transaction - redirect insert")
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *Repo) TeamHistoryGet(ctx context.Context, teamID string) ([]gensql.TeamHistory, error) {
+	return r.querier.TeamHistoryGet(ctx, teamID)
+}
+
+// TeamSlugRedirectGet returns sql.ErrNoRows if no team has been renamed from the slug
+func (r *Repo) TeamSlugRedirectGet(ctx context.Context, slug string) (gensql.TeamSlugRedirect, error) {
+	return r.querier.TeamSlugRedirectGet(ctx, slug)
+}
+
+func (r *Repo) TeamSlugRedirectDelete(ctx context.Context, slug string) error {
+	return r.querier.TeamSlugRedirectDelete(ctx, slug)
+}
+
+// TeamSlugRedirectsGet lists the old slugs of the team which still redirect to the current one
+func (r *Repo) TeamSlugRedirectsGet(ctx context.Context, teamID string) ([]gensql.TeamSlugRedirect, error) {
+	return r.querier.TeamSlugRedirectsGet(ctx, teamID)
+}
+
+func (r *Repo) TeamSlugRedirectsExpiredGet(ctx context.Context) ([]gensql.TeamSlugRedirect, error) {
+	return r.querier.TeamSlugRedirectsExpiredGet(ctx)
+}
@@ -0,0 +1,81 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/navikt/knorten/pkg/database/gensql"
+)
+
+func TestRepo_TeamRename(t *testing.T) {
+	ctx := context.Background()
+
+	team := gensql.Team{ID: "rename-1234", Slug: "rename", Users: []string{"user@nav.no"}}
+	if err := repo.TeamCreate(ctx, &team); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		if err := repo.TeamDelete(ctx, team.ID); err != nil {
+			t.Error(err)
+		}
+	})
+
+	if err := repo.TeamRename(ctx, team.ID, "rename", "renamed", "user@nav.no", time.Now().Add(-time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	renamed, err := repo.TeamGet(ctx, team.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if renamed.Slug != "renamed" {
+		t.Errorf("TeamRename(): expected slug renamed, got %v", renamed.Slug)
+	}
+
+	redirects, err := repo.TeamSlugRedirectsGet(ctx, team.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(redirects) != 0 {
+		t.Errorf("TeamSlugRedirectsGet(): expected expired redirects to be left out, got %v", redirects)
+	}
+
+	expired, err := repo.TeamSlugRedirectsExpiredGet(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(expired) != 1 || expired[0].Slug != "rename" {
+		t.Errorf("TeamSlugRedirectsExpiredGet(): expected the redirect from rename, got %v", expired)
+	}
+
+	if err := repo.TeamRename(ctx, team.ID, "renamed", "rename-again", "user@nav.no", time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	redirects, err = repo.TeamSlugRedirectsGet(ctx, team.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(redirects) != 1 || redirects[0].Slug != "renamed" {
+		t.Errorf("TeamSlugRedirectsGet(): expected the redirect from renamed, got %v", redirects)
+	}
+
+	history, err := repo.TeamHistoryGet(ctx, team.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(history) != 2 {
+		t.Fatalf("TeamHistoryGet(): expected 2 renames, got %v", history)
+	}
+
+	if history[0].OldValue != "renamed" || history[0].NewValue != "rename-again" {
+		t.Errorf("TeamHistoryGet(): expected the latest rename first, got %v", history[0])
+	}
+}
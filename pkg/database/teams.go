@@ -4,27 +4,242 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"slices"
 	"strings"
 
 	"github.com/navikt/knorten/pkg/database/gensql"
 	"github.com/navikt/knorten/pkg/reflect"
 )
 
-func (r *Repo) TeamCreate(ctx context.Context, team *gensql.Team) error {
-	return r.querier.TeamCreate(ctx, gensql.TeamCreateParams{
+// TeamMember is a member of a team, and the role which decides what the member is allowed to do.
+// Members synced from an Azure AD group have the id of the group, and are replaced on every sync.
+type TeamMember struct {
+	Email        string
+	Role         gensql.TeamRole
+	AzureGroupID string `json:",omitempty"`
+}
+
+// Team is the payload of team events. The users column on the teams table lists every member,
+// while the roles are stored in team_members.
+type Team struct {
+	gensql.Team
+	Members []TeamMember
+}
+
+// TeamRoles lists the roles from most to least privileged
+var TeamRoles = []gensql.TeamRole{
+	gensql.TeamRoleOwner,
+	gensql.TeamRoleMaintainer,
+	gensql.TeamRoleViewer,
+}
+
+// NewTeam creates a team where the given emails are owners
+func NewTeam(team gensql.Team) Team {
+	return Team{Team: team, Members: membersAsOwners(team.Users)}
+}
+
+// EmailsWithRole lists the members having one of the roles
+func (t Team) EmailsWithRole(roles ...gensql.TeamRole) []string {
+	emails := []string{}
+	for _, member := range t.members() {
+		if slices.Contains(roles, member.Role) {
+			emails = append(emails, member.Email)
+		}
+	}
+
+	return emails
+}
+
+// members falls back to making every user an owner, for events registered before roles existed
+func (t Team) members() []TeamMember {
+	if len(t.Members) == 0 {
+		return membersAsOwners(t.Users)
+	}
+
+	members := make([]TeamMember, 0, len(t.Members))
+	for _, member := range t.Members {
+		members = append(members, TeamMember{
+			Email:        strings.ToLower(member.Email),
+			Role:         member.Role,
+			AzureGroupID: member.AzureGroupID,
+		})
+	}
+
+	return members
+}
+
+// SetMembers replaces the members, and lists them as the users of the team
+func (t *Team) SetMembers(members []TeamMember) {
+	t.Members = members
+	t.Users = memberEmails(members)
+}
+
+func membersAsOwners(users []string) []TeamMember {
+	members := []TeamMember{}
+	for _, user := range users {
+		members = append(members, TeamMember{Email: strings.ToLower(user), Role: gensql.TeamRoleOwner})
+	}
+
+	return members
+}
+
+// TeamRoleAllows checks if the role is at least as privileged as the required role
+func TeamRoleAllows(role, required gensql.TeamRole) bool {
+	rank := map[gensql.TeamRole]int{
+		gensql.TeamRoleViewer:     1,
+		gensql.TeamRoleMaintainer: 2,
+		gensql.TeamRoleOwner:      3,
+	}
+
+	return rank[role] >= rank[required]
+}
+
+// TeamCreate creates the team and its members. Without members, every user becomes an owner.
+func (r *Repo) TeamCreate(ctx context.Context, team *gensql.Team, members ...TeamMember) error {
+	members = Team{Team: *team, Members: members}.members()
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	querier := r.querier.WithTx(tx)
+	err = querier.TeamCreate(ctx, gensql.TeamCreateParams{
 		ID:    team.ID,
-		Users: stringSliceToLower(team.Users),
+		Users: memberEmails(members),
 		Slug:  team.Slug,
 	})
+	if err != nil {
+		if err := tx.Rollback(); err != nil {
+			r.log.WithError(err).Error("rolling back team create transaction")
+		}
+		return err
+	}
+
+	if err := insertTeamMembers(ctx, querier, team.ID, members); err != nil {
+		if err := tx.Rollback(); err != nil {
+			r.log.WithError(err).Error("rolling back team create transaction - team member insert")
+		}
+		return err
+	}
+
+	return tx.Commit()
 }
 
-func (r *Repo) TeamUpdate(ctx context.Context, team *gensql.Team) error {
-	return r.querier.TeamUpdate(ctx, gensql.TeamUpdateParams{
+// TeamUpdate replaces the members of the team. Without members, every user becomes an owner.
+func (r *Repo) TeamUpdate(ctx context.Context, team *gensql.Team, members ...TeamMember) error {
+	members = Team{Team: *team, Members: members}.members()
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	querier := r.querier.WithTx(tx)
+	err = querier.TeamUpdate(ctx, gensql.TeamUpdateParams{
 		ID:    team.ID,
-		Users: stringSliceToLower(team.Users),
+		Users: memberEmails(members),
+	})
+	if err != nil {
+		if err := tx.Rollback(); err != nil {
+			r.log.WithError(err).Error("rolling back team update transaction")
+		}
+		return err
+	}
+
+	if err := querier.TeamMembersDelete(ctx, team.ID); err != nil {
+		if err := tx.Rollback(); err != nil {
+			r.log.WithError(err).Error("rolling back team update transaction - team members delete")
+		}
+		return err
+	}
+
+	if err := insertTeamMembers(ctx, querier, team.ID, members); err != nil {
+		if err := tx.Rollback(); err != nil {
+			r.log.WithError(err).Error("rolling back team update transaction - team member insert")
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func insertTeamMembers(ctx context.Context, querier *gensql.Queries, teamID string, members []TeamMember) error {
+	for _, member := range members {
+		err := querier.TeamMemberInsert(ctx, gensql.TeamMemberInsertParams{
+			TeamID: teamID,
+			Email:  member.Email,
+			Role:   member.Role,
+			AzureGroupID: sql.NullString{
+				String: member.AzureGroupID,
+				Valid:  member.AzureGroupID != "",
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func memberEmails(members []TeamMember) []string {
+	emails := []string{}
+	for _, member := range members {
+		emails = append(emails, member.Email)
+	}
+
+	return emails
+}
+
+func (r *Repo) TeamMembersGet(ctx context.Context, teamID string) ([]TeamMember, error) {
+	rows, err := r.querier.TeamMembersGet(ctx, teamID)
+	if err != nil {
+		return nil, err
+	}
+
+	members := []TeamMember{}
+	for _, row := range rows {
+		members = append(members, TeamMember{
+			Email:        row.Email,
+			Role:         row.Role,
+			AzureGroupID: row.AzureGroupID.String,
+		})
+	}
+
+	return members, nil
+}
+
+// TeamMemberRoleGet returns sql.ErrNoRows if the email isn't a member of the team
+func (r *Repo) TeamMemberRoleGet(ctx context.Context, teamID, email string) (gensql.TeamRole, error) {
+	return r.querier.TeamMemberRoleGet(ctx, gensql.TeamMemberRoleGetParams{
+		TeamID: teamID,
+		Email:  email,
 	})
 }
 
+// TeamWithMembersGet returns the team along with the members and their roles
+func (r *Repo) TeamWithMembersGet(ctx context.Context, teamID string) (Team, error) {
+	team, err := r.querier.TeamGet(ctx, teamID)
+	if err != nil {
+		return Team{}, err
+	}
+
+	members, err := r.TeamMembersGet(ctx, teamID)
+	if err != nil {
+		return Team{}, err
+	}
+
+	return Team{
+		Team: gensql.Team{
+			ID:    team.ID,
+			Slug:  team.Slug,
+			Users: team.Users,
+		},
+		Members: members,
+	}, nil
+}
+
 func (r *Repo) TeamGet(ctx context.Context, teamID string) (gensql.TeamGetRow, error) {
 	return r.querier.TeamGet(ctx, teamID)
 }
@@ -41,6 +256,12 @@ func (r *Repo) TeamsGet(ctx context.Context) ([]gensql.Team, error) {
 	return r.querier.TeamsGet(ctx)
 }
 
+// TeamIDsInUseGet lists the IDs of the teams which exist, are being created, or have events
+// which aren't done yet, i.e. the teams whose resources must be left alone
+func (r *Repo) TeamIDsInUseGet(ctx context.Context) ([]string, error) {
+	return r.querier.TeamIDsInUseGet(ctx)
+}
+
 func (r *Repo) TeamsForChartGet(ctx context.Context, chartType gensql.ChartType) ([]string, error) {
 	return r.querier.TeamsForChartGet(ctx, chartType)
 }
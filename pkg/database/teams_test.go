@@ -0,0 +1,130 @@
+package database
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/navikt/knorten/pkg/database/gensql"
+)
+
+func TestRepo_TeamMembers(t *testing.T) {
+	ctx := context.Background()
+
+	team := gensql.Team{
+		ID:    "team-roles-1234",
+		Slug:  "team-roles",
+		Users: []string{"Owner@nav.no"},
+	}
+
+	if err := repo.TeamCreate(ctx, &team); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		if err := repo.TeamDelete(ctx, team.ID); err != nil {
+			t.Error(err)
+		}
+	})
+
+	members, err := repo.TeamMembersGet(ctx, team.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff([]TeamMember{{Email: "owner@nav.no", Role: gensql.TeamRoleOwner}}, members); diff != "" {
+		t.Errorf("TeamCreate(): members mismatch (-want +got):\n%s", diff)
+	}
+
+	update := []TeamMember{
+		{Email: "owner@nav.no", Role: gensql.TeamRoleOwner},
+		{Email: "viewer@nav.no", Role: gensql.TeamRoleViewer},
+		{Email: "maintainer@nav.no", Role: gensql.TeamRoleMaintainer},
+	}
+
+	if err := repo.TeamUpdate(ctx, &team, update...); err != nil {
+		t.Fatal(err)
+	}
+
+	members, err = repo.TeamMembersGet(ctx, team.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := []TeamMember{
+		{Email: "owner@nav.no", Role: gensql.TeamRoleOwner},
+		{Email: "maintainer@nav.no", Role: gensql.TeamRoleMaintainer},
+		{Email: "viewer@nav.no", Role: gensql.TeamRoleViewer},
+	}
+	if diff := cmp.Diff(expect, members); diff != "" {
+		t.Errorf("TeamUpdate(): members mismatch (-want +got):\n%s", diff)
+	}
+
+	role, err := repo.TeamMemberRoleGet(ctx, team.ID, "Viewer@nav.no")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if role != gensql.TeamRoleViewer {
+		t.Errorf("TeamMemberRoleGet(): expected %v, got %v", gensql.TeamRoleViewer, role)
+	}
+
+	teamWithMembers := Team{Team: team, Members: members}
+	if diff := cmp.Diff([]string{"owner@nav.no", "maintainer@nav.no"}, teamWithMembers.EmailsWithRole(gensql.TeamRoleOwner, gensql.TeamRoleMaintainer)); diff != "" {
+		t.Errorf("EmailsWithRole(): mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestRepo_TeamIDsInUseGet(t *testing.T) {
+	ctx := context.Background()
+
+	team := gensql.Team{ID: "in-use-1234", Slug: "in-use", Users: []string{"user@nav.no"}}
+	if err := repo.TeamCreate(ctx, &team); err != nil {
+		t.Fatal(err)
+	}
+
+	provisioning := "being-created-1234"
+	if err := repo.TeamProvisioningStepComplete(ctx, provisioning, "namespace", "team-being-created-1234"); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		if err := repo.TeamDelete(ctx, team.ID); err != nil {
+			t.Error(err)
+		}
+		if err := repo.TeamProvisioningStepsDelete(ctx, provisioning); err != nil {
+			t.Error(err)
+		}
+	})
+
+	teamIDs, err := repo.TeamIDsInUseGet(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Contains(teamIDs, team.ID) || !slices.Contains(teamIDs, provisioning) {
+		t.Errorf("TeamIDsInUseGet(): expected %v and %v to be in use, got %v", team.ID, provisioning, teamIDs)
+	}
+}
+
+func TestTeamRoleAllows(t *testing.T) {
+	testCases := []struct {
+		role     gensql.TeamRole
+		required gensql.TeamRole
+		expect   bool
+	}{
+		{role: gensql.TeamRoleOwner, required: gensql.TeamRoleOwner, expect: true},
+		{role: gensql.TeamRoleOwner, required: gensql.TeamRoleViewer, expect: true},
+		{role: gensql.TeamRoleMaintainer, required: gensql.TeamRoleOwner, expect: false},
+		{role: gensql.TeamRoleMaintainer, required: gensql.TeamRoleMaintainer, expect: true},
+		{role: gensql.TeamRoleViewer, required: gensql.TeamRoleMaintainer, expect: false},
+		{role: "", required: gensql.TeamRoleViewer, expect: false},
+	}
+
+	for _, tc := range testCases {
+		if got := TeamRoleAllows(tc.role, tc.required); got != tc.expect {
+			t.Errorf("TeamRoleAllows(%v, %v): expected %v, got %v", tc.role, tc.required, tc.expect, got)
+		}
+	}
+}
@@ -4,10 +4,11 @@ import (
 	"context"
 
 	"github.com/navikt/knorten/pkg/database"
+	"github.com/navikt/knorten/pkg/team"
 )
 
 type airflowClient interface {
-	DeleteSchedulerPods(ctx context.Context, namespace string) error
+	RestartComponent(ctx context.Context, namespace string, component team.AirflowComponent) error
 }
 
 type airflowMock struct {
@@ -20,7 +21,7 @@ func newAirflowMock() airflowMock {
 	}
 }
 
-func (ac airflowMock) DeleteSchedulerPods(ctx context.Context, namespace string) error {
-	ac.EventCounts[database.EventTypeDeleteSchedulerPods]++
+func (ac airflowMock) RestartComponent(ctx context.Context, namespace string, component team.AirflowComponent) error {
+	ac.EventCounts[database.EventTypeRestartAirflowComponent]++
 	return nil
 }
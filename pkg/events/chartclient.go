@@ -8,8 +8,10 @@ import (
 )
 
 type chartClient interface {
-	SyncAirflow(ctx context.Context, values *chart.AirflowConfigurableValues) error
-	DeleteAirflow(ctx context.Context, teamID string) error
+	SyncAirflow(ctx context.Context, values *chart.AirflowConfigurableValues, opts ...database.EventOption) error
+	DeleteAirflow(ctx context.Context, teamID string, opts ...database.EventOption) error
+	HibernateAirflow(ctx context.Context, teamID string, opts ...database.EventOption) error
+	WakeAirflow(ctx context.Context, teamID string, opts ...database.EventOption) error
 }
 
 type chartMock struct {
@@ -25,13 +27,24 @@ func newChartMock() chartMock {
 func (cm chartMock) SyncAirflow(
 	ctx context.Context,
 	values *chart.AirflowConfigurableValues,
+	opts ...database.EventOption,
 ) error {
 	cm.EventCounts[database.EventTypeCreateAirflow]++
 	cm.EventCounts[database.EventTypeUpdateAirflow]++
 	return nil
 }
 
-func (cm chartMock) DeleteAirflow(ctx context.Context, teamID string) error {
+func (cm chartMock) DeleteAirflow(ctx context.Context, teamID string, opts ...database.EventOption) error {
 	cm.EventCounts[database.EventTypeDeleteAirflow]++
 	return nil
 }
+
+func (cm chartMock) HibernateAirflow(ctx context.Context, teamID string, opts ...database.EventOption) error {
+	cm.EventCounts[database.EventTypeHibernateAirflow]++
+	return nil
+}
+
+func (cm chartMock) WakeAirflow(ctx context.Context, teamID string, opts ...database.EventOption) error {
+	cm.EventCounts[database.EventTypeWakeAirflow]++
+	return nil
+}
@@ -2,8 +2,9 @@ package events
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/navikt/knorten/pkg/gcpapi"
@@ -13,16 +14,22 @@ import (
 	"github.com/navikt/knorten/pkg/api"
 	"github.com/navikt/knorten/pkg/api/auth"
 	"github.com/navikt/knorten/pkg/chart"
+	"github.com/navikt/knorten/pkg/config"
 	"github.com/navikt/knorten/pkg/database"
 	"github.com/navikt/knorten/pkg/database/gensql"
 	"github.com/navikt/knorten/pkg/helm"
 	"github.com/navikt/knorten/pkg/leaderelection"
 	"github.com/navikt/knorten/pkg/logger"
 	"github.com/navikt/knorten/pkg/team"
+	"github.com/navikt/knorten/pkg/tracing"
 	"github.com/navikt/knorten/pkg/user"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = tracing.Tracer("github.com/navikt/knorten/pkg/events")
+
 type EventHandler struct {
 	repo                       database.Repository
 	maintenanceExclusionConfig *maintenance.MaintenanceExclusion
@@ -33,93 +40,82 @@ type EventHandler struct {
 	chartClient                chartClient
 	helmClient                 helmClient
 	airflowClient              airflowClient
+	notifier                   notifier
+	pools                      *pools
+	inFlight                   *inFlightEvents
+	stop                       chan struct{}
+	stopOnce                   *sync.Once
+	stopped                    chan struct{}
+	dryRun                     bool
 }
 
-const (
-	maxConcurrentEventsHandled = 5
-)
-
 type workerFunc func(context.Context, gensql.Event, logger.Logger) error
 
 func (e EventHandler) distributeWork(eventType database.EventType) workerFunc {
-	switch eventType {
-	case database.EventTypeCreateTeam,
-		database.EventTypeUpdateTeam:
-		return func(ctx context.Context, event gensql.Event, logger logger.Logger) error {
-			var team gensql.Team
-			return e.processWork(ctx, event, logger, &team)
-		}
-	case database.EventTypeCreateUserGSM:
-		return func(ctx context.Context, event gensql.Event, logger logger.Logger) error {
-			var manager gensql.UserGoogleSecretManager
-			return e.processWork(ctx, event, logger, &manager)
-		}
-	case database.EventTypeCreateAirflow,
-		database.EventTypeUpdateAirflow:
-		return func(ctx context.Context, event gensql.Event, logger logger.Logger) error {
-			var values chart.AirflowConfigurableValues
-			return e.processWork(ctx, event, logger, &values)
-		}
-	case database.EventTypeDeleteTeam,
-		database.EventTypeDeleteUserGSM,
-		database.EventTypeDeleteAirflow:
-		return func(ctx context.Context, event gensql.Event, logger logger.Logger) error {
-			return e.processWork(ctx, event, logger, nil)
-		}
-	case database.EventTypeHelmRolloutAirflow,
-		database.EventTypeHelmRollbackAirflow,
-		database.EventTypeHelmUninstallAirflow:
-		var values helm.EventData
-		return func(ctx context.Context, event gensql.Event, logger logger.Logger) error {
-			return e.processWork(ctx, event, logger, &values)
-		}
-	case database.EventTypeDeleteSchedulerPods:
-		return func(ctx context.Context, event gensql.Event, logger logger.Logger) error {
-			var airflowProperties api.AirflowProperties
-			return e.processWork(ctx, event, logger, &airflowProperties)
-		}
+	if _, ok := payloadSchemas[eventType]; !ok {
+		return nil
 	}
 
-	return nil
+	return e.processWork
 }
 
 func (e EventHandler) processWork(
 	ctx context.Context,
 	event gensql.Event,
 	logger logger.Logger,
-	form any,
-) error {
-	if err := json.Unmarshal(event.Payload, &form); err != nil {
-		if err := e.repo.EventSetStatus(e.context, event.ID, database.EventStatusFailed); err != nil {
+) (err error) {
+	// The event continues the trace of the request which registered it
+	ctx, span := tracer.Start(tracing.Extract(ctx, event.TraceContext), "event "+event.Type, trace.WithAttributes(
+		attribute.String("event.id", event.ID.String()),
+		attribute.String("event.type", event.Type),
+		attribute.String("event.owner", event.Owner),
+		attribute.Int("event.retry_count", int(event.RetryCount)),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	// The events registered while processing, like the Helm rollout of an Airflow sync, keep the
+	// priority of this event, so that a bulk operation stays low priority all the way through
+	priority := database.WithEventPriority(database.EventPriority(event.Priority))
+
+	form, err := decodePayload(event)
+	if err != nil {
+		if err := e.setEventStatus(event, database.EventStatusFailed, err.Error()); err != nil {
 			return err
 		}
 		return err
 	}
 
-	if err := e.repo.EventSetStatus(e.context, event.ID, database.EventStatusProcessing); err != nil {
+	if err := e.setEventStatus(event, database.EventStatusProcessing, ""); err != nil {
 		return err
 	}
 
-	var err error
 	switch database.EventType(event.Type) {
 	case database.EventTypeCreateTeam:
-		t, ok := form.(*gensql.Team)
+		t, ok := form.(*database.Team)
 		if !ok {
 			return fmt.Errorf("invalid form type for event type %v", event.Type)
 		}
 
 		logger.Infof("Creating team '%v'", t.ID)
-		err = e.teamClient.Create(ctx, t)
+		err = e.teamClient.Create(ctx, t, logger)
 	case database.EventTypeUpdateTeam:
-		t, ok := form.(*gensql.Team)
+		t, ok := form.(*database.Team)
 		if !ok {
 			return fmt.Errorf("invalid form type for event type %v", event.Type)
 		}
 
 		logger.Infof("Updating team '%v'", t.ID)
-		err = e.teamClient.Update(ctx, t)
+		err = e.teamClient.Update(ctx, t, priority)
+	case database.EventTypeRenameTeam:
+		change, ok := form.(*database.TeamSlugChange)
+		if !ok {
+			return fmt.Errorf("invalid form type for event type %v", event.Type)
+		}
+
+		logger.Infof("Renaming team '%v' from '%v' to '%v'", event.Owner, change.OldSlug, change.NewSlug)
+		err = e.teamClient.Rename(ctx, event.Owner, *change, priority)
 	case database.EventTypeDeleteTeam:
-		err = e.teamClient.Delete(ctx, event.Owner)
+		err = e.teamClient.Delete(ctx, event.Owner, priority)
 	case database.EventTypeCreateUserGSM:
 		m, ok := form.(*gensql.UserGoogleSecretManager)
 		if !ok {
@@ -137,16 +133,22 @@ func (e EventHandler) processWork(
 		}
 
 		logger.Infof("Syncing Airflow for team '%v'", v.TeamID)
-		err = e.chartClient.SyncAirflow(ctx, v)
+		err = e.chartClient.SyncAirflow(ctx, v, priority)
 	case database.EventTypeDeleteAirflow:
-		err = e.chartClient.DeleteAirflow(ctx, event.Owner)
+		err = e.chartClient.DeleteAirflow(ctx, event.Owner, priority)
+	case database.EventTypeHibernateAirflow:
+		logger.Infof("Hibernating Airflow for team '%v'", event.Owner)
+		err = e.chartClient.HibernateAirflow(ctx, event.Owner, priority)
+	case database.EventTypeWakeAirflow:
+		logger.Infof("Waking Airflow for team '%v'", event.Owner)
+		err = e.chartClient.WakeAirflow(ctx, event.Owner, priority)
 	case database.EventTypeHelmRolloutAirflow:
 		d, ok := form.(*helm.EventData)
 		if !ok {
 			return fmt.Errorf("invalid form type for event type %v", event.Type)
 		}
 		logger.Infof("Rolling out helm chart for team '%v'", d.TeamID)
-		err = e.helmClient.InstallOrUpgrade(ctx, d)
+		err = e.helmClient.InstallOrUpgrade(ctx, d, priority)
 	case database.EventTypeHelmRollbackAirflow:
 		d, ok := form.(*helm.EventData)
 		if !ok {
@@ -161,14 +163,20 @@ func (e EventHandler) processWork(
 		}
 		logger.Infof("Uninstalling helm chart for team '%v'", d.TeamID)
 		err = e.helmClient.Uninstall(ctx, d)
-	case database.EventTypeDeleteSchedulerPods:
+	case database.EventTypeRestartAirflowComponent, database.EventTypeDeleteSchedulerPods:
 		props, ok := form.(*api.AirflowProperties)
 		if !ok {
 			return fmt.Errorf("invalid form type for event type %v", event.Type)
 		}
 
-		logger.Infof("Deleting Airflow scheduler pods for team '%v'", event.Owner)
-		err = e.airflowClient.DeleteSchedulerPods(ctx, props.Namespace)
+		component := team.AirflowComponent(props.Component)
+		if component == "" {
+			// Events registered before the component was added always restarted the scheduler
+			component = team.AirflowComponentScheduler
+		}
+
+		logger.Infof("Restarting Airflow %v for team '%v'", component, event.Owner)
+		err = e.airflowClient.RestartComponent(ctx, props.Namespace, component)
 	}
 
 	if err != nil {
@@ -176,7 +184,39 @@ func (e EventHandler) processWork(
 		return fmt.Errorf("failed processing event: %w", err)
 	}
 
-	return e.repo.EventSetStatus(e.context, event.ID, database.EventStatusCompleted)
+	return e.setEventStatus(event, database.EventStatusCompleted, "")
+}
+
+// compensate undoes what an event which failed for good managed to do before it failed
+func (e EventHandler) compensate(event gensql.Event, deadline time.Duration, logger logger.Logger) {
+	switch database.EventType(event.Type) {
+	case database.EventTypeCreateTeam:
+		ctx, cancel := context.WithTimeout(e.context, deadline)
+		defer cancel()
+
+		if err := e.teamClient.CompensateCreate(ctx, event.Owner, logger); err != nil {
+			logger.WithError(err).Error("failed cleaning up after creating team")
+		}
+	}
+}
+
+// setEventStatus changes the status of the event, and notifies those subscribing to the change.
+// A failing notification doesn't stop the event from being processed.
+func (e EventHandler) setEventStatus(event gensql.Event, status database.EventStatus, message string) error {
+	// Events abandoned at shutdown have already been set to pending
+	if e.inFlight.isAbandoned(event.ID) {
+		return nil
+	}
+
+	if err := e.repo.EventSetStatus(e.context, event.ID, status); err != nil {
+		return err
+	}
+
+	if err := e.notifier.EventStatusChanged(e.context, event, status, message); err != nil {
+		e.log.WithError(err).WithField("eventID", event.ID).Errorf("queueing notifications for status %v", status)
+	}
+
+	return nil
 }
 
 func NewHandler(
@@ -190,6 +230,8 @@ func NewHandler(
 	teamAirflowClient airflowClient,
 	gcpProject, gcpRegion, gcpZone, airflowChartVersion, topLevelDomain string,
 	maintenanceExclusionConfig *maintenance.MaintenanceExclusion,
+	eventNotifier notifier,
+	concurrency config.Events,
 	dryRun bool,
 	log *logrus.Entry,
 ) (EventHandler, error) {
@@ -224,25 +266,34 @@ func NewHandler(
 		chartClient:                chartClient,
 		helmClient:                 client,
 		airflowClient:              teamAirflowClient,
+		notifier:                   eventNotifier,
+		pools:                      newPools(concurrency.DefaultConcurrency, concurrency.Concurrency),
+		inFlight:                   newInFlightEvents(),
+		stop:                       make(chan struct{}),
+		stopOnce:                   &sync.Once{},
+		stopped:                    make(chan struct{}),
+		dryRun:                     dryRun,
 	}, nil
 }
 
 func (e EventHandler) Run(tickDuration time.Duration) {
-	eventQueue := make(chan struct{}, maxConcurrentEventsHandled)
-
 	var isLeader bool
 	var err error
 	go func() {
-		var cancelFuncs []context.CancelFunc
+		defer close(e.stopped)
+
+		ticker := time.NewTicker(tickDuration)
+		defer ticker.Stop()
+
 		for {
 			select {
-			case <-time.NewTicker(tickDuration).C:
+			case <-ticker.C:
 				e.log.Debug("Event dispatcher run!")
+			case <-e.stop:
+				e.log.Info("Shutting down, no longer dispatching events.")
+				return
 			case <-e.context.Done():
 				e.log.Debug("Context cancelled, stopping the event dispatcher.")
-				for _, cancelFunc := range cancelFuncs {
-					cancelFunc()
-				}
 				return
 			}
 
@@ -261,61 +312,145 @@ func (e EventHandler) Run(tickDuration time.Duration) {
 				continue
 			}
 
+			// The events are ordered by priority, so once the pool of a class is full the
+			// remaining events of that class wait for the next tick
+			fullPools := map[string]bool{}
 			for _, event := range events {
-				eventQueue <- struct{}{}
+				select {
+				case <-e.stop:
+					e.log.Info("Shutting down, no longer dispatching events.")
+					return
+				default:
+				}
+
+				class := eventClass(event.Type)
+				if fullPools[class] {
+					continue
+				}
+				if !e.pools.tryAcquire(class) {
+					fullPools[class] = true
+					continue
+				}
+
 				worker := e.distributeWork(database.EventType(event.Type))
 				if worker == nil {
 					e.log.WithField("eventID", event.ID).
 						Errorf("No worker found for event type %v", event.Type)
+					e.pools.release(class)
+					continue
+				}
+
+				deadline, err := time.ParseDuration(event.Deadline)
+				if err != nil {
+					e.log.WithError(err).WithField("eventID", event.ID).Error("failed parsing event deadline")
+					e.pools.release(class)
 					continue
 				}
 
 				eventLogger := newEventLogger(e.context, e.log, e.repo, event)
 				eventLogger.log.Infof("Dispatching event '%v'", event.Type)
 				event := event
-				go func() {
-					deadline, err := time.ParseDuration(event.Deadline)
-					if err != nil {
-						eventLogger.log.WithError(err).Error("failed parsing event deadline")
-						return
-					}
+				observeDispatch(event)
 
-					ctx, cancelFunc := context.WithTimeout(e.context, deadline)
-					cancelFuncs = append(cancelFuncs, cancelFunc)
+				ctx, cancelFunc := context.WithTimeout(e.context, deadline)
+				if e.dryRun {
+					ctx = e.withPlanRecorder(ctx, event, eventLogger.log)
+				}
+				e.inFlight.add(event, cancelFunc)
+				go func() {
+					defer e.pools.release(class)
+					defer e.inFlight.done(event.ID)
 
+					start := time.Now()
 					if err := worker(ctx, event, eventLogger); err != nil {
+						if e.inFlight.isAbandoned(event.ID) {
+							return
+						}
+
 						eventLogger.log.WithError(err).Info("failed processing event")
+						if errors.Is(err, ErrUnknownSchemaVersion) {
+							// Retrying won't help, the event has to be registered again by a version
+							// of knorten which knows the payload
+							observeEventDone(event, database.EventStatusFailed, start)
+							eventLogger.Infof("Hendelsen ble avvist: %v", err)
+							return
+						}
+
 						if event.RetryCount > 5 {
+							observeEventDone(event, database.EventStatusFailed, start)
 							eventLogger.log.WithError(err).
 								Error("failed processing event, reached max retries")
-							if err := e.repo.EventSetStatus(e.context, event.ID, database.EventStatusFailed); err != nil {
+							if err := e.setEventStatus(event, database.EventStatusFailed, err.Error()); err != nil {
 								eventLogger.log.WithError(err).
 									Error("failed setting event status to 'failed'")
 							}
+							e.compensate(event, deadline, eventLogger)
 						} else {
 							if err := e.repo.EventIncrementRetryCount(e.context, event.ID); err != nil {
 								eventLogger.log.WithError(err).Errorf("failed to increment retry count for event %v on error", event.ID)
 							}
 							select {
 							case <-ctx.Done():
+								observeEventDone(event, database.EventStatusDeadlineReached, start)
 								eventLogger.log.WithError(err).Info("failed processing event, deadline reached")
-								if err := e.repo.EventSetStatus(e.context, event.ID, database.EventStatusDeadlineReached); err != nil {
+								if err := e.setEventStatus(event, database.EventStatusDeadlineReached, err.Error()); err != nil {
 									eventLogger.log.WithError(err).Error("failed setting event status to 'deadline_reached'")
 								}
 							default:
-								if err := e.repo.EventSetStatus(e.context, event.ID, database.EventStatusPending); err != nil {
+								observeEventDone(event, database.EventStatusPending, start)
+								if err := e.setEventStatus(event, database.EventStatusPending, err.Error()); err != nil {
 									eventLogger.log.WithError(err).Error("failed setting event status to 'pending'")
 								}
 							}
 						}
+					} else {
+						observeEventDone(event, database.EventStatusCompleted, start)
 					}
-					<-eventQueue
 				}()
 			}
 		}
 	}()
 }
 
+// Shutdown stops dispatching new events, and waits for the events in flight to finish
+// until the context is done. The events which are still in flight are then cancelled
+// and set to pending, so that the next leader picks them up again.
+func (e EventHandler) Shutdown(ctx context.Context) error {
+	e.stopOnce.Do(func() { close(e.stop) })
+
+	// The dispatcher might be in the middle of dispatching events when it is stopped
+	select {
+	case <-e.stopped:
+	case <-ctx.Done():
+	}
+
+	if err := e.inFlight.wait(ctx); err == nil {
+		return nil
+	}
+
+	abandoned := e.inFlight.abandon()
+	for _, event := range abandoned {
+		log := e.log.WithField("eventID", event.ID)
+		log.Info("event still in flight at shutdown, setting it to pending")
+
+		message := "Knorten ble stoppet før hendelsen var ferdig, den vil bli forsøkt på nytt"
+		if err := e.repo.EventLogCreate(e.context, event.ID, message, database.LogTypeInfo); err != nil {
+			log.WithError(err).Error("failed writing event log at shutdown")
+		}
+
+		if err := e.repo.EventSetStatus(e.context, event.ID, database.EventStatusPending); err != nil {
+			log.WithError(err).Error("failed setting event status to 'pending' at shutdown")
+			continue
+		}
+
+		if err := e.notifier.EventStatusChanged(e.context, event, database.EventStatusPending, message); err != nil {
+			log.WithError(err).Error("queueing notifications for status pending")
+		}
+	}
+
+	return fmt.Errorf("%v events still in flight after draining", len(abandoned))
+}
+
 func (e EventHandler) getDispatchableEvents() ([]gensql.Event, error) {
 	dispatchableEvents, err := e.repo.DispatchableEventsGet(e.context)
 	if err != nil {
@@ -333,7 +468,9 @@ func (e EventHandler) omitAirflowEventsIfUpgradesPaused(in []gensql.Event) []gen
 		case database.EventTypeCreateAirflow,
 			database.EventTypeUpdateAirflow,
 			database.EventTypeHelmRolloutAirflow,
-			database.EventTypeHelmRollbackAirflow:
+			database.EventTypeHelmRollbackAirflow,
+			database.EventTypeHibernateAirflow,
+			database.EventTypeWakeAirflow:
 			if e.maintenanceExclusionConfig.ActiveExcludePeriodForTeam(event.Owner) != nil {
 				continue
 			}
@@ -2,17 +2,24 @@ package events
 
 import (
 	"context"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 
+	"github.com/navikt/knorten/pkg/chart"
 	"github.com/navikt/knorten/pkg/database"
 	"github.com/navikt/knorten/pkg/database/gensql"
 	"github.com/navikt/knorten/pkg/maintenance"
+	"github.com/navikt/knorten/pkg/tracing"
 )
 
 func TestEventHandler_distributeWork(t *testing.T) {
@@ -20,17 +27,20 @@ func TestEventHandler_distributeWork(t *testing.T) {
 		switch eventType {
 		case database.EventTypeCreateTeam,
 			database.EventTypeUpdateTeam,
+			database.EventTypeRenameTeam,
 			database.EventTypeDeleteTeam:
 			return teamMock.EventCounts[eventType]
 		case database.EventTypeCreateAirflow,
 			database.EventTypeUpdateAirflow,
-			database.EventTypeDeleteAirflow:
+			database.EventTypeDeleteAirflow,
+			database.EventTypeHibernateAirflow,
+			database.EventTypeWakeAirflow:
 			return chartMock.EventCounts[eventType]
 		case database.EventTypeHelmRolloutAirflow,
 			database.EventTypeHelmRollbackAirflow,
 			database.EventTypeHelmUninstallAirflow:
 			return helmMock.EventCounts[eventType]
-		case database.EventTypeDeleteSchedulerPods:
+		case database.EventTypeRestartAirflowComponent:
 			return airflowMock.EventCounts[eventType]
 		}
 
@@ -40,6 +50,7 @@ func TestEventHandler_distributeWork(t *testing.T) {
 	eventTypes := []database.EventType{
 		database.EventTypeCreateTeam,
 		database.EventTypeUpdateTeam,
+		database.EventTypeRenameTeam,
 		database.EventTypeDeleteTeam,
 		database.EventTypeCreateAirflow,
 		database.EventTypeUpdateAirflow,
@@ -47,7 +58,9 @@ func TestEventHandler_distributeWork(t *testing.T) {
 		database.EventTypeHelmRolloutAirflow,
 		database.EventTypeHelmRollbackAirflow,
 		database.EventTypeHelmUninstallAirflow,
-		database.EventTypeDeleteSchedulerPods,
+		database.EventTypeRestartAirflowComponent,
+		database.EventTypeHibernateAirflow,
+		database.EventTypeWakeAirflow,
 	}
 	for _, eventType := range eventTypes {
 		t.Run(string(eventType), func(t *testing.T) {
@@ -56,6 +69,7 @@ func TestEventHandler_distributeWork(t *testing.T) {
 			chartMock := newChartMock()
 			helmMock := newHelmMock()
 			airflowMock := newAirflowMock()
+			notifierMock := newNotifierMock()
 			handler := EventHandler{
 				repo:          &database.RepoMock{},
 				userClient:    &userMock,
@@ -63,15 +77,20 @@ func TestEventHandler_distributeWork(t *testing.T) {
 				chartClient:   &chartMock,
 				helmClient:    &helmMock,
 				airflowClient: &airflowMock,
+				notifier:      &notifierMock,
 			}
 			worker := handler.distributeWork(eventType)
-			if err := worker(context.Background(), gensql.Event{Payload: []byte("{}"), Type: string(eventType)}, logrus.New()); err != nil {
+			if err := worker(context.Background(), gensql.Event{Payload: []byte("{}"), Type: string(eventType), SchemaVersion: 1}, logrus.New()); err != nil {
 				t.Errorf("worker(): %v", err)
 			}
 
 			if count := checkEventType(eventType, chartMock, teamMock, userMock, helmMock, airflowMock); count != 1 {
 				t.Errorf("distributeWork(): expected 1 %v event, got %v", eventType, count)
 			}
+
+			if count := notifierMock.StatusCounts[database.EventStatusCompleted]; count != 1 {
+				t.Errorf("distributeWork(): expected 1 notification of the event completing, got %v", count)
+			}
 		})
 	}
 }
@@ -163,6 +182,7 @@ func TestOmitAirflowEventsIfUpgradesPaused(t *testing.T) {
 			chartMock := newChartMock()
 			helmMock := newHelmMock()
 			airflowMock := newAirflowMock()
+			notifierMock := newNotifierMock()
 			handler := EventHandler{
 				repo:                       &database.RepoMock{},
 				userClient:                 &userMock,
@@ -170,6 +190,7 @@ func TestOmitAirflowEventsIfUpgradesPaused(t *testing.T) {
 				chartClient:                &chartMock,
 				helmClient:                 &helmMock,
 				airflowClient:              &airflowMock,
+				notifier:                   &notifierMock,
 				maintenanceExclusionConfig: maintenanceExclusionConfig,
 			}
 
@@ -180,3 +201,157 @@ func TestOmitAirflowEventsIfUpgradesPaused(t *testing.T) {
 		})
 	}
 }
+
+func TestEventHandler_processWorkResumesTrace(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(tracing.NewProvider(sdktrace.WithSyncer(exporter)))
+	if _, err := tracing.Setup(context.Background(), ""); err != nil {
+		t.Fatal(err)
+	}
+
+	requestCtx, requestSpan := otel.Tracer("test").Start(context.Background(), "POST /team/:slug/edit")
+	event := gensql.Event{
+		Type:          string(database.EventTypeDeleteTeam),
+		Payload:       []byte("{}"),
+		TraceContext:  tracing.Inject(requestCtx),
+		SchemaVersion: 1,
+	}
+	requestSpan.End()
+
+	teamMock := newTeamMock()
+	notifierMock := newNotifierMock()
+	handler := EventHandler{
+		repo:       &database.RepoMock{},
+		teamClient: &teamMock,
+		notifier:   &notifierMock,
+	}
+
+	worker := handler.distributeWork(database.EventTypeDeleteTeam)
+	if err := worker(context.Background(), event, logrus.New()); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+
+	eventSpan := spans[1]
+	assert.Equal(t, "event delete:team", eventSpan.Name)
+	assert.Equal(t, requestSpan.SpanContext().TraceID(), eventSpan.SpanContext.TraceID())
+	assert.Equal(t, requestSpan.SpanContext().SpanID(), eventSpan.Parent.SpanID())
+}
+
+func TestEventHandler_Shutdown(t *testing.T) {
+	newHandler := func(notifier *notifierMock) EventHandler {
+		handler := EventHandler{
+			repo:     &database.RepoMock{},
+			log:      logrus.NewEntry(logrus.New()),
+			context:  context.Background(),
+			notifier: notifier,
+			inFlight: newInFlightEvents(),
+			stop:     make(chan struct{}),
+			stopOnce: &sync.Once{},
+			stopped:  make(chan struct{}),
+		}
+		close(handler.stopped)
+
+		return handler
+	}
+
+	t.Run("events in flight are drained", func(t *testing.T) {
+		notifierMock := newNotifierMock()
+		handler := newHandler(&notifierMock)
+
+		event := gensql.Event{ID: uuid.New(), Type: string(database.EventTypeUpdateTeam)}
+		_, cancel := context.WithCancel(context.Background())
+		handler.inFlight.add(event, cancel)
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			handler.inFlight.done(event.ID)
+		}()
+
+		ctx, cancelDrain := context.WithTimeout(context.Background(), time.Second)
+		defer cancelDrain()
+
+		require.NoError(t, handler.Shutdown(ctx))
+		assert.Zero(t, notifierMock.StatusCounts[database.EventStatusPending])
+	})
+
+	t.Run("events still in flight after draining are set to pending", func(t *testing.T) {
+		notifierMock := newNotifierMock()
+		handler := newHandler(&notifierMock)
+
+		event := gensql.Event{ID: uuid.New(), Type: string(database.EventTypeUpdateAirflow)}
+		workerCtx, cancel := context.WithCancel(context.Background())
+		handler.inFlight.add(event, cancel)
+
+		ctx, cancelDrain := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancelDrain()
+
+		require.Error(t, handler.Shutdown(ctx))
+		assert.Equal(t, 1, notifierMock.StatusCounts[database.EventStatusPending])
+		assert.ErrorIs(t, workerCtx.Err(), context.Canceled)
+
+		// The worker of an abandoned event no longer changes its status
+		require.NoError(t, handler.setEventStatus(event, database.EventStatusDeadlineReached, ""))
+		assert.Zero(t, notifierMock.StatusCounts[database.EventStatusDeadlineReached])
+	})
+}
+
+func TestEventHandler_compensate(t *testing.T) {
+	teamMock := newTeamMock()
+	handler := EventHandler{
+		repo:       &database.RepoMock{},
+		context:    context.Background(),
+		teamClient: &teamMock,
+	}
+
+	handler.compensate(gensql.Event{Type: string(database.EventTypeCreateTeam), Owner: "team-a-1234"}, time.Minute, logrus.New())
+	handler.compensate(gensql.Event{Type: string(database.EventTypeUpdateTeam), Owner: "team-b-1234"}, time.Minute, logrus.New())
+
+	assert.Equal(t, map[string]int{"team-a-1234": 1}, teamMock.Compensations)
+}
+
+// priorityChartMock records the priority the chart client registers its helm events with
+type priorityChartMock struct {
+	chartMock
+	priority *database.EventPriority
+}
+
+func (cm priorityChartMock) SyncAirflow(
+	ctx context.Context,
+	values *chart.AirflowConfigurableValues,
+	opts ...database.EventOption,
+) error {
+	var params gensql.EventCreateParams
+	for _, opt := range opts {
+		opt(&params)
+	}
+
+	*cm.priority = database.EventPriority(params.Priority)
+	return cm.chartMock.SyncAirflow(ctx, values, opts...)
+}
+
+func TestEventHandler_processWorkKeepsPriority(t *testing.T) {
+	var priority database.EventPriority
+	notifierMock := newNotifierMock()
+	handler := EventHandler{
+		repo:        &database.RepoMock{},
+		chartClient: priorityChartMock{chartMock: newChartMock(), priority: &priority},
+		notifier:    &notifierMock,
+	}
+
+	event := gensql.Event{
+		Type:          string(database.EventTypeUpdateAirflow),
+		Owner:         "team-a-1234",
+		Payload:       []byte(`{"TeamID":"team-a-1234"}`),
+		Priority:      int32(database.EventPriorityLow),
+		SchemaVersion: 1,
+	}
+
+	worker := handler.distributeWork(database.EventTypeUpdateAirflow)
+	if err := worker(context.Background(), event, logrus.New()); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, database.EventPriorityLow, priority, "the helm event registered by the sync should be low priority")
+}
@@ -8,7 +8,7 @@ import (
 )
 
 type helmClient interface {
-	InstallOrUpgrade(ctx context.Context, helmData *helm.EventData) error
+	InstallOrUpgrade(ctx context.Context, helmData *helm.EventData, opts ...database.EventOption) error
 	Rollback(ctx context.Context, helmData *helm.EventData) error
 	Uninstall(ctx context.Context, helmData *helm.EventData) error
 }
@@ -23,7 +23,7 @@ func newHelmMock() helmMock {
 	}
 }
 
-func (hm helmMock) InstallOrUpgrade(ctx context.Context, helmEvent *helm.EventData) error {
+func (hm helmMock) InstallOrUpgrade(ctx context.Context, helmEvent *helm.EventData, opts ...database.EventOption) error {
 	hm.EventCounts[database.EventTypeHelmRolloutAirflow]++
 	return nil
 }
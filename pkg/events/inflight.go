@@ -0,0 +1,96 @@
+package events
+
+import (
+	"context"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/navikt/knorten/pkg/database/gensql"
+)
+
+// inFlightEvents keeps track of the events being processed, so that they can be
+// drained, or abandoned, when Knorten shuts down
+type inFlightEvents struct {
+	mu        sync.Mutex
+	wg        sync.WaitGroup
+	events    map[uuid.UUID]inFlightEvent
+	abandoned map[uuid.UUID]struct{}
+}
+
+type inFlightEvent struct {
+	event  gensql.Event
+	cancel context.CancelFunc
+}
+
+func newInFlightEvents() *inFlightEvents {
+	return &inFlightEvents{
+		events:    map[uuid.UUID]inFlightEvent{},
+		abandoned: map[uuid.UUID]struct{}{},
+	}
+}
+
+func (f *inFlightEvents) add(event gensql.Event, cancel context.CancelFunc) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.wg.Add(1)
+	f.events[event.ID] = inFlightEvent{event: event, cancel: cancel}
+}
+
+func (f *inFlightEvents) done(id uuid.UUID) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	entry, ok := f.events[id]
+	if !ok {
+		return
+	}
+
+	entry.cancel()
+	delete(f.events, id)
+	f.wg.Done()
+}
+
+// wait blocks until every event in flight is done, or the context is done
+func (f *inFlightEvents) wait(ctx context.Context) error {
+	drained := make(chan struct{})
+	go func() {
+		f.wg.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// abandon cancels the events still in flight, and returns them. The status of
+// an abandoned event is no longer changed by its worker.
+func (f *inFlightEvents) abandon() []gensql.Event {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var events []gensql.Event
+	for id, entry := range f.events {
+		entry.cancel()
+		f.abandoned[id] = struct{}{}
+		events = append(events, entry.event)
+	}
+
+	return events
+}
+
+func (f *inFlightEvents) isAbandoned(id uuid.UUID) bool {
+	if f == nil {
+		return false
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	_, ok := f.abandoned[id]
+	return ok
+}
@@ -0,0 +1,112 @@
+package events
+
+import (
+	"context"
+	"time"
+
+	"github.com/navikt/knorten/pkg/database"
+	"github.com/navikt/knorten/pkg/database/gensql"
+	"github.com/navikt/knorten/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/sirupsen/logrus"
+)
+
+const queueCountTimeout = 5 * time.Second
+
+var (
+	dispatchLatency = promauto.With(metrics.Registry).NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "events",
+		Name:      "dispatch_latency_seconds",
+		Help:      "Time from an event is ready until it is dispatched, by event type.",
+		Buckets:   prometheus.ExponentialBuckets(1, 2, 12),
+	}, []string{"type"})
+
+	eventDuration = promauto.With(metrics.Registry).NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "events",
+		Name:      "duration_seconds",
+		Help:      "How long processing an event takes, by event type and the status it ended in.",
+		Buckets:   []float64{1, 5, 15, 30, 60, 120, 300, 600, 1200, 1800},
+	}, []string{"type", "status"})
+
+	retries = promauto.With(metrics.Registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "events",
+		Name:      "retries_total",
+		Help:      "Events which failed and will be retried, by event type.",
+	}, []string{"type"})
+
+	deadlinesReached = promauto.With(metrics.Registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "events",
+		Name:      "deadline_reached_total",
+		Help:      "Events which didn't finish before their deadline, by event type.",
+	}, []string{"type"})
+)
+
+func observeDispatch(event gensql.Event) {
+	// updated_at is when the event was registered, or when it was last set to pending
+	latency := time.Since(event.UpdatedAt)
+	if latency < 0 {
+		latency = 0
+	}
+
+	dispatchLatency.WithLabelValues(event.Type).Observe(latency.Seconds())
+}
+
+func observeEventDone(event gensql.Event, status database.EventStatus, start time.Time) {
+	eventDuration.WithLabelValues(event.Type, string(status)).Observe(time.Since(start).Seconds())
+
+	switch status {
+	case database.EventStatusPending:
+		retries.WithLabelValues(event.Type).Inc()
+	case database.EventStatusDeadlineReached:
+		deadlinesReached.WithLabelValues(event.Type).Inc()
+	}
+}
+
+type eventCounter interface {
+	EventCountsGet(ctx context.Context) ([]gensql.EventCountsGetRow, error)
+}
+
+// QueueCollector reports how many events there are of each status and type when scraped
+type QueueCollector struct {
+	repo eventCounter
+	desc *prometheus.Desc
+	log  *logrus.Entry
+}
+
+func NewQueueCollector(repo eventCounter, log *logrus.Entry) *QueueCollector {
+	return &QueueCollector{
+		repo: repo,
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(metrics.Namespace, "events", "queue"),
+			"Events by status and type.",
+			[]string{"status", "type"},
+			nil,
+		),
+		log: log,
+	}
+}
+
+func (q *QueueCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- q.desc
+}
+
+func (q *QueueCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), queueCountTimeout)
+	defer cancel()
+
+	counts, err := q.repo.EventCountsGet(ctx)
+	if err != nil {
+		q.log.WithError(err).Error("counting events for metrics")
+		ch <- prometheus.NewInvalidMetric(q.desc, err)
+		return
+	}
+
+	for _, count := range counts {
+		ch <- prometheus.MustNewConstMetric(q.desc, prometheus.GaugeValue, float64(count.Count), count.Status, count.Type)
+	}
+}
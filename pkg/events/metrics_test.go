@@ -0,0 +1,36 @@
+package events
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/navikt/knorten/pkg/database/gensql"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+)
+
+type eventCounterMock struct {
+	counts []gensql.EventCountsGetRow
+}
+
+func (e eventCounterMock) EventCountsGet(context.Context) ([]gensql.EventCountsGetRow, error) {
+	return e.counts, nil
+}
+
+func TestQueueCollector(t *testing.T) {
+	collector := NewQueueCollector(eventCounterMock{counts: []gensql.EventCountsGetRow{
+		{Status: "new", Type: "update:airflow", Count: 3},
+		{Status: "failed", Type: "create:team", Count: 1},
+	}}, logrus.NewEntry(logrus.StandardLogger()))
+
+	expected := `
+# HELP knorten_events_queue Events by status and type.
+# TYPE knorten_events_queue gauge
+knorten_events_queue{status="failed",type="create:team"} 1
+knorten_events_queue{status="new",type="update:airflow"} 3
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,27 @@
+package events
+
+import (
+	"context"
+
+	"github.com/navikt/knorten/pkg/database"
+	"github.com/navikt/knorten/pkg/database/gensql"
+)
+
+type notifier interface {
+	EventStatusChanged(ctx context.Context, event gensql.Event, status database.EventStatus, message string) error
+}
+
+type notifierMock struct {
+	StatusCounts map[database.EventStatus]int
+}
+
+func newNotifierMock() notifierMock {
+	return notifierMock{
+		StatusCounts: map[database.EventStatus]int{},
+	}
+}
+
+func (nm notifierMock) EventStatusChanged(ctx context.Context, event gensql.Event, status database.EventStatus, message string) error {
+	nm.StatusCounts[status]++
+	return nil
+}
@@ -0,0 +1,40 @@
+package events
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/navikt/knorten/pkg/database"
+	"github.com/navikt/knorten/pkg/database/gensql"
+	"github.com/navikt/knorten/pkg/plan"
+	"github.com/sirupsen/logrus"
+)
+
+// planRecorder stores the steps an event would have made in dry run against the event, so
+// they can be shown as a plan
+type planRecorder struct {
+	eventID uuid.UUID
+	repo    database.Repository
+	log     *logrus.Entry
+}
+
+func (r *planRecorder) Record(ctx context.Context, step plan.Step) {
+	// The plan is worth keeping even if the event runs past its deadline
+	if err := r.repo.EventPlanStepCreate(context.WithoutCancel(ctx), r.eventID, step); err != nil {
+		r.log.WithError(err).Errorf("recording plan step %v %v", step.Action, step.Target)
+	}
+}
+
+// withPlanRecorder records the plan of the event with the returned context. The plan of an
+// earlier attempt is removed, so the plan shows what the last attempt would have done.
+func (e EventHandler) withPlanRecorder(ctx context.Context, event gensql.Event, log *logrus.Entry) context.Context {
+	if err := e.repo.EventPlanStepsDelete(ctx, event.ID); err != nil {
+		log.WithError(err).Error("removing plan of earlier attempt")
+	}
+
+	return plan.WithRecorder(ctx, &planRecorder{
+		eventID: event.ID,
+		repo:    e.repo,
+		log:     log,
+	})
+}
@@ -0,0 +1,63 @@
+package events
+
+import (
+	"strings"
+	"sync"
+)
+
+// pools limits how many events of each class are processed at the same time, so that
+// a lot of events of one class, e.g. helm rollouts for every team, don't hold up the others
+type pools struct {
+	mu          sync.Mutex
+	defaultSize int
+	sizes       map[string]int
+	running     map[string]int
+}
+
+func newPools(defaultSize int, sizes map[string]int) *pools {
+	return &pools{
+		defaultSize: defaultSize,
+		sizes:       sizes,
+		running:     map[string]int{},
+	}
+}
+
+// eventClass is the part of the event type after the colon, e.g. helm for rolloutAirflow:helm
+func eventClass(eventType string) string {
+	_, class, found := strings.Cut(eventType, ":")
+	if !found {
+		return eventType
+	}
+
+	return class
+}
+
+func (p *pools) size(class string) int {
+	if size, ok := p.sizes[class]; ok {
+		return size
+	}
+
+	return p.defaultSize
+}
+
+// tryAcquire takes a slot in the pool of the class, and returns false if the pool is full
+func (p *pools) tryAcquire(class string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.running[class] >= p.size(class) {
+		return false
+	}
+
+	p.running[class]++
+	return true
+}
+
+func (p *pools) release(class string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.running[class] > 0 {
+		p.running[class]--
+	}
+}
@@ -0,0 +1,53 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/navikt/knorten/pkg/database"
+)
+
+func TestEventClass(t *testing.T) {
+	testCases := []struct {
+		eventType database.EventType
+		want      string
+	}{
+		{eventType: database.EventTypeCreateTeam, want: "team"},
+		{eventType: database.EventTypeHelmRolloutAirflow, want: "helm"},
+		{eventType: database.EventTypeRestartAirflowComponent, want: "airflowcomponent"},
+		{eventType: "unknown", want: "unknown"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(string(tc.eventType), func(t *testing.T) {
+			if got := eventClass(string(tc.eventType)); got != tc.want {
+				t.Errorf("eventClass(): expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestPools(t *testing.T) {
+	p := newPools(2, map[string]int{"helm": 1})
+
+	if !p.tryAcquire("helm") {
+		t.Fatal("tryAcquire(): expected a slot in the helm pool")
+	}
+	if p.tryAcquire("helm") {
+		t.Error("tryAcquire(): expected the helm pool to be full")
+	}
+
+	// A full pool doesn't hold up the other classes
+	for i := 0; i < 2; i++ {
+		if !p.tryAcquire("team") {
+			t.Fatalf("tryAcquire(): expected slot %v in the team pool", i+1)
+		}
+	}
+	if p.tryAcquire("team") {
+		t.Error("tryAcquire(): expected the team pool to be full")
+	}
+
+	p.release("helm")
+	if !p.tryAcquire("helm") {
+		t.Error("tryAcquire(): expected a slot in the helm pool after releasing it")
+	}
+}
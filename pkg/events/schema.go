@@ -0,0 +1,93 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/navikt/knorten/pkg/api"
+	"github.com/navikt/knorten/pkg/chart"
+	"github.com/navikt/knorten/pkg/database"
+	"github.com/navikt/knorten/pkg/database/gensql"
+	"github.com/navikt/knorten/pkg/helm"
+)
+
+// ErrUnknownSchemaVersion is returned for events with a payload which can't be migrated to the
+// current version, e.g. because it was registered by a newer version of knorten
+var ErrUnknownSchemaVersion = errors.New("unknown payload schema version")
+
+// upcaster migrates a payload to the next version of the schema
+type upcaster func(payload json.RawMessage) (json.RawMessage, error)
+
+// payloadSchema decodes the payload of an event type. Payloads of earlier versions are migrated
+// to the current version by the upcasters, which are keyed by the version they migrate from.
+type payloadSchema struct {
+	decode    func(payload json.RawMessage) (any, error)
+	upcasters map[int32]upcaster
+}
+
+// payloadSchemas are the schemas of the event types which can be processed
+var payloadSchemas = map[database.EventType]payloadSchema{
+	database.EventTypeCreateTeam:              {decode: decodeInto[database.Team]},
+	database.EventTypeUpdateTeam:              {decode: decodeInto[database.Team]},
+	database.EventTypeRenameTeam:              {decode: decodeInto[database.TeamSlugChange]},
+	database.EventTypeDeleteTeam:              {decode: noPayload},
+	database.EventTypeCreateUserGSM:           {decode: decodeInto[gensql.UserGoogleSecretManager]},
+	database.EventTypeDeleteUserGSM:           {decode: noPayload},
+	database.EventTypeCreateAirflow:           {decode: decodeInto[chart.AirflowConfigurableValues]},
+	database.EventTypeUpdateAirflow:           {decode: decodeInto[chart.AirflowConfigurableValues]},
+	database.EventTypeDeleteAirflow:           {decode: noPayload},
+	database.EventTypeHibernateAirflow:        {decode: noPayload},
+	database.EventTypeWakeAirflow:             {decode: noPayload},
+	database.EventTypeHelmRolloutAirflow:      {decode: decodeInto[helm.EventData]},
+	database.EventTypeHelmRollbackAirflow:     {decode: decodeInto[helm.EventData]},
+	database.EventTypeHelmUninstallAirflow:    {decode: decodeInto[helm.EventData]},
+	database.EventTypeRestartAirflowComponent: {decode: decodeInto[api.AirflowProperties]},
+	database.EventTypeDeleteSchedulerPods:     {decode: decodeInto[api.AirflowProperties]},
+}
+
+func decodeInto[T any](payload json.RawMessage) (any, error) {
+	var form T
+	if err := json.Unmarshal(payload, &form); err != nil {
+		return nil, err
+	}
+
+	return &form, nil
+}
+
+func noPayload(json.RawMessage) (any, error) {
+	return nil, nil
+}
+
+// decodePayload decodes the payload of the event, after migrating it to the current version
+func decodePayload(event gensql.Event) (any, error) {
+	eventType := database.EventType(event.Type)
+
+	schema, ok := payloadSchemas[eventType]
+	if !ok {
+		return nil, fmt.Errorf("no payload schema for event type %v", event.Type)
+	}
+
+	return schema.decodeVersion(event.Payload, event.SchemaVersion, database.EventSchemaVersion(eventType))
+}
+
+func (s payloadSchema) decodeVersion(payload json.RawMessage, version, current int32) (any, error) {
+	if version < 1 || version > current {
+		return nil, fmt.Errorf("%w %v, the current version is %v", ErrUnknownSchemaVersion, version, current)
+	}
+
+	for ; version < current; version++ {
+		upcast, ok := s.upcasters[version]
+		if !ok {
+			return nil, fmt.Errorf("%w %v, there is no upcaster to version %v", ErrUnknownSchemaVersion, version, version+1)
+		}
+
+		var err error
+		payload, err = upcast(payload)
+		if err != nil {
+			return nil, fmt.Errorf("upcasting payload from version %v: %w", version, err)
+		}
+	}
+
+	return s.decode(payload)
+}
@@ -0,0 +1,95 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/navikt/knorten/pkg/database"
+	"github.com/navikt/knorten/pkg/database/gensql"
+)
+
+func TestPayloadSchemas(t *testing.T) {
+	// Every event type which can be registered must have a schema covering all its versions
+	for eventType, schema := range payloadSchemas {
+		for version := int32(1); version < database.EventSchemaVersion(eventType); version++ {
+			if _, ok := schema.upcasters[version]; !ok {
+				t.Errorf("%v: no upcaster from version %v", eventType, version)
+			}
+		}
+	}
+}
+
+func TestPayloadSchema_decodeVersion(t *testing.T) {
+	type slugChangeV1 struct {
+		Old string
+		New string
+	}
+
+	schema := payloadSchema{
+		decode: decodeInto[database.TeamSlugChange],
+		upcasters: map[int32]upcaster{
+			1: func(payload json.RawMessage) (json.RawMessage, error) {
+				var v1 slugChangeV1
+				if err := json.Unmarshal(payload, &v1); err != nil {
+					return nil, err
+				}
+
+				return json.Marshal(database.TeamSlugChange{OldSlug: v1.Old, NewSlug: v1.New})
+			},
+		},
+	}
+
+	expected := &database.TeamSlugChange{OldSlug: "old", NewSlug: "new"}
+
+	testCases := []struct {
+		name    string
+		payload string
+		version int32
+		err     error
+	}{
+		{name: "current version", payload: `{"OldSlug":"old","NewSlug":"new"}`, version: 2},
+		{name: "earlier version is upcast", payload: `{"Old":"old","New":"new"}`, version: 1},
+		{name: "newer version is rejected", payload: `{}`, version: 3, err: ErrUnknownSchemaVersion},
+		{name: "missing version is rejected", payload: `{}`, version: 0, err: ErrUnknownSchemaVersion},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			form, err := schema.decodeVersion(json.RawMessage(tc.payload), tc.version, 2)
+			if tc.err != nil {
+				if !errors.Is(err, tc.err) {
+					t.Errorf("expected %v, got %v", tc.err, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(expected, form); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	t.Run("versions without an upcaster are rejected", func(t *testing.T) {
+		_, err := schema.decodeVersion(json.RawMessage(`{}`), 1, 3)
+		if !errors.Is(err, ErrUnknownSchemaVersion) {
+			t.Errorf("expected %v, got %v", ErrUnknownSchemaVersion, err)
+		}
+	})
+}
+
+func TestDecodePayload(t *testing.T) {
+	_, err := decodePayload(gensql.Event{
+		Type:          string(database.EventTypeCreateTeam),
+		Payload:       []byte(`{}`),
+		SchemaVersion: database.EventSchemaVersion(database.EventTypeCreateTeam) + 1,
+	})
+	if !errors.Is(err, ErrUnknownSchemaVersion) {
+		t.Errorf("expected events registered by a newer version to be rejected, got %v", err)
+	}
+}
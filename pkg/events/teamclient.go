@@ -4,36 +4,50 @@ import (
 	"context"
 
 	"github.com/navikt/knorten/pkg/database"
-	"github.com/navikt/knorten/pkg/database/gensql"
+	"github.com/navikt/knorten/pkg/logger"
 )
 
 type teamClient interface {
-	Create(ctx context.Context, team *gensql.Team) error
-	Update(ctx context.Context, team *gensql.Team) error
-	Delete(ctx context.Context, teamID string) error
+	Create(ctx context.Context, team *database.Team, log logger.Logger) error
+	CompensateCreate(ctx context.Context, teamID string, log logger.Logger) error
+	Update(ctx context.Context, team *database.Team, opts ...database.EventOption) error
+	Rename(ctx context.Context, teamID string, change database.TeamSlugChange, opts ...database.EventOption) error
+	Delete(ctx context.Context, teamID string, opts ...database.EventOption) error
 }
 
 type teamMock struct {
-	EventCounts map[database.EventType]int
+	EventCounts   map[database.EventType]int
+	Compensations map[string]int
 }
 
 func newTeamMock() teamMock {
 	return teamMock{
-		EventCounts: map[database.EventType]int{},
+		EventCounts:   map[database.EventType]int{},
+		Compensations: map[string]int{},
 	}
 }
 
-func (tm teamMock) Create(ctx context.Context, team *gensql.Team) error {
+func (tm teamMock) Create(ctx context.Context, team *database.Team, log logger.Logger) error {
 	tm.EventCounts[database.EventTypeCreateTeam]++
 	return nil
 }
 
-func (tm teamMock) Update(ctx context.Context, team *gensql.Team) error {
+func (tm teamMock) CompensateCreate(ctx context.Context, teamID string, log logger.Logger) error {
+	tm.Compensations[teamID]++
+	return nil
+}
+
+func (tm teamMock) Update(ctx context.Context, team *database.Team, opts ...database.EventOption) error {
 	tm.EventCounts[database.EventTypeUpdateTeam]++
 	return nil
 }
 
-func (tm teamMock) Delete(ctx context.Context, teamID string) error {
+func (tm teamMock) Rename(ctx context.Context, teamID string, change database.TeamSlugChange, opts ...database.EventOption) error {
+	tm.EventCounts[database.EventTypeRenameTeam]++
+	return nil
+}
+
+func (tm teamMock) Delete(ctx context.Context, teamID string, opts ...database.EventOption) error {
 	tm.EventCounts[database.EventTypeDeleteTeam]++
 	return nil
 }
@@ -3,11 +3,14 @@ package gcp
 import (
 	"context"
 	"fmt"
+	"slices"
 
 	secretmanager "cloud.google.com/go/secretmanager/apiv1"
 	"cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
 	"github.com/googleapis/gax-go/v2/apierror"
+	"github.com/navikt/knorten/pkg/metrics"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
 )
 
 func CreateSecret(ctx context.Context, gcpProject, gcpRegion, secretID string, labels map[string]string) (*secretmanagerpb.Secret, error) {
@@ -39,13 +42,16 @@ func CreateSecret(ctx context.Context, gcpProject, gcpRegion, secretID string, l
 	}
 
 	s, err := client.CreateSecret(ctx, req)
+	observe("secretmanager.CreateSecret", err, codes.AlreadyExists)
 	if err != nil {
 		apiError, ok := apierror.FromError(err)
 		if ok {
 			if apiError.GRPCStatus().Code() == codes.AlreadyExists {
-				return client.GetSecret(ctx, &secretmanagerpb.GetSecretRequest{
+				s, err := client.GetSecret(ctx, &secretmanagerpb.GetSecretRequest{
 					Name: fmt.Sprintf("projects/%v/secrets/%v", gcpProject, secretID),
 				})
+				observe("secretmanager.GetSecret", err)
+				return s, err
 			}
 		}
 		return nil, err
@@ -72,6 +78,7 @@ func DeleteSecret(ctx context.Context, gcpProject, secretID string) error {
 	}
 
 	err = client.DeleteSecret(ctx, req)
+	observe("secretmanager.DeleteSecret", err, codes.NotFound)
 	if err != nil {
 		apiError, ok := apierror.FromError(err)
 		if ok && apiError.GRPCStatus().Code() == codes.NotFound {
@@ -83,3 +90,51 @@ func DeleteSecret(ctx context.Context, gcpProject, secretID string) error {
 
 	return nil
 }
+
+// UpdateSecretLabels sets the given labels on the secret, leaving the other labels as they are
+func UpdateSecretLabels(ctx context.Context, gcpProject, secretID string, labels map[string]string) error {
+	client, err := secretmanager.NewClient(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	name := fmt.Sprintf("projects/%v/secrets/%v", gcpProject, secretID)
+	secret, err := client.GetSecret(ctx, &secretmanagerpb.GetSecretRequest{
+		Name: name,
+	})
+	observe("secretmanager.GetSecret", err)
+	if err != nil {
+		return err
+	}
+
+	if secret.Labels == nil {
+		secret.Labels = map[string]string{}
+	}
+
+	for key, value := range labels {
+		secret.Labels[key] = value
+	}
+
+	_, err = client.UpdateSecret(ctx, &secretmanagerpb.UpdateSecretRequest{
+		Secret: &secretmanagerpb.Secret{
+			Name:   name,
+			Labels: secret.Labels,
+		},
+		UpdateMask: &fieldmaskpb.FieldMask{
+			Paths: []string{"labels"},
+		},
+	})
+	observe("secretmanager.UpdateSecret", err)
+
+	return err
+}
+
+// observe counts the request to the GCP API, where the expected error codes count as a success
+func observe(operation string, err error, expected ...codes.Code) {
+	if apiError, ok := apierror.FromError(err); ok && slices.Contains(expected, apiError.GRPCStatus().Code()) {
+		err = nil
+	}
+
+	metrics.APIRequest(metrics.APIGCP, operation, err)
+}
@@ -23,6 +23,7 @@ func SetUsersSecretOwnerBinding(ctx context.Context, users []string, secret stri
 
 	handle := client.IAM(secret)
 	policy, err := handle.Policy(ctx)
+	observe("secretmanager.GetIamPolicy", err)
 	if err != nil {
 		return err
 	}
@@ -35,6 +36,7 @@ func SetUsersSecretOwnerBinding(ctx context.Context, users []string, secret stri
 	}
 
 	err = handle.SetPolicy(ctx, policy)
+	observe("secretmanager.SetIamPolicy", err)
 	if err != nil {
 		return err
 	}
@@ -50,6 +52,7 @@ func SetUsersSecretOwnerBinding(ctx context.Context, users []string, secret stri
 
 func updatePolicy(ctx context.Context, handle *iam.Handle, user string) error {
 	policy, err := handle.Policy(ctx)
+	observe("secretmanager.GetIamPolicy", err)
 	if err != nil {
 		return err
 	}
@@ -58,6 +61,7 @@ func updatePolicy(ctx context.Context, handle *iam.Handle, user string) error {
 	if !slices.Contains(policyMembers, user) {
 		policy.Add(user, secretRoleName)
 		err = handle.SetPolicy(ctx, policy)
+		observe("secretmanager.SetIamPolicy", err, codes.InvalidArgument)
 		if err != nil {
 			apiError, ok := apierror.FromError(err)
 			if ok && apiError.GRPCStatus().Code() == codes.InvalidArgument {
@@ -7,6 +7,10 @@ import (
 	"slices"
 
 	"github.com/hashicorp/errwrap"
+	"github.com/navikt/knorten/pkg/metrics"
+	"github.com/navikt/knorten/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/api/googleapi"
 	"google.golang.org/api/iam/v1"
 )
@@ -74,22 +78,28 @@ type serviceAccountPolicyManager struct {
 	project string
 }
 
-func (s *serviceAccountPolicyManager) SetPolicy(ctx context.Context, resource string, policy *iam.Policy) (*iam.Policy, error) {
+func (s *serviceAccountPolicyManager) SetPolicy(ctx context.Context, resource string, policy *iam.Policy) (_ *iam.Policy, err error) {
+	ctx, span := startSpan(ctx, "gcpapi.SetIamPolicy", resource)
+	defer func() { tracing.End(span, err) }()
+
 	request := &iam.SetIamPolicyRequest{
 		Policy: policy,
 	}
 
 	p, err := s.Projects.ServiceAccounts.SetIamPolicy(resource, request).Context(ctx).Do()
-	if err != nil {
+	if err := metrics.APIRequest(metrics.APIGCP, "iam.SetIamPolicy", err); err != nil {
 		return nil, fmt.Errorf("setting service account policy: %w", err)
 	}
 
 	return p, nil
 }
 
-func (s *serviceAccountPolicyManager) GetPolicy(ctx context.Context, resource string) (*iam.Policy, error) {
+func (s *serviceAccountPolicyManager) GetPolicy(ctx context.Context, resource string) (_ *iam.Policy, err error) {
+	ctx, span := startSpan(ctx, "gcpapi.GetIamPolicy", resource)
+	defer func() { tracing.End(span, err) }()
+
 	policy, err := s.Projects.ServiceAccounts.GetIamPolicy(resource).Context(ctx).Do()
-	if err != nil {
+	if err := metrics.APIRequest(metrics.APIGCP, "iam.GetIamPolicy", err); err != nil {
 		return nil, fmt.Errorf("getting service account policy: %w", err)
 	}
 
@@ -166,7 +176,17 @@ type serviceAccountFetcher struct {
 func (s *serviceAccountFetcher) Get(ctx context.Context, name string) (*iam.ServiceAccount, error) {
 	resource := ServiceAccountResource(name, s.project)
 
+	ctx, span := startSpan(ctx, "gcpapi.GetServiceAccount", resource)
+
 	sa, err := s.Projects.ServiceAccounts.Get(resource).Context(ctx).Do()
+	// Not finding the service account is how the checker learns that it doesn't exist
+	unexpected := err
+	if IsGoogleApiErrorWithCode(err, http.StatusNotFound) {
+		unexpected = nil
+	}
+	metrics.APIRequest(metrics.APIGCP, "iam.GetServiceAccount", unexpected)
+	tracing.End(span, unexpected)
+
 	if err != nil {
 		return nil, err
 	}
@@ -206,6 +226,15 @@ func NewServiceAccountChecker(project string, fetcher ServiceAccountFetcher) Ser
 	}
 }
 
+var tracer = tracing.Tracer("github.com/navikt/knorten/pkg/gcpapi")
+
+func startSpan(ctx context.Context, name, resource string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("gcp.resource", resource)),
+	)
+}
+
 // Borrowed from Hashicorp's GCP provider:
 // - https://github.com/hashicorp/terraform-provider-google/blob/main/google/transport/transport.go#L150-L153
 func IsGoogleApiErrorWithCode(err error, errCode int) bool {
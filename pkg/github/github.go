@@ -10,6 +10,7 @@ import (
 	"github.com/bradleyfalzon/ghinstallation/v2"
 	"github.com/google/go-github/v62/github"
 	"github.com/gregjones/httpcache"
+	"github.com/navikt/knorten/pkg/metrics"
 	"github.com/sirupsen/logrus"
 )
 
@@ -152,6 +153,7 @@ type Fetcher struct {
 	log          *logrus.Entry
 	lister       Lister
 	repositories map[string]Repository
+	refreshedAt  time.Time
 	mu           sync.RWMutex
 }
 
@@ -175,10 +177,33 @@ func (s *Fetcher) Refresh(ctx context.Context) (int, error) {
 	for _, repo := range repos {
 		s.repositories[repo.Name] = repo
 	}
+	s.refreshedAt = time.Now()
 
 	return len(s.repositories), nil
 }
 
+// RefreshedAt returns when the repositories were last refreshed successfully
+func (s *Fetcher) RefreshedAt() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.refreshedAt
+}
+
+// CheckFreshness returns an error if the repositories have not been refreshed within maxAge
+func (s *Fetcher) CheckFreshness(maxAge time.Duration) error {
+	refreshedAt := s.RefreshedAt()
+	if refreshedAt.IsZero() {
+		return fmt.Errorf("github repositories have not been refreshed yet")
+	}
+
+	if age := time.Since(refreshedAt); age > maxAge {
+		return fmt.Errorf("github repositories were last refreshed %v ago, more than %v", age.Round(time.Second), maxAge)
+	}
+
+	return nil
+}
+
 func (s *Fetcher) StartRefreshLoop(ctx context.Context, interval time.Duration) {
 	s.log.WithField("interval", interval.String()).Info("starting refresh loop")
 
@@ -186,8 +211,11 @@ func (s *Fetcher) StartRefreshLoop(ctx context.Context, interval time.Duration)
 		t0 := time.Now()
 
 		n, err := s.Refresh(ctx)
+		refreshDuration.WithLabelValues(metrics.Outcome(err)).Observe(time.Since(t0).Seconds())
 		if err != nil {
 			s.log.WithError(err).Error("refreshing repositories")
+		} else {
+			repositories.Set(float64(n))
 		}
 
 		s.log.WithField("num_repos", n).WithField("refresh_duration", time.Since(t0).String()).Info("done refreshing github repositories")
@@ -0,0 +1,24 @@
+package github
+
+import (
+	"github.com/navikt/knorten/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	refreshDuration = promauto.With(metrics.Registry).NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "github",
+		Name:      "refresh_duration_seconds",
+		Help:      "How long refreshing the GitHub repositories takes, by outcome.",
+		Buckets:   prometheus.ExponentialBuckets(0.25, 2, 10),
+	}, []string{"outcome"})
+
+	repositories = promauto.With(metrics.Registry).NewGauge(prometheus.GaugeOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "github",
+		Name:      "repositories",
+		Help:      "Repositories known after the last refresh.",
+	})
+)
@@ -0,0 +1,96 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChecker_Readyz(t *testing.T) {
+	ok := Check{Name: "ok", Fn: func(context.Context) error { return nil }}
+	failing := Check{Name: "failing", Fn: func(context.Context) error { return errors.New("connection refused") }}
+	hanging := Check{Name: "hanging", Fn: func(context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	}}
+
+	testCases := []struct {
+		name       string
+		checks     []Check
+		wantStatus int
+		want       Report
+	}{
+		{
+			name:       "all checks pass",
+			checks:     []Check{ok},
+			wantStatus: http.StatusOK,
+			want: Report{
+				Status: StatusOK,
+				Checks: map[string]CheckResult{"ok": {Status: StatusOK}},
+			},
+		},
+		{
+			name:       "a check fails",
+			checks:     []Check{ok, failing},
+			wantStatus: http.StatusServiceUnavailable,
+			want: Report{
+				Status: StatusFail,
+				Checks: map[string]CheckResult{
+					"ok":      {Status: StatusOK},
+					"failing": {Status: StatusFail, Error: "connection refused"},
+				},
+			},
+		},
+		{
+			name:       "a check ignoring the context times out",
+			checks:     []Check{hanging},
+			wantStatus: http.StatusServiceUnavailable,
+			want: Report{
+				Status: StatusFail,
+				Checks: map[string]CheckResult{
+					"hanging": {Status: StatusFail, Error: "timed out after 10ms"},
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			checker := NewChecker(10*time.Millisecond, logrus.NewEntry(logrus.New()), tc.checks...)
+
+			rec := httptest.NewRecorder()
+			checker.Readyz(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			assert.Equal(t, tc.wantStatus, rec.Code)
+			assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+			var got Report
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+
+			for name, result := range got.Checks {
+				result.DurationMs = 0
+				got.Checks[name] = result
+			}
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestChecker_Healthz(t *testing.T) {
+	failing := Check{Name: "failing", Fn: func(context.Context) error { return errors.New("connection refused") }}
+	checker := NewChecker(time.Second, logrus.NewEntry(logrus.New()), failing)
+
+	rec := httptest.NewRecorder()
+	checker.Healthz(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"status":"ok"}`, rec.Body.String())
+}
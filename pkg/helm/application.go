@@ -5,13 +5,17 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"path/filepath"
 	"slices"
 	"strconv"
 	"strings"
 	"time"
 
 	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/helmpath"
 	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/repo"
 
 	"github.com/navikt/knorten/pkg/database"
 	"github.com/navikt/knorten/pkg/database/gensql"
@@ -52,7 +56,27 @@ func NewClient(config *Config, repo *database.Repo) (*Client, error) {
 	}, nil
 }
 
-func (c *Client) InstallOrUpgrade(ctx context.Context, ev *EventData) error {
+// CheckRepositories verifies that the index of every configured repository
+// is present in the cache and can be loaded
+func (c *Client) CheckRepositories(_ context.Context) error {
+	settings := cli.New()
+
+	f, err := repo.LoadFile(c.cfg.RepositoryConfig)
+	if err != nil {
+		return fmt.Errorf("loading repository config: %w", err)
+	}
+
+	for _, r := range f.Repositories {
+		indexFile := filepath.Join(settings.RepositoryCache, helmpath.CacheIndexFile(r.Name))
+		if _, err := repo.LoadIndexFile(indexFile); err != nil {
+			return fmt.Errorf("loading index for repository %v: %w", r.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func (c *Client) InstallOrUpgrade(ctx context.Context, ev *EventData, opts ...database.EventOption) error {
 	// The enrichers are processed in the order they are added
 	enrichers := []Enricher{
 		NewGlobalEnricher(ev.ChartType, c.repo),
@@ -72,12 +96,14 @@ func (c *Client) InstallOrUpgrade(ctx context.Context, ev *EventData) error {
 		NewChainEnricher(enrichers...),
 	)
 
+	start := time.Now()
 	err := c.ops.Apply(ctx, l, &ApplyOpts{
 		ReleaseName: ev.ReleaseName,
 		Namespace:   ev.Namespace,
 	})
+	observeOperation(operationApply, ev.ChartType, start, err)
 	if err != nil {
-		handleErrWithRollback(ctx, err, ev, c)
+		handleErrWithRollback(ctx, err, ev, c, opts...)
 
 		return fmt.Errorf("installing or upgrading %v failed: %w", ev.ChartType, err)
 	}
@@ -86,21 +112,23 @@ func (c *Client) InstallOrUpgrade(ctx context.Context, ev *EventData) error {
 }
 
 // FIXME: Can we get rid of the switch at least, shouldn't be doing this here
-func handleErrWithRollback(ctx context.Context, err error, helmEvent *EventData, c *Client) {
+func handleErrWithRollback(ctx context.Context, err error, helmEvent *EventData, c *Client, opts ...database.EventOption) {
 	var rollbackErr *ErrRollback
 	if errors.As(err, &rollbackErr) {
 		switch helmEvent.ChartType {
 		case gensql.ChartTypeAirflow:
-			_ = c.repo.RegisterHelmRollbackAirflowEvent(ctx, helmEvent.TeamID, helmEvent)
+			_ = c.repo.RegisterHelmRollbackAirflowEvent(ctx, helmEvent.TeamID, helmEvent, opts...)
 		}
 	}
 }
 
 func (c *Client) Uninstall(ctx context.Context, helmEvent *EventData) error {
+	start := time.Now()
 	err := c.ops.Delete(ctx, &DeleteOpts{
 		ReleaseName: helmEvent.ReleaseName,
 		Namespace:   helmEvent.Namespace,
 	})
+	observeOperation(operationUninstall, helmEvent.ChartType, start, err)
 	if err != nil {
 		return fmt.Errorf("uninstalling %v failed: %w", helmEvent.ChartType, err)
 	}
@@ -109,10 +137,12 @@ func (c *Client) Uninstall(ctx context.Context, helmEvent *EventData) error {
 }
 
 func (c *Client) Rollback(ctx context.Context, helmEvent *EventData) error {
+	start := time.Now()
 	err := c.ops.Rollback(ctx, &RollbackOpts{
 		ReleaseName: helmEvent.ReleaseName,
 		Namespace:   helmEvent.Namespace,
 	})
+	observeOperation(operationRollback, helmEvent.ChartType, start, err)
 	if err != nil {
 		return fmt.Errorf("rolling back %v failed: %w", helmEvent.ChartType, err)
 	}
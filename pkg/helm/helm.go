@@ -10,6 +10,10 @@ import (
 	"strings"
 	"time"
 
+	"github.com/navikt/knorten/pkg/plan"
+	"github.com/navikt/knorten/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/oauth2/google"
 	"helm.sh/helm/v3/pkg/action"
 	"helm.sh/helm/v3/pkg/chart"
@@ -133,7 +137,23 @@ func NewHelm(config *Config) *Helm {
 
 var _ Operations = &Helm{}
 
-func (h *Helm) Apply(ctx context.Context, loader ChartLoader, opts *ApplyOpts) error {
+var tracer = tracing.Tracer("github.com/navikt/knorten/pkg/helm")
+
+func releaseAttributes(releaseName, namespace string) trace.SpanStartOption {
+	return trace.WithAttributes(
+		attribute.String("helm.release", releaseName),
+		attribute.String("k8s.namespace.name", namespace),
+	)
+}
+
+func releaseTarget(releaseName, namespace string) string {
+	return fmt.Sprintf("%v/%v", namespace, releaseName)
+}
+
+func (h *Helm) Apply(ctx context.Context, loader ChartLoader, opts *ApplyOpts) (err error) {
+	ctx, span := tracer.Start(ctx, "helm.Apply", releaseAttributes(opts.ReleaseName, opts.Namespace))
+	defer func() { tracing.End(span, err) }()
+
 	restoreFn, err := EstablishEnv(h.config.ToHelmEnvs())
 	if err != nil {
 		return fmt.Errorf("establishing helm env: %w", err)
@@ -163,13 +183,16 @@ func (h *Helm) Apply(ctx context.Context, loader ChartLoader, opts *ApplyOpts) e
 		return fmt.Errorf("checking if release exists: %w", err)
 	}
 
-	ch, err := loader.Load(ctx)
+	loadCtx, secrets := withSecretKeys(ctx)
+
+	ch, err := loader.Load(loadCtx)
 	if err != nil {
 		return fmt.Errorf("loading values: %w", err)
 	}
 
 	if !exists {
 		// Release does not exist, so we install
+		plan.Record(ctx, plan.KindHelm, "install", releaseTarget(opts.ReleaseName, opts.Namespace), secrets.redact(ch.Values))
 		installClient := action.NewInstall(actionConfig)
 		installClient.Namespace = opts.Namespace
 		installClient.ReleaseName = opts.ReleaseName
@@ -190,6 +213,7 @@ func (h *Helm) Apply(ctx context.Context, loader ChartLoader, opts *ApplyOpts) e
 	}
 
 	// Release exists, so we upgrade
+	plan.Record(ctx, plan.KindHelm, "upgrade", releaseTarget(opts.ReleaseName, opts.Namespace), secrets.redact(ch.Values))
 	upgradeClient := action.NewUpgrade(actionConfig)
 	upgradeClient.Namespace = opts.Namespace
 	upgradeClient.Timeout = timeout
@@ -207,7 +231,10 @@ func (h *Helm) Apply(ctx context.Context, loader ChartLoader, opts *ApplyOpts) e
 	return nil
 }
 
-func (h *Helm) Delete(_ context.Context, opts *DeleteOpts) error {
+func (h *Helm) Delete(ctx context.Context, opts *DeleteOpts) (err error) {
+	_, span := tracer.Start(ctx, "helm.Delete", releaseAttributes(opts.ReleaseName, opts.Namespace))
+	defer func() { tracing.End(span, err) }()
+
 	restoreFn, err := EstablishEnv(h.config.ToHelmEnvs())
 	if err != nil {
 		return fmt.Errorf("establishing helm env: %w", err)
@@ -243,7 +270,9 @@ func (h *Helm) Delete(_ context.Context, opts *DeleteOpts) error {
 		return nil
 	}
 
+	plan.Record(ctx, plan.KindHelm, "uninstall", releaseTarget(opts.ReleaseName, opts.Namespace), nil)
 	uninstallClient := action.NewUninstall(actionConfig)
+	uninstallClient.DryRun = h.config.DryRun
 	_, err = uninstallClient.Run(opts.ReleaseName)
 	if err != nil {
 		return fmt.Errorf("uninstalling release: %w", err)
@@ -252,7 +281,10 @@ func (h *Helm) Delete(_ context.Context, opts *DeleteOpts) error {
 	return nil
 }
 
-func (h *Helm) Rollback(_ context.Context, opts *RollbackOpts) error {
+func (h *Helm) Rollback(ctx context.Context, opts *RollbackOpts) (err error) {
+	_, span := tracer.Start(ctx, "helm.Rollback", releaseAttributes(opts.ReleaseName, opts.Namespace))
+	defer func() { tracing.End(span, err) }()
+
 	restoreFn, err := EstablishEnv(h.config.ToHelmEnvs())
 	if err != nil {
 		return fmt.Errorf("establishing helm env: %w", err)
@@ -282,8 +314,10 @@ func (h *Helm) Rollback(_ context.Context, opts *RollbackOpts) error {
 		return fmt.Errorf("getting last successful helm release: %w", err)
 	}
 
+	plan.Record(ctx, plan.KindHelm, "rollback", releaseTarget(opts.ReleaseName, opts.Namespace), map[string]int{"version": version})
 	rollbackClient := action.NewRollback(actionConfig)
 	rollbackClient.Version = version
+	rollbackClient.DryRun = h.config.DryRun
 	if err := rollbackClient.Run(opts.ReleaseName); err != nil {
 		return fmt.Errorf("rolling back release: %w", err)
 	}
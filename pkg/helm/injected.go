@@ -0,0 +1,164 @@
+package helm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/navikt/knorten/pkg/database/gensql"
+	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	injectedInitContainersKey = "workers.extraInitContainers"
+	injectedVolumesKey        = "workers.extraVolumes"
+)
+
+// ParseInjectedContainer validates the container spec and volumes an admin has written as YAML
+// (or JSON), and returns them as the JSON we store in the database. Unknown fields are rejected,
+// so typos don't silently disappear from the rollout.
+func ParseInjectedContainer(containerSpec, volumesSpec string) (string, string, error) {
+	var container v1.Container
+	if err := strictUnmarshal(containerSpec, &container); err != nil {
+		return "", "", fmt.Errorf("parsing container: %w", err)
+	}
+
+	if container.Name == "" {
+		return "", "", fmt.Errorf("container is missing name")
+	}
+
+	if container.Image == "" {
+		return "", "", fmt.Errorf("container %v is missing image", container.Name)
+	}
+
+	volumes := []v1.Volume{}
+	if strings.TrimSpace(volumesSpec) != "" {
+		if err := strictUnmarshal(volumesSpec, &volumes); err != nil {
+			return "", "", fmt.Errorf("parsing volumes: %w", err)
+		}
+	}
+
+	for _, volume := range volumes {
+		if volume.Name == "" {
+			return "", "", fmt.Errorf("volume is missing name")
+		}
+	}
+
+	containerJSON, err := json.Marshal(container)
+	if err != nil {
+		return "", "", fmt.Errorf("marshalling container: %w", err)
+	}
+
+	volumesJSON, err := json.Marshal(volumes)
+	if err != nil {
+		return "", "", fmt.Errorf("marshalling volumes: %w", err)
+	}
+
+	return string(containerJSON), string(volumesJSON), nil
+}
+
+func strictUnmarshal(spec string, into any) error {
+	raw, err := yaml.YAMLToJSON([]byte(spec))
+	if err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+
+	return decoder.Decode(into)
+}
+
+// InjectedContainerValues builds the chart values for the injected containers, leaving out
+// disabled containers, and those the team has opted out of when the policy allows it.
+func InjectedContainerValues(containers []gensql.InjectedContainer, optOuts []string) (map[string]any, error) {
+	initContainers := []any{}
+	volumes := []any{}
+
+	for _, c := range containers {
+		if !c.Enabled {
+			continue
+		}
+
+		if c.OptOutAllowed && slices.Contains(optOuts, c.Name) {
+			continue
+		}
+
+		var container map[string]any
+		if err := json.Unmarshal([]byte(c.Container), &container); err != nil {
+			return nil, fmt.Errorf("unmarshalling injected container %v: %w", c.Name, err)
+		}
+
+		initContainers = append(initContainers, container)
+
+		var containerVolumes []any
+		if err := json.Unmarshal([]byte(c.Volumes), &containerVolumes); err != nil {
+			return nil, fmt.Errorf("unmarshalling volumes for injected container %v: %w", c.Name, err)
+		}
+
+		volumes = append(volumes, containerVolumes...)
+	}
+
+	values := map[string]any{}
+	if len(initContainers) > 0 {
+		SetChartValue(keySplitHandleEscape(injectedInitContainersKey), initContainers, values)
+	}
+
+	if len(volumes) > 0 {
+		SetChartValue(keySplitHandleEscape(injectedVolumesKey), volumes, values)
+	}
+
+	return values, nil
+}
+
+// InjectedContainerPreview renders the values an injected container adds to the chart, so admins
+// can see what will be rolled out before saving a new version.
+func InjectedContainerPreview(container gensql.InjectedContainer) (string, error) {
+	values, err := InjectedContainerValues([]gensql.InjectedContainer{container}, nil)
+	if err != nil {
+		return "", err
+	}
+
+	out, err := yaml.Marshal(values)
+	if err != nil {
+		return "", fmt.Errorf("marshalling preview: %w", err)
+	}
+
+	return string(out), nil
+}
+
+// appendInjectedValues appends the injected containers and volumes to the lists already in the
+// values, instead of replacing whatever the chart or global values have put there.
+func appendInjectedValues(values, injected map[string]any) map[string]any {
+	for _, key := range []string{injectedInitContainersKey, injectedVolumesKey} {
+		keys := keySplitHandleEscape(key)
+
+		items, ok := chartValue(keys, injected).([]any)
+		if !ok {
+			continue
+		}
+
+		existing, _ := chartValue(keys, values).([]any)
+		SetChartValue(keys, append(slices.Clone(existing), items...), values)
+	}
+
+	return values
+}
+
+func chartValue(keys []string, values map[string]any) any {
+	var current any = values
+
+	for _, key := range keys {
+		m, ok := current.(map[string]any)
+		if !ok {
+			return nil
+		}
+
+		current = m[key]
+	}
+
+	return current
+}
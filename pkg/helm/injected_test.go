@@ -0,0 +1,88 @@
+package helm_test
+
+import (
+	"testing"
+
+	"github.com/navikt/knorten/pkg/database/gensql"
+	"github.com/navikt/knorten/pkg/helm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseInjectedContainer(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name            string
+		container       string
+		volumes         string
+		expectContainer string
+		expectVolumes   string
+		expectErr       bool
+	}{
+		{
+			name: "yaml container without volumes",
+			container: `name: knaudit
+image: knaudit:latest
+env:
+  - name: GIT_REPO_PATH
+    value: /dags`,
+			expectContainer: `{"name":"knaudit","image":"knaudit:latest","env":[{"name":"GIT_REPO_PATH","value":"/dags"}],"resources":{}}`,
+			expectVolumes:   `[]`,
+		},
+		{
+			name:            "json container with volumes",
+			container:       `{"name":"knaudit","image":"knaudit:latest"}`,
+			volumes:         `[{"name":"audit","emptyDir":{}}]`,
+			expectContainer: `{"name":"knaudit","image":"knaudit:latest","resources":{}}`,
+			expectVolumes:   `[{"name":"audit","emptyDir":{}}]`,
+		},
+		{
+			name:      "unknown field",
+			container: `{"name":"knaudit","image":"knaudit:latest","imagee":"typo"}`,
+			expectErr: true,
+		},
+		{
+			name:      "missing image",
+			container: `{"name":"knaudit"}`,
+			expectErr: true,
+		},
+		{
+			name:      "volume without name",
+			container: `{"name":"knaudit","image":"knaudit:latest"}`,
+			volumes:   `[{"emptyDir":{}}]`,
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			container, volumes, err := helm.ParseInjectedContainer(tc.container, tc.volumes)
+			if tc.expectErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.JSONEq(t, tc.expectContainer, container)
+			assert.JSONEq(t, tc.expectVolumes, volumes)
+		})
+	}
+}
+
+func TestInjectedContainerPreview(t *testing.T) {
+	t.Parallel()
+
+	preview, err := helm.InjectedContainerPreview(gensql.InjectedContainer{
+		Name:      "knaudit",
+		Enabled:   true,
+		Container: `{"name":"knaudit","image":"knaudit:latest"}`,
+		Volumes:   "[]",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "workers:\n  extraInitContainers:\n  - image: knaudit:latest\n    name: knaudit\n", preview)
+}
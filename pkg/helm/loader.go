@@ -2,18 +2,20 @@ package helm
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"slices"
 
+	"github.com/navikt/knorten/pkg/database"
 	"github.com/navikt/knorten/pkg/database/gensql"
 	"helm.sh/helm/v3/pkg/chart"
 )
 
 const (
-	ProfileListKey  = "singleuser.profileList"
-	EnvKey          = "env"
-	KnauditImageKey = "knauditImage,omit"
+	ProfileListKey = "singleuser.profileList"
+	EnvKey         = "env"
 )
 
 type Enricher interface {
@@ -114,6 +116,9 @@ func (g *GlobalEnricher) Enrich(
 		}
 
 		keys := keySplitHandleEscape(v.Key)
+		if v.Encrypted {
+			markSecret(ctx, keys)
+		}
 
 		value, err := ParseValue(v.Value)
 		if err != nil {
@@ -186,6 +191,8 @@ type AirflowEnricherStore interface {
 		key string,
 	) (gensql.ChartGlobalValue, error)
 	TeamValueGet(ctx context.Context, key, teamID string) (gensql.ChartTeamValue, error)
+	InjectedContainersGet(ctx context.Context, chartType gensql.ChartType) ([]gensql.InjectedContainer, error)
+	InjectedContainerOptOutsGet(ctx context.Context, chartType gensql.ChartType, teamID string) ([]string, error)
 }
 
 type AirflowEnricher struct {
@@ -197,105 +204,31 @@ func (e *AirflowEnricher) Enrich(
 	ctx context.Context,
 	values map[string]any,
 ) (map[string]any, error) {
-	knauditImage, err := e.store.GlobalValueGet(ctx, gensql.ChartTypeAirflow, KnauditImageKey)
+	injectedContainers, err := e.store.InjectedContainersGet(ctx, gensql.ChartTypeAirflow)
+	if err != nil {
+		return nil, fmt.Errorf("getting injected containers: %w", err)
+	}
+
+	optOuts, err := e.store.InjectedContainerOptOutsGet(ctx, gensql.ChartTypeAirflow, e.teamID)
 	if err != nil {
-		return nil, fmt.Errorf("getting knaudit image: %w", err)
+		return nil, fmt.Errorf("getting injected container opt-outs: %w", err)
 	}
 
-	image := map[string]any{
-		"workers": map[string]any{
-			"extraInitContainers": []any{
-				map[string]any{
-					"name":  "knaudit",
-					"image": knauditImage.Value,
-					"env": []any{
-						map[string]any{
-							"name": "POD_NAME",
-							"valueFrom": map[string]any{
-								"fieldRef": map[string]any{"fieldPath": "metadata.name"},
-							},
-						},
-						map[string]any{
-							"name": "NAMESPACE",
-							"valueFrom": map[string]any{
-								"fieldRef": map[string]any{"fieldPath": "metadata.namespace"},
-							},
-						},
-						map[string]any{
-							"name":  "KNAUDIT_PROXY_URL",
-							"value": "http://knaudit-proxy.knada-system.svc.cluster.local",
-						},
-						map[string]any{
-							"name":  "CA_CERT_PATH",
-							"value": "/etc/pki/tls/certs/ca-bundle.crt",
-						},
-						map[string]any{
-							"name":  "GIT_REPO_PATH",
-							"value": "/dags",
-						},
-						map[string]any{
-							"name": "AIRFLOW_DAG_ID",
-							"valueFrom": map[string]any{
-								"fieldRef": map[string]any{
-									"fieldPath": "metadata.annotations['dag_id']",
-								},
-							},
-						},
-						map[string]any{
-							"name": "AIRFLOW_RUN_ID",
-							"valueFrom": map[string]any{
-								"fieldRef": map[string]any{
-									"fieldPath": "metadata.annotations['run_id']",
-								},
-							},
-						},
-						map[string]any{
-							"name": "AIRFLOW_TASK_ID",
-							"valueFrom": map[string]any{
-								"fieldRef": map[string]any{
-									"fieldPath": "metadata.annotations['task_id']",
-								},
-							},
-						},
-						map[string]any{
-							"name": "AIRFLOW_DB_URL",
-							"valueFrom": map[string]any{
-								"secretKeyRef": map[string]any{
-									"name": "airflow-db",
-									"key":  "connection",
-								},
-							},
-						},
-					},
-					"resources": map[string]any{
-						"requests": map[string]any{
-							"cpu":    "200m",
-							"memory": "128Mi",
-						},
-					},
-					"volumeMounts": []any{
-						map[string]any{
-							"mountPath": "/dags",
-							"name":      "dags",
-						},
-						map[string]any{
-							"mountPath": "/etc/pki/tls/certs/ca-bundle.crt",
-							"name":      "ca-bundle-pem",
-							"readOnly":  true,
-							"subPath":   "ca-bundle.pem",
-						},
-					},
-					"securityContext": map[string]any{
-						"allowPrivilegeEscalation": false,
-						"runAsGroup":               0,
-						"runAsUser":                50000,
-					},
-				},
-			},
-		},
+	injected, err := InjectedContainerValues(injectedContainers, optOuts)
+	if err != nil {
+		return nil, fmt.Errorf("building injected container values: %w", err)
 	}
 
-	values = mergeMaps(values, image)
+	values = appendInjectedValues(values, injected)
+
+	hibernated, err := e.store.TeamValueGet(ctx, database.TeamValueKeyHibernated, e.teamID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("getting hibernation state: %w", err)
+	}
+
+	if hibernated.Value == "true" {
+		values = mergeMaps(values, hibernatedReplicas())
+	}
 
 	globalEnvsSQL, err := e.store.GlobalValueGet(ctx, gensql.ChartTypeAirflow, EnvKey)
 	if err != nil {
@@ -328,6 +261,18 @@ func (e *AirflowEnricher) Enrich(
 	return values, nil
 }
 
+// hibernatedReplicas scales every Airflow component down to zero, while keeping the
+// rest of the release as is.
+func hibernatedReplicas() map[string]any {
+	replicas := map[string]any{}
+
+	for _, component := range []string{"scheduler", "webserver", "triggerer", "workers", "dagProcessor"} {
+		replicas[component] = map[string]any{"replicas": 0}
+	}
+
+	return replicas
+}
+
 func NewAirflowEnricher(teamID string, store AirflowEnricherStore) *AirflowEnricher {
 	return &AirflowEnricher{
 		teamID: teamID,
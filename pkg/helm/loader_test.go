@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/navikt/knorten/pkg/database"
 	"github.com/navikt/knorten/pkg/database/gensql"
 	"github.com/navikt/knorten/pkg/helm"
 	"github.com/navikt/knorten/pkg/helm/mock"
@@ -155,10 +156,6 @@ func TestEnricher(t *testing.T) {
 			enricher: helm.NewAirflowEnricher(
 				"team",
 				mock.NewEnricherStore(nil, nil, nil, nil).
-					SetGlobalValue(helm.KnauditImageKey, gensql.ChartGlobalValue{
-						Key:   helm.KnauditImageKey,
-						Value: "knaudit:latest",
-					}).
 					SetGlobalValue(helm.EnvKey, gensql.ChartGlobalValue{
 						Key:   helm.EnvKey,
 						Value: "[]",
@@ -174,6 +171,118 @@ func TestEnricher(t *testing.T) {
 				return strings.Contains(p.GoString(), "workers")
 			}, cmp.Ignore()),
 		},
+		{
+			name: "airflow: hibernated",
+			enricher: helm.NewAirflowEnricher(
+				"team",
+				mock.NewEnricherStore(nil, nil, nil, nil).
+					SetGlobalValue(helm.EnvKey, gensql.ChartGlobalValue{
+						Key:   helm.EnvKey,
+						Value: "[]",
+					}).
+					SetTeamValue(helm.EnvKey, gensql.ChartTeamValue{
+						Key:   helm.EnvKey,
+						Value: "[]",
+					}).
+					SetTeamValue(database.TeamValueKeyHibernated, gensql.ChartTeamValue{
+						Key:   database.TeamValueKeyHibernated,
+						Value: "true",
+					}),
+			),
+			values: map[string]any{},
+			expect: map[string]any{
+				"scheduler":    map[string]any{"replicas": 0},
+				"webserver":    map[string]any{"replicas": 0},
+				"triggerer":    map[string]any{"replicas": 0},
+				"dagProcessor": map[string]any{"replicas": 0},
+			},
+			filter: cmp.FilterPath(func(p cmp.Path) bool {
+				return strings.Contains(p.GoString(), "workers")
+			}, cmp.Ignore()),
+		},
+		{
+			name: "airflow: with injected containers",
+			enricher: helm.NewAirflowEnricher(
+				"team",
+				mock.NewEnricherStore(nil, nil, nil, nil).
+					SetGlobalValue(helm.EnvKey, gensql.ChartGlobalValue{
+						Key:   helm.EnvKey,
+						Value: "[]",
+					}).
+					SetTeamValue(helm.EnvKey, gensql.ChartTeamValue{
+						Key:   helm.EnvKey,
+						Value: "[]",
+					}).
+					AddInjectedContainer(gensql.InjectedContainer{
+						Name:      "knaudit",
+						Enabled:   true,
+						Container: `{"name":"knaudit","image":"knaudit:latest"}`,
+						Volumes:   `[{"name":"audit","emptyDir":{}}]`,
+					}).
+					AddInjectedContainer(gensql.InjectedContainer{
+						Name:      "disabled",
+						Enabled:   false,
+						Container: `{"name":"disabled","image":"disabled:latest"}`,
+						Volumes:   "[]",
+					}).
+					AddInjectedContainer(gensql.InjectedContainer{
+						Name:          "optional",
+						Enabled:       true,
+						OptOutAllowed: true,
+						Container:     `{"name":"optional","image":"optional:latest"}`,
+						Volumes:       "[]",
+					}).
+					AddOptOut("optional"),
+			),
+			values: map[string]any{
+				"workers": map[string]any{
+					"extraInitContainers": []any{
+						map[string]any{"name": "existing"},
+					},
+				},
+			},
+			expect: map[string]any{
+				"workers": map[string]any{
+					"extraInitContainers": []any{
+						map[string]any{"name": "existing"},
+						map[string]any{"name": "knaudit", "image": "knaudit:latest"},
+					},
+					"extraVolumes": []any{
+						map[string]any{"name": "audit", "emptyDir": map[string]any{}},
+					},
+				},
+			},
+		},
+		{
+			name: "airflow: opt-out ignored when not allowed",
+			enricher: helm.NewAirflowEnricher(
+				"team",
+				mock.NewEnricherStore(nil, nil, nil, nil).
+					SetGlobalValue(helm.EnvKey, gensql.ChartGlobalValue{
+						Key:   helm.EnvKey,
+						Value: "[]",
+					}).
+					SetTeamValue(helm.EnvKey, gensql.ChartTeamValue{
+						Key:   helm.EnvKey,
+						Value: "[]",
+					}).
+					AddInjectedContainer(gensql.InjectedContainer{
+						Name:      "knaudit",
+						Enabled:   true,
+						Container: `{"name":"knaudit","image":"knaudit:latest"}`,
+						Volumes:   "[]",
+					}).
+					AddOptOut("knaudit"),
+			),
+			values: map[string]any{},
+			expect: map[string]any{
+				"workers": map[string]any{
+					"extraInitContainers": []any{
+						map[string]any{"name": "knaudit", "image": "knaudit:latest"},
+					},
+				},
+			},
+		},
 		{
 			name: "airflow: with error",
 			enricher: helm.NewAirflowEnricher(
@@ -187,10 +296,6 @@ func TestEnricher(t *testing.T) {
 			enricher: helm.NewAirflowEnricher(
 				"team",
 				mock.NewEnricherStore(nil, nil, nil, nil).
-					SetGlobalValue(helm.KnauditImageKey, gensql.ChartGlobalValue{
-						Key:   helm.KnauditImageKey,
-						Value: "knaudit:latest",
-					}).
 					SetGlobalValue(helm.EnvKey, gensql.ChartGlobalValue{
 						Key:   helm.EnvKey,
 						Value: "[{\"globalKey\": \"value\"}]",
@@ -0,0 +1,38 @@
+package helm
+
+import (
+	"time"
+
+	"github.com/navikt/knorten/pkg/database/gensql"
+	"github.com/navikt/knorten/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	operationApply     = "apply"
+	operationRollback  = "rollback"
+	operationUninstall = "uninstall"
+)
+
+var (
+	operations = promauto.With(metrics.Registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "helm",
+		Name:      "operations_total",
+		Help:      "Helm applies, rollbacks and uninstalls, by chart type and outcome.",
+	}, []string{"operation", "chart_type", "outcome"})
+
+	operationDuration = promauto.With(metrics.Registry).NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "helm",
+		Name:      "operation_duration_seconds",
+		Help:      "How long Helm applies, rollbacks and uninstalls take.",
+		Buckets:   []float64{1, 5, 15, 30, 60, 120, 300, 600, 1200},
+	}, []string{"operation", "chart_type"})
+)
+
+func observeOperation(operation string, chartType gensql.ChartType, start time.Time, err error) {
+	operations.WithLabelValues(operation, string(chartType), metrics.Outcome(err)).Inc()
+	operationDuration.WithLabelValues(operation, string(chartType)).Observe(time.Since(start).Seconds())
+}
@@ -14,8 +14,13 @@ type EnricherStore struct {
 	TeamValuesGetFn   func(ctx context.Context, chartType gensql.ChartType, teamID string) ([]gensql.ChartTeamValue, error)
 	DecryptValueFn    func(encValue string) (string, error)
 
-	globalValues map[string]gensql.ChartGlobalValue
-	teamValues   map[string]gensql.ChartTeamValue
+	InjectedContainersGetFn       func(ctx context.Context, chartType gensql.ChartType) ([]gensql.InjectedContainer, error)
+	InjectedContainerOptOutsGetFn func(ctx context.Context, chartType gensql.ChartType, teamID string) ([]string, error)
+
+	globalValues       map[string]gensql.ChartGlobalValue
+	teamValues         map[string]gensql.ChartTeamValue
+	injectedContainers []gensql.InjectedContainer
+	optOuts            []string
 }
 
 // We should probably have a mock for each of the interfaces in the helm package, but
@@ -60,6 +65,21 @@ func (s *EnricherStore) DecryptValue(encValue string) (string, error) {
 	return s.DecryptValueFn(encValue)
 }
 
+func (s *EnricherStore) InjectedContainersGet(
+	ctx context.Context,
+	chartType gensql.ChartType,
+) ([]gensql.InjectedContainer, error) {
+	return s.InjectedContainersGetFn(ctx, chartType)
+}
+
+func (s *EnricherStore) InjectedContainerOptOutsGet(
+	ctx context.Context,
+	chartType gensql.ChartType,
+	teamID string,
+) ([]string, error) {
+	return s.InjectedContainerOptOutsGetFn(ctx, chartType, teamID)
+}
+
 func (e *EnricherStore) SetGlobalValue(key string, value gensql.ChartGlobalValue) *EnricherStore {
 	e.globalValues[key] = value
 	return e
@@ -70,6 +90,16 @@ func (e *EnricherStore) SetTeamValue(key string, value gensql.ChartTeamValue) *E
 	return e
 }
 
+func (e *EnricherStore) AddInjectedContainer(container gensql.InjectedContainer) *EnricherStore {
+	e.injectedContainers = append(e.injectedContainers, container)
+	return e
+}
+
+func (e *EnricherStore) AddOptOut(name string) *EnricherStore {
+	e.optOuts = append(e.optOuts, name)
+	return e
+}
+
 func NewEnricherStore(
 	decryptValue *string,
 	globalValue *gensql.ChartGlobalValue,
@@ -105,6 +135,14 @@ func NewEnricherStore(
 		return []gensql.ChartTeamValue{*teamValue}, err
 	}
 
+	e.InjectedContainersGetFn = func(_ context.Context, chartType gensql.ChartType) ([]gensql.InjectedContainer, error) {
+		return e.injectedContainers, err
+	}
+
+	e.InjectedContainerOptOutsGetFn = func(_ context.Context, chartType gensql.ChartType, teamID string) ([]string, error) {
+		return e.optOuts, err
+	}
+
 	e.DecryptValueFn = func(_ string) (string, error) {
 		if decryptValue == nil {
 			return "", err
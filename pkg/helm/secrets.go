@@ -0,0 +1,58 @@
+package helm
+
+import (
+	"context"
+	"maps"
+)
+
+const redactedValue = "<redacted>"
+
+// secretKeys collects the keys of the values loaded from encrypted global values, so they can
+// be left out when the values are recorded in a plan
+type secretKeys struct {
+	keys [][]string
+}
+
+type secretKeysKey struct{}
+
+func withSecretKeys(ctx context.Context) (context.Context, *secretKeys) {
+	secrets := &secretKeys{}
+	return context.WithValue(ctx, secretKeysKey{}, secrets), secrets
+}
+
+func markSecret(ctx context.Context, keys []string) {
+	if secrets, ok := ctx.Value(secretKeysKey{}).(*secretKeys); ok {
+		secrets.keys = append(secrets.keys, keys)
+	}
+}
+
+// redact returns a copy of values where the secret values are replaced, without changing values
+func (s *secretKeys) redact(values map[string]any) map[string]any {
+	redacted := maps.Clone(values)
+
+	for _, keys := range s.keys {
+		current := redacted
+		for i, key := range keys {
+			value, ok := current[key]
+			if !ok {
+				break
+			}
+
+			if i == len(keys)-1 {
+				current[key] = redactedValue
+				break
+			}
+
+			nested, ok := value.(map[string]any)
+			if !ok {
+				break
+			}
+
+			nested = maps.Clone(nested)
+			current[key] = nested
+			current = nested
+		}
+	}
+
+	return redacted
+}
@@ -0,0 +1,46 @@
+package helm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/navikt/knorten/pkg/database/gensql"
+)
+
+type globalValuesStore []gensql.ChartGlobalValue
+
+func (s globalValuesStore) GlobalValuesGet(context.Context, gensql.ChartType) ([]gensql.ChartGlobalValue, error) {
+	return s, nil
+}
+
+func (s globalValuesStore) DecryptValue(string) (string, error) {
+	return "decrypted", nil
+}
+
+func TestSecretKeys_Redact(t *testing.T) {
+	enricher := NewGlobalEnricher(gensql.ChartTypeAirflow, globalValuesStore{
+		{Key: "webserver.secretKey", Value: "enc", Encrypted: true},
+		{Key: "webserver.replicas", Value: "2"},
+		{Key: "images.airflow.tag", Value: "2.9.0"},
+	})
+
+	ctx, secrets := withSecretKeys(context.Background())
+
+	values, err := enricher.Enrich(ctx, map[string]any{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := map[string]any{
+		"webserver": map[string]any{"secretKey": redactedValue, "replicas": int64(2)},
+		"images":    map[string]any{"airflow": map[string]any{"tag": "2.9.0"}},
+	}
+	if diff := cmp.Diff(expect, secrets.redact(values)); diff != "" {
+		t.Errorf("redact(): mismatch (-want +got):\n%s", diff)
+	}
+
+	if secret := values["webserver"].(map[string]any)["secretKey"]; secret != "decrypted" {
+		t.Errorf("redact(): expected the values to be left as they were, got %v", secret)
+	}
+}
@@ -0,0 +1,58 @@
+package hibernation
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/navikt/knorten/pkg/k8s"
+)
+
+const (
+	// airflowDBSecretName is the secret with the connection to the Airflow metadata database, which
+	// is created along with the database
+	airflowDBSecretName = "airflow-db"
+
+	// lastDagRunQuery is the last time a DAG run started or ended, a run which is still going is
+	// active now
+	lastDagRunQuery = `SELECT MAX(CASE WHEN state = 'running' THEN NOW() ELSE GREATEST(start_date, end_date) END)
+FROM dag_run`
+)
+
+// activitySource tells when a team's Airflow last ran a DAG, the zero time if it never has
+type activitySource interface {
+	LastDagRun(ctx context.Context, teamID string) (time.Time, error)
+}
+
+// airflowDB reads the DAG runs from the metadata database of the team's Airflow. Unlike the task
+// pods, which are gone shortly after the tasks end, the DAG runs are kept, so a run is seen no
+// matter when we check.
+type airflowDB struct {
+	manager k8s.Manager
+}
+
+func (a airflowDB) LastDagRun(ctx context.Context, teamID string) (time.Time, error) {
+	secret, err := a.manager.GetSecret(ctx, airflowDBSecretName, k8s.TeamIDToNamespace(teamID))
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	connection, ok := secret.Data["connection"]
+	if !ok {
+		return time.Time{}, fmt.Errorf("missing connection key in secret %v", secret.Name)
+	}
+
+	db, err := sql.Open("postgres", string(connection))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("opening airflow database: %w", err)
+	}
+	defer db.Close()
+
+	var lastDagRun sql.NullTime
+	if err := db.QueryRowContext(ctx, lastDagRunQuery).Scan(&lastDagRun); err != nil {
+		return time.Time{}, fmt.Errorf("querying dag runs: %w", err)
+	}
+
+	return lastDagRun.Time, nil
+}
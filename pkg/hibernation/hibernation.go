@@ -0,0 +1,126 @@
+package hibernation
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/navikt/knorten/pkg/database"
+	"github.com/navikt/knorten/pkg/database/gensql"
+	"github.com/navikt/knorten/pkg/k8s"
+	"github.com/navikt/knorten/pkg/leaderelection"
+	"github.com/sirupsen/logrus"
+)
+
+// client hibernates Airflow instances that have not run any DAGs for a while. Activity is read
+// from the DAG runs in the Airflow metadata database, and the last activity is stored as a team
+// value, which is also reset when the Airflow is woken.
+type client struct {
+	repo      *database.Repo
+	activity  activitySource
+	idleAfter time.Duration
+	log       *logrus.Entry
+}
+
+func NewClient(repo *database.Repo, manager k8s.Manager, idleAfter time.Duration, log *logrus.Entry) *client {
+	return &client{
+		repo:      repo,
+		activity:  airflowDB{manager: manager},
+		idleAfter: idleAfter,
+		log:       log,
+	}
+}
+
+func (c *client) Run(ctx context.Context, frequency time.Duration) {
+	leaderelection.RunPeriodically(ctx, frequency, c.log, c.run)
+}
+
+func (c *client) run(ctx context.Context) {
+	if err := c.hibernateIdleAirflows(ctx); err != nil {
+		c.log.WithError(err).Error("hibernating idle airflows")
+	}
+}
+
+func (c *client) hibernateIdleAirflows(ctx context.Context) error {
+	teams, err := c.repo.TeamsForChartGet(ctx, gensql.ChartTypeAirflow)
+	if err != nil {
+		return err
+	}
+
+	for _, teamID := range teams {
+		if err := c.checkActivity(ctx, teamID, time.Now()); err != nil {
+			c.log.WithError(err).WithField("team", teamID).Error("checking airflow activity")
+		}
+	}
+
+	return nil
+}
+
+func (c *client) checkActivity(ctx context.Context, teamID string, now time.Time) error {
+	hibernated, err := c.repo.AirflowIsHibernated(ctx, teamID)
+	if err != nil {
+		return fmt.Errorf("getting hibernation state: %w", err)
+	}
+
+	if hibernated {
+		return nil
+	}
+
+	lastActivity, err := c.repo.TeamValueGet(ctx, database.TeamValueKeyLastActivity, teamID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// We have not seen this team before, so we start counting from now
+			return c.repo.TeamValueInsert(ctx, gensql.ChartTypeAirflow, database.TeamValueKeyLastActivity, now.Format(time.RFC3339), teamID)
+		}
+
+		return fmt.Errorf("getting last activity: %w", err)
+	}
+
+	// An Airflow we can't read the DAG runs of is never hibernated
+	lastDagRun, err := c.activity.LastDagRun(ctx, teamID)
+	if err != nil {
+		return fmt.Errorf("getting last dag run: %w", err)
+	}
+
+	last := lastActivity.Value
+	if stored, err := time.Parse(time.RFC3339, last); err == nil && lastDagRun.After(stored) {
+		last = lastDagRun.Format(time.RFC3339)
+		if err := c.repo.TeamValueInsert(ctx, gensql.ChartTypeAirflow, database.TeamValueKeyLastActivity, last, teamID); err != nil {
+			return fmt.Errorf("storing last activity: %w", err)
+		}
+	}
+
+	idle, err := isIdle(last, now, c.idleAfter)
+	if err != nil {
+		return err
+	}
+
+	if !idle {
+		return nil
+	}
+
+	// The hibernation registered on an earlier tick may not have been processed yet
+	pending, err := c.repo.EventUnprocessedExists(ctx, teamID, database.EventTypeHibernateAirflow)
+	if err != nil {
+		return fmt.Errorf("checking for unprocessed hibernation: %w", err)
+	}
+
+	if pending {
+		return nil
+	}
+
+	c.log.WithField("team", teamID).Infof("no airflow activity since %v, registering hibernation", last)
+
+	return c.repo.RegisterHibernateAirflowEvent(ctx, teamID)
+}
+
+func isIdle(lastActivity string, now time.Time, idleAfter time.Duration) (bool, error) {
+	last, err := time.Parse(time.RFC3339, lastActivity)
+	if err != nil {
+		return false, fmt.Errorf("parsing last activity %q: %w", lastActivity, err)
+	}
+
+	return now.Sub(last) >= idleAfter, nil
+}
@@ -0,0 +1,189 @@
+package hibernation
+
+import (
+	"context"
+	"log"
+	"os"
+	"path"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/navikt/knorten/local/dbsetup"
+	"github.com/navikt/knorten/pkg/database"
+	"github.com/navikt/knorten/pkg/database/gensql"
+	"github.com/navikt/knorten/pkg/k8s"
+	"github.com/sirupsen/logrus"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var repo *database.Repo
+
+func init() {
+	_, filename, _, _ := runtime.Caller(0)
+	dir := path.Join(path.Dir(filename), "../..")
+	err := os.Chdir(dir)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func TestMain(m *testing.M) {
+	dbConn, err := dbsetup.SetupDBForTests()
+	if err != nil {
+		log.Fatal(err)
+	}
+	repo, err = database.New(dbConn, "", logrus.NewEntry(logrus.StandardLogger()))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	code := m.Run()
+	os.Exit(code)
+}
+
+// activityStub is an Airflow which last ran a DAG at lastDagRun
+type activityStub struct {
+	lastDagRun time.Time
+}
+
+func (a activityStub) LastDagRun(context.Context, string) (time.Time, error) {
+	return a.lastDagRun, nil
+}
+
+func TestCheckActivity(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	newTeam := func(t *testing.T, id string) string {
+		t.Helper()
+
+		team := gensql.Team{ID: id, Slug: strings.TrimSuffix(id, "-1234"), Users: []string{"owner@nav.no"}}
+		if err := repo.TeamCreate(ctx, &team); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() {
+			if err := repo.TeamDelete(ctx, team.ID); err != nil {
+				t.Error(err)
+			}
+		})
+
+		lastActivity := now.Add(-30 * 24 * time.Hour).Format(time.RFC3339)
+		if err := repo.TeamValueInsert(ctx, gensql.ChartTypeAirflow, database.TeamValueKeyLastActivity, lastActivity, team.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		return team.ID
+	}
+
+	newClient := func(lastDagRun time.Time) *client {
+		manager := k8s.NewManager(&k8s.Client{Client: fake.NewClientBuilder().Build()})
+		c := NewClient(repo, manager, 14*24*time.Hour, logrus.NewEntry(logrus.StandardLogger()))
+		c.activity = activityStub{lastDagRun: lastDagRun}
+		return c
+	}
+
+	t.Run("idle airflow is hibernated once", func(t *testing.T) {
+		teamID := newTeam(t, "hibernate-1234")
+		client := newClient(time.Time{})
+
+		for tick := 0; tick < 2; tick++ {
+			if err := client.checkActivity(ctx, teamID, now); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		events, err := repo.EventsByOwnerGet(ctx, teamID, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(events) != 1 || events[0].Type != string(database.EventTypeHibernateAirflow) {
+			t.Errorf("checkActivity(): expected one %v event after two ticks, got %+v", database.EventTypeHibernateAirflow, events)
+		}
+	})
+
+	t.Run("a dag run which ended between the checks keeps airflow awake", func(t *testing.T) {
+		teamID := newTeam(t, "hibernate-active-1234")
+		lastDagRun := now.Add(-2 * 24 * time.Hour).Truncate(time.Second)
+		client := newClient(lastDagRun)
+
+		if err := client.checkActivity(ctx, teamID, now); err != nil {
+			t.Fatal(err)
+		}
+
+		events, err := repo.EventsByOwnerGet(ctx, teamID, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(events) != 0 {
+			t.Errorf("checkActivity(): expected no events, got %+v", events)
+		}
+
+		lastActivity, err := repo.TeamValueGet(ctx, database.TeamValueKeyLastActivity, teamID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if lastActivity.Value != lastDagRun.Format(time.RFC3339) {
+			t.Errorf("checkActivity(): expected the last activity to be the dag run at %v, got %v", lastDagRun, lastActivity.Value)
+		}
+	})
+}
+
+func TestIsIdle(t *testing.T) {
+	now := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name         string
+		lastActivity string
+		idleAfter    time.Duration
+		expect       bool
+		expectErr    bool
+	}{
+		{
+			name:         "recent activity",
+			lastActivity: "2024-03-14T12:00:00Z",
+			idleAfter:    14 * 24 * time.Hour,
+			expect:       false,
+		},
+		{
+			name:         "idle exactly at the limit",
+			lastActivity: "2024-03-01T12:00:00Z",
+			idleAfter:    14 * 24 * time.Hour,
+			expect:       true,
+		},
+		{
+			name:         "idle for a long time",
+			lastActivity: "2023-12-24T18:00:00Z",
+			idleAfter:    14 * 24 * time.Hour,
+			expect:       true,
+		},
+		{
+			name:         "invalid timestamp",
+			lastActivity: "yesterday",
+			idleAfter:    14 * 24 * time.Hour,
+			expectErr:    true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := isIdle(tc.lastActivity, now, tc.idleAfter)
+			if tc.expectErr {
+				if err == nil {
+					t.Errorf("isIdle(): expected error, got nil")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("isIdle(): unexpected error: %v", err)
+			}
+
+			if got != tc.expect {
+				t.Errorf("isIdle(): expected %v, got %v", tc.expect, got)
+			}
+		})
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/navikt/knorten/pkg/chart"
 	"github.com/navikt/knorten/pkg/database"
 	"github.com/navikt/knorten/pkg/database/gensql"
+	"github.com/navikt/knorten/pkg/metrics"
 	"github.com/sirupsen/logrus"
 	"google.golang.org/api/iterator"
 )
@@ -34,9 +35,8 @@ func NewClient(ctx context.Context, repo *database.Repo, log *logrus.Entry) (*cl
 	}, nil
 }
 
-func (c *client) Run(frequency time.Duration) {
-	ctx := context.Background()
-
+// Run updates the images on every tick, until the context is done
+func (c *client) Run(ctx context.Context, frequency time.Duration) {
 	ticker := time.NewTicker(frequency)
 	defer ticker.Stop()
 	for {
@@ -50,7 +50,12 @@ func (c *client) Run(frequency time.Duration) {
 }
 
 func (c *client) run(ctx context.Context) {
-	if err := c.updateAirflowImages(ctx); err != nil {
+	start := time.Now()
+	err := c.updateAirflowImages(ctx)
+	runDuration.Observe(time.Since(start).Seconds())
+	runs.WithLabelValues(metrics.Outcome(err)).Inc()
+
+	if err != nil {
 		c.log.WithError(err).Error("updating airflow images")
 	}
 }
@@ -62,7 +67,7 @@ func (c *client) triggerSync(ctx context.Context, chartType gensql.ChartType) er
 	}
 
 	for _, team := range teams {
-		err := c.syncChart(ctx, team, chartType)
+		err := c.syncChart(ctx, team, chartType, database.WithEventPriority(database.EventPriorityLow))
 		if err != nil {
 			return err
 		}
@@ -71,13 +76,13 @@ func (c *client) triggerSync(ctx context.Context, chartType gensql.ChartType) er
 	return nil
 }
 
-func (c *client) syncChart(ctx context.Context, teamID string, chartType gensql.ChartType) error {
+func (c *client) syncChart(ctx context.Context, teamID string, chartType gensql.ChartType, opts ...database.EventOption) error {
 	switch chartType {
 	case gensql.ChartTypeAirflow:
 		values := chart.AirflowConfigurableValues{
 			TeamID: teamID,
 		}
-		return c.repo.RegisterUpdateAirflowEvent(ctx, teamID, values)
+		return c.repo.RegisterUpdateAirflowEvent(ctx, teamID, values, opts...)
 	}
 
 	return nil
@@ -0,0 +1,24 @@
+package imageupdater
+
+import (
+	"github.com/navikt/knorten/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	runs = promauto.With(metrics.Registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "imageupdater",
+		Name:      "runs_total",
+		Help:      "Checks for new Airflow images, by outcome.",
+	}, []string{"outcome"})
+
+	runDuration = promauto.With(metrics.Registry).NewHistogram(prometheus.HistogramOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "imageupdater",
+		Name:      "run_duration_seconds",
+		Help:      "How long a check for new Airflow images takes.",
+		Buckets:   prometheus.ExponentialBuckets(0.5, 2, 10),
+	})
+)
@@ -18,6 +18,7 @@ const (
 	defaultRequestMemory           = "500Mi"
 	defaultRequestCPU              = "100m"
 	DefaultBackupRetentionPolicy   = "30d"
+	hibernationAnnotation          = "cnpg.io/hibernation"
 )
 
 type ClusterOption func(*cnpgv1.Cluster)
@@ -72,6 +73,21 @@ func WithMonitoring(monitoring bool) ClusterOption {
 	}
 }
 
+// WithHibernation sets the hibernation annotation, which makes the operator shut down
+// all instances of the cluster while keeping the volumes.
+func WithHibernation(hibernate bool) ClusterOption {
+	return func(c *cnpgv1.Cluster) {
+		if c.Annotations == nil {
+			c.Annotations = make(map[string]string)
+		}
+
+		c.Annotations[hibernationAnnotation] = "off"
+		if hibernate {
+			c.Annotations[hibernationAnnotation] = "on"
+		}
+	}
+}
+
 func NewCluster(name, namespace, database, owner string, options ...ClusterOption) *cnpgv1.Cluster {
 	c := &cnpgv1.Cluster{
 		TypeMeta: metav1.TypeMeta{
@@ -91,6 +91,17 @@ func TestNew(t *testing.T) {
 				cnpg.WithMonitoring(true),
 			),
 		},
+		{
+			name: "cluster-with-hibernation",
+			desc: "Create a new hibernated cluster",
+			cluster: cnpg.NewCluster(
+				"test-cluster",
+				"test-namespace",
+				"test-database",
+				"test-owner",
+				cnpg.WithHibernation(true),
+			),
+		},
 	}
 
 	for _, tc := range testCases {
@@ -0,0 +1,98 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/navikt/knorten/pkg/plan"
+	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+const redacted = "<redacted>"
+
+// dryRunClient sends every write to the cluster as a dry run, and records it as a step of the plan
+type dryRunClient struct {
+	client.Client
+}
+
+// NewDryRunClient creates a dry run client which will not apply any
+// actual changes to the cluster.
+func NewDryRunClient(c client.Client) client.Client {
+	return &dryRunClient{
+		Client: client.NewDryRunClient(c),
+	}
+}
+
+func (c *dryRunClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	plan.Record(ctx, plan.KindKubernetes, "create", c.target(obj), planDetails(obj))
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+func (c *dryRunClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	plan.Record(ctx, plan.KindKubernetes, "update", c.target(obj), planDetails(obj))
+	return c.Client.Update(ctx, obj, opts...)
+}
+
+func (c *dryRunClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	plan.Record(ctx, plan.KindKubernetes, "patch", c.target(obj), planDetails(obj))
+	return c.Client.Patch(ctx, obj, patch, opts...)
+}
+
+func (c *dryRunClient) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	plan.Record(ctx, plan.KindKubernetes, "delete", c.target(obj), nil)
+	return c.Client.Delete(ctx, obj, opts...)
+}
+
+func (c *dryRunClient) DeleteAllOf(ctx context.Context, obj client.Object, opts ...client.DeleteAllOfOption) error {
+	options := &client.DeleteAllOfOptions{}
+	options.ApplyOptions(opts)
+
+	target := fmt.Sprintf("%v %v", c.kind(obj), options.Namespace)
+	if options.LabelSelector != nil {
+		target = fmt.Sprintf("%v (%v)", target, options.LabelSelector)
+	}
+
+	plan.Record(ctx, plan.KindKubernetes, "deleteAllOf", target, nil)
+	return c.Client.DeleteAllOf(ctx, obj, opts...)
+}
+
+func (c *dryRunClient) kind(obj client.Object) string {
+	gvk, err := apiutil.GVKForObject(obj, c.Scheme())
+	if err != nil {
+		return fmt.Sprintf("%T", obj)
+	}
+
+	return gvk.Kind
+}
+
+func (c *dryRunClient) target(obj client.Object) string {
+	if obj.GetNamespace() == "" {
+		return fmt.Sprintf("%v %v", c.kind(obj), obj.GetName())
+	}
+
+	return fmt.Sprintf("%v %v/%v", c.kind(obj), obj.GetNamespace(), obj.GetName())
+}
+
+// planDetails is the object as it would have been written, without the values of secrets
+func planDetails(obj client.Object) any {
+	secret, ok := obj.(*v1.Secret)
+	if !ok {
+		return obj
+	}
+
+	secret = secret.DeepCopy()
+	stringData := map[string]string{}
+	for key := range secret.Data {
+		stringData[key] = redacted
+	}
+	for key := range secret.StringData {
+		stringData[key] = redacted
+	}
+
+	secret.Data = nil
+	secret.StringData = stringData
+
+	return secret
+}
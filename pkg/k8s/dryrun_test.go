@@ -0,0 +1,49 @@
+package k8s_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/navikt/knorten/pkg/k8s"
+	"github.com/navikt/knorten/pkg/k8s/core"
+	"github.com/navikt/knorten/pkg/plan"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+type planRecorder struct {
+	steps []plan.Step
+}
+
+func (r *planRecorder) Record(_ context.Context, step plan.Step) {
+	r.steps = append(r.steps, step)
+}
+
+func TestDryRunClient(t *testing.T) {
+	t.Parallel()
+
+	c := fake.NewClientBuilder().Build()
+	manager := k8s.NewManager(&k8s.Client{Client: k8s.NewDryRunClient(c)})
+
+	recorder := &planRecorder{}
+	ctx := plan.WithRecorder(context.Background(), recorder)
+
+	err := manager.ApplySecret(ctx, core.NewSecret("airflow-db", "team-test", map[string]string{"connection": "postgres://secret"}))
+	require.NoError(t, err)
+
+	err = c.Get(ctx, client.ObjectKey{Name: "airflow-db", Namespace: "team-test"}, &v1.Secret{})
+	assert.True(t, apierrors.IsNotFound(err), "expected the secret not to be created in dry run, got %v", err)
+
+	require.Len(t, recorder.steps, 1)
+	step := recorder.steps[0]
+	assert.Equal(t, plan.KindKubernetes, step.Kind)
+	assert.Equal(t, "Secret team-test/airflow-db", step.Target)
+
+	secret, ok := step.Details.(*v1.Secret)
+	require.True(t, ok, "expected the secret as details, got %T", step.Details)
+	assert.Equal(t, map[string]string{"connection": "<redacted>"}, secret.StringData)
+}
@@ -3,12 +3,18 @@ package k8s
 import (
 	"context"
 	"fmt"
+	"io"
 	"strings"
 	"time"
 
 	cnpgv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
 	"github.com/navikt/knorten/pkg/k8s/core"
 	"github.com/navikt/knorten/pkg/k8s/networking"
+	"github.com/navikt/knorten/pkg/metrics"
+	"github.com/navikt/knorten/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
 	netv1 "k8s.io/api/networking/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -17,9 +23,11 @@ import (
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/klog/v2"
 	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
 	"sigs.k8s.io/controller-runtime/pkg/log"
@@ -30,12 +38,16 @@ import (
 
 const (
 	fieldManager = "knorten"
+	// Same annotation as used by `kubectl rollout restart`
+	restartedAtAnnotation = "kubectl.kubernetes.io/restartedAt"
 )
 
 type Client struct {
 	client.Client
 	RESTConfig *rest.Config
 	KubeConfig *KubeConfig
+	// Clientset is used for what the controller-runtime client doesn't support, like pod logs
+	Clientset kubernetes.Interface
 }
 
 type (
@@ -77,6 +89,11 @@ func NewClient(context string, fn SchemeAdderFn) (*Client, error) {
 		}
 	}
 
+	clientset, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("creating clientset: %w", err)
+	}
+
 	kubeConfig := NewKubeConfig("knorten")
 
 	err = kubeConfig.FromREST(cfg)
@@ -90,13 +107,44 @@ func NewClient(context string, fn SchemeAdderFn) (*Client, error) {
 		Client:     c,
 		RESTConfig: cfg,
 		KubeConfig: kubeConfig,
+		Clientset:  clientset,
 	}, nil
 }
 
-// NewDryRunClient creates a dry run client which will not apply any
-// actual changes to the cluster.
-func NewDryRunClient(c client.Client) client.Client {
-	return client.NewDryRunClient(c)
+// Ping checks that the API server is reachable and ready to serve requests
+func (c *Client) Ping(ctx context.Context) error {
+	if c.Clientset == nil {
+		return fmt.Errorf("pinging api server: missing clientset")
+	}
+
+	err := c.Clientset.Discovery().RESTClient().Get().AbsPath("/readyz").Do(ctx).Error()
+	if err := observe("readyz", err); err != nil {
+		return fmt.Errorf("pinging api server: %w", err)
+	}
+
+	return nil
+}
+
+// NewCache creates a cache backed by watches on the objects in byObject, which can be used
+// as a client.Reader. The cache must be started with Start before it returns any data.
+func NewCache(ctx context.Context, c *Client, byObject map[client.Object]cache.ByObject) (cache.Cache, error) {
+	ca, err := cache.New(c.RESTConfig, cache.Options{
+		Scheme:           c.Scheme(),
+		ByObject:         byObject,
+		DefaultTransform: cache.TransformStripManagedFields(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating cache: %w", err)
+	}
+
+	// Set up the informers right away, so the watches are established when the cache starts
+	for obj := range byObject {
+		if _, err := ca.GetInformer(ctx, obj); err != nil {
+			return nil, fmt.Errorf("getting informer for %T: %w", obj, err)
+		}
+	}
+
+	return ca, nil
 }
 
 type Manager interface {
@@ -114,11 +162,14 @@ type Manager interface {
 	DeleteHealthCheckPolicy(ctx context.Context, name, namespace string) error
 	ApplyNamespace(ctx context.Context, namespace *v1.Namespace) error
 	DeleteNamespace(ctx context.Context, name string) error
+	ListNamespacesWithLabels(ctx context.Context, labels string) ([]v1.Namespace, error)
 	ApplyServiceAccount(ctx context.Context, serviceAccount *v1.ServiceAccount) error
 	DeleteServiceAccount(ctx context.Context, name, namespace string) error
 	ApplyNetworkPolicy(ctx context.Context, policy *netv1.NetworkPolicy) error
 	DeleteNetworkPolicy(ctx context.Context, name, namespace string) error
 	DeletePodsWithLabels(ctx context.Context, namespace, lables string) error
+	RolloutRestartWithLabels(ctx context.Context, namespace, labels string) (int, error)
+	StreamPodLogs(ctx context.Context, namespace, name string, opts *v1.PodLogOptions) (io.ReadCloser, error)
 	GetStatusForPodsWithLabels(ctx context.Context, namespace, labels string) ([]v1.PodStatus, error)
 }
 
@@ -239,6 +290,17 @@ func (m *manager) DeleteNamespace(ctx context.Context, name string) error {
 	return nil
 }
 
+func (m *manager) ListNamespacesWithLabels(ctx context.Context, labels string) ([]v1.Namespace, error) {
+	namespaces := &v1.NamespaceList{}
+
+	err := m.list(ctx, "", labels, namespaces)
+	if err != nil {
+		return nil, fmt.Errorf("listing namespaces with labels: %w", err)
+	}
+
+	return namespaces.Items, nil
+}
+
 func (m *manager) ApplyPostgresCluster(ctx context.Context, cluster *cnpgv1.Cluster) error {
 	err := m.apply(ctx, cluster)
 	if err != nil {
@@ -382,6 +444,99 @@ func (m *manager) DeletePodsWithLabels(ctx context.Context, namespace, lables st
 	return nil
 }
 
+// RolloutRestartWithLabels does a rolling restart of all deployments and statefulsets
+// matching the labels, and returns how many were restarted.
+func (m *manager) RolloutRestartWithLabels(ctx context.Context, namespace, labels string) (int, error) {
+	deployments := &appsv1.DeploymentList{}
+
+	err := m.list(ctx, namespace, labels, deployments)
+	if err != nil {
+		return 0, fmt.Errorf("listing deployments with labels: %w", err)
+	}
+
+	statefulSets := &appsv1.StatefulSetList{}
+
+	err = m.list(ctx, namespace, labels, statefulSets)
+	if err != nil {
+		return 0, fmt.Errorf("listing statefulsets with labels: %w", err)
+	}
+
+	restartedAt := time.Now().Format(time.RFC3339)
+	restarted := 0
+
+	for i := range deployments.Items {
+		deployment := &deployments.Items[i]
+
+		err = m.restartPodTemplate(ctx, deployment, &deployment.Spec.Template, restartedAt)
+		if err != nil {
+			return restarted, fmt.Errorf("restarting deployment %v: %w", deployment.Name, err)
+		}
+
+		restarted++
+	}
+
+	for i := range statefulSets.Items {
+		statefulSet := &statefulSets.Items[i]
+
+		err = m.restartPodTemplate(ctx, statefulSet, &statefulSet.Spec.Template, restartedAt)
+		if err != nil {
+			return restarted, fmt.Errorf("restarting statefulset %v: %w", statefulSet.Name, err)
+		}
+
+		restarted++
+	}
+
+	return restarted, nil
+}
+
+// restartPodTemplate changes an annotation on the pod template of obj, which makes the
+// owning controller replace its pods one by one.
+func (m *manager) restartPodTemplate(
+	ctx context.Context,
+	obj client.Object,
+	template *v1.PodTemplateSpec,
+	restartedAt string,
+) error {
+	original, ok := obj.DeepCopyObject().(client.Object)
+	if !ok {
+		return fmt.Errorf("unable to cast object to client.Object")
+	}
+
+	if template.Annotations == nil {
+		template.Annotations = map[string]string{}
+	}
+
+	template.Annotations[restartedAtAnnotation] = restartedAt
+
+	err := observe("patch", m.client.Patch(ctx, obj, client.MergeFrom(original), &client.PatchOptions{
+		FieldManager: fieldManager,
+	}))
+	if err != nil {
+		return fmt.Errorf("patching resource: %w", err)
+	}
+
+	return nil
+}
+
+// StreamPodLogs returns the logs of a pod. The logs subresource isn't supported by the
+// controller-runtime client, so we go through client-go instead.
+func (m *manager) StreamPodLogs(
+	ctx context.Context,
+	namespace, name string,
+	opts *v1.PodLogOptions,
+) (io.ReadCloser, error) {
+	if m.client.Clientset == nil {
+		return nil, fmt.Errorf("streaming pod logs: missing clientset")
+	}
+
+	stream, err := m.client.Clientset.CoreV1().Pods(namespace).GetLogs(name, opts).Stream(ctx)
+	if err := observe("logs", err); err != nil {
+		return nil, fmt.Errorf("streaming pod logs: %w", err)
+	}
+
+	return stream, nil
+}
+
 func (m *manager) GetStatusForPodsWithLabels(
 	ctx context.Context,
 	namespace, labels string,
@@ -468,13 +623,16 @@ func (m *manager) waitForResource(
 	}
 }
 
-func (m *manager) delete(ctx context.Context, obj client.Object) error {
+func (m *manager) delete(ctx context.Context, obj client.Object) (err error) {
+	ctx, span := startSpan(ctx, "k8s.delete", obj)
+	defer func() { tracing.End(span, err) }()
+
 	existing, ok := obj.DeepCopyObject().(client.Object)
 	if !ok {
 		return fmt.Errorf("unable to cast object to client.Object")
 	}
 
-	err := m.client.Get(ctx, client.ObjectKeyFromObject(obj), existing)
+	err = observe("get", m.client.Get(ctx, client.ObjectKeyFromObject(obj), existing))
 	if err != nil {
 		// If the resource does not exist, we consider it deleted
 		if errors.IsNotFound(err) {
@@ -484,11 +642,14 @@ func (m *manager) delete(ctx context.Context, obj client.Object) error {
 		return fmt.Errorf("checking resource: %w", err)
 	}
 
-	return m.client.Delete(ctx, existing)
+	return observe("delete", m.client.Delete(ctx, existing))
 }
 
-func (m *manager) get(ctx context.Context, into client.Object) (client.Object, error) {
-	err := m.client.Get(ctx, client.ObjectKeyFromObject(into), into)
+func (m *manager) get(ctx context.Context, into client.Object) (_ client.Object, err error) {
+	ctx, span := startSpan(ctx, "k8s.get", into)
+	defer func() { tracing.End(span, err) }()
+
+	err = observe("get", m.client.Get(ctx, client.ObjectKeyFromObject(into), into))
 	if err != nil {
 		return nil, fmt.Errorf("getting resource: %w", err)
 	}
@@ -496,19 +657,22 @@ func (m *manager) get(ctx context.Context, into client.Object) (client.Object, e
 	return into, nil
 }
 
-func (m *manager) apply(ctx context.Context, obj client.Object) error {
+func (m *manager) apply(ctx context.Context, obj client.Object) (err error) {
+	ctx, span := startSpan(ctx, "k8s.apply", obj)
+	defer func() { tracing.End(span, err) }()
+
 	existing, ok := obj.DeepCopyObject().(client.Object)
 	if !ok {
 		return fmt.Errorf("unable to cast object to client.Object")
 	}
 
-	err := m.client.Get(ctx, client.ObjectKeyFromObject(obj), existing)
+	err = observe("get", m.client.Get(ctx, client.ObjectKeyFromObject(obj), existing))
 	if err != nil {
 		// If the resource does not exist, we create it
 		if errors.IsNotFound(err) {
-			err = m.client.Create(ctx, obj, &client.CreateOptions{
+			err = observe("create", m.client.Create(ctx, obj, &client.CreateOptions{
 				FieldManager: fieldManager,
-			})
+			}))
 			if err != nil {
 				return fmt.Errorf("creating resource: %w", err)
 			}
@@ -520,10 +684,10 @@ func (m *manager) apply(ctx context.Context, obj client.Object) error {
 	}
 
 	// Otherwise, we update it
-	err = m.client.Patch(ctx, obj, client.Apply, &client.PatchOptions{
+	err = observe("patch", m.client.Patch(ctx, obj, client.Apply, &client.PatchOptions{
 		Force:        ptr.To(true), // Need to force the update to take ownership of the resource
 		FieldManager: fieldManager,
-	})
+	}))
 	if err != nil {
 		return fmt.Errorf("patching resource: %w", err)
 	}
@@ -536,16 +700,22 @@ func (m *manager) list(
 	namespace string,
 	labelSelectorString string,
 	obj client.ObjectList,
-) error {
+) (err error) {
+	ctx, span := tracer.Start(ctx, "k8s.list", trace.WithAttributes(
+		attribute.String("k8s.namespace.name", namespace),
+		attribute.String("k8s.label_selector", labelSelectorString),
+	))
+	defer func() { tracing.End(span, err) }()
+
 	labelSelector, err := labels.Parse(labelSelectorString)
 	if err != nil {
 		return fmt.Errorf("parsing label selector: %w", err)
 	}
 
-	err = m.client.List(ctx, obj, &client.ListOptions{
+	err = observe("list", m.client.List(ctx, obj, &client.ListOptions{
 		Namespace:     namespace,
 		LabelSelector: labelSelector,
-	})
+	}))
 	if err != nil {
 		return fmt.Errorf("listing resources: %w", err)
 	}
@@ -553,6 +723,27 @@ func (m *manager) list(
 	return nil
 }
 
+var tracer = tracing.Tracer("github.com/navikt/knorten/pkg/k8s")
+
+func startSpan(ctx context.Context, name string, obj client.Object) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name, trace.WithAttributes(
+		attribute.String("k8s.object.type", fmt.Sprintf("%T", obj)),
+		attribute.String("k8s.object.name", obj.GetName()),
+		attribute.String("k8s.namespace.name", obj.GetNamespace()),
+	))
+}
+
+// observe counts the request to the Kubernetes API. Not finding a resource is how we learn that
+// it has to be created, so it isn't counted as an error.
+func observe(operation string, err error) error {
+	if errors.IsNotFound(err) {
+		metrics.APIRequest(metrics.APIKubernetes, operation, nil)
+		return err
+	}
+
+	return metrics.APIRequest(metrics.APIKubernetes, operation, err)
+}
+
 func NewManager(c *Client) Manager {
 	return &manager{
 		client: c,
@@ -0,0 +1,81 @@
+package k8s_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/navikt/knorten/pkg/k8s"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestManager_RolloutRestartWithLabels(t *testing.T) {
+	t.Parallel()
+
+	objectMeta := func(name string, labels map[string]string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "team-test",
+			Labels:    labels,
+		}
+	}
+
+	c := fake.NewClientBuilder().WithObjects(
+		&appsv1.Deployment{ObjectMeta: objectMeta("airflow-scheduler", map[string]string{"component": "scheduler"})},
+		&appsv1.Deployment{ObjectMeta: objectMeta("airflow-webserver", map[string]string{"component": "webserver"})},
+		&appsv1.StatefulSet{ObjectMeta: objectMeta("airflow-triggerer", map[string]string{"component": "triggerer"})},
+	).Build()
+
+	manager := k8s.NewManager(&k8s.Client{Client: c})
+
+	testCases := []struct {
+		name      string
+		labels    string
+		restarted int
+		objects   []client.Object
+	}{
+		{
+			name:      "Should restart deployment",
+			labels:    "component=scheduler",
+			restarted: 1,
+			objects:   []client.Object{&appsv1.Deployment{ObjectMeta: objectMeta("airflow-scheduler", nil)}},
+		},
+		{
+			name:      "Should restart statefulset",
+			labels:    "component=triggerer",
+			restarted: 1,
+			objects:   []client.Object{&appsv1.StatefulSet{ObjectMeta: objectMeta("airflow-triggerer", nil)}},
+		},
+		{
+			name:      "Should restart nothing when no workloads match",
+			labels:    "component=worker",
+			restarted: 0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			restarted, err := manager.RolloutRestartWithLabels(context.Background(), "team-test", tc.labels)
+			require.NoError(t, err)
+			assert.Equal(t, tc.restarted, restarted)
+
+			for _, obj := range tc.objects {
+				require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(obj), obj))
+
+				var annotations map[string]string
+				switch o := obj.(type) {
+				case *appsv1.Deployment:
+					annotations = o.Spec.Template.Annotations
+				case *appsv1.StatefulSet:
+					annotations = o.Spec.Template.Annotations
+				}
+
+				assert.Contains(t, annotations, "kubectl.kubernetes.io/restartedAt")
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package networking
 
 import (
 	"fmt"
+	"net/http"
 
 	"github.com/navikt/knorten/pkg/k8s/meta"
 	v1 "k8s.io/api/core/v1"
@@ -10,11 +11,13 @@ import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
 	gwapiv1b1 "sigs.k8s.io/gateway-api/apis/v1beta1"
 )
 
 const (
 	defaultAirflowServiceName       = "airflow-webserver"
+	airflowRedirectHTTPRoutePrefix  = "airflow-redirect-"
 	defaultAirflowPort              = 8080
 	defaultHTTPRouteSystemNamespace = "knada-system"
 	defaultHTTPRouteName            = "knada-io"
@@ -59,6 +62,25 @@ func WithServiceBackend(serviceName string, port int) HTTPRouteOption {
 	}
 }
 
+// WithRedirect sends every request to the same path on another hostname
+func WithRedirect(hostname string) HTTPRouteOption {
+	return func(route *gwapiv1b1.HTTPRoute) {
+		route.Spec.Rules = []gwapiv1b1.HTTPRouteRule{
+			{
+				Filters: []gwapiv1b1.HTTPRouteFilter{
+					{
+						Type: gwapiv1.HTTPRouteFilterRequestRedirect,
+						RequestRedirect: &gwapiv1b1.HTTPRequestRedirectFilter{
+							Hostname:   preciseHostnamePtr(hostname),
+							StatusCode: intPtr(http.StatusMovedPermanently),
+						},
+					},
+				},
+			},
+		}
+	}
+}
+
 func NewHTTPRoute(
 	name, namespace, hostname string,
 	options ...HTTPRouteOption,
@@ -106,6 +128,21 @@ func NewAirflowHTTPRoute(
 	return NewHTTPRouteWithDefaultGateway(name, namespace, hostname, options...)
 }
 
+// AirflowRedirectHTTPRouteName is the name of the route redirecting the old hostname of a
+// renamed team to the Airflow of the team
+func AirflowRedirectHTTPRouteName(oldSlug string) string {
+	return airflowRedirectHTTPRoutePrefix + oldSlug
+}
+
+func NewAirflowRedirectHTTPRoute(namespace, oldSlug, fromHostname, toHostname string) *gwapiv1b1.HTTPRoute {
+	return NewHTTPRouteWithDefaultGateway(
+		AirflowRedirectHTTPRouteName(oldSlug),
+		namespace,
+		fromHostname,
+		WithRedirect(toHostname),
+	)
+}
+
 const (
 	healthCheckPolicyKind       = "HealthCheckPolicy"
 	healthCheckPolicyAPIVersion = "networking.gke.io/v1"
@@ -335,6 +372,15 @@ func namespacePtr(namespace string) *gwapiv1b1.Namespace {
 	return &n
 }
 
+func preciseHostnamePtr(hostname string) *gwapiv1b1.PreciseHostname {
+	h := gwapiv1b1.PreciseHostname(hostname)
+	return &h
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
 func portPtr(port int) *gwapiv1b1.PortNumber {
 	p := gwapiv1b1.PortNumber(port)
 	return &p
@@ -45,6 +45,16 @@ func TestHTTPRoute(t *testing.T) {
 				"hostname.example.com",
 			),
 		},
+		{
+			name: "route-with-airflow-redirect",
+			desc: "Create a new route redirecting the old hostname of a renamed team",
+			route: networking.NewAirflowRedirectHTTPRoute(
+				"test-namespace",
+				"old-team",
+				"old-team.airflow.example.com",
+				"new-team.airflow.example.com",
+			),
+		},
 	}
 
 	for _, tc := range testCases {
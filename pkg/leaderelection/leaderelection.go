@@ -1,14 +1,38 @@
 package leaderelection
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"time"
+
+	"github.com/sirupsen/logrus"
 )
 
+// RunPeriodically calls run right away and then on every tick, as long as this instance is the
+// leader, until the context is done
+func RunPeriodically(ctx context.Context, frequency time.Duration, log *logrus.Entry, run func(context.Context)) {
+	ticker := time.NewTicker(frequency)
+	defer ticker.Stop()
+	for {
+		isLeader, err := IsLeader()
+		if err != nil {
+			log.WithError(err).Error("checking leader status")
+		} else if isLeader {
+			run(ctx)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
 func IsLeader() (bool, error) {
 	electorPath := os.Getenv("ELECTOR_PATH")
 	if electorPath == "" {
@@ -0,0 +1,36 @@
+package leaderelection
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestRunPeriodically(t *testing.T) {
+	t.Setenv("ELECTOR_PATH", "")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runs := 0
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		RunPeriodically(ctx, time.Millisecond, logrus.NewEntry(logrus.New()), func(context.Context) {
+			runs++
+			if runs == 3 {
+				cancel()
+			}
+		})
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected RunPeriodically to return when the context is done")
+	}
+
+	if runs != 3 {
+		t.Errorf("expected 3 runs before the context was done, got %v", runs)
+	}
+}
@@ -0,0 +1,56 @@
+// Package metrics holds the registry every package registers its Prometheus metrics in
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	Namespace = "knorten"
+
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+
+	APIKubernetes = "kubernetes"
+	APIGCP        = "gcp"
+)
+
+var Registry = prometheus.NewRegistry()
+
+var apiRequests = promauto.With(Registry).NewCounterVec(prometheus.CounterOpts{
+	Namespace: Namespace,
+	Name:      "api_requests_total",
+	Help:      "Requests to the Kubernetes and GCP APIs, by operation and outcome.",
+}, []string{"api", "operation", "outcome"})
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// Handler serves the metrics in the registry
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// Outcome is the outcome label for the result of an operation
+func Outcome(err error) string {
+	if err != nil {
+		return OutcomeFailure
+	}
+
+	return OutcomeSuccess
+}
+
+// APIRequest counts a request to an external API, and returns the error it was given
+func APIRequest(api, operation string, err error) error {
+	apiRequests.WithLabelValues(api, operation, Outcome(err)).Inc()
+	return err
+}
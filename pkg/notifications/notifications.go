@@ -0,0 +1,149 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/navikt/knorten/pkg/database"
+	"github.com/navikt/knorten/pkg/database/gensql"
+	"github.com/navikt/knorten/pkg/leaderelection"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	deliveryBatchSize = 50
+	retryBaseDelay    = time.Minute
+	retryMaxDelay     = time.Hour
+)
+
+// Notification is sent to the subscribers when an event changes status
+type Notification struct {
+	EventID   string    `json:"eventId"`
+	EventType string    `json:"eventType"`
+	Status    string    `json:"status"`
+	Owner     string    `json:"owner"`
+	Message   string    `json:"message,omitempty"`
+	Time      time.Time `json:"time"`
+}
+
+func (n Notification) Summary() string {
+	summary := fmt.Sprintf("%v for %v is %v", n.EventType, n.Owner, n.Status)
+	if n.Message != "" {
+		summary += ": " + n.Message
+	}
+
+	return summary
+}
+
+type Sink interface {
+	Send(ctx context.Context, target string, notification Notification) error
+}
+
+// Client queues notifications for the subscriptions matching a status change, and delivers them
+// in the background. Failed deliveries are retried with an increasing delay until maxAttempts.
+type Client struct {
+	repo        *database.Repo
+	sinks       map[database.NotificationSink]Sink
+	maxAttempts int
+	log         *logrus.Entry
+	now         func() time.Time
+}
+
+func NewClient(repo *database.Repo, sinks map[database.NotificationSink]Sink, maxAttempts int, log *logrus.Entry) *Client {
+	return &Client{
+		repo:        repo,
+		sinks:       sinks,
+		maxAttempts: maxAttempts,
+		log:         log,
+		now:         time.Now,
+	}
+}
+
+// EventStatusChanged queues a notification for every subscription to the new status of the event
+func (c *Client) EventStatusChanged(ctx context.Context, event gensql.Event, status database.EventStatus, message string) error {
+	notification := Notification{
+		EventID:   event.ID.String(),
+		EventType: event.Type,
+		Status:    string(status),
+		Owner:     event.Owner,
+		Message:   message,
+		Time:      c.now(),
+	}
+
+	_, err := c.repo.NotificationDeliveriesCreate(ctx, event.Owner, database.EventType(event.Type), status, notification)
+	return err
+}
+
+func (c *Client) Run(ctx context.Context, frequency time.Duration) {
+	leaderelection.RunPeriodically(ctx, frequency, c.log, c.run)
+}
+
+func (c *Client) run(ctx context.Context) {
+	if err := c.DeliverDue(ctx); err != nil {
+		c.log.WithError(err).Error("delivering notifications")
+	}
+}
+
+// DeliverDue sends the notifications which are due, either for the first time or as a retry
+func (c *Client) DeliverDue(ctx context.Context) error {
+	deliveries, err := c.repo.NotificationDeliveriesDueGet(ctx, deliveryBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, delivery := range deliveries {
+		if err := c.deliver(ctx, delivery); err != nil {
+			c.log.WithError(err).Errorf("recording delivery of notification %v", delivery.ID)
+		}
+	}
+
+	return nil
+}
+
+func (c *Client) deliver(ctx context.Context, delivery gensql.NotificationDeliveriesDueGetRow) error {
+	sendErr := c.send(ctx, delivery)
+	if sendErr == nil {
+		return c.repo.NotificationDeliveredSet(ctx, delivery.ID)
+	}
+
+	attempts := int(delivery.Attempts) + 1
+	giveUp := attempts >= c.maxAttempts
+
+	log := c.log.WithError(sendErr).WithField("subscription", delivery.SubscriptionID)
+	if giveUp {
+		log.Errorf("giving up delivering notification %v after %v attempts", delivery.ID, attempts)
+	} else {
+		log.Warnf("delivering notification %v failed, retrying", delivery.ID)
+	}
+
+	return c.repo.NotificationDeliveryFailedSet(ctx, delivery.ID, sendErr, c.now().Add(RetryDelay(attempts)), giveUp)
+}
+
+func (c *Client) send(ctx context.Context, delivery gensql.NotificationDeliveriesDueGetRow) error {
+	sink, ok := c.sinks[database.NotificationSink(delivery.Sink)]
+	if !ok {
+		return fmt.Errorf("notification sink %v is not configured", delivery.Sink)
+	}
+
+	var notification Notification
+	if err := json.Unmarshal(delivery.Payload, &notification); err != nil {
+		return fmt.Errorf("decoding notification: %w", err)
+	}
+
+	return sink.Send(ctx, delivery.Target, notification)
+}
+
+// RetryDelay doubles the delay for every failed attempt, up to an hour
+func RetryDelay(attempts int) time.Duration {
+	delay := retryBaseDelay
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= retryMaxDelay {
+			return retryMaxDelay
+		}
+	}
+
+	return delay
+}
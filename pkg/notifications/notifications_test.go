@@ -0,0 +1,153 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+	"path"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/navikt/knorten/local/dbsetup"
+	"github.com/navikt/knorten/pkg/database"
+	"github.com/navikt/knorten/pkg/database/gensql"
+	"github.com/navikt/knorten/pkg/notifications/notificationstest"
+	"github.com/sirupsen/logrus"
+)
+
+var repo *database.Repo
+
+func init() {
+	_, filename, _, _ := runtime.Caller(0)
+	dir := path.Join(path.Dir(filename), "../..")
+	err := os.Chdir(dir)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func TestMain(m *testing.M) {
+	dbConn, err := dbsetup.SetupDBForTests()
+	if err != nil {
+		log.Fatal(err)
+	}
+	repo, err = database.New(dbConn, "", logrus.NewEntry(logrus.StandardLogger()))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	code := m.Run()
+	os.Exit(code)
+}
+
+func TestDeliverDue(t *testing.T) {
+	ctx := context.Background()
+
+	server := notificationstest.NewServer()
+	defer server.Close()
+
+	team := gensql.Team{ID: "notify-1234", Slug: "notify", Users: []string{"owner@nav.no"}}
+	if err := repo.TeamCreate(ctx, &team); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		if err := repo.TeamDelete(ctx, team.ID); err != nil {
+			t.Error(err)
+		}
+	})
+
+	err := repo.NotificationSubscriptionCreate(ctx, database.NotificationSubscription{
+		TeamID:     team.ID,
+		Sink:       database.NotificationSinkWebhook,
+		Target:     server.URL + "/hook",
+		EventTypes: []database.EventType{database.EventTypeUpdateAirflow},
+		Statuses:   []database.EventStatus{database.EventStatusFailed},
+		CreatedBy:  "owner@nav.no",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := NewClient(repo, map[database.NotificationSink]Sink{
+		database.NotificationSinkWebhook: NewWebhookSink(server.Client()),
+	}, 3, logrus.NewEntry(logrus.StandardLogger()))
+	// Retries are scheduled in the past, so they are due right away
+	client.now = func() time.Time { return time.Now().Add(-2 * time.Hour) }
+
+	event := gensql.Event{ID: uuid.New(), Type: string(database.EventTypeUpdateAirflow), Owner: team.ID}
+
+	if err := client.EventStatusChanged(ctx, event, database.EventStatusCompleted, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	otherType := gensql.Event{ID: uuid.New(), Type: string(database.EventTypeUpdateTeam), Owner: team.ID}
+	if err := client.EventStatusChanged(ctx, otherType, database.EventStatusFailed, "boom"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := client.EventStatusChanged(ctx, event, database.EventStatusFailed, "boom"); err != nil {
+		t.Fatal(err)
+	}
+
+	server.FailNext(1)
+	if err := client.DeliverDue(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(server.Requests()) != 0 {
+		t.Fatalf("DeliverDue(): expected the first attempt to fail, got %v requests", len(server.Requests()))
+	}
+
+	if err := client.DeliverDue(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	requests := server.Requests()
+	if len(requests) != 1 {
+		t.Fatalf("DeliverDue(): expected only the subscribed status change to be delivered, got %v requests", len(requests))
+	}
+
+	var notification Notification
+	if err := json.Unmarshal(requests[0].Body, &notification); err != nil {
+		t.Fatal(err)
+	}
+
+	if notification.EventID != event.ID.String() || notification.Status != string(database.EventStatusFailed) || notification.Message != "boom" {
+		t.Errorf("DeliverDue(): unexpected notification %+v", notification)
+	}
+
+	deliveries, err := repo.NotificationDeliveriesGet(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(deliveries) != 1 || deliveries[0].Attempts != 2 || !deliveries[0].Delivered.Valid {
+		t.Errorf("expected one delivery, delivered on the second attempt, got %+v", deliveries)
+	}
+
+	t.Run("give up after max attempts", func(t *testing.T) {
+		server.FailNext(3)
+		if err := client.EventStatusChanged(ctx, event, database.EventStatusFailed, "again"); err != nil {
+			t.Fatal(err)
+		}
+
+		for i := 0; i < 4; i++ {
+			if err := client.DeliverDue(ctx); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		deliveries, err := repo.NotificationDeliveriesGet(ctx, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !deliveries[0].Failed || deliveries[0].Attempts != 3 || deliveries[0].LastError == "" {
+			t.Errorf("expected the delivery to be given up after 3 attempts, got %+v", deliveries[0])
+		}
+	})
+}
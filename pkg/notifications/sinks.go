@@ -0,0 +1,111 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strings"
+)
+
+// WebhookSink posts the notification as JSON to the target URL
+type WebhookSink struct {
+	client *http.Client
+}
+
+func NewWebhookSink(client *http.Client) *WebhookSink {
+	return &WebhookSink{
+		client: client,
+	}
+}
+
+func (w *WebhookSink) Send(ctx context.Context, target string, notification Notification) error {
+	return postJSON(ctx, w.client, target, notification)
+}
+
+// SlackSink posts a summary of the notification to a Slack incoming webhook, or anything else
+// accepting the same format
+type SlackSink struct {
+	client *http.Client
+}
+
+func NewSlackSink(client *http.Client) *SlackSink {
+	return &SlackSink{
+		client: client,
+	}
+}
+
+func (s *SlackSink) Send(ctx context.Context, target string, notification Notification) error {
+	return postJSON(ctx, s.client, target, map[string]string{
+		"text": fmt.Sprintf("%v Knorten: %v", slackEmoji(notification.Status), notification.Summary()),
+	})
+}
+
+func slackEmoji(status string) string {
+	switch status {
+	case "completed":
+		return ":white_check_mark:"
+	case "failed", "deadline_reached":
+		return ":x:"
+	default:
+		return ":information_source:"
+	}
+}
+
+func postJSON(ctx context.Context, client *http.Client, target string, body any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("webhook responded with %v: %v", resp.Status, strings.TrimSpace(string(message)))
+	}
+
+	return nil
+}
+
+type sendMailFunc func(addr string, auth smtp.Auth, from string, to []string, msg []byte) error
+
+// EmailSink sends the notification by email to the target address through an SMTP relay
+type EmailSink struct {
+	addr     string
+	from     string
+	sendMail sendMailFunc
+}
+
+func NewEmailSink(host, port, from string) *EmailSink {
+	return &EmailSink{
+		addr:     net.JoinHostPort(host, port),
+		from:     from,
+		sendMail: smtp.SendMail,
+	}
+}
+
+func (e *EmailSink) Send(_ context.Context, target string, notification Notification) error {
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %v\r\n", e.from)
+	fmt.Fprintf(&msg, "To: %v\r\n", target)
+	fmt.Fprintf(&msg, "Subject: [Knorten] %v for %v is %v\r\n", notification.EventType, notification.Owner, notification.Status)
+	fmt.Fprintf(&msg, "Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	fmt.Fprintf(&msg, "%v\r\n\r\nEvent: %v\r\nTidspunkt: %v\r\n", notification.Summary(), notification.EventID, notification.Time.Format("2006-01-02 15:04:05"))
+
+	return e.sendMail(e.addr, nil, e.from, []string{target}, msg.Bytes())
+}
@@ -0,0 +1,117 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/navikt/knorten/pkg/notifications/notificationstest"
+)
+
+func TestSinks(t *testing.T) {
+	ctx := context.Background()
+
+	server := notificationstest.NewServer()
+	defer server.Close()
+
+	notification := Notification{
+		EventID:   "b0a4c6a8-0c7e-4d8a-9b5e-1f2f3e4d5c6b",
+		EventType: "update:airflow",
+		Status:    "failed",
+		Owner:     "team-1234",
+		Message:   "deadline reached",
+		Time:      time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+
+	t.Run("webhook", func(t *testing.T) {
+		sink := NewWebhookSink(server.Client())
+		if err := sink.Send(ctx, server.URL+"/webhook", notification); err != nil {
+			t.Fatal(err)
+		}
+
+		requests := server.Requests()
+		last := requests[len(requests)-1]
+		if last.Path != "/webhook" || last.ContentType != "application/json" {
+			t.Errorf("Send(): expected a json request to /webhook, got %v %v", last.ContentType, last.Path)
+		}
+
+		var received Notification
+		if err := json.Unmarshal(last.Body, &received); err != nil {
+			t.Fatal(err)
+		}
+
+		if diff := cmp.Diff(notification, received); diff != "" {
+			t.Errorf("Send(): mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("slack", func(t *testing.T) {
+		sink := NewSlackSink(server.Client())
+		if err := sink.Send(ctx, server.URL+"/slack", notification); err != nil {
+			t.Fatal(err)
+		}
+
+		requests := server.Requests()
+		var received map[string]string
+		if err := json.Unmarshal(requests[len(requests)-1].Body, &received); err != nil {
+			t.Fatal(err)
+		}
+
+		expect := ":x: Knorten: update:airflow for team-1234 is failed: deadline reached"
+		if received["text"] != expect {
+			t.Errorf("Send(): expected text %q, got %q", expect, received["text"])
+		}
+	})
+
+	t.Run("failing webhook", func(t *testing.T) {
+		server.FailNext(1)
+
+		sink := NewWebhookSink(server.Client())
+		err := sink.Send(ctx, server.URL+"/webhook", notification)
+		if err == nil || !strings.Contains(err.Error(), "503") {
+			t.Errorf("Send(): expected the 503 from the webhook, got %v", err)
+		}
+	})
+
+	t.Run("email", func(t *testing.T) {
+		var addr, from string
+		var to []string
+		var msg []byte
+
+		sink := NewEmailSink("smtp.example.com", "25", "knorten@nav.no")
+		sink.sendMail = func(a string, _ smtp.Auth, f string, t []string, m []byte) error {
+			addr, from, to, msg = a, f, t, m
+			return nil
+		}
+
+		if err := sink.Send(ctx, "team@nav.no", notification); err != nil {
+			t.Fatal(err)
+		}
+
+		if addr != "smtp.example.com:25" || from != "knorten@nav.no" || !cmp.Equal(to, []string{"team@nav.no"}) {
+			t.Errorf("Send(): unexpected envelope %v %v %v", addr, from, to)
+		}
+
+		if !strings.Contains(string(msg), "Subject: [Knorten] update:airflow for team-1234 is failed\r\n") {
+			t.Errorf("Send(): unexpected message %q", msg)
+		}
+	})
+}
+
+func TestRetryDelay(t *testing.T) {
+	for attempts, expect := range map[int]time.Duration{
+		1:  time.Minute,
+		2:  2 * time.Minute,
+		3:  4 * time.Minute,
+		7:  time.Hour,
+		20: time.Hour,
+	} {
+		if delay := RetryDelay(attempts); delay != expect {
+			t.Errorf("RetryDelay(%v): expected %v, got %v", attempts, expect, delay)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package notifications
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Host names which only make sense inside the cluster, or on the host itself
+var internalHostSuffixes = []string{".localhost", ".local", ".internal", ".svc"}
+
+// Carrier-grade NAT, which isn't covered by net.IP.IsPrivate
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// ValidateWebhookTarget checks that target is an https URL to a host outside the cluster, so teams
+// can't make Knorten post to internal services or the metadata server
+func ValidateWebhookTarget(target string) error {
+	u, err := url.ParseRequestURI(target)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("'%v' er ikke en gyldig URL", target)
+	}
+
+	return validateWebhookURL(u)
+}
+
+func validateWebhookURL(u *url.URL) error {
+	if u.Scheme != "https" {
+		return fmt.Errorf("'%v' må bruke https", u.Redacted())
+	}
+
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if ip := net.ParseIP(host); ip != nil {
+		if isInternalIP(ip) {
+			return fmt.Errorf("'%v' er en intern adresse", host)
+		}
+
+		return nil
+	}
+
+	// Names without a dot are resolved through the search domains of the cluster
+	if host == "localhost" || !strings.Contains(host, ".") {
+		return fmt.Errorf("'%v' er en intern adresse", host)
+	}
+
+	for _, suffix := range internalHostSuffixes {
+		if strings.HasSuffix(host, suffix) {
+			return fmt.Errorf("'%v' er en intern adresse", host)
+		}
+	}
+
+	return nil
+}
+
+func isInternalIP(ip net.IP) bool {
+	return !ip.IsGlobalUnicast() || ip.IsPrivate() || sharedAddressSpace.Contains(ip)
+}
+
+// NewWebhookClient creates a client for the webhook and Slack sinks, which only connects to
+// external addresses over https. Every request, redirects included, is checked, and the
+// address is checked again when dialing after the host name is resolved, so a name
+// resolving to an internal address is refused as well.
+func NewWebhookClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: controlWebhookDial,
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// A proxy would be dialed instead of the target, and bypass the check
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: webhookTransport{transport},
+	}
+}
+
+type webhookTransport struct {
+	next http.RoundTripper
+}
+
+func (t webhookTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := validateWebhookURL(req.URL); err != nil {
+		return nil, err
+	}
+
+	return t.next.RoundTrip(req)
+}
+
+func controlWebhookDial(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || isInternalIP(ip) {
+		return fmt.Errorf("refusing to connect to internal address %v", host)
+	}
+
+	return nil
+}
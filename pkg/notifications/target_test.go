@@ -0,0 +1,63 @@
+package notifications
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateWebhookTarget(t *testing.T) {
+	testCases := []struct {
+		target    string
+		expectErr bool
+	}{
+		{target: "https://hooks.slack.com/services/abc"},
+		{target: "https://93.184.216.34/hook"},
+		{target: "http://hooks.slack.com/services/abc", expectErr: true},
+		{target: "https://169.254.169.254/computeMetadata/v1/", expectErr: true},
+		{target: "https://127.0.0.1:8080/", expectErr: true},
+		{target: "https://10.0.0.1/", expectErr: true},
+		{target: "https://[::1]/", expectErr: true},
+		{target: "https://localhost/", expectErr: true},
+		{target: "https://metadata/", expectErr: true},
+		{target: "https://knorten.knada-system.svc/", expectErr: true},
+		{target: "https://knorten.knada-system.svc.cluster.local./", expectErr: true},
+		{target: "https://metadata.google.internal/", expectErr: true},
+		{target: "not a url", expectErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.target, func(t *testing.T) {
+			err := ValidateWebhookTarget(tc.target)
+			if tc.expectErr && err == nil {
+				t.Errorf("ValidateWebhookTarget(): expected %v to be rejected", tc.target)
+			}
+			if !tc.expectErr && err != nil {
+				t.Errorf("ValidateWebhookTarget(): expected %v to be accepted, got %v", tc.target, err)
+			}
+		})
+	}
+}
+
+func TestWebhookClient(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {}))
+	defer server.Close()
+
+	client := NewWebhookClient(time.Second)
+
+	err := postJSON(context.Background(), client, server.URL, map[string]string{})
+	if err == nil || !strings.Contains(err.Error(), "intern") {
+		t.Errorf("postJSON(): expected the internal server to be refused, got %v", err)
+	}
+
+	if err := controlWebhookDial("tcp", "127.0.0.1:443", nil); err == nil {
+		t.Error("controlWebhookDial(): expected a host resolving to loopback to be refused")
+	}
+
+	if err := controlWebhookDial("tcp", "93.184.216.34:443", nil); err != nil {
+		t.Errorf("controlWebhookDial(): expected an external address to be allowed, got %v", err)
+	}
+}
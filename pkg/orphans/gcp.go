@@ -0,0 +1,219 @@
+package orphans
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+
+	secretmanager "cloud.google.com/go/secretmanager/apiv1"
+	"cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"cloud.google.com/go/storage"
+	"github.com/navikt/knorten/pkg/gcp"
+	"google.golang.org/api/googleapi"
+	iamv1 "google.golang.org/api/iam/v1"
+	"google.golang.org/api/iterator"
+)
+
+const (
+	// teamServiceAccountDisplayName is how the team service accounts are told apart from the
+	// other service accounts in the project, as they can't be labeled
+	teamServiceAccountDisplayName = "Service account for team "
+	airflowLogsBucketPrefix       = "airflow-logs-"
+	createdByLabel                = "created-by"
+	createdByKnorten              = "knorten"
+)
+
+type serviceAccounts struct {
+	gcpProject string
+}
+
+// NewServiceAccountSource finds the IAM service accounts of the teams
+func NewServiceAccountSource(gcpProject string) Source {
+	return &serviceAccounts{gcpProject: gcpProject}
+}
+
+func (s *serviceAccounts) Kind() Kind {
+	return KindServiceAccount
+}
+
+func (s *serviceAccounts) Name(teamID string) string {
+	return teamID
+}
+
+func (s *serviceAccounts) List(ctx context.Context) ([]string, error) {
+	service, err := iamv1.NewService(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("creating IAM service: %w", err)
+	}
+
+	var names []string
+	err = service.Projects.ServiceAccounts.List("projects/"+s.gcpProject).Pages(ctx, func(response *iamv1.ListServiceAccountsResponse) error {
+		for _, account := range response.Accounts {
+			if !strings.HasPrefix(account.DisplayName, teamServiceAccountDisplayName) {
+				continue
+			}
+
+			accountID, _, _ := strings.Cut(account.Email, "@")
+			names = append(names, accountID)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing service accounts: %w", err)
+	}
+
+	return names, nil
+}
+
+func (s *serviceAccounts) Delete(ctx context.Context, name string) error {
+	service, err := iamv1.NewService(ctx)
+	if err != nil {
+		return fmt.Errorf("creating IAM service: %w", err)
+	}
+
+	sa := fmt.Sprintf("projects/%v/serviceAccounts/%v@%v.iam.gserviceaccount.com", s.gcpProject, name, s.gcpProject)
+	_, err = service.Projects.ServiceAccounts.Delete(sa).Context(ctx).Do()
+	if err != nil {
+		var apiError *googleapi.Error
+		if errors.As(err, &apiError) && apiError.Code == http.StatusNotFound {
+			return nil
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+type secrets struct {
+	gcpProject string
+}
+
+// NewSecretSource finds the Secret Manager secrets of the teams
+func NewSecretSource(gcpProject string) Source {
+	return &secrets{gcpProject: gcpProject}
+}
+
+func (s *secrets) Kind() Kind {
+	return KindSecret
+}
+
+func (s *secrets) Name(teamID string) string {
+	return teamID
+}
+
+func (s *secrets) List(ctx context.Context) ([]string, error) {
+	client, err := secretmanager.NewClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	// The user secrets are created by knorten as well, but are labeled with the owner instead of a team
+	it := client.ListSecrets(ctx, &secretmanagerpb.ListSecretsRequest{
+		Parent: "projects/" + s.gcpProject,
+		Filter: fmt.Sprintf("labels.%v=%v AND labels.team:*", createdByLabel, createdByKnorten),
+	})
+
+	var names []string
+	for {
+		secret, err := it.Next()
+		if err != nil {
+			if errors.Is(err, iterator.Done) {
+				break
+			}
+
+			return nil, fmt.Errorf("listing secrets: %w", err)
+		}
+
+		names = append(names, path.Base(secret.Name))
+	}
+
+	return names, nil
+}
+
+func (s *secrets) Delete(ctx context.Context, name string) error {
+	return gcp.DeleteSecret(ctx, s.gcpProject, name)
+}
+
+type buckets struct {
+	gcpProject string
+}
+
+// NewBucketSource finds the buckets for the Airflow logs of the teams
+func NewBucketSource(gcpProject string) Source {
+	return &buckets{gcpProject: gcpProject}
+}
+
+func (b *buckets) Kind() Kind {
+	return KindBucket
+}
+
+func (b *buckets) Name(teamID string) string {
+	return airflowLogsBucketPrefix + teamID + "-north"
+}
+
+func (b *buckets) List(ctx context.Context) ([]string, error) {
+	client, err := storage.NewClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("creating storage client: %w", err)
+	}
+	defer client.Close()
+
+	it := client.Buckets(ctx, b.gcpProject)
+	it.Prefix = airflowLogsBucketPrefix
+
+	var names []string
+	for {
+		bucket, err := it.Next()
+		if err != nil {
+			if errors.Is(err, iterator.Done) {
+				break
+			}
+
+			return nil, fmt.Errorf("listing buckets: %w", err)
+		}
+
+		if bucket.Labels[createdByLabel] == createdByKnorten {
+			names = append(names, bucket.Name)
+		}
+	}
+
+	return names, nil
+}
+
+// Delete deletes the logs in the bucket before the bucket itself, as only empty buckets can be deleted
+func (b *buckets) Delete(ctx context.Context, name string) error {
+	client, err := storage.NewClient(ctx)
+	if err != nil {
+		return fmt.Errorf("creating storage client: %w", err)
+	}
+	defer client.Close()
+
+	bucket := client.Bucket(name)
+	it := bucket.Objects(ctx, nil)
+	for {
+		object, err := it.Next()
+		if err != nil {
+			if errors.Is(err, iterator.Done) {
+				break
+			}
+
+			return fmt.Errorf("listing objects: %w", err)
+		}
+
+		if err := bucket.Object(object.Name).Delete(ctx); err != nil && !errors.Is(err, storage.ErrObjectNotExist) {
+			return fmt.Errorf("deleting object %v: %w", object.Name, err)
+		}
+	}
+
+	if err := bucket.Delete(ctx); err != nil && !errors.Is(err, storage.ErrBucketNotExist) {
+		return fmt.Errorf("deleting bucket: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,46 @@
+package orphans
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/navikt/knorten/pkg/k8s"
+	"github.com/navikt/knorten/pkg/k8s/meta"
+)
+
+type namespaces struct {
+	manager k8s.Manager
+}
+
+// NewNamespaceSource finds the namespaces of the teams
+func NewNamespaceSource(manager k8s.Manager) Source {
+	return &namespaces{manager: manager}
+}
+
+func (n *namespaces) Kind() Kind {
+	return KindNamespace
+}
+
+func (n *namespaces) Name(teamID string) string {
+	return k8s.TeamIDToNamespace(teamID)
+}
+
+func (n *namespaces) List(ctx context.Context) ([]string, error) {
+	selector := fmt.Sprintf("%v=%v,%v=true", meta.ManagedByLabel, meta.Knorten, meta.TeamNamespaceLabel)
+
+	items, err := n.manager.ListNamespacesWithLabels(ctx, selector)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, len(items))
+	for i, namespace := range items {
+		names[i] = namespace.Name
+	}
+
+	return names, nil
+}
+
+func (n *namespaces) Delete(ctx context.Context, name string) error {
+	return n.manager.DeleteNamespace(ctx, name)
+}
@@ -0,0 +1,150 @@
+package orphans
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/sirupsen/logrus"
+)
+
+type Kind string
+
+const (
+	KindServiceAccount Kind = "serviceaccount"
+	KindSecret         Kind = "secret"
+	KindBucket         Kind = "bucket"
+	KindNamespace      Kind = "namespace"
+)
+
+var ErrNotOrphaned = errors.New("resource is not orphaned")
+
+// Source lists the resources of one kind which knorten has created for teams
+type Source interface {
+	Kind() Kind
+	List(ctx context.Context) ([]string, error)
+	// Name is the name of the resource knorten creates for the team
+	Name(teamID string) string
+	Delete(ctx context.Context, name string) error
+}
+
+// Candidate is a resource created by knorten for a team which no longer exists
+type Candidate struct {
+	Kind Kind
+	Name string
+}
+
+type teamsRepo interface {
+	TeamIDsInUseGet(ctx context.Context) ([]string, error)
+}
+
+// Scanner finds resources knorten has created for teams, which are left behind after the team is
+// gone, e.g. because the deletion failed halfway through
+type Scanner struct {
+	repo    teamsRepo
+	sources []Source
+	dryRun  bool
+	log     *logrus.Entry
+}
+
+func NewScanner(repo teamsRepo, dryRun bool, log *logrus.Entry, sources ...Source) *Scanner {
+	return &Scanner{
+		repo:    repo,
+		sources: sources,
+		dryRun:  dryRun,
+		log:     log,
+	}
+}
+
+// Scan returns the orphan candidates of every source. A source which can't be listed doesn't stop
+// the others from being scanned, but is part of the returned error.
+func (s *Scanner) Scan(ctx context.Context) ([]Candidate, error) {
+	var candidates []Candidate
+	var errs []error
+
+	for _, source := range s.sources {
+		orphans, err := s.scan(ctx, source)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("scanning %v: %w", source.Kind(), err))
+			continue
+		}
+
+		for _, name := range orphans {
+			candidates = append(candidates, Candidate{Kind: source.Kind(), Name: name})
+		}
+	}
+
+	return candidates, errors.Join(errs...)
+}
+
+// Delete deletes the resource, after checking that it's still an orphan
+func (s *Scanner) Delete(ctx context.Context, kind Kind, name string) error {
+	source, err := s.source(kind)
+	if err != nil {
+		return err
+	}
+
+	orphans, err := s.scan(ctx, source)
+	if err != nil {
+		return fmt.Errorf("scanning %v: %w", kind, err)
+	}
+
+	if !slices.Contains(orphans, name) {
+		return ErrNotOrphaned
+	}
+
+	log := s.log.WithField("kind", kind).WithField("name", name)
+	if s.dryRun {
+		log.Info("dry run, not deleting orphaned resource")
+		return nil
+	}
+
+	if err := source.Delete(ctx, name); err != nil {
+		return fmt.Errorf("deleting %v %v: %w", kind, name, err)
+	}
+
+	log.Info("deleted orphaned resource")
+
+	return nil
+}
+
+func (s *Scanner) source(kind Kind) (Source, error) {
+	for _, source := range s.sources {
+		if source.Kind() == kind {
+			return source, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown resource kind %v", kind)
+}
+
+// scan lists the resources of the source which don't belong to a team in use. The teams are read
+// after the resources, so that a team created in between isn't mistaken for an orphan.
+func (s *Scanner) scan(ctx context.Context, source Source) ([]string, error) {
+	names, err := source.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	teamIDs, err := s.repo.TeamIDsInUseGet(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting teams in use: %w", err)
+	}
+
+	inUse := map[string]bool{}
+	for _, teamID := range teamIDs {
+		inUse[source.Name(teamID)] = true
+	}
+
+	var orphans []string
+	for _, name := range names {
+		if !inUse[name] {
+			orphans = append(orphans, name)
+		}
+	}
+
+	slices.Sort(orphans)
+
+	return orphans, nil
+}
@@ -0,0 +1,112 @@
+package orphans
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/navikt/knorten/pkg/k8s"
+	"github.com/navikt/knorten/pkg/k8s/core"
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+type teamsRepoMock struct {
+	teamIDs []string
+}
+
+func (r *teamsRepoMock) TeamIDsInUseGet(context.Context) ([]string, error) {
+	return r.teamIDs, nil
+}
+
+type failingSource struct{}
+
+func (failingSource) Kind() Kind                             { return KindSecret }
+func (failingSource) List(context.Context) ([]string, error) { return nil, errors.New("unavailable") }
+func (failingSource) Name(teamID string) string              { return teamID }
+func (failingSource) Delete(context.Context, string) error   { return nil }
+
+func TestScanner(t *testing.T) {
+	ctx := context.Background()
+
+	c := fake.NewFakeClient(
+		core.NewNamespace("team-active-1234", core.WithTeamNamespaceLabel()),
+		core.NewNamespace("team-deleted-1234", core.WithTeamNamespaceLabel()),
+		core.NewNamespace("team-unmanaged-1234"),
+	)
+	manager := k8s.NewManager(&k8s.Client{Client: c})
+	repo := &teamsRepoMock{teamIDs: []string{"active-1234"}}
+
+	t.Run("resources of teams which are gone are candidates", func(t *testing.T) {
+		scanner := NewScanner(repo, false, logrus.NewEntry(logrus.New()), NewNamespaceSource(manager))
+
+		candidates, err := scanner.Scan(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := []Candidate{{Kind: KindNamespace, Name: "team-deleted-1234"}}
+		if diff := cmp.Diff(expected, candidates); diff != "" {
+			t.Errorf("mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("a failing source doesn't stop the others", func(t *testing.T) {
+		scanner := NewScanner(repo, false, logrus.NewEntry(logrus.New()), failingSource{}, NewNamespaceSource(manager))
+
+		candidates, err := scanner.Scan(ctx)
+		if err == nil {
+			t.Error("expected the failing source to be reported")
+		}
+		if len(candidates) != 1 {
+			t.Errorf("expected the namespace candidate, got %v", candidates)
+		}
+	})
+
+	t.Run("resources in use are not deleted", func(t *testing.T) {
+		scanner := NewScanner(repo, false, logrus.NewEntry(logrus.New()), NewNamespaceSource(manager))
+
+		err := scanner.Delete(ctx, KindNamespace, "team-active-1234")
+		if !errors.Is(err, ErrNotOrphaned) {
+			t.Errorf("expected %v, got %v", ErrNotOrphaned, err)
+		}
+	})
+
+	t.Run("dry run doesn't delete", func(t *testing.T) {
+		scanner := NewScanner(repo, true, logrus.NewEntry(logrus.New()), NewNamespaceSource(manager))
+
+		if err := scanner.Delete(ctx, KindNamespace, "team-deleted-1234"); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := c.Get(ctx, client.ObjectKey{Name: "team-deleted-1234"}, &v1.Namespace{}); err != nil {
+			t.Errorf("expected the namespace to be kept in dry run, got %v", err)
+		}
+	})
+
+	t.Run("orphans are deleted", func(t *testing.T) {
+		scanner := NewScanner(repo, false, logrus.NewEntry(logrus.New()), NewNamespaceSource(manager))
+
+		if err := scanner.Delete(ctx, KindNamespace, "team-deleted-1234"); err != nil {
+			t.Fatal(err)
+		}
+
+		err := c.Get(ctx, client.ObjectKey{Name: "team-deleted-1234"}, &v1.Namespace{})
+		if !apierrors.IsNotFound(err) {
+			t.Errorf("expected the namespace to be deleted, got %v", err)
+		}
+
+		candidates, err := scanner.Scan(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if slices.Contains(candidates, Candidate{Kind: KindNamespace, Name: "team-deleted-1234"}) {
+			t.Errorf("expected the deleted namespace not to be a candidate, got %v", candidates)
+		}
+	})
+}
@@ -0,0 +1,44 @@
+package plan
+
+import "context"
+
+// The systems a step changes
+const (
+	KindKubernetes = "kubernetes"
+	KindGCP        = "gcp"
+	KindHelm       = "helm"
+)
+
+// Step is a change which would have been made, if knorten wasn't running in dry run
+type Step struct {
+	Kind    string
+	Action  string
+	Target  string
+	Details any
+}
+
+type Recorder interface {
+	Record(ctx context.Context, step Step)
+}
+
+type recorderKey struct{}
+
+// WithRecorder makes the steps planned with the returned context be recorded by the recorder
+func WithRecorder(ctx context.Context, recorder Recorder) context.Context {
+	return context.WithValue(ctx, recorderKey{}, recorder)
+}
+
+// Record records the step with the recorder of the context, and does nothing if there is none
+func Record(ctx context.Context, kind, action, target string, details any) {
+	recorder, ok := ctx.Value(recorderKey{}).(Recorder)
+	if !ok {
+		return
+	}
+
+	recorder.Record(ctx, Step{
+		Kind:    kind,
+		Action:  action,
+		Target:  target,
+		Details: details,
+	})
+}
@@ -0,0 +1,34 @@
+package plan
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type recorderMock struct {
+	steps []Step
+}
+
+func (r *recorderMock) Record(_ context.Context, step Step) {
+	r.steps = append(r.steps, step)
+}
+
+func TestRecord(t *testing.T) {
+	t.Run("steps are recorded by the recorder of the context", func(t *testing.T) {
+		recorder := &recorderMock{}
+		ctx := WithRecorder(context.Background(), recorder)
+
+		Record(ctx, KindGCP, "deleteSecret", "projects/knada/secrets/team-1234", nil)
+
+		expected := []Step{{Kind: KindGCP, Action: "deleteSecret", Target: "projects/knada/secrets/team-1234"}}
+		if diff := cmp.Diff(expected, recorder.steps); diff != "" {
+			t.Errorf("mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("steps without a recorder are ignored", func(t *testing.T) {
+		Record(context.Background(), KindGCP, "deleteSecret", "projects/knada/secrets/team-1234", nil)
+	})
+}
@@ -0,0 +1,69 @@
+package retention
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"cloud.google.com/go/storage"
+)
+
+// Archive stores the archives of pruned events
+type Archive interface {
+	Write(ctx context.Context, name string, content []byte) error
+}
+
+type dirArchive struct {
+	dir string
+}
+
+// NewDirArchive stores the archives as files in dir
+func NewDirArchive(dir string) Archive {
+	return &dirArchive{dir: dir}
+}
+
+// Write writes to a temporary file which is renamed when complete, so that an archive which is
+// cut short isn't mistaken for a complete one
+func (a *dirArchive) Write(_ context.Context, name string, content []byte) error {
+	if err := os.MkdirAll(a.dir, 0o750); err != nil {
+		return fmt.Errorf("creating archive directory: %w", err)
+	}
+
+	path := filepath.Join(a.dir, name)
+	if err := os.WriteFile(path+".tmp", content, 0o640); err != nil {
+		return fmt.Errorf("writing archive: %w", err)
+	}
+
+	return os.Rename(path+".tmp", path)
+}
+
+type bucketArchive struct {
+	bucket string
+}
+
+// NewBucketArchive stores the archives as objects in the bucket
+func NewBucketArchive(bucket string) Archive {
+	return &bucketArchive{bucket: bucket}
+}
+
+func (a *bucketArchive) Write(ctx context.Context, name string, content []byte) error {
+	client, err := storage.NewClient(ctx)
+	if err != nil {
+		return fmt.Errorf("creating storage client: %w", err)
+	}
+	defer client.Close()
+
+	w := client.Bucket(a.bucket).Object(name).NewWriter(ctx)
+	w.ContentType = "application/gzip"
+	if _, err := w.Write(content); err != nil {
+		_ = w.Close()
+		return fmt.Errorf("writing archive: %w", err)
+	}
+
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("writing archive: %w", err)
+	}
+
+	return nil
+}
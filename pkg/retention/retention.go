@@ -0,0 +1,164 @@
+package retention
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/navikt/knorten/pkg/database"
+	"github.com/navikt/knorten/pkg/database/gensql"
+	"github.com/navikt/knorten/pkg/leaderelection"
+	"github.com/sirupsen/logrus"
+)
+
+// batchSize is the number of events in each archive
+const batchSize = 1000
+
+type retentionRepo interface {
+	EventsExpiredGet(ctx context.Context, completedDays, failedDays, limit int32) ([]database.EventWithLogs, error)
+	EventsDelete(ctx context.Context, ids []uuid.UUID) error
+	SessionsExpiredDelete(ctx context.Context) (int64, error)
+	CloudEventsExpiredDelete(ctx context.Context, days int32) (int64, error)
+	NotificationDeliveriesExpiredDelete(ctx context.Context, days int32) (int64, error)
+}
+
+// client prunes events which are past retention, after archiving them with their logs as
+// gzipped JSON lines, and prunes expired sessions and the outboxes
+type client struct {
+	repo          retentionRepo
+	archive       Archive
+	completedDays int32
+	failedDays    int32
+	outboxDays    int32
+	log           *logrus.Entry
+}
+
+func NewClient(repo retentionRepo, archive Archive, completedDays, failedDays, outboxDays int, log *logrus.Entry) *client {
+	return &client{
+		repo:          repo,
+		archive:       archive,
+		completedDays: int32(completedDays),
+		failedDays:    int32(failedDays),
+		outboxDays:    int32(outboxDays),
+		log:           log,
+	}
+}
+
+func (c *client) Run(ctx context.Context, frequency time.Duration) {
+	leaderelection.RunPeriodically(ctx, frequency, c.log, c.run)
+}
+
+func (c *client) run(ctx context.Context) {
+	if err := c.archiveExpiredEvents(ctx, time.Now()); err != nil {
+		c.log.WithError(err).Error("archiving expired events")
+	}
+
+	if err := c.pruneSessions(ctx); err != nil {
+		c.log.WithError(err).Error("pruning expired sessions")
+	}
+
+	if err := c.pruneOutboxes(ctx); err != nil {
+		c.log.WithError(err).Error("pruning outboxes")
+	}
+}
+
+// archiveExpiredEvents archives and deletes the expired events a batch at a time. A batch is only
+// deleted once its archive is written, so a failing archive leaves the events for the next run.
+func (c *client) archiveExpiredEvents(ctx context.Context, now time.Time) error {
+	for batch := 1; ; batch++ {
+		events, err := c.repo.EventsExpiredGet(ctx, c.completedDays, c.failedDays, batchSize)
+		if err != nil {
+			return err
+		}
+
+		if len(events) == 0 {
+			return nil
+		}
+
+		content, err := encodeArchive(events)
+		if err != nil {
+			return err
+		}
+
+		name := fmt.Sprintf("events-%v-%04d.jsonl.gz", now.UTC().Format("20060102T150405Z"), batch)
+		if err := c.archive.Write(ctx, name, content); err != nil {
+			return fmt.Errorf("archiving %v: %w", name, err)
+		}
+
+		ids := make([]uuid.UUID, len(events))
+		for i, event := range events {
+			ids[i] = event.ID
+		}
+
+		if err := c.repo.EventsDelete(ctx, ids); err != nil {
+			return fmt.Errorf("deleting events archived in %v: %w", name, err)
+		}
+
+		c.log.Infof("archived %v expired events to %v", len(events), name)
+
+		if len(events) < batchSize {
+			return nil
+		}
+	}
+}
+
+// archivedEvent is a line of the archive
+type archivedEvent struct {
+	gensql.Event
+	Logs []gensql.EventLog
+}
+
+func encodeArchive(events []database.EventWithLogs) ([]byte, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+
+	encoder := json.NewEncoder(gz)
+	for _, event := range events {
+		if err := encoder.Encode(archivedEvent{Event: event.Event, Logs: event.Logs}); err != nil {
+			return nil, fmt.Errorf("encoding event %v: %w", event.ID, err)
+		}
+	}
+
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (c *client) pruneSessions(ctx context.Context) error {
+	pruned, err := c.repo.SessionsExpiredDelete(ctx)
+	if err != nil {
+		return err
+	}
+
+	if pruned > 0 {
+		c.log.Infof("pruned %v expired sessions", pruned)
+	}
+
+	return nil
+}
+
+// pruneOutboxes deletes the CloudEvents and notifications which have been delivered or given up on.
+// The outbox is written to even when no endpoint is configured, so it has to be pruned either way.
+func (c *client) pruneOutboxes(ctx context.Context) error {
+	cloudEvents, err := c.repo.CloudEventsExpiredDelete(ctx, c.outboxDays)
+	if err != nil {
+		return fmt.Errorf("pruning cloudevents outbox: %w", err)
+	}
+
+	notifications, err := c.repo.NotificationDeliveriesExpiredDelete(ctx, c.outboxDays)
+	if err != nil {
+		return fmt.Errorf("pruning notification deliveries: %w", err)
+	}
+
+	if cloudEvents > 0 || notifications > 0 {
+		c.log.Infof("pruned %v cloudevents and %v notification deliveries", cloudEvents, notifications)
+	}
+
+	return nil
+}
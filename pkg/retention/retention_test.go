@@ -0,0 +1,184 @@
+package retention
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/navikt/knorten/pkg/database"
+	"github.com/navikt/knorten/pkg/database/gensql"
+	"github.com/sirupsen/logrus"
+)
+
+type retentionRepoMock struct {
+	events        []database.EventWithLogs
+	deleted       []uuid.UUID
+	sessions      int64
+	cloudEvents   map[int32]int64
+	notifications map[int32]int64
+}
+
+func (r *retentionRepoMock) EventsExpiredGet(_ context.Context, _, _, limit int32) ([]database.EventWithLogs, error) {
+	return r.events[:min(int(limit), len(r.events))], nil
+}
+
+func (r *retentionRepoMock) EventsDelete(_ context.Context, ids []uuid.UUID) error {
+	r.deleted = append(r.deleted, ids...)
+	r.events = r.events[len(ids):]
+	return nil
+}
+
+func (r *retentionRepoMock) SessionsExpiredDelete(context.Context) (int64, error) {
+	pruned := r.sessions
+	r.sessions = 0
+	return pruned, nil
+}
+
+// CloudEventsExpiredDelete prunes the messages which are older than days
+func (r *retentionRepoMock) CloudEventsExpiredDelete(_ context.Context, days int32) (int64, error) {
+	pruned := r.cloudEvents[days]
+	delete(r.cloudEvents, days)
+	return pruned, nil
+}
+
+// NotificationDeliveriesExpiredDelete prunes the deliveries which are older than days
+func (r *retentionRepoMock) NotificationDeliveriesExpiredDelete(_ context.Context, days int32) (int64, error) {
+	pruned := r.notifications[days]
+	delete(r.notifications, days)
+	return pruned, nil
+}
+
+type failingArchive struct{}
+
+func (failingArchive) Write(context.Context, string, []byte) error {
+	return errors.New("unavailable")
+}
+
+func expiredEvents(n int) []database.EventWithLogs {
+	events := make([]database.EventWithLogs, n)
+	for i := range events {
+		id := uuid.New()
+		events[i] = database.EventWithLogs{
+			Event: gensql.Event{ID: id, Owner: "team-a-1234", Type: string(database.EventTypeUpdateAirflow), Payload: json.RawMessage(`{}`)},
+			Logs:  []gensql.EventLog{{EventID: id, Message: "ferdig"}},
+		}
+	}
+
+	return events
+}
+
+func readArchive(t *testing.T, path string) []archivedEvent {
+	t.Helper()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var events []archivedEvent
+	scanner := bufio.NewScanner(gz)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var event archivedEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatal(err)
+		}
+		events = append(events, event)
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	return events
+}
+
+func TestArchiveExpiredEvents(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	log := logrus.NewEntry(logrus.New())
+
+	t.Run("expired events are archived in batches before they are deleted", func(t *testing.T) {
+		dir := t.TempDir()
+		repo := &retentionRepoMock{events: expiredEvents(batchSize + 1)}
+		c := NewClient(repo, NewDirArchive(dir), 30, 90, 14, log)
+
+		if err := c.archiveExpiredEvents(ctx, now); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(repo.deleted) != batchSize+1 {
+			t.Errorf("expected %v deleted events, got %v", batchSize+1, len(repo.deleted))
+		}
+
+		first := readArchive(t, filepath.Join(dir, "events-20240301T120000Z-0001.jsonl.gz"))
+		second := readArchive(t, filepath.Join(dir, "events-20240301T120000Z-0002.jsonl.gz"))
+		if len(first) != batchSize || len(second) != 1 {
+			t.Fatalf("expected batches of %v and 1, got %v and %v", batchSize, len(first), len(second))
+		}
+
+		if first[0].ID != repo.deleted[0] || len(first[0].Logs) != 1 || first[0].Logs[0].Message != "ferdig" {
+			t.Errorf("expected the event to be archived with its logs, got %+v", first[0])
+		}
+	})
+
+	t.Run("events are kept when the archive fails", func(t *testing.T) {
+		repo := &retentionRepoMock{events: expiredEvents(2)}
+		c := NewClient(repo, failingArchive{}, 30, 90, 14, log)
+
+		if err := c.archiveExpiredEvents(ctx, now); err == nil {
+			t.Error("expected the failing archive to be reported")
+		}
+
+		if len(repo.deleted) != 0 || len(repo.events) != 2 {
+			t.Errorf("expected no events to be deleted, got %v", repo.deleted)
+		}
+	})
+}
+
+func TestPruneSessions(t *testing.T) {
+	repo := &retentionRepoMock{sessions: 3}
+	c := NewClient(repo, NewDirArchive(t.TempDir()), 30, 90, 14, logrus.NewEntry(logrus.New()))
+
+	if err := c.pruneSessions(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if repo.sessions != 0 {
+		t.Errorf("expected the expired sessions to be pruned, %v left", repo.sessions)
+	}
+}
+
+func TestPruneOutboxes(t *testing.T) {
+	repo := &retentionRepoMock{
+		cloudEvents:   map[int32]int64{14: 5, 30: 2},
+		notifications: map[int32]int64{14: 1},
+	}
+	c := NewClient(repo, NewDirArchive(t.TempDir()), 30, 90, 14, logrus.NewEntry(logrus.New()))
+
+	if err := c.pruneOutboxes(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := repo.cloudEvents[14]; ok {
+		t.Error("expected the cloudevents to be pruned after 14 days")
+	}
+	if _, ok := repo.notifications[14]; ok {
+		t.Error("expected the notification deliveries to be pruned after 14 days")
+	}
+	if repo.cloudEvents[30] != 2 {
+		t.Error("expected the cloudevents to be pruned with the outbox retention, not the event retention")
+	}
+}
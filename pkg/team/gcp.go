@@ -9,43 +9,85 @@ import (
 	secretmanager "cloud.google.com/go/secretmanager/apiv1"
 	"cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
 
+	"github.com/navikt/knorten/pkg/database"
 	"github.com/navikt/knorten/pkg/database/gensql"
 	"github.com/navikt/knorten/pkg/gcp"
 	"github.com/navikt/knorten/pkg/k8s"
+	"github.com/navikt/knorten/pkg/plan"
 	"google.golang.org/api/googleapi"
 	iamv1 "google.golang.org/api/iam/v1"
 )
 
-func (c Client) createGCPTeamResources(ctx context.Context, team *gensql.Team) error {
+func (c Client) createGCPTeamResources(ctx context.Context, team *database.Team, p *provisioning) error {
 	if c.dryRun {
+		c.planGCPTeamResources(ctx, team)
 		return nil
 	}
 
-	sa, err := c.createIAMServiceAccount(ctx, team.ID)
+	saEmail, err := p.run(ctx, stepIAMServiceAccount, func(ctx context.Context) (string, error) {
+		sa, err := c.createIAMServiceAccount(ctx, team.ID)
+		if err != nil {
+			return "", err
+		}
+		return sa.Email, nil
+	})
 	if err != nil {
 		return fmt.Errorf("creating service account: %w", err)
 	}
 
-	secret, err := c.createSecret(ctx, team.Slug, team.ID)
+	secretName, err := p.run(ctx, stepSecret, func(ctx context.Context) (string, error) {
+		secret, err := c.createSecret(ctx, team.Slug, team.ID)
+		if err != nil {
+			return "", err
+		}
+		return secret.Name, nil
+	})
 	if err != nil {
 		return fmt.Errorf("creating secret: %w", err)
 	}
 
-	if err := c.createServiceAccountSecretAccessorBinding(ctx, sa.Email, secret.Name); err != nil {
+	_, err = p.run(ctx, stepSecretAccessorBinding, func(ctx context.Context) (string, error) {
+		return saEmail, c.createServiceAccountSecretAccessorBinding(ctx, saEmail, secretName)
+	})
+	if err != nil {
 		return fmt.Errorf("creating secret accessor binding: %w", err)
 	}
 
-	if err := gcp.SetUsersSecretOwnerBinding(ctx, team.Users, secret.Name); err != nil {
+	_, err = p.run(ctx, stepSecretOwnerBinding, func(ctx context.Context) (string, error) {
+		return secretName, gcp.SetUsersSecretOwnerBinding(ctx, secretOwners(team), secretName)
+	})
+	if err != nil {
 		return fmt.Errorf("setting secret owner binding: %w", err)
 	}
 
-	if err := c.createSAWorkloadIdentityBinding(ctx, sa.Email, team.ID); err != nil {
+	_, err = p.run(ctx, stepWorkloadIdentityBinding, func(ctx context.Context) (string, error) {
+		return saEmail, c.createSAWorkloadIdentityBinding(ctx, saEmail, team.ID)
+	})
+	if err != nil {
 		return fmt.Errorf("creating workload identity binding: %w", err)
 	}
 
 	return nil
 }
 
+// planGCPTeamResources records what createGCPTeamResources would have done
+func (c Client) planGCPTeamResources(ctx context.Context, team *database.Team) {
+	saEmail := fmt.Sprintf("%v@%v.iam.gserviceaccount.com", team.ID, c.gcpProject)
+	secretName := fmt.Sprintf("projects/%v/secrets/%v", c.gcpProject, team.ID)
+	namespace := k8s.TeamIDToNamespace(team.ID)
+
+	plan.Record(ctx, plan.KindGCP, "createServiceAccount", saEmail, nil)
+	plan.Record(ctx, plan.KindGCP, "createSecret", secretName, map[string]string{"team": team.Slug, "created-by": "knorten"})
+	plan.Record(ctx, plan.KindGCP, "setIamPolicy", secretName, planBinding("roles/secretmanager.secretAccessor", "serviceAccount:"+saEmail))
+	plan.Record(ctx, plan.KindGCP, "setIamPolicy", secretName, planBinding("roles/owner", secretOwners(team)...))
+	plan.Record(ctx, plan.KindGCP, "setIamPolicy", saEmail, planBinding("roles/iam.workloadIdentityUser",
+		fmt.Sprintf("serviceAccount:%v.svc.id.goog[%v/%v]", c.gcpProject, namespace, team.ID)))
+}
+
+func planBinding(role string, members ...string) map[string]any {
+	return map[string]any{"role": role, "members": members}
+}
+
 func (c Client) createSAWorkloadIdentityBinding(ctx context.Context, email, teamID string) error {
 	service, err := iamv1.NewService(ctx)
 	if err != nil {
@@ -95,6 +137,15 @@ func (c Client) createSecret(ctx context.Context, slug, teamID string) (*secretm
 	return gcp.CreateSecret(ctx, c.gcpProject, c.gcpRegion, teamID, map[string]string{"team": slug})
 }
 
+func (c Client) updateSecretSlugLabel(ctx context.Context, slug, teamID string) error {
+	if c.dryRun {
+		plan.Record(ctx, plan.KindGCP, "updateSecretLabels", fmt.Sprintf("projects/%v/secrets/%v", c.gcpProject, teamID), map[string]string{"team": slug})
+		return nil
+	}
+
+	return gcp.UpdateSecretLabels(ctx, c.gcpProject, teamID, map[string]string{"team": slug})
+}
+
 func (c Client) createServiceAccountSecretAccessorBinding(ctx context.Context, sa, secret string) error {
 	client, err := secretmanager.NewClient(ctx)
 	if err != nil {
@@ -146,8 +197,12 @@ func (c Client) createIAMServiceAccount(ctx context.Context, team string) (*iamv
 	return account, nil
 }
 
-func (c Client) updateGCPTeamResources(ctx context.Context, team *gensql.Team) error {
+func (c Client) updateGCPTeamResources(ctx context.Context, team *database.Team) error {
 	if c.dryRun {
+		secretName := fmt.Sprintf("projects/%v/secrets/%v", c.gcpProject, team.ID)
+		plan.Record(ctx, plan.KindGCP, "setIamPolicy", secretName, planBinding("roles/secretmanager.secretAccessor",
+			fmt.Sprintf("serviceAccount:%v@%v.iam.gserviceaccount.com", team.ID, c.gcpProject)))
+		plan.Record(ctx, plan.KindGCP, "setIamPolicy", secretName, planBinding("roles/owner", secretOwners(team)...))
 		return nil
 	}
 
@@ -155,11 +210,18 @@ func (c Client) updateGCPTeamResources(ctx context.Context, team *gensql.Team) e
 		return err
 	}
 
-	return gcp.SetUsersSecretOwnerBinding(ctx, team.Users, fmt.Sprintf("projects/%v/secrets/%v", c.gcpProject, team.ID))
+	return gcp.SetUsersSecretOwnerBinding(ctx, secretOwners(team), fmt.Sprintf("projects/%v/secrets/%v", c.gcpProject, team.ID))
+}
+
+// secretOwners are the members allowed to change the team secret, viewers are left out
+func secretOwners(team *database.Team) []string {
+	return team.EmailsWithRole(gensql.TeamRoleOwner, gensql.TeamRoleMaintainer)
 }
 
 func (c Client) deleteGCPTeamResources(ctx context.Context, teamID string) error {
 	if c.dryRun {
+		plan.Record(ctx, plan.KindGCP, "deleteServiceAccount", fmt.Sprintf("%v@%v.iam.gserviceaccount.com", teamID, c.gcpProject), nil)
+		plan.Record(ctx, plan.KindGCP, "deleteSecret", fmt.Sprintf("projects/%v/secrets/%v", c.gcpProject, teamID), nil)
 		return nil
 	}
 
@@ -3,44 +3,87 @@ package team
 import (
 	"context"
 	"fmt"
+	"slices"
 
 	"github.com/navikt/knorten/pkg/k8s"
 	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 type AirflowClient struct {
 	manager k8s.Manager
+	// reader is used for status lookups, and is normally backed by NewAirflowStatusCache
+	reader client.Reader
 }
 
-func NewAirflowClient(mngr k8s.Manager) *AirflowClient {
+func NewAirflowClient(mngr k8s.Manager, reader client.Reader) *AirflowClient {
 	return &AirflowClient{
 		manager: mngr,
+		reader:  reader,
 	}
 }
 
-const airflowSchedulerLabel = "component=scheduler"
+type AirflowComponent string
 
-func (ac AirflowClient) DeleteSchedulerPods(ctx context.Context, namespace string) error {
-	err := ac.manager.DeletePodsWithLabels(ctx, namespace, airflowSchedulerLabel)
+const (
+	AirflowComponentScheduler AirflowComponent = "scheduler"
+	AirflowComponentWebserver AirflowComponent = "webserver"
+	AirflowComponentTriggerer AirflowComponent = "triggerer"
+)
+
+// AirflowComponents are the components a team is allowed to restart. Workers are left out, as
+// they are task pods started by the KubernetesExecutor, and restarting them fails the tasks.
+var AirflowComponents = []AirflowComponent{
+	AirflowComponentScheduler,
+	AirflowComponentWebserver,
+	AirflowComponentTriggerer,
+}
+
+func (c AirflowComponent) IsValid() bool {
+	return slices.Contains(AirflowComponents, c)
+}
+
+// label returns the label selector the Airflow chart uses for the component
+func (c AirflowComponent) label() string {
+	return "component=" + string(c)
+}
+
+// RestartComponent does a rolling restart of the deployments and statefulsets running the
+// component, and fails if there are none.
+func (ac AirflowClient) RestartComponent(ctx context.Context, namespace string, component AirflowComponent) error {
+	if !component.IsValid() {
+		return fmt.Errorf("unknown airflow component %q", component)
+	}
+
+	restarted, err := ac.manager.RolloutRestartWithLabels(ctx, namespace, component.label())
 	if err != nil {
-		return fmt.Errorf("delete scheduler pods: %w", err)
+		return fmt.Errorf("rollout restart %v: %w", component, err)
 	}
+
+	if restarted == 0 {
+		return fmt.Errorf("rollout restart %v: found nothing to restart", component)
+	}
+
 	return nil
 }
 
 func (ac *AirflowClient) IsSchedulerDown(ctx context.Context, namespace string) (bool, error) {
-	statuses, err := ac.manager.GetStatusForPodsWithLabels(ctx, namespace, airflowSchedulerLabel)
+	pods := &v1.PodList{}
+
+	err := ac.reader.List(ctx, pods, client.InNamespace(namespace), client.MatchingLabels{
+		airflowComponentKey: string(AirflowComponentScheduler),
+	})
 	if err != nil {
 		return false, fmt.Errorf("is scheduler running: %w", err)
 	}
 
-	if len(statuses) == 0 {
+	if len(pods.Items) == 0 {
 		// No scheduler pods found for team
 		return true, nil
 	}
 
-	for _, status := range statuses {
-		if status.Phase == v1.PodRunning {
+	for _, pod := range pods.Items {
+		if pod.Status.Phase == v1.PodRunning {
 			return false, nil
 		}
 	}
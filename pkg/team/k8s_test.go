@@ -0,0 +1,53 @@
+package team
+
+import (
+	"context"
+	"testing"
+
+	"github.com/navikt/knorten/pkg/k8s"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestAirflowClient_RestartComponent(t *testing.T) {
+	namespace := "team-test"
+
+	c := fake.NewClientBuilder().WithObjects(
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "airflow-scheduler",
+				Namespace: namespace,
+				Labels:    map[string]string{"component": "scheduler"},
+			},
+		},
+		&v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "task-pod",
+				Namespace: namespace,
+				Labels:    map[string]string{"component": "worker"},
+			},
+		},
+	).Build()
+
+	client := NewAirflowClient(k8s.NewManager(&k8s.Client{Client: c}), c)
+
+	if err := client.RestartComponent(context.Background(), namespace, AirflowComponentScheduler); err != nil {
+		t.Errorf("RestartComponent(): expected the scheduler to be restarted, got %v", err)
+	}
+
+	if err := client.RestartComponent(context.Background(), namespace, AirflowComponentTriggerer); err == nil {
+		t.Error("RestartComponent(): expected an error when there is nothing to restart")
+	}
+
+	if err := client.RestartComponent(context.Background(), namespace, "workers"); err == nil {
+		t.Error("RestartComponent(): expected workers not to be restartable")
+	}
+
+	pod := &v1.Pod{}
+	if err := c.Get(context.Background(), ctrlclient.ObjectKey{Namespace: namespace, Name: "task-pod"}, pod); err != nil {
+		t.Errorf("RestartComponent(): expected the task pod to be left alone, got %v", err)
+	}
+}
@@ -0,0 +1,59 @@
+package team
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var (
+	ErrAirflowPodNotFound       = errors.New("airflow pod not found")
+	ErrAirflowContainerNotFound = errors.New("container not found in airflow pod")
+)
+
+type PodLogOptions struct {
+	Container string
+	TailLines int64
+	Follow    bool
+}
+
+// PodLogs streams the logs of an Airflow pod in the namespace. Only pods created by the
+// Airflow chart are allowed, and if no container is given we use the first one, which is
+// the Airflow component itself.
+func (ac *AirflowClient) PodLogs(ctx context.Context, namespace, podName string, opts PodLogOptions) (io.ReadCloser, error) {
+	pod := &v1.Pod{}
+
+	err := ac.reader.Get(ctx, client.ObjectKey{Namespace: namespace, Name: podName}, pod)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, ErrAirflowPodNotFound
+		}
+
+		return nil, fmt.Errorf("getting pod: %w", err)
+	}
+
+	if pod.Labels[airflowTierLabel] != airflowTierValue || len(pod.Spec.Containers) == 0 {
+		return nil, ErrAirflowPodNotFound
+	}
+
+	container := opts.Container
+	if container == "" {
+		container = pod.Spec.Containers[0].Name
+	}
+
+	if !slices.ContainsFunc(pod.Spec.Containers, func(c v1.Container) bool { return c.Name == container }) {
+		return nil, ErrAirflowContainerNotFound
+	}
+
+	return ac.manager.StreamPodLogs(ctx, namespace, podName, &v1.PodLogOptions{
+		Container: container,
+		TailLines: &opts.TailLines,
+		Follow:    opts.Follow,
+	})
+}
@@ -0,0 +1,82 @@
+package team
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/navikt/knorten/pkg/k8s"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestAirflowClient_PodLogs(t *testing.T) {
+	namespace := "team-test"
+
+	newPod := func(name string, labels map[string]string) *v1.Pod {
+		return &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+				Labels:    labels,
+			},
+			Spec: v1.PodSpec{
+				Containers: []v1.Container{{Name: "scheduler"}, {Name: "git-sync"}},
+			},
+		}
+	}
+
+	c := fake.NewClientBuilder().WithObjects(
+		newPod("airflow-scheduler-0", map[string]string{"tier": "airflow", "component": "scheduler"}),
+		newPod("some-other-pod", map[string]string{"app": "other"}),
+	).Build()
+
+	client := NewAirflowClient(k8s.NewManager(&k8s.Client{Client: c}), c)
+
+	testCases := []struct {
+		name      string
+		namespace string
+		pod       string
+		container string
+		expectErr error
+	}{
+		{
+			name:      "pod does not exist",
+			namespace: namespace,
+			pod:       "airflow-webserver-0",
+			expectErr: ErrAirflowPodNotFound,
+		},
+		{
+			name:      "pod in another namespace",
+			namespace: "team-other",
+			pod:       "airflow-scheduler-0",
+			expectErr: ErrAirflowPodNotFound,
+		},
+		{
+			name:      "pod not created by the airflow chart",
+			namespace: namespace,
+			pod:       "some-other-pod",
+			expectErr: ErrAirflowPodNotFound,
+		},
+		{
+			name:      "unknown container",
+			namespace: namespace,
+			pod:       "airflow-scheduler-0",
+			container: "sidecar",
+			expectErr: ErrAirflowContainerNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := client.PodLogs(context.Background(), tc.namespace, tc.pod, PodLogOptions{
+				Container: tc.container,
+				TailLines: 10,
+			})
+			if !errors.Is(err, tc.expectErr) {
+				t.Errorf("PodLogs(): expected error %v, got %v", tc.expectErr, err)
+			}
+		})
+	}
+}
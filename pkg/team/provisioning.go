@@ -0,0 +1,147 @@
+package team
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/navikt/knorten/pkg/database/gensql"
+	"github.com/navikt/knorten/pkg/gcp"
+	"github.com/navikt/knorten/pkg/logger"
+)
+
+// The steps of creating a team, in the order they are run
+const (
+	stepIAMServiceAccount       = "iam_service_account"
+	stepSecret                  = "secret"
+	stepSecretAccessorBinding   = "secret_accessor_binding"
+	stepSecretOwnerBinding      = "secret_owner_binding"
+	stepWorkloadIdentityBinding = "workload_identity_binding"
+	stepNamespace               = "namespace"
+	stepK8sServiceAccount       = "k8s_service_account"
+)
+
+// removedWith are the steps which don't need to be undone, as what they create is removed
+// together with what an earlier step created
+var removedWith = map[string]string{
+	stepSecretAccessorBinding:   stepSecret,
+	stepSecretOwnerBinding:      stepSecret,
+	stepWorkloadIdentityBinding: stepIAMServiceAccount,
+}
+
+// provisioning records the steps of creating a team as they complete. A step which completed in
+// an earlier attempt is skipped, and the resource it created is reused.
+type provisioning struct {
+	client    Client
+	teamID    string
+	log       logger.Logger
+	completed map[string]string
+}
+
+func (c Client) newProvisioning(ctx context.Context, teamID string, log logger.Logger) (*provisioning, error) {
+	steps, err := c.repo.TeamProvisioningStepsGet(ctx, teamID)
+	if err != nil {
+		return nil, fmt.Errorf("getting provisioning steps: %w", err)
+	}
+
+	completed := map[string]string{}
+	for _, step := range steps {
+		if !step.Compensated.Valid {
+			completed[step.Step] = step.Resource
+		}
+	}
+
+	return &provisioning{
+		client:    c,
+		teamID:    teamID,
+		log:       log,
+		completed: completed,
+	}, nil
+}
+
+// run runs the step, unless it completed in an earlier attempt, and returns the resource it created
+func (p *provisioning) run(ctx context.Context, step string, fn func(ctx context.Context) (string, error)) (string, error) {
+	if resource, ok := p.completed[step]; ok {
+		p.log.Infof("Steg %v er allerede fullført (%v)", step, resource)
+		return resource, nil
+	}
+
+	resource, err := fn(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	if err := p.client.repo.TeamProvisioningStepComplete(ctx, p.teamID, step, resource); err != nil {
+		return "", fmt.Errorf("recording provisioning step %v: %w", step, err)
+	}
+
+	p.completed[step] = resource
+	p.log.Infof("Steg %v fullført (%v)", step, resource)
+
+	return resource, nil
+}
+
+// CompensateCreate removes what the steps of creating the team created, in the reverse order,
+// after the creation has failed for good
+func (c Client) CompensateCreate(ctx context.Context, teamID string, log logger.Logger) error {
+	steps, err := c.repo.TeamProvisioningStepsGet(ctx, teamID)
+	if err != nil {
+		return fmt.Errorf("getting provisioning steps: %w", err)
+	}
+
+	if len(steps) == 0 {
+		return nil
+	}
+
+	slices.Reverse(steps)
+	log.Infof("Rydder opp etter at opprettelsen av teamet feilet")
+	for _, step := range steps {
+		if step.Compensated.Valid {
+			continue
+		}
+
+		if err := c.compensate(ctx, step); err != nil {
+			log.Infof("Klarte ikke å fjerne %v (%v): %v", step.Step, step.Resource, err)
+			return fmt.Errorf("compensating provisioning step %v: %w", step.Step, err)
+		}
+
+		if err := c.repo.TeamProvisioningStepCompensatedSet(ctx, teamID, step.Step); err != nil {
+			return fmt.Errorf("recording compensation of provisioning step %v: %w", step.Step, err)
+		}
+
+		if earlier, ok := removedWith[step.Step]; ok {
+			log.Infof("Steg %v fjernes sammen med %v", step.Step, earlier)
+		} else {
+			log.Infof("Fjernet %v (%v)", step.Step, step.Resource)
+		}
+	}
+
+	if err := c.repo.TeamProvisioningStepsDelete(ctx, teamID); err != nil {
+		return fmt.Errorf("deleting provisioning steps: %w", err)
+	}
+
+	log.Infof("Opprydding etter opprettelsen av teamet er ferdig")
+
+	return nil
+}
+
+func (c Client) compensate(ctx context.Context, step gensql.TeamProvisioningStep) error {
+	if _, ok := removedWith[step.Step]; ok {
+		return nil
+	}
+
+	switch step.Step {
+	case stepIAMServiceAccount:
+		return c.deleteIAMServiceAccount(ctx, step.TeamID)
+	case stepSecret:
+		return gcp.DeleteSecret(ctx, c.gcpProject, step.TeamID)
+	case stepNamespace:
+		return c.manager.DeleteNamespace(ctx, step.Resource)
+	case stepK8sServiceAccount:
+		namespace, name, _ := strings.Cut(step.Resource, "/")
+		return c.manager.DeleteServiceAccount(ctx, name, namespace)
+	}
+
+	return fmt.Errorf("unknown provisioning step %v", step.Step)
+}
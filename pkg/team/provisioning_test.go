@@ -0,0 +1,90 @@
+package team
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/navikt/knorten/pkg/k8s"
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestProvisioning(t *testing.T) {
+	ctx := context.Background()
+	teamID := "saga-team-1234"
+	namespace := k8s.TeamIDToNamespace(teamID)
+
+	c := fake.NewFakeClient()
+	teamClient, err := NewClient(repo, k8s.NewManager(&k8s.Client{Client: c}), "", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		if err := repo.TeamProvisioningStepsDelete(ctx, teamID); err != nil {
+			t.Error(err)
+		}
+	})
+
+	p, err := teamClient.newProvisioning(ctx, teamID, logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = p.run(ctx, stepNamespace, func(ctx context.Context) (string, error) {
+		ns := &v1.Namespace{}
+		ns.Name = namespace
+		return namespace, teamClient.manager.ApplyNamespace(ctx, ns)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	steps, err := repo.TeamProvisioningStepsGet(ctx, teamID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(steps) != 1 || steps[0].Step != stepNamespace || steps[0].Resource != namespace {
+		t.Fatalf("expected the namespace step to be recorded, got %+v", steps)
+	}
+
+	t.Run("completed steps are skipped when retrying", func(t *testing.T) {
+		retry, err := teamClient.newProvisioning(ctx, teamID, logrus.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		resource, err := retry.run(ctx, stepNamespace, func(ctx context.Context) (string, error) {
+			return "", errors.New("the step should not run again")
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resource != namespace {
+			t.Errorf("expected the resource of the completed step %v, got %v", namespace, resource)
+		}
+	})
+
+	t.Run("compensation removes what was created", func(t *testing.T) {
+		if err := teamClient.CompensateCreate(ctx, teamID, logrus.New()); err != nil {
+			t.Fatal(err)
+		}
+
+		err := c.Get(ctx, client.ObjectKey{Name: namespace}, &v1.Namespace{})
+		if !apierrors.IsNotFound(err) {
+			t.Errorf("expected namespace %v to be deleted, got %v", namespace, err)
+		}
+
+		steps, err := repo.TeamProvisioningStepsGet(ctx, teamID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(steps) != 0 {
+			t.Errorf("expected no provisioning steps after compensating, got %+v", steps)
+		}
+	})
+}
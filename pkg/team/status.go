@@ -0,0 +1,254 @@
+package team
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/navikt/knorten/pkg/k8s"
+	"github.com/navikt/knorten/pkg/k8s/meta"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// The Airflow chart sets this label on the pods of all its components
+	airflowTierLabel    = "tier"
+	airflowTierValue    = "airflow"
+	airflowComponentKey = "component"
+	maxWarningEvents    = 10
+)
+
+type AirflowStatus struct {
+	Components []ComponentStatus
+	Warnings   []WarningEvent
+}
+
+type ComponentStatus struct {
+	Name string
+	Pods []PodStatus
+}
+
+// Ready is true when the component has pods, and all of them are ready
+func (c ComponentStatus) Ready() bool {
+	if len(c.Pods) == 0 {
+		return false
+	}
+
+	for _, pod := range c.Pods {
+		if !pod.Ready {
+			return false
+		}
+	}
+
+	return true
+}
+
+type PodStatus struct {
+	Name       string
+	Phase      v1.PodPhase
+	Ready      bool
+	Restarts   int32
+	Containers []string
+	Images     []string
+	Created    time.Time
+}
+
+type WarningEvent struct {
+	Object   string
+	Reason   string
+	Message  string
+	Count    int32
+	LastSeen time.Time
+}
+
+// AirflowStatusCache serves status lookups for team namespaces from watches, and falls back to the
+// API server for other namespaces. The watches cover every namespace, so that teams created after
+// startup are served from the cache as well.
+type AirflowStatusCache struct {
+	cache startableReader
+	live  client.Reader
+}
+
+type startableReader interface {
+	client.Reader
+	Start(ctx context.Context) error
+}
+
+// NewAirflowStatusCache creates a cache watching the team namespaces, and the Airflow pods and their
+// warning events, used by AirflowClient to answer status requests without calling the API server.
+func NewAirflowStatusCache(ctx context.Context, c *k8s.Client) (*AirflowStatusCache, error) {
+	statusCache, err := k8s.NewCache(ctx, c, map[client.Object]cache.ByObject{
+		&v1.Namespace{}: {
+			Label: labels.SelectorFromSet(labels.Set{
+				meta.ManagedByLabel:     meta.Knorten,
+				meta.TeamNamespaceLabel: "true",
+			}),
+		},
+		&v1.Pod{}: {
+			Label: labels.SelectorFromSet(labels.Set{airflowTierLabel: airflowTierValue}),
+		},
+		&v1.Event{}: {
+			Field: fields.SelectorFromSet(fields.Set{
+				"type":                v1.EventTypeWarning,
+				"involvedObject.kind": "Pod",
+			}),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &AirflowStatusCache{
+		cache: statusCache,
+		live:  c,
+	}, nil
+}
+
+// Start runs the watches until ctx is done
+func (c *AirflowStatusCache) Start(ctx context.Context) error {
+	return c.cache.Start(ctx)
+}
+
+func (c *AirflowStatusCache) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	return c.reader(ctx, key.Namespace).Get(ctx, key, obj, opts...)
+}
+
+func (c *AirflowStatusCache) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	listOpts := &client.ListOptions{}
+	listOpts.ApplyOptions(opts)
+
+	return c.reader(ctx, listOpts.Namespace).List(ctx, list, opts...)
+}
+
+// reader is the cache when the namespace is a team namespace, which the cache only knows
+// namespaces with the team namespace labels as
+func (c *AirflowStatusCache) reader(ctx context.Context, namespace string) client.Reader {
+	if namespace == "" {
+		return c.live
+	}
+
+	if err := c.cache.Get(ctx, client.ObjectKey{Name: namespace}, &v1.Namespace{}); err != nil {
+		return c.live
+	}
+
+	return c.cache
+}
+
+func (ac *AirflowClient) Status(ctx context.Context, namespace string) (*AirflowStatus, error) {
+	pods := &v1.PodList{}
+
+	err := ac.reader.List(ctx, pods, client.InNamespace(namespace), client.MatchingLabels{
+		airflowTierLabel: airflowTierValue,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing airflow pods: %w", err)
+	}
+
+	events := &v1.EventList{}
+
+	err = ac.reader.List(ctx, events, client.InNamespace(namespace))
+	if err != nil {
+		return nil, fmt.Errorf("listing events: %w", err)
+	}
+
+	return newAirflowStatus(pods.Items, events.Items), nil
+}
+
+func newAirflowStatus(pods []v1.Pod, events []v1.Event) *AirflowStatus {
+	components := map[string]*ComponentStatus{}
+
+	for _, pod := range pods {
+		name := pod.Labels[airflowComponentKey]
+		if name == "" {
+			name = "ukjent"
+		}
+
+		component, ok := components[name]
+		if !ok {
+			component = &ComponentStatus{Name: name}
+			components[name] = component
+		}
+
+		component.Pods = append(component.Pods, newPodStatus(pod))
+	}
+
+	status := &AirflowStatus{}
+
+	for _, component := range components {
+		sort.Slice(component.Pods, func(i, j int) bool {
+			return component.Pods[i].Name < component.Pods[j].Name
+		})
+
+		status.Components = append(status.Components, *component)
+	}
+
+	sort.Slice(status.Components, func(i, j int) bool {
+		return status.Components[i].Name < status.Components[j].Name
+	})
+
+	for _, event := range events {
+		if event.Type != v1.EventTypeWarning || event.InvolvedObject.Kind != "Pod" {
+			continue
+		}
+
+		status.Warnings = append(status.Warnings, WarningEvent{
+			Object:   fmt.Sprintf("%v/%v", event.InvolvedObject.Kind, event.InvolvedObject.Name),
+			Reason:   event.Reason,
+			Message:  event.Message,
+			Count:    event.Count,
+			LastSeen: eventLastSeen(event),
+		})
+	}
+
+	sort.Slice(status.Warnings, func(i, j int) bool {
+		return status.Warnings[i].LastSeen.After(status.Warnings[j].LastSeen)
+	})
+
+	if len(status.Warnings) > maxWarningEvents {
+		status.Warnings = status.Warnings[:maxWarningEvents]
+	}
+
+	return status
+}
+
+func newPodStatus(pod v1.Pod) PodStatus {
+	status := PodStatus{
+		Name:    pod.Name,
+		Phase:   pod.Status.Phase,
+		Created: pod.CreationTimestamp.Time,
+	}
+
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == v1.PodReady {
+			status.Ready = condition.Status == v1.ConditionTrue
+		}
+	}
+
+	for _, containerStatus := range pod.Status.ContainerStatuses {
+		status.Restarts += containerStatus.RestartCount
+	}
+
+	for _, container := range pod.Spec.Containers {
+		status.Containers = append(status.Containers, container.Name)
+		status.Images = append(status.Images, container.Image)
+	}
+
+	return status
+}
+
+// eventLastSeen handles both core/v1 events, and events.k8s.io events which only set EventTime
+func eventLastSeen(event v1.Event) time.Time {
+	switch {
+	case !event.LastTimestamp.IsZero():
+		return event.LastTimestamp.Time
+	case !event.EventTime.IsZero():
+		return event.EventTime.Time
+	default:
+		return event.CreationTimestamp.Time
+	}
+}
@@ -0,0 +1,154 @@
+package team
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/navikt/knorten/pkg/k8s"
+	"github.com/navikt/knorten/pkg/k8s/core"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestAirflowClient_Status(t *testing.T) {
+	namespace := "team-test"
+	created := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+
+	newPod := func(name, component string, ready bool, restarts int32) *v1.Pod {
+		readyStatus := v1.ConditionFalse
+		if ready {
+			readyStatus = v1.ConditionTrue
+		}
+
+		return &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Namespace:         namespace,
+				CreationTimestamp: metav1.NewTime(created),
+				Labels: map[string]string{
+					"tier":      "airflow",
+					"component": component,
+				},
+			},
+			Spec: v1.PodSpec{
+				Containers: []v1.Container{
+					{Name: component, Image: "apache/airflow:2.9.0"},
+				},
+			},
+			Status: v1.PodStatus{
+				Phase:      v1.PodRunning,
+				Conditions: []v1.PodCondition{{Type: v1.PodReady, Status: readyStatus}},
+				ContainerStatuses: []v1.ContainerStatus{
+					{Name: component, RestartCount: restarts},
+				},
+			},
+		}
+	}
+
+	newEvent := func(name, eventType, reason string, lastSeen time.Time) *v1.Event {
+		return &v1.Event{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+			},
+			InvolvedObject: v1.ObjectReference{Kind: "Pod", Name: "airflow-scheduler-0"},
+			Type:           eventType,
+			Reason:         reason,
+			Message:        reason + " happened",
+			Count:          2,
+			LastTimestamp:  metav1.NewTime(lastSeen),
+		}
+	}
+
+	c := fake.NewClientBuilder().WithObjects(
+		newPod("airflow-webserver-0", "webserver", true, 0),
+		newPod("airflow-scheduler-1", "scheduler", false, 3),
+		newPod("airflow-scheduler-0", "scheduler", true, 1),
+		newEvent("older", v1.EventTypeWarning, "BackOff", created),
+		newEvent("newer", v1.EventTypeWarning, "Unhealthy", created.Add(time.Minute)),
+		newEvent("normal", v1.EventTypeNormal, "Pulled", created.Add(time.Hour)),
+	).Build()
+
+	client := NewAirflowClient(k8s.NewManager(&k8s.Client{Client: c}), c)
+
+	got, err := client.Status(context.Background(), namespace)
+	if err != nil {
+		t.Fatalf("Status(): %v", err)
+	}
+
+	expect := &AirflowStatus{
+		Components: []ComponentStatus{
+			{
+				Name: "scheduler",
+				Pods: []PodStatus{
+					{Name: "airflow-scheduler-0", Phase: v1.PodRunning, Ready: true, Restarts: 1, Containers: []string{"scheduler"}, Images: []string{"apache/airflow:2.9.0"}, Created: created},
+					{Name: "airflow-scheduler-1", Phase: v1.PodRunning, Ready: false, Restarts: 3, Containers: []string{"scheduler"}, Images: []string{"apache/airflow:2.9.0"}, Created: created},
+				},
+			},
+			{
+				Name: "webserver",
+				Pods: []PodStatus{
+					{Name: "airflow-webserver-0", Phase: v1.PodRunning, Ready: true, Restarts: 0, Containers: []string{"webserver"}, Images: []string{"apache/airflow:2.9.0"}, Created: created},
+				},
+			},
+		},
+		Warnings: []WarningEvent{
+			{Object: "Pod/airflow-scheduler-0", Reason: "Unhealthy", Message: "Unhealthy happened", Count: 2, LastSeen: created.Add(time.Minute)},
+			{Object: "Pod/airflow-scheduler-0", Reason: "BackOff", Message: "BackOff happened", Count: 2, LastSeen: created},
+		},
+	}
+
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Errorf("Status(): mismatch (-want +got):\n%s", diff)
+	}
+
+	if got.Components[0].Ready() {
+		t.Errorf("Ready(): expected scheduler not to be ready")
+	}
+
+	if !got.Components[1].Ready() {
+		t.Errorf("Ready(): expected webserver to be ready")
+	}
+}
+
+type startableFakeReader struct {
+	client.Reader
+}
+
+func (startableFakeReader) Start(ctx context.Context) error {
+	<-ctx.Done()
+	return nil
+}
+
+func TestAirflowStatusCache_Reader(t *testing.T) {
+	newPod := func(namespace string) *v1.Pod {
+		return &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "airflow-webserver-0", Namespace: namespace}}
+	}
+
+	// The cache only holds the namespaces with the team namespace labels, including those created after startup
+	statusCache := &AirflowStatusCache{
+		cache: startableFakeReader{fake.NewClientBuilder().WithObjects(
+			core.NewNamespace("team-new", core.WithTeamNamespaceLabel()),
+			newPod("team-new"),
+		).Build()},
+		live: fake.NewClientBuilder().WithObjects(newPod("other")).Build(),
+	}
+
+	for _, namespace := range []string{"team-new", "other"} {
+		pods := &v1.PodList{}
+		if err := statusCache.List(context.Background(), pods, client.InNamespace(namespace)); err != nil {
+			t.Fatal(err)
+		}
+		if len(pods.Items) != 1 {
+			t.Errorf("List(): expected the pod in %v, got %v pods", namespace, len(pods.Items))
+		}
+
+		if err := statusCache.Get(context.Background(), client.ObjectKey{Namespace: namespace, Name: "airflow-webserver-0"}, &v1.Pod{}); err != nil {
+			t.Errorf("Get(): expected the pod in %v, got %v", namespace, err)
+		}
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/navikt/knorten/pkg/database"
 	"github.com/navikt/knorten/pkg/database/gensql"
 	"github.com/navikt/knorten/pkg/k8s"
+	"github.com/navikt/knorten/pkg/logger"
 )
 
 var ErrTeamExists = errors.New("team with slug already exists")
@@ -39,7 +40,7 @@ func NewClient(
 	}, nil
 }
 
-func (c Client) Create(ctx context.Context, team *gensql.Team) error {
+func (c Client) Create(ctx context.Context, team *database.Team, log logger.Logger) error {
 	existingTeam, err := c.repo.TeamBySlugGet(ctx, team.Slug)
 	if err != nil && !errors.Is(err, sql.ErrNoRows) {
 		return fmt.Errorf("retrieving team by slug: %w", err)
@@ -49,29 +50,45 @@ func (c Client) Create(ctx context.Context, team *gensql.Team) error {
 		return ErrTeamExists
 	}
 
-	if err := c.createGCPTeamResources(ctx, team); err != nil {
+	p, err := c.newProvisioning(ctx, team.ID, log)
+	if err != nil {
+		return err
+	}
+
+	if err := c.createGCPTeamResources(ctx, team, p); err != nil {
 		return fmt.Errorf("creating GCP resources: %w", err)
 	}
 
-	namespace := k8s.TeamIDToNamespace(team.ID)
-	err = c.manager.ApplyNamespace(ctx, core.NewNamespace(namespace, core.WithTeamNamespaceLabel()))
+	namespace, err := p.run(ctx, stepNamespace, func(ctx context.Context) (string, error) {
+		namespace := k8s.TeamIDToNamespace(team.ID)
+		return namespace, c.manager.ApplyNamespace(ctx, core.NewNamespace(namespace, core.WithTeamNamespaceLabel()))
+	})
 	if err != nil {
 		return fmt.Errorf("creating k8s namespace: %w", err)
 	}
 
-	if err := c.manager.ApplyServiceAccount(ctx, core.NewServiceAccount(team.ID, namespace, core.WithGKEIAMAccountAnnotation(team.ID, c.gcpProject))); err != nil {
+	_, err = p.run(ctx, stepK8sServiceAccount, func(ctx context.Context) (string, error) {
+		sa := core.NewServiceAccount(team.ID, namespace, core.WithGKEIAMAccountAnnotation(team.ID, c.gcpProject))
+		return namespace + "/" + team.ID, c.manager.ApplyServiceAccount(ctx, sa)
+	})
+	if err != nil {
 		return fmt.Errorf("creating k8s service account: %w", err)
 	}
 
-	if err := c.repo.TeamCreate(ctx, team); err != nil {
+	if err := c.repo.TeamCreate(ctx, &team.Team, team.Members...); err != nil {
 		return fmt.Errorf("saving team to database: %w", err)
 	}
 
+	// The team is created, so there is nothing left to clean up if a step fails
+	if err := c.repo.TeamProvisioningStepsDelete(ctx, team.ID); err != nil {
+		return fmt.Errorf("deleting provisioning steps: %w", err)
+	}
+
 	return nil
 }
 
-func (c Client) Update(ctx context.Context, team *gensql.Team) error {
-	err := c.repo.TeamUpdate(ctx, team)
+func (c Client) Update(ctx context.Context, team *database.Team, opts ...database.EventOption) error {
+	err := c.repo.TeamUpdate(ctx, &team.Team, team.Members...)
 	if err != nil {
 		return fmt.Errorf("updating team in database: %w", err)
 	}
@@ -109,7 +126,46 @@ func (c Client) Update(ctx context.Context, team *gensql.Team) error {
 			airflowValues := chart.AirflowConfigurableValues{
 				TeamID: team.ID,
 			}
-			if err := c.repo.RegisterUpdateAirflowEvent(ctx, team.ID, airflowValues); err != nil {
+			if err := c.repo.RegisterUpdateAirflowEvent(ctx, team.ID, airflowValues, opts...); err != nil {
+				return fmt.Errorf("registering Airflow update event: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Rename moves the resources which are named after the slug over to the new slug. The slug itself
+// is changed in the database when the rename is requested, so the team ID and namespace stay put.
+func (c Client) Rename(ctx context.Context, teamID string, change database.TeamSlugChange, opts ...database.EventOption) error {
+	team, err := c.repo.TeamGet(ctx, teamID)
+	if err != nil {
+		return fmt.Errorf("getting team from database: %w", err)
+	}
+
+	// The team was renamed again before this event ran, and the event of that rename moves the
+	// resources to the slug the team has now
+	if team.Slug != change.NewSlug {
+		return nil
+	}
+
+	if err := c.updateSecretSlugLabel(ctx, team.Slug, team.ID); err != nil {
+		return fmt.Errorf("updating secret labels: %w", err)
+	}
+
+	apps, err := c.repo.ChartsForTeamGet(ctx, team.ID)
+	if err != nil {
+		return fmt.Errorf("getting apps for team: %w", err)
+	}
+
+	for _, app := range apps {
+		switch app {
+		case gensql.ChartTypeAirflow:
+			// Syncing Airflow moves the http route to the new hostname and adds the redirect
+			airflowValues := chart.AirflowConfigurableValues{
+				TeamID: team.ID,
+			}
+			if err := c.repo.RegisterUpdateAirflowEvent(ctx, team.ID, airflowValues, opts...); err != nil {
 				return fmt.Errorf("registering Airflow update event: %w", err)
 			}
 		}
@@ -118,7 +174,7 @@ func (c Client) Update(ctx context.Context, team *gensql.Team) error {
 	return nil
 }
 
-func (c Client) Delete(ctx context.Context, teamID string) error {
+func (c Client) Delete(ctx context.Context, teamID string, opts ...database.EventOption) error {
 	team, err := c.repo.TeamGet(ctx, teamID)
 	if err != nil && errors.Is(err, sql.ErrNoRows) {
 		return fmt.Errorf("getting team from database: %w", err)
@@ -137,7 +193,7 @@ func (c Client) Delete(ctx context.Context, teamID string) error {
 	}
 
 	// Kun Airflow som har ressurser utenfor clusteret
-	err = c.repo.RegisterDeleteAirflowEvent(ctx, team.ID)
+	err = c.repo.RegisterDeleteAirflowEvent(ctx, team.ID, opts...)
 	if err != nil {
 		return fmt.Errorf("registering Airflow delete event: %w", err)
 	}
@@ -10,6 +10,7 @@ import (
 	"path"
 	"runtime"
 	"testing"
+	"time"
 
 	cnpgv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
 	"github.com/navikt/knorten/pkg/k8s"
@@ -74,14 +75,14 @@ func TestTeam(t *testing.T) {
 	operation := func(ctx context.Context, eventType database.EventType, team *gensql.Team, teamClient *Client) error {
 		switch eventType {
 		case database.EventTypeCreateTeam:
-			err := teamClient.Create(ctx, team)
+			err := teamClient.Create(ctx, &database.Team{Team: *team}, logrus.New())
 			if errors.Is(err, ErrTeamExists) {
 				return nil
 			}
 
 			return err
 		case database.EventTypeUpdateTeam:
-			return teamClient.Update(ctx, team)
+			return teamClient.Update(ctx, &database.Team{Team: *team})
 		case database.EventTypeDeleteTeam:
 			return teamClient.Delete(ctx, team.ID)
 		}
@@ -206,3 +207,38 @@ func TestTeam(t *testing.T) {
 		})
 	}
 }
+
+func TestClient_RenameTwice(t *testing.T) {
+	ctx := context.Background()
+
+	team := gensql.Team{ID: "rename-twice-1234", Slug: "rename-first", Users: []string{"dummy@nav.no"}}
+	if err := repo.TeamCreate(ctx, &team); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := repo.TeamDelete(ctx, team.ID); err != nil {
+			t.Error(err)
+		}
+	})
+
+	redirectUntil := time.Now().Add(time.Hour)
+	if err := repo.TeamRename(ctx, team.ID, "rename-first", "rename-second", "dummy@nav.no", redirectUntil); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.TeamRename(ctx, team.ID, "rename-second", "rename-third", "dummy@nav.no", redirectUntil); err != nil {
+		t.Fatal(err)
+	}
+
+	teamClient, err := NewClient(repo, k8s.NewManager(&k8s.Client{Client: fake.NewFakeClient()}), "", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := teamClient.Rename(ctx, team.ID, database.TeamSlugChange{OldSlug: "rename-first", NewSlug: "rename-second"}); err != nil {
+		t.Errorf("Rename(): expected the outdated rename to be skipped, got %v", err)
+	}
+
+	if err := teamClient.Rename(ctx, team.ID, database.TeamSlugChange{OldSlug: "rename-second", NewSlug: "rename-third"}); err != nil {
+		t.Errorf("Rename(): %v", err)
+	}
+}
@@ -0,0 +1,51 @@
+package teamdeletion
+
+import (
+	"context"
+	"time"
+
+	"github.com/navikt/knorten/pkg/database"
+	"github.com/navikt/knorten/pkg/leaderelection"
+	"github.com/sirupsen/logrus"
+)
+
+// client purges teams which have been pending deletion for longer than the grace period, by
+// registering the delete:team event which removes everything the team owns
+type client struct {
+	repo *database.Repo
+	log  *logrus.Entry
+}
+
+func NewClient(repo *database.Repo, log *logrus.Entry) *client {
+	return &client{
+		repo: repo,
+		log:  log,
+	}
+}
+
+func (c *client) Run(ctx context.Context, frequency time.Duration) {
+	leaderelection.RunPeriodically(ctx, frequency, c.log, c.run)
+}
+
+func (c *client) run(ctx context.Context) {
+	if err := c.purgeDueTeams(ctx); err != nil {
+		c.log.WithError(err).Error("purging teams pending deletion")
+	}
+}
+
+func (c *client) purgeDueTeams(ctx context.Context) error {
+	teams, err := c.repo.TeamDeletionsDueGet(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, teamID := range teams {
+		c.log.WithField("team", teamID).Info("grace period is over, registering team deletion")
+
+		if err := c.repo.RegisterDeleteTeamEvent(ctx, teamID); err != nil {
+			c.log.WithError(err).WithField("team", teamID).Error("registering delete team event")
+		}
+	}
+
+	return nil
+}
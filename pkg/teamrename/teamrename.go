@@ -0,0 +1,62 @@
+package teamrename
+
+import (
+	"context"
+	"time"
+
+	"github.com/navikt/knorten/pkg/database"
+	"github.com/navikt/knorten/pkg/k8s"
+	"github.com/navikt/knorten/pkg/k8s/networking"
+	"github.com/navikt/knorten/pkg/leaderelection"
+	"github.com/sirupsen/logrus"
+)
+
+// client removes the redirects from the old hostnames of renamed teams when the transition
+// period is over, which also frees the old slugs for other teams
+type client struct {
+	repo    *database.Repo
+	manager k8s.Manager
+	log     *logrus.Entry
+}
+
+func NewClient(repo *database.Repo, manager k8s.Manager, log *logrus.Entry) *client {
+	return &client{
+		repo:    repo,
+		manager: manager,
+		log:     log,
+	}
+}
+
+func (c *client) Run(ctx context.Context, frequency time.Duration) {
+	leaderelection.RunPeriodically(ctx, frequency, c.log, c.run)
+}
+
+func (c *client) run(ctx context.Context) {
+	if err := c.removeExpiredRedirects(ctx); err != nil {
+		c.log.WithError(err).Error("removing expired team slug redirects")
+	}
+}
+
+func (c *client) removeExpiredRedirects(ctx context.Context) error {
+	redirects, err := c.repo.TeamSlugRedirectsExpiredGet(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, redirect := range redirects {
+		log := c.log.WithField("team", redirect.TeamID)
+		log.Infof("redirect from %v has expired, removing it", redirect.Slug)
+
+		name := networking.AirflowRedirectHTTPRouteName(redirect.Slug)
+		if err := c.manager.DeleteHTTPRoute(ctx, name, k8s.TeamIDToNamespace(redirect.TeamID)); err != nil {
+			log.WithError(err).Errorf("deleting redirect http route %v", name)
+			continue
+		}
+
+		if err := c.repo.TeamSlugRedirectDelete(ctx, redirect.Slug); err != nil {
+			log.WithError(err).Errorf("deleting redirect from %v", redirect.Slug)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,86 @@
+// Package tracing sets up OpenTelemetry, and carries the trace context of a request over to the
+// events it registers
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const ServiceName = "knorten"
+
+// Setup exports the spans over OTLP to the endpoint. Without an endpoint the spans are only
+// propagated, not recorded. The returned function flushes the spans which haven't been exported.
+func Setup(ctx context.Context, endpoint string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	if endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(endpoint))
+	if err != nil {
+		return nil, err
+	}
+
+	provider := NewProvider(sdktrace.WithBatcher(exporter))
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// NewProvider creates a tracer provider for Knorten, tests use it with an in-memory exporter
+func NewProvider(opts ...sdktrace.TracerProviderOption) *sdktrace.TracerProvider {
+	opts = append(opts, sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(ServiceName))))
+	return sdktrace.NewTracerProvider(opts...)
+}
+
+// Tracer is the tracer for a package, named by its import path
+func Tracer(name string) trace.Tracer {
+	return otel.Tracer(name)
+}
+
+// Inject returns the trace context of ctx, to be stored with an event
+func Inject(ctx context.Context) json.RawMessage {
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+
+	traceContext, err := json.Marshal(carrier)
+	if err != nil {
+		return json.RawMessage("{}")
+	}
+
+	return traceContext
+}
+
+// Extract resumes the trace context stored with an event
+func Extract(ctx context.Context, traceContext json.RawMessage) context.Context {
+	carrier := propagation.MapCarrier{}
+	if err := json.Unmarshal(traceContext, &carrier); err != nil {
+		return ctx
+	}
+
+	return otel.GetTextMapPropagator().Extract(ctx, carrier)
+}
+
+// End marks the span as failed if there is an error, and ends it
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
@@ -0,0 +1,43 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestInjectExtract(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(NewProvider(sdktrace.WithSyncer(exporter)))
+
+	if _, err := Setup(context.Background(), ""); err != nil {
+		t.Fatal(err)
+	}
+
+	tracer := Tracer("test")
+	ctx, request := tracer.Start(context.Background(), "request")
+	traceContext := Inject(ctx)
+	request.End()
+
+	_, event := tracer.Start(Extract(context.Background(), traceContext), "event")
+	event.End()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %v", len(spans))
+	}
+
+	if spans[1].Parent.SpanID() != spans[0].SpanContext.SpanID() || spans[1].SpanContext.TraceID() != spans[0].SpanContext.TraceID() {
+		t.Errorf("expected the event span to continue the trace of the request, got %v and %v", spans[0].SpanContext, spans[1].Parent)
+	}
+
+	t.Run("without trace context", func(t *testing.T) {
+		ctx := Extract(context.Background(), nil)
+		if traceContext := string(Inject(ctx)); traceContext != "{}" {
+			t.Errorf("expected an empty trace context, got %v", traceContext)
+		}
+	})
+}
@@ -2,12 +2,17 @@ package user
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/navikt/knorten/pkg/gcp"
+	"github.com/navikt/knorten/pkg/plan"
 )
 
 func (c Client) createUserGSMInGCP(ctx context.Context, name, owner string) error {
 	if c.dryRun {
+		secretName := fmt.Sprintf("projects/%v/secrets/%v", c.gcpProject, name)
+		plan.Record(ctx, plan.KindGCP, "createSecret", secretName, map[string]string{"owner": name, "created-by": "knorten"})
+		plan.Record(ctx, plan.KindGCP, "setIamPolicy", secretName, map[string]any{"role": "roles/owner", "members": []string{owner}})
 		return nil
 	}
 
@@ -25,6 +30,7 @@ func (c Client) createUserGSMInGCP(ctx context.Context, name, owner string) erro
 
 func (c Client) deleteUserGSMFromGCP(ctx context.Context, name string) error {
 	if c.dryRun {
+		plan.Record(ctx, plan.KindGCP, "deleteSecret", fmt.Sprintf("projects/%v/secrets/%v", c.gcpProject, name), nil)
 		return nil
 	}
 